@@ -8,10 +8,12 @@ import (
 	"academy/internal/database"
 	"academy/internal/logger"
 	"academy/internal/service"
+	"academy/internal/service/bot"
 	"academy/internal/service/jwt"
 	"academy/internal/storage/cache"
 	"academy/internal/storage/repository"
 	"log"
+	_ "time/tzdata" // Time zones of event lessons.
 
 	"github.com/joho/godotenv"
 	"go.uber.org/fx"
@@ -38,6 +40,7 @@ func Build() *fx.App {
 		cache.Module(),
 
 		service.Module(),
+		bot.Module(),
 		server.Module(),
 
 		v1.Module(),
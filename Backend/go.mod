@@ -25,6 +25,7 @@ require (
 	github.com/xuri/excelize/v2 v2.9.0
 	go.uber.org/fx v1.23.0
 	go.uber.org/zap v1.27.0
+	golang.org/x/image v0.18.0
 )
 
 require (
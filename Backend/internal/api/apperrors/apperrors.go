@@ -85,6 +85,9 @@ func ServiceUnavailable(message string, err ...error) error {
 
 const (
 	StatusLoginTimeout = 440
+	// StatusChecksumMismatch is returned by the tus protocol when the checksum
+	// of the received data does not match.
+	StatusChecksumMismatch = 460
 )
 
 func LoginTimeout(message string, err ...error) error {
@@ -99,6 +102,30 @@ func AlreadyExist(message string, err ...error) error {
 	return newAppError(http.StatusConflict, message, err...)
 }
 
+func Conflict(message string, err ...error) error {
+	return newAppError(http.StatusConflict, message, err...)
+}
+
+func PreconditionFailed(message string, err ...error) error {
+	return newAppError(http.StatusPreconditionFailed, message, err...)
+}
+
+func RequestEntityTooLarge(message string, err ...error) error {
+	return newAppError(http.StatusRequestEntityTooLarge, message, err...)
+}
+
+func UnsupportedMediaType(message string, err ...error) error {
+	return newAppError(http.StatusUnsupportedMediaType, message, err...)
+}
+
+func ChecksumMismatch(message string, err ...error) error {
+	return newAppError(StatusChecksumMismatch, message, err...)
+}
+
+func TooManyRequests(message string, err ...error) error {
+	return newAppError(http.StatusTooManyRequests, message, err...)
+}
+
 // Teapot indicates that handler is a teapot and unable to brew a coffee
 func Teapot(message string, err ...error) error {
 	return newAppError(http.StatusTeapot, message, err...)
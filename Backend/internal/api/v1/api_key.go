@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service"
+	"academy/internal/service/jwt"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// apiKeyScheme is the authorization scheme of requests with API keys.
+const apiKeyScheme = "ApiKey "
+
+// AuthMiddleware accepts either the access token or the API key of the owner.
+func (h *V1Handler) AuthMiddleware(c fiber.Ctx) error {
+	if strings.HasPrefix(c.Get("Authorization"), apiKeyScheme) {
+		return h.APIKeyAuthMiddleware(c)
+	}
+
+	return h.JWTAuthMiddleware(c)
+}
+
+// APIKeyAuthMiddleware authenticates the request with the API key. Key has
+// only the permissions of its scopes, see isPermittedInProduct.
+func (h *V1Handler) APIKeyAuthMiddleware(c fiber.Ctx) error {
+	token := strings.TrimPrefix(c.Get("Authorization"), apiKeyScheme)
+
+	key, err := h.apiKeyService.Authenticate(c.Context(), token)
+	if errors.Is(err, service.ErrAPIKeyInvalid) {
+		return apperrors.Unauthorized("invalid api key")
+	}
+	if errors.Is(err, service.ErrAPIKeyRateLimited) {
+		return apperrors.TooManyRequests("api key rate limit exceeded")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to check api key", err)
+	}
+
+	c.Locals("claims", jwt.TokenClaims{
+		UserID:       key.UserID,
+		MiniAppID:    key.MiniAppID,
+		APIKeyID:     key.ID,
+		APIKeyScopes: key.Scopes,
+	})
+
+	return c.Next()
+}
+
+// StudentMiddleware rejects API keys on the routes acting as the student.
+// Key claims carry the user ID of the owner, so without it a key could submit
+// lessons and reviews on their behalf.
+func (h *V1Handler) StudentMiddleware(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if claims.APIKeyID != uuid.Nil {
+		return apperrors.Unauthorized("api key is not permitted")
+	}
+
+	return c.Next()
+}
+
+func (h *V1Handler) APIKeys(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	keys, err := h.apiKeyService.MiniAppKeys(c.Context(), claims.MiniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get api keys", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"api_keys": keys,
+	})
+}
+
+func (h *V1Handler) CreateAPIKey(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.CreateAPIKeyRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if apiKeyNameLimit < utf8.RuneCountInString(req.Name) {
+		return apperrors.BadRequest("api key name exceeds the limit")
+	}
+
+	key, token, err := h.apiKeyService.Create(c.Context(), claims.MiniAppID, claims.UserID, &req)
+	if err != nil {
+		return apperrors.Internal("failed to create api key", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"api_key": key,
+		"token":   token,
+	})
+}
+
+func (h *V1Handler) RevokeAPIKey(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	err = h.apiKeyService.Revoke(c.Context(), claims.MiniAppID, keyID)
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		return apperrors.NotFound("api key not found")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to revoke api key", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service"
+	"academy/internal/service/jwt"
+	"academy/internal/service/upload"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// auditParamsLimit is a max size of the request body that is saved as params.
+const auditParamsLimit = 64 * 1024
+
+// auditTarget tells where ID of the entity of the action is taken from.
+type auditTarget struct {
+	param       string
+	bodyKey     string
+	responseKey string
+	miniApp     bool
+	self        bool
+}
+
+var (
+	auditNone    = auditTarget{}
+	auditPath    = auditTarget{param: "id"}
+	auditMiniApp = auditTarget{miniApp: true}
+	auditSelf    = auditTarget{self: true}
+)
+
+// auditBody takes ID of the entity from the request field.
+func auditBody(key string) auditTarget {
+	return auditTarget{bodyKey: key}
+}
+
+// auditResponse takes ID of the created entity from the response field.
+func auditResponse(key string) auditTarget {
+	return auditTarget{responseKey: key}
+}
+
+// audit records the action of the owner or moderator after the handler
+// succeeds. Entity is compared before and after the action, actions of
+// students are not recorded.
+func (h *V1Handler) audit(action, entityType string, target auditTarget) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		claims, ok := c.Locals("claims").(jwt.TokenClaims)
+		if !ok || !claims.IsStaff() {
+			return c.Next()
+		}
+
+		params := auditParams(c)
+
+		var entityID uuid.UUID
+		switch {
+		case target.param != "":
+			entityID, _ = uuid.Parse(c.Params(target.param))
+		case target.bodyKey != "":
+			id, _ := params[target.bodyKey].(string)
+			entityID, _ = uuid.Parse(id)
+		case target.miniApp:
+			entityID = claims.MiniAppID
+		case target.self:
+			entityID = claims.UserID
+		}
+
+		before, err := h.auditService.Snapshot(c.Context(), entityType, entityID)
+		if err != nil {
+			h.logger.Error("failed to get audit snapshot", zap.Error(err))
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if fiber.StatusBadRequest <= c.Response().StatusCode() {
+			return nil
+		}
+
+		if target.responseKey != "" {
+			entityID = auditResponseID(c.Response().Body(), target.responseKey)
+		}
+
+		after, err := h.auditService.Snapshot(c.Context(), entityType, entityID)
+		if err != nil {
+			h.logger.Error("failed to get audit snapshot", zap.Error(err))
+		}
+
+		// API key acts on behalf of the owner who created it.
+		var role model.UserRole = model.UserRoleModerator
+		if claims.IsOwner || claims.APIKeyID != uuid.Nil {
+			role = model.UserRoleOwner
+		}
+
+		log := &model.AuditLog{
+			ID:         uuid.New(),
+			MiniAppID:  claims.MiniAppID,
+			ActorID:    claims.UserID,
+			ActorRole:  role,
+			Action:     action,
+			EntityType: entityType,
+			EntityID:   entityID,
+			Changes:    model.DiffAuditSnapshots(before, after),
+			Params:     params,
+			IP:         c.IP(),
+			APIKeyID:   claims.APIKeyID,
+			CreatedAt:  time.Now().UTC(),
+		}
+
+		// Action is already done, so failed record does not fail the request.
+		if err := h.auditService.Record(c.Context(), log); err != nil {
+			h.logger.Error("failed to record audit log",
+				zap.String("action", action), zap.Error(err))
+		}
+
+		return nil
+	}
+}
+
+// auditParams returns JSON body or form values of the request, files are
+// not included.
+func auditParams(c fiber.Ctx) map[string]any {
+	params := make(map[string]any)
+
+	contentType := c.Get(fiber.HeaderContentType)
+	switch {
+	case strings.HasPrefix(contentType, fiber.MIMEApplicationJSON):
+		body := c.Body()
+		if len(body) == 0 || auditParamsLimit < len(body) {
+			return params
+		}
+		if err := json.Unmarshal(body, &params); err != nil {
+			return make(map[string]any)
+		}
+	case strings.HasPrefix(contentType, fiber.MIMEMultipartForm):
+		form, err := c.MultipartForm()
+		if err != nil {
+			return params
+		}
+		for k, v := range form.Value {
+			if len(v) == 1 {
+				params[k] = v[0]
+			} else {
+				params[k] = v
+			}
+		}
+	}
+
+	return model.RedactAuditParams(params)
+}
+
+// auditResponseID returns ID from the response field that is either the ID
+// or the object with it.
+func auditResponseID(body []byte, key string) uuid.UUID {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return uuid.Nil
+	}
+
+	var id uuid.UUID
+	if err := json.Unmarshal(resp[key], &id); err == nil {
+		return id
+	}
+
+	var entity struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if err := json.Unmarshal(resp[key], &entity); err == nil {
+		return entity.ID
+	}
+
+	return uuid.Nil
+}
+
+func (h *V1Handler) AuditLog(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionAccountSettings) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.FilterAuditLogRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	req.Limit = validateLimit(req.Limit)
+
+	logs, total, err := h.auditService.Find(c.Context(), claims.MiniAppID, &req)
+	if err != nil {
+		return apperrors.Internal("failed to get audit log", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"audit_log": logs,
+		"total":     total,
+	})
+}
+
+func (h *V1Handler) ExportAuditLog(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionAccountSettings) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.FilterAuditLogRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	excelData, err := h.auditService.Export(c.Context(), claims.MiniAppID, &req)
+	if errors.Is(err, service.ErrNoData) {
+		return apperrors.BadRequest("no data for selected filter")
+	}
+	if err != nil {
+		return apperrors.Internal("error while getting excel file", err)
+	}
+
+	filenameBase := fmt.Sprintf("audit_%s.xlsx", time.Now().UTC().Format(time.DateOnly))
+
+	miniAppPath := upload.MaterialFilePath{
+		MiniAppID: claims.MiniAppID,
+	}
+
+	filename, _, err := h.uploadService.UploadExcel(miniAppPath.String(), excelData, filenameBase)
+	if err != nil {
+		return apperrors.Internal("error while saving excel file", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"file_path": filename,
+	})
+}
@@ -3,12 +3,18 @@ package v1
 import (
 	"academy/internal/api/apperrors"
 	"academy/internal/model"
+	"academy/internal/service"
+	"academy/internal/service/jwt"
 	"academy/internal/service/upload"
+	"context"
+	"errors"
 	"fmt"
 	"net/textproto"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
@@ -27,11 +33,28 @@ func (h *V1Handler) JWTAuthMiddleware(c fiber.Ctx) error {
 		return apperrors.Internal("failed to parse token")
 	}
 
+	if err := h.checkSession(c.Context(), claims); err != nil {
+		return err
+	}
+
 	c.Locals("claims", *claims)
 
 	return c.Next()
 }
 
+// checkSession rejects access tokens of revoked sessions.
+func (h *V1Handler) checkSession(ctx context.Context, claims *jwt.TokenClaims) error {
+	err := h.jwtService.CheckSession(ctx, claims)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		return apperrors.Unauthorized("session is revoked")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to check session", err)
+	}
+
+	return nil
+}
+
 func (h *V1Handler) MaterialAuthMiddleware(c fiber.Ctx) error {
 	token := c.Query("jwt")
 	if token == "" {
@@ -47,6 +70,10 @@ func (h *V1Handler) MaterialAuthMiddleware(c fiber.Ctx) error {
 		return apperrors.Internal("failed to parse token")
 	}
 
+	if err := h.checkSession(c.Context(), claims); err != nil {
+		return err
+	}
+
 	filename, ok := strings.CutPrefix(c.Path(), uploadPath)
 	if !ok {
 		return apperrors.BadRequest("unexpected path")
@@ -62,6 +89,8 @@ func (h *V1Handler) MaterialAuthMiddleware(c fiber.Ctx) error {
 		return apperrors.BadRequest("mini-app access is restricted")
 	}
 
+	c.Locals("claims", *claims)
+
 	if claims.IsOwner || claims.IsMod {
 		return c.Next()
 	}
@@ -74,6 +103,26 @@ func (h *V1Handler) MaterialAuthMiddleware(c fiber.Ctx) error {
 		return c.Next()
 	}
 
+	// Blobs are accessible if any of materials that share them is.
+	if materialPath.Blob {
+		materials, err := h.materialService.FindByFilename(c.Context(), filename)
+		if err != nil {
+			return apperrors.Internal("error getting materials", err)
+		}
+		if len(materials) == 0 {
+			return c.Next()
+		}
+
+		for _, material := range materials {
+			err = h.checkMaterialAccess(c.Context(), claims, material)
+			if err == nil {
+				return c.Next()
+			}
+		}
+
+		return err
+	}
+
 	// Allow access to product/mini-app resourses.
 	if materialPath.LessonID == uuid.Nil && materialPath.ProductLevelID == uuid.Nil {
 		return c.Next()
@@ -84,13 +133,39 @@ func (h *V1Handler) MaterialAuthMiddleware(c fiber.Ctx) error {
 		return apperrors.Internal("error getting material", err)
 	}
 
+	// Subtitles are accessible the same way as their material.
 	if material == nil {
-		return c.Next()
+		subtitle, err := h.subtitleService.GetByFilename(c.Context(), filename)
+		if err != nil {
+			return apperrors.Internal("error getting subtitles", err)
+		}
+		if subtitle == nil {
+			return c.Next()
+		}
+
+		material, err = h.materialService.GetByID(c.Context(), subtitle.MaterialID)
+		if err != nil {
+			return apperrors.Internal("error getting material", err)
+		}
+	}
+
+	if err := h.checkMaterialAccess(c.Context(), claims, material); err != nil {
+		return err
+	}
+
+	return c.Next()
+}
+
+// checkMaterialAccess checks if the student has access to the file of the
+// material. Quarantined files are not served till they are scanned.
+func (h *V1Handler) checkMaterialAccess(ctx context.Context, claims *jwt.TokenClaims, material *model.Material) error {
+	if material.Status == model.MaterialStatusQuarantined {
+		return apperrors.Unauthorized("material is not scanned yet")
 	}
 
 	if material.ProductLevelID != uuid.Nil {
 		isUnlocked, err := h.productLevelService.IsProductLevelUnlocked(
-			c.Context(), material.ProductLevelID, claims.UserID)
+			ctx, material.ProductLevelID, claims.UserID)
 
 		if err != nil {
 			return apperrors.Internal("error getting product level", err)
@@ -100,52 +175,151 @@ func (h *V1Handler) MaterialAuthMiddleware(c fiber.Ctx) error {
 			return apperrors.Unauthorized("product level access restricted", err)
 		}
 
-		return c.Next()
+		return nil
 	}
 
 	if material.LessonID != uuid.Nil {
 		if material.Category == model.MaterialCategoryLessonCover {
-			return c.Next()
+			return nil
 		}
 
-		_, err = h.validateLessonAccess(c.Context(), claims, material.LessonID)
+		_, err := h.validateLessonAccess(ctx, claims, material.LessonID)
 		if err != nil {
 			return apperrors.Unauthorized("material access restricted", err)
 		}
 	}
 
+	return nil
+}
+
+// ImageVariantMiddleware serves the variant of the image requested by the
+// size and format query parameters. The closest existing variant is served,
+// images without variants are served as is.
+func (h *V1Handler) ImageVariantMiddleware(c fiber.Ctx) error {
+	size, format := c.Query("size"), c.Query("format")
+	if size == "" && format == "" {
+		return c.Next()
+	}
+
+	switch size {
+	case "", upload.ImageSizeSmall, upload.ImageSizeMedium, upload.ImageSizeLarge, upload.ImageSizeFull:
+	default:
+		return apperrors.BadRequest("invalid image size")
+	}
+	if format != "" && format != upload.ImageFormatWebP {
+		return apperrors.BadRequest("invalid image format")
+	}
+
+	filename, ok := strings.CutPrefix(c.Path(), uploadPath)
+	if !ok {
+		return apperrors.BadRequest("unexpected path")
+	}
+
+	variant, err := h.uploadService.ResolveImageVariant(c.Context(), filename, size, format)
+	if err != nil {
+		return apperrors.Internal("failed to get image variant", err)
+	}
+
+	c.Path(uploadPath + variant)
+
 	return c.Next()
 }
 
-func (h *V1Handler) RefreshTokens(c fiber.Ctx) error {
-	var tokenReq struct {
-		RefreshToken string `json:"refresh_token"`
+// PDFWatermarkMiddleware serves PDFs of watermarked materials with the
+// student who downloads them and the time of the download on every page.
+// Files that could not be watermarked are not served to students.
+func (h *V1Handler) PDFWatermarkMiddleware(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok || claims.IsStaff() {
+		return c.Next()
 	}
-	if err := c.Bind().JSON(&tokenReq); err != nil {
-		return apperrors.BadRequest("invalid request data", err)
+
+	filename, ok := strings.CutPrefix(c.Path(), uploadPath)
+	if !ok {
+		return apperrors.BadRequest("unexpected path")
+	}
+	if strings.ToLower(filepath.Ext(filename)) != ".pdf" {
+		return c.Next()
 	}
 
-	claims, err := h.jwtService.ParseRefreshToken(tokenReq.RefreshToken)
+	materials, err := h.materialService.FindByFilename(c.Context(), filename)
 	if err != nil {
-		return apperrors.Unauthorized("failed to parse token", err)
+		return apperrors.Internal("error getting materials", err)
+	}
+	isWatermarked := slices.ContainsFunc(materials, func(m *model.Material) bool {
+		return m.IsWatermarked
+	})
+	if !isWatermarked {
+		return c.Next()
 	}
 
-	if claims == nil {
-		return apperrors.Internal("failed to parse token")
+	user, err := h.userService.GetByID(c.Context(), claims.UserID)
+	if err != nil {
+		return apperrors.Internal("error getting user", err)
+	}
+	if user == nil {
+		return apperrors.Unauthorized("user not found")
 	}
 
-	storedRefresh, err := h.jwtService.GetRefreshByUserID(c.Context(), claims.UserID)
+	text := fmt.Sprintf("Downloaded by @%s (ID %d) at %s",
+		user.TelegramUsername, user.TelegramID,
+		time.Now().UTC().Format("2006-01-02 15:04 UTC"),
+	)
+
+	data, err := h.uploadService.WatermarkPDF(c.Context(), filename, text)
+	if errors.Is(err, upload.ErrInvalidPDF) ||
+		errors.Is(err, upload.ErrEncryptedPDF) ||
+		errors.Is(err, upload.ErrPDFTooLarge) {
+
+		h.logger.Warn("pdf could not be watermarked",
+			zap.String("file", filename),
+			zap.Error(err),
+		)
+		return apperrors.Conflict("file could not be watermarked", err)
+	}
 	if err != nil {
-		return apperrors.Unauthorized("invalid token claims", err)
+		return apperrors.Internal("failed to watermark file", err)
 	}
 
-	if tokenReq.RefreshToken != storedRefresh {
-		return apperrors.Unauthorized("refresh token does not match with stored one")
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	return c.Send(data)
+}
+
+// StorageRedirectMiddleware redirects to the presigned URL of the file if the
+// storage supports them, otherwise the file is served by the API.
+func (h *V1Handler) StorageRedirectMiddleware(c fiber.Ctx) error {
+	filename, ok := strings.CutPrefix(c.Path(), uploadPath)
+	if !ok {
+		return apperrors.BadRequest("unexpected path")
 	}
 
-	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims)
+	fileURL, err := h.uploadService.PresignedURL(c.Context(), filename)
+	if errors.Is(err, upload.ErrPresignNotSupported) {
+		return c.Next()
+	}
 	if err != nil {
-		return apperrors.Internal("failed to generate response", err)
+		return apperrors.Internal("failed to get file url", err)
+	}
+
+	return c.Redirect().Status(fiber.StatusTemporaryRedirect).To(fileURL)
+}
+
+func (h *V1Handler) RefreshTokens(c fiber.Ctx) error {
+	var tokenReq struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.Bind().JSON(&tokenReq); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	tokenPair, err := h.jwtService.RefreshTokenPair(c.Context(), tokenReq.RefreshToken, sessionClient(c))
+	if errors.Is(err, service.ErrTokenReused) {
+		return apperrors.Unauthorized("refresh token is reused, session is revoked", err)
+	}
+	if err != nil {
+		return apperrors.Unauthorized("failed to refresh token", err)
 	}
 
 	jwtInfo := &model.SignInJWTResp{
@@ -158,6 +332,13 @@ func (h *V1Handler) RefreshTokens(c fiber.Ctx) error {
 	})
 }
 
+func sessionClient(c fiber.Ctx) *model.SessionClient {
+	return &model.SessionClient{
+		UserAgent: c.Get("User-Agent"),
+		IP:        c.IP(),
+	}
+}
+
 // HandleMaterialHeaders fixes bugs with playing video content. Currently not needed.
 func (h *V1Handler) HandleMaterialHeaders(c fiber.Ctx) error {
 	c.Set("Content-Disposition", "inline")
@@ -5,10 +5,12 @@ import (
 	"academy/internal/config"
 	"academy/internal/model"
 	"academy/internal/service/jwt"
+	"context"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
 )
 
 func (h *V1Handler) AdminSignIn(c fiber.Ctx) error {
@@ -51,7 +53,7 @@ func (h *V1Handler) AdminSignIn(c fiber.Ctx) error {
 		IsOwner:   true,
 	}
 
-	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims)
+	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims, sessionClient(c))
 	if err != nil {
 		return apperrors.Internal("failed to generate response", err)
 	}
@@ -138,7 +140,7 @@ func (h *V1Handler) ModSignIn(c fiber.Ctx) error {
 		IsMod:     true,
 	}
 
-	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims)
+	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims, sessionClient(c))
 	if err != nil {
 		return apperrors.Internal("failed to generate response", err)
 	}
@@ -173,39 +175,102 @@ func (h *V1Handler) SignIn(c fiber.Ctx) error {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 
-	miniApp, err := h.miniAppService.GetByName(c.Context(), req.MiniAppName)
-	if err != nil || miniApp == nil {
-		return apperrors.BadRequest("invalid mini-app name", err)
+	miniApp, err := h.studentMiniApp(c.Context(), req.MiniAppName)
+	if err != nil {
+		return err
 	}
 
-	if miniApp.BotID == 0 {
+	initData, err := h.telegramService.ParseToken(miniApp.BotID, req.InitData, validateInitData)
+	if err != nil {
+		return apperrors.BadRequest("failed to parse token", err)
+	}
+
+	if initData.User.ID == 0 {
+		return apperrors.BadRequest("no user id provided in initialization data", err)
+	}
+
+	return h.signInStudent(c, miniApp.ID, initData)
+}
+
+// SignInWithWidget signs in the student with the Telegram Login Widget, so
+// the mini-app could be used in the browser outside of Telegram.
+func (h *V1Handler) SignInWithWidget(c fiber.Ctx) error {
+	validateAuthData := true
+
+	if h.config.App.Environment != config.EnvironmentProduction &&
+		h.config.Auth.SkipSecurityKey != "" &&
+		c.Get("X-API-Key") == h.config.Auth.SkipSecurityKey {
+
+		validateAuthData = false
+	}
+
+	var req model.SignInWithWidgetRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	miniApp, err := h.studentMiniApp(c.Context(), req.MiniAppName)
+	if err != nil {
+		return err
+	}
+
+	if miniApp.BotToken == "" {
 		return apperrors.Unauthorized("no bot available for mini-app")
 	}
 
-	if !miniApp.IsActive {
-		return apperrors.BadRequest("mini-app not found")
+	botToken, err := h.securityService.DecryptString(miniApp.BotToken)
+	if err != nil {
+		return apperrors.Internal("failed to decrypt bot token", err)
 	}
 
-	initData, err := h.telegramService.ParseToken(miniApp.BotID, req.InitData, validateInitData)
+	initData, err := h.telegramService.ParseLoginWidget(botToken, req.AuthData, validateAuthData)
 	if err != nil {
-		return apperrors.BadRequest("failed to parse token", err)
+		return apperrors.BadRequest("failed to parse auth data", err)
 	}
 
 	if initData.User.ID == 0 {
-		return apperrors.BadRequest("no user id provided in initialization data", err)
+		return apperrors.BadRequest("no user id provided in auth data", err)
 	}
 
-	user, err := h.userService.SignInWithTelegramMiniApp(c.Context(), miniApp.ID, initData, model.UserRoleStudent)
+	return h.signInStudent(c, miniApp.ID, initData)
+}
+
+// studentMiniApp returns the active mini-app with a bot that students sign in
+// to.
+func (h *V1Handler) studentMiniApp(ctx context.Context, name string) (*model.MiniApp, error) {
+	miniApp, err := h.miniAppService.GetByName(ctx, name)
+	if err != nil || miniApp == nil {
+		return nil, apperrors.BadRequest("invalid mini-app name", err)
+	}
+
+	if miniApp.BotID == 0 {
+		return nil, apperrors.Unauthorized("no bot available for mini-app")
+	}
+
+	if !miniApp.IsActive {
+		return nil, apperrors.BadRequest("mini-app not found")
+	}
+
+	return miniApp, nil
+}
+
+// signInStudent finds or creates the student of the Telegram user and responds
+// with the user and issued tokens.
+func (h *V1Handler) signInStudent(c fiber.Ctx, miniAppID uuid.UUID, initData *initdata.InitData) error {
+	user, err := h.userService.SignInWithTelegramMiniApp(c.Context(), miniAppID, initData, model.UserRoleStudent)
 	if err != nil {
 		return apperrors.BadRequest("failed to sign in", err)
 	}
 
 	claims := &jwt.TokenClaims{
-		MiniAppID: miniApp.ID,
+		MiniAppID: miniAppID,
 		UserID:    user.ID,
 	}
 
-	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims)
+	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims, sessionClient(c))
 	if err != nil {
 		return apperrors.Internal("failed to generate response", err)
 	}
@@ -267,7 +332,7 @@ func (h *V1Handler) SignInWithInvite(c fiber.Ctx) error {
 		UserID:    user.ID,
 	}
 
-	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims)
+	tokenPair, err := h.jwtService.GenerateTokenPair(c.Context(), claims, sessionClient(c))
 	if err != nil {
 		return apperrors.Internal("failed to generate response", err)
 	}
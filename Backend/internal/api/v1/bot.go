@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/bot"
+	"academy/internal/service/jwt"
+	"academy/internal/service/upload"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+const botWebhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+func (h *V1Handler) BotSettings(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionBranding) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	settings, err := h.botService.Settings(c.Context(), claims.MiniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get bot settings", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"bot_settings": settings,
+	})
+}
+
+func (h *V1Handler) EditBotSettings(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionBranding) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	mpForm, err := c.MultipartForm()
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	data := mpForm.Value["bot_settings"]
+	if len(data) != 1 {
+		return apperrors.BadRequest("data not provided")
+	}
+
+	var req model.EditBotSettingsRequest
+	if err := json.Unmarshal([]byte(data[0]), &req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if botWelcomeMessageLimit < utf8.RuneCountInString(req.WelcomeMessage) {
+		return apperrors.BadRequest("welcome message exceeds the limit")
+	}
+	if err := checkBotButtonLimits(req.Buttons); err != nil {
+		return err
+	}
+
+	settings, err := h.botService.Settings(c.Context(), claims.MiniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get bot settings", err)
+	}
+
+	req.UpdateBotSettings(settings)
+
+	var isUpdated bool
+	var newFiles []string
+	var oldFiles []string
+	defer func() {
+		h.flushFiles(isUpdated, newFiles, oldFiles)
+	}()
+
+	if images := mpForm.File["image"]; !req.DeleteImage && 0 < len(images) {
+		filename, size, err := h.uploadBotWelcomeImage(c.Context(), images[0], claims.MiniAppID)
+		if err != nil {
+			return err
+		}
+		oldFiles = append(oldFiles, settings.WelcomeImage)
+		newFiles = append(newFiles, filename)
+
+		settings.WelcomeImage = filename
+		settings.WelcomeImageSize = size
+	}
+
+	if req.DeleteImage {
+		oldFiles = append(oldFiles, settings.WelcomeImage)
+		settings.WelcomeImage = ""
+		settings.WelcomeImageSize = 0
+	}
+
+	if err := h.botService.SaveSettings(c.Context(), settings); err != nil {
+		return apperrors.Internal("failed to save bot settings", err)
+	}
+
+	isUpdated = true
+
+	return c.JSON(fiber.Map{
+		"bot_settings": settings,
+	})
+}
+
+func checkBotButtonLimits(buttons []*model.BotButton) error {
+	for _, b := range buttons {
+		if botButtonTextLimit < utf8.RuneCountInString(b.Text) {
+			return apperrors.BadRequest("button text exceeds the limit")
+		}
+		if botButtonURLLimit < len(b.URL) {
+			return apperrors.BadRequest("button url exceeds the limit")
+		}
+	}
+
+	return nil
+}
+
+func (h *V1Handler) uploadBotWelcomeImage(
+	ctx context.Context,
+	image *multipart.FileHeader,
+	miniAppID uuid.UUID,
+) (string, int64, error) {
+
+	fileExt := strings.ToLower(filepath.Ext(image.Filename))
+
+	if !isPictureAllowed(image.Header.Get("Content-Type"), fileExt) {
+		return "", 0, apperrors.BadRequest("image type is not allowed")
+	}
+	if botWelcomeImageSizeLimit < image.Size {
+		return "", 0, apperrors.BadRequest("welcome image size exceeds the limit")
+	}
+
+	f, err := image.Open()
+	if err != nil {
+		return "", 0, apperrors.BadRequest("error while opening image", err)
+	}
+	defer f.Close()
+
+	miniAppPath := upload.MaterialFilePath{MiniAppID: miniAppID}
+
+	filename, size, err := h.uploadService.UploadImage(ctx, miniAppPath.String(), f)
+	if err != nil {
+		return "", 0, apperrors.BadRequest("error while uploading image", err)
+	}
+
+	return filename, size, nil
+}
+
+// BotWebhook receives updates of the mini-app bot from Telegram.
+func (h *V1Handler) BotWebhook(c fiber.Ctx) error {
+	miniAppID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	err = h.botManager.HandleWebhook(c.Context(), miniAppID, c.Get(botWebhookSecretHeader), c.Body())
+	if errors.Is(err, bot.ErrInvalidSecret) {
+		return apperrors.Unauthorized("invalid secret token")
+	}
+	if errors.Is(err, bot.ErrBotNotRunning) {
+		return apperrors.NotFound("bot not found")
+	}
+	if err != nil {
+		return apperrors.BadRequest("invalid update", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,342 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+	"academy/internal/service/upload"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func (h *V1Handler) Broadcasts(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.FilterBroadcastsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	req.Limit = validateLimit(req.Limit)
+
+	broadcasts, total, err := h.broadcastService.Find(c.Context(), claims.MiniAppID, &req)
+	if err != nil {
+		return apperrors.Internal("failed to get broadcasts", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"broadcasts": broadcasts,
+		"total":      total,
+	})
+}
+
+func (h *V1Handler) GetBroadcast(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	broadcast, err := h.getBroadcast(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"broadcast": broadcast,
+	})
+}
+
+// CreateBroadcast schedules message to the segment of students. Broadcast
+// without scheduled time is sent within a minute.
+func (h *V1Handler) CreateBroadcast(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	mpForm, err := c.MultipartForm()
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	data := mpForm.Value["broadcast"]
+	if len(data) != 1 {
+		return apperrors.BadRequest("broadcast not provided")
+	}
+
+	var req model.BroadcastRequest
+	if err := json.Unmarshal([]byte(data[0]), &req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := checkBotButtonLimits(req.Buttons); err != nil {
+		return err
+	}
+
+	images := mpForm.File["image"]
+
+	textLimit := broadcastTextLimit
+	if 0 < len(images) {
+		textLimit = broadcastCaptionLimit
+	}
+	if textLimit < utf8.RuneCountInString(req.Text) {
+		return apperrors.BadRequest("text exceeds the limit")
+	}
+
+	if err := h.validateBroadcastSegment(c.Context(), &claims, req.Segment); err != nil {
+		return err
+	}
+
+	broadcast := req.ToBroadcast(claims.MiniAppID, claims.UserID)
+
+	var isUpdated bool
+	var newFiles []string
+	defer func() {
+		h.flushFiles(isUpdated, newFiles, nil)
+	}()
+
+	if 0 < len(images) {
+		filename, size, err := h.uploadBroadcastImage(c.Context(), images[0], claims.MiniAppID)
+		if err != nil {
+			return err
+		}
+		newFiles = append(newFiles, filename)
+
+		broadcast.Image = filename
+		broadcast.ImageSize = size
+	}
+
+	if err := h.broadcastService.Create(c.Context(), broadcast); err != nil {
+		return apperrors.Internal("failed to create broadcast", err)
+	}
+
+	isUpdated = true
+
+	return c.JSON(fiber.Map{
+		"broadcast": broadcast,
+	})
+}
+
+// CountBroadcastSegment returns number of students in the segment, so the
+// owner could check it before sending.
+func (h *V1Handler) CountBroadcastSegment(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var segment model.BroadcastSegment
+	if err := c.Bind().JSON(&segment); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := segment.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if err := h.validateBroadcastSegment(c.Context(), &claims, &segment); err != nil {
+		return err
+	}
+
+	count, err := h.broadcastService.CountSegment(c.Context(), claims.MiniAppID, &segment)
+	if err != nil {
+		return apperrors.Internal("failed to count segment", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"total": count,
+	})
+}
+
+func (h *V1Handler) CancelBroadcast(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	broadcast, err := h.getBroadcast(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	isCancelled, err := h.broadcastService.Cancel(c.Context(), broadcast)
+	if err != nil {
+		return apperrors.Internal("failed to cancel broadcast", err)
+	}
+	if !isCancelled {
+		return apperrors.BadRequest("broadcast is already completed")
+	}
+
+	return c.JSON(fiber.Map{
+		"broadcast": broadcast,
+	})
+}
+
+// BroadcastRecipients returns recipients with delivery status, e.g. students
+// who blocked the bot.
+func (h *V1Handler) BroadcastRecipients(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	broadcast, err := h.getBroadcast(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	var req model.FilterBroadcastRecipientsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	req.Limit = validateLimit(req.Limit)
+
+	recipients, total, err := h.broadcastService.Recipients(c.Context(), broadcast.ID, &req)
+	if err != nil {
+		return apperrors.Internal("failed to get broadcast recipients", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"recipients": recipients,
+		"total":      total,
+	})
+}
+
+func (h *V1Handler) getBroadcast(c fiber.Ctx, miniAppID uuid.UUID) (*model.Broadcast, error) {
+	broadcastID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	broadcast, err := h.broadcastService.GetByID(c.Context(), broadcastID)
+	if err != nil {
+		return nil, apperrors.NotFound("broadcast not found", err)
+	}
+
+	if broadcast.MiniAppID != miniAppID {
+		return nil, apperrors.Unauthorized("user is not permitted")
+	}
+
+	return broadcast, nil
+}
+
+// validateBroadcastSegment checks that levels and cohorts of the segment
+// belong to its product. Moderators assigned to cohorts are able to message
+// only their students.
+func (h *V1Handler) validateBroadcastSegment(
+	ctx context.Context,
+	claims *jwt.TokenClaims,
+	segment *model.BroadcastSegment,
+) error {
+
+	if segment.ProductID == uuid.Nil {
+		return nil
+	}
+
+	if err := h.checkProduct(ctx, claims.MiniAppID, segment.ProductID); err != nil {
+		return err
+	}
+
+	for _, id := range segment.ProductLevelIDs {
+		level, err := h.productLevelService.GetByID(ctx, id)
+		if err != nil {
+			return apperrors.BadRequest("invalid product level", err)
+		}
+		if level.ProductID != segment.ProductID {
+			return apperrors.BadRequest("product level does not belong to the product")
+		}
+	}
+
+	for _, id := range segment.CohortIDs {
+		cohort, err := h.cohortService.GetByID(ctx, id)
+		if err != nil {
+			return apperrors.BadRequest("invalid cohort", err)
+		}
+		if cohort.ProductID != segment.ProductID {
+			return apperrors.BadRequest("cohort does not belong to the product")
+		}
+	}
+
+	cohortScope, err := h.cohortScope(ctx, claims, segment.ProductID)
+	if err != nil {
+		return err
+	}
+	var ok bool
+	segment.CohortIDs, ok = model.ScopeCohorts(cohortScope, segment.CohortIDs)
+	if !ok {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	return nil
+}
+
+func (h *V1Handler) uploadBroadcastImage(
+	ctx context.Context,
+	image *multipart.FileHeader,
+	miniAppID uuid.UUID,
+) (string, int64, error) {
+
+	fileExt := strings.ToLower(filepath.Ext(image.Filename))
+
+	if !isPictureAllowed(image.Header.Get("Content-Type"), fileExt) {
+		return "", 0, apperrors.BadRequest("image type is not allowed")
+	}
+	if broadcastImageSizeLimit < image.Size {
+		return "", 0, apperrors.BadRequest("broadcast image size exceeds the limit")
+	}
+
+	f, err := image.Open()
+	if err != nil {
+		return "", 0, apperrors.BadRequest("error while opening image", err)
+	}
+	defer f.Close()
+
+	miniAppPath := upload.MaterialFilePath{MiniAppID: miniAppID}
+
+	filename, size, err := h.uploadService.UploadImage(ctx, miniAppPath.String(), f)
+	if err != nil {
+		return "", 0, apperrors.BadRequest("error while uploading image", err)
+	}
+
+	return filename, size, nil
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/calendar"
+	"academy/internal/service/jwt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// calendarFeedPeriod is how long past events are kept in the student feed.
+const calendarFeedPeriod = 30 * 24 * time.Hour
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// UserCalendar returns URL of the student calendar feed that could be
+// subscribed to in calendar apps.
+func (h *V1Handler) UserCalendar(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if claims.IsStaff() {
+		return apperrors.Unauthorized("only students have calendar")
+	}
+
+	token, err := h.userService.CalendarToken(c.Context(), claims.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to get calendar token", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"url": c.BaseURL() + "/v1/calendar/" + token.String() + ".ics",
+	})
+}
+
+// CalendarFeed returns events of the student found by calendar token. It is
+// requested by calendar apps, so JWT is not used.
+func (h *V1Handler) CalendarFeed(c fiber.Ctx) error {
+	token, err := uuid.Parse(strings.TrimSuffix(c.Params("token"), ".ics"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	user, err := h.userService.GetByCalendarToken(c.Context(), token)
+	if err != nil {
+		return apperrors.NotFound("calendar not found", err)
+	}
+
+	miniApp, err := h.miniAppService.GetByID(c.Context(), user.MiniAppID)
+	if err != nil {
+		return apperrors.NotFound("calendar not found", err)
+	}
+
+	events, err := h.lessonService.UserEvents(c.Context(), user.ID, time.Now().Add(-calendarFeedPeriod))
+	if err != nil {
+		return apperrors.Internal("failed to get events", err)
+	}
+
+	c.Set(fiber.HeaderContentType, calendarContentType)
+
+	return c.Send(calendar.Encode(miniApp.Name, events))
+}
+
+// ProductCalendar returns events of the product. Students get only available
+// events shifted by the schedule of their cohort.
+func (h *V1Handler) ProductCalendar(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	product, err := h.productService.GetByID(c.Context(), productID, false)
+	if err != nil {
+		return apperrors.Internal("failed to get product", err)
+	}
+
+	if product.MiniAppID != claims.MiniAppID {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	if !product.IsActive && !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
+		return apperrors.BadRequest("product not active")
+	}
+
+	// Staff get all events without cohort shift, students only events of
+	// the product levels they have paid.
+	userID := uuid.Nil
+	if !claims.IsStaff() {
+		productAccess := model.NewProductAccess(claims.UserID, product.ID)
+		productAccess, err = h.productService.CheckProductAccess(c.Context(), productAccess)
+		if err != nil {
+			return apperrors.Internal("failed to get product access", err)
+		}
+		if productAccess.DeletedAt != nil {
+			return apperrors.Unauthorized("user deleted from accessing the product")
+		}
+
+		userID = claims.UserID
+	}
+
+	events, err := h.lessonService.ProductEvents(c.Context(), product.ID, userID)
+	if err != nil {
+		return apperrors.Internal("failed to get events", err)
+	}
+
+	c.Set(fiber.HeaderContentType, calendarContentType)
+	c.Attachment("calendar.ics")
+
+	return c.Send(calendar.Encode(product.Title, events))
+}
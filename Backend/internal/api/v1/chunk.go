@@ -3,6 +3,7 @@ package v1
 import (
 	"academy/internal/api/apperrors"
 	"academy/internal/model"
+	"academy/internal/service"
 	"academy/internal/service/jwt"
 	"academy/internal/service/upload"
 	"encoding/json"
@@ -163,12 +164,6 @@ func (h *V1Handler) SubmitChunks(c fiber.Ctx) error {
 		return err
 	}
 
-	lessonPath := upload.MaterialFilePath{
-		MiniAppID: claims.MiniAppID,
-		ProductID: lesson.ProductID,
-		LessonID:  lesson.ID,
-	}
-
 	var isUpdated bool
 	var newFiles []string
 	var oldFiles []string
@@ -186,8 +181,9 @@ func (h *V1Handler) SubmitChunks(c fiber.Ctx) error {
 
 	fileExt := strings.ToLower(filepath.Ext(req.OriginalFilename))
 
+	// Files are shared with materials of the mini-app with the same content.
 	filename, fileSize, err := h.uploadService.UploadChunks(
-		c.Context(), lessonPath.String(), fileExt, chunks)
+		c.Context(), claims.MiniAppID, fileExt, chunks)
 	if err != nil {
 		return apperrors.Internal("failed to submit chunks", err)
 	}
@@ -211,7 +207,7 @@ func (h *V1Handler) SubmitChunks(c fiber.Ctx) error {
 	}
 
 	if req.Status != "" {
-		material.Status = req.Status
+		material.Status = h.uploadService.VideoStatus(req.Status)
 	}
 
 	if err := checkMaterialFile(
@@ -221,7 +217,18 @@ func (h *V1Handler) SubmitChunks(c fiber.Ctx) error {
 		return err
 	}
 
+	if err := material.CheckWatermark(); err != nil {
+		return apperrors.RequestEntityTooLarge(err.Error())
+	}
+
+	if err := h.scanMaterialFile(c.Context(), material); err != nil {
+		return err
+	}
+
 	err = h.materialService.Update(c.Context(), material)
+	if errors.Is(err, service.ErrBlobDeleted) {
+		return apperrors.Conflict("file is deleted, upload it again")
+	}
 	if err != nil {
 		return apperrors.Internal("error while submiting chunks", err)
 	}
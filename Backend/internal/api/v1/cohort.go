@@ -0,0 +1,284 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service"
+	"academy/internal/service/jwt"
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func (h *V1Handler) ProductCohorts(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims,
+		model.PermissionStudentManagement,
+		model.PermissionStudentInteraction,
+		model.PermissionAnalytics,
+	) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, productID); err != nil {
+		return err
+	}
+
+	cohorts, err := h.cohortService.ProductCohorts(c.Context(), productID)
+	if err != nil {
+		return apperrors.Internal("failed to get product cohorts", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"cohorts": cohorts,
+	})
+}
+
+func (h *V1Handler) CreateCohort(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, productID); err != nil {
+		return err
+	}
+
+	var req model.CohortRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := h.validateCohortRequest(c.Context(), claims.MiniAppID, &req); err != nil {
+		return err
+	}
+
+	cohort := req.ToCohort(productID)
+
+	if err := h.cohortService.Create(c.Context(), cohort); err != nil {
+		return apperrors.Internal("failed to create cohort", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"cohort": cohort,
+	})
+}
+
+func (h *V1Handler) EditCohort(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	cohort, err := h.getCohort(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	var req model.CohortRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := h.validateCohortRequest(c.Context(), claims.MiniAppID, &req); err != nil {
+		return err
+	}
+
+	req.UpdateCohort(cohort)
+
+	if err := h.cohortService.Update(c.Context(), cohort); err != nil {
+		return apperrors.Internal("failed to update cohort", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"cohort": cohort,
+	})
+}
+
+func (h *V1Handler) DeleteCohort(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	cohort, err := h.getCohort(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	if err := h.cohortService.Delete(c.Context(), cohort.ID); err != nil {
+		return apperrors.Internal("failed to delete cohort", err)
+	}
+
+	return nil
+}
+
+func (h *V1Handler) AssignCohortStudents(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	cohort, err := h.getCohort(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	var req model.AssignCohortStudentsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	err = h.cohortService.AssignStudents(c.Context(), claims.MiniAppID, cohort, req.UserIDs)
+	if errors.Is(err, service.ErrCohortFull) {
+		return apperrors.BadRequest("cohort has no free seats")
+	}
+	if err != nil {
+		return apperrors.BadRequest("failed to assign students", err)
+	}
+
+	return nil
+}
+
+func (h *V1Handler) JoinCohort(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if claims.IsStaff() {
+		return apperrors.Unauthorized("only students can join cohort")
+	}
+
+	var req model.JoinCohortRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if req.InviteID == uuid.Nil {
+		return apperrors.BadRequest("invalid invite id")
+	}
+
+	cohort, err := h.cohortService.Join(c.Context(), claims.MiniAppID, req.InviteID, claims.UserID)
+	if errors.Is(err, service.ErrCohortFull) {
+		return apperrors.BadRequest("cohort has no free seats")
+	}
+	if errors.Is(err, service.ErrAlreadyInCohort) {
+		return apperrors.AlreadyExist("student already assigned to a cohort")
+	}
+	if err != nil {
+		return apperrors.BadRequest("invalid invite", err)
+	}
+
+	cohort.InviteID = uuid.Nil
+	cohort.Moderators = nil
+
+	return c.JSON(fiber.Map{
+		"cohort": cohort,
+	})
+}
+
+func (h *V1Handler) getCohort(c fiber.Ctx, miniAppID uuid.UUID) (*model.Cohort, error) {
+	cohortID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	cohort, err := h.cohortService.GetByID(c.Context(), cohortID)
+	if err != nil {
+		return nil, apperrors.NotFound("cohort not found", err)
+	}
+
+	if err := h.checkProduct(c.Context(), miniAppID, cohort.ProductID); err != nil {
+		return nil, err
+	}
+
+	return cohort, nil
+}
+
+func (h *V1Handler) validateCohortRequest(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	req *model.CohortRequest,
+) error {
+
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if cohortNameLimit < utf8.RuneCountInString(req.Name) {
+		return apperrors.BadRequest("cohort name exceeds the limit")
+	}
+	if err := h.cohortService.ValidateModerators(ctx, miniAppID, req.ModeratorIDs); err != nil {
+		return apperrors.BadRequest("invalid cohort moderators", err)
+	}
+
+	return nil
+}
+
+// purchaseCohort returns cohort that student joins with the purchase. Cohort
+// could be chosen with optional cohort_id query parameter.
+func (h *V1Handler) purchaseCohort(c fiber.Ctx, userID, productID uuid.UUID) (*model.Cohort, error) {
+	var cohortID uuid.UUID
+	if v := fiber.Query[string](c, "cohort_id"); v != "" {
+		var err error
+		cohortID, err = uuid.Parse(v)
+		if err != nil {
+			return nil, apperrors.BadRequest("invalid cohort id", err)
+		}
+	}
+
+	cohort, err := h.cohortService.PurchaseCohort(c.Context(), productID, userID, cohortID)
+	if errors.Is(err, service.ErrCohortFull) {
+		return nil, apperrors.BadRequest("cohort has no free seats")
+	}
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid cohort", err)
+	}
+
+	return cohort, nil
+}
+
+// cohortScope returns cohorts that moderator is limited to within the
+// product. Empty result means no limits.
+func (h *V1Handler) cohortScope(ctx context.Context, claims *jwt.TokenClaims, productID uuid.UUID) ([]uuid.UUID, error) {
+	if !claims.IsMod {
+		return nil, nil
+	}
+
+	cohortIDs, err := h.cohortService.ModeratorCohortIDs(ctx, productID, claims.UserID)
+	if err != nil {
+		return nil, apperrors.Internal("failed to get moderator cohorts", err)
+	}
+
+	return cohortIDs, nil
+}
@@ -0,0 +1,139 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// JoinEvent returns meeting URL of the event and checks the student in.
+func (h *V1Handler) JoinEvent(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	lessonID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	lesson, err := h.validateLessonAccess(c.Context(), &claims, lessonID)
+	if err != nil {
+		return err
+	}
+
+	if lesson.ContentType != model.LessonTypeEvent {
+		return apperrors.BadRequest("lesson is not an event")
+	}
+
+	if lesson.MeetingURL == "" {
+		return apperrors.BadRequest("meeting is not available")
+	}
+
+	if !claims.IsStaff() {
+		attendance := model.NewEventAttendance(lesson.ID, claims.UserID, model.AttendanceSourceJoinLink)
+		if err := h.lessonService.CheckIn(c.Context(), attendance); err != nil {
+			return apperrors.Internal("failed to check in", err)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"meeting_url": lesson.MeetingURL,
+	})
+}
+
+func (h *V1Handler) EventAttendance(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims,
+		model.PermissionStudentManagement,
+		model.PermissionStudentInteraction,
+		model.PermissionAnalytics,
+	) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	lessonID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if err := h.checkLesson(c.Context(), claims.MiniAppID, lessonID); err != nil {
+		return err
+	}
+
+	attendance, err := h.lessonService.Attendance(c.Context(), lessonID)
+	if err != nil {
+		return apperrors.Internal("failed to get attendance", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"attendance": attendance,
+	})
+}
+
+// AttachRecording creates the recording material of the event lesson. Recording
+// is uploaded into returned material with chunks, the lesson becomes a video
+// lesson once the recording is ready.
+func (h *V1Handler) AttachRecording(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	lessonID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	lesson, err := h.lessonService.GetByID(c.Context(), lessonID, uuid.Nil)
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, lesson.ProductID); err != nil {
+		return err
+	}
+
+	if lesson.ContentType != model.LessonTypeEvent {
+		return apperrors.BadRequest("only event lessons could get a recording")
+	}
+
+	var req model.AttachRecordingRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if req.Title == "" {
+		req.Title = lesson.Title
+	}
+	if lessonTitleLimit < utf8.RuneCountInString(req.Title) {
+		return apperrors.BadRequest("lesson title exceeds the limit")
+	}
+	if videoLessonDescriptionLimit < utf8.RuneCountInString(req.Description) {
+		return apperrors.BadRequest("lesson description exceeds the limit")
+	}
+
+	recording := req.ToMaterial(lesson.ID)
+
+	if err := h.lessonService.AttachRecording(c.Context(), recording); err != nil {
+		return apperrors.Internal("failed to attach recording", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"lesson":   lesson,
+		"material": recording,
+	})
+}
@@ -0,0 +1,445 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+	"academy/internal/service/upload"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func (h *V1Handler) PointRules(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	rules, err := h.gamificationService.PointRules(c.Context(), claims.MiniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get point rules", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+func (h *V1Handler) EditPointRules(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.EditPointRulesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	rules := req.ToPointRules(claims.MiniAppID)
+
+	err := h.gamificationService.EditPointRules(c.Context(), claims.MiniAppID, rules)
+	if err != nil {
+		return apperrors.Internal("failed to edit point rules", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+func (h *V1Handler) AdjustPoints(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.AdjustPointsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if pointReasonLimit < utf8.RuneCountInString(req.Reason) {
+		return apperrors.BadRequest("reason exceeds the limit")
+	}
+
+	user, err := h.userService.GetByID(c.Context(), req.UserID)
+	if err != nil || user == nil {
+		return apperrors.NotFound("user not found", err)
+	}
+	if user.MiniAppID != claims.MiniAppID {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	if req.ProductID != uuid.Nil {
+		if err := h.checkProduct(c.Context(), claims.MiniAppID, req.ProductID); err != nil {
+			return err
+		}
+	}
+
+	transaction := req.ToPointTransaction(claims.MiniAppID, claims.UserID)
+
+	if err := h.gamificationService.AdjustPoints(c.Context(), transaction); err != nil {
+		return apperrors.Internal("failed to adjust points", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"transaction": transaction,
+	})
+}
+
+func (h *V1Handler) PointHistory(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	var req model.FilterPointTransactionsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	// Students are able to see only own history.
+	if !h.isPermitted(c.Context(), &claims, model.PermissionAnalytics) {
+		req.UserID = []uuid.UUID{claims.UserID}
+	}
+
+	req.Limit = validateLimit(req.Limit)
+
+	transactions, total, err := h.gamificationService.PointHistory(c.Context(), claims.MiniAppID, &req)
+	if err != nil {
+		return apperrors.Internal("failed to get point history", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"transactions": transactions,
+		"total":        total,
+	})
+}
+
+func (h *V1Handler) ProductLeaderboard(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if productID == uuid.Nil {
+		return apperrors.BadRequest("invalid product id")
+	}
+
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, productID); err != nil {
+		return err
+	}
+
+	if !claims.IsStaff() {
+		productAccess := model.NewProductAccess(claims.UserID, productID)
+		productAccess, err = h.productService.CheckProductAccess(c.Context(), productAccess)
+		if err != nil {
+			return apperrors.Internal("failed to get product access", err)
+		}
+		if productAccess.DeletedAt != nil {
+			return apperrors.Unauthorized("user deleted from accessing the product")
+		}
+	} else if !h.isPermittedInProduct(c.Context(), &claims, productID, model.PermissionProductReports) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	offset := fiber.Query[uint](c, "offset")
+
+	limit := fiber.Query[uint](c, "limit")
+	limit = validateLimit(limit)
+
+	entries, total, err := h.gamificationService.Leaderboard(c.Context(), productID, limit, offset)
+	if err != nil {
+		return apperrors.Internal("failed to get leaderboard", err)
+	}
+
+	me, err := h.gamificationService.LeaderboardPosition(c.Context(), productID, claims.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to get leaderboard position", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"leaderboard": entries,
+		"total":       total,
+		"me":          me,
+	})
+}
+
+func (h *V1Handler) UserBadges(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	badges, err := h.gamificationService.UserBadges(c.Context(), claims.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to get user badges", err)
+	}
+
+	points, err := h.gamificationService.TotalPoints(c.Context(), claims.UserID, uuid.Nil)
+	if err != nil {
+		return apperrors.Internal("failed to get user points", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"badges": badges,
+		"points": points,
+	})
+}
+
+func (h *V1Handler) Badges(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	badges, err := h.gamificationService.Badges(c.Context(), claims.MiniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get badges", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"badges": badges,
+	})
+}
+
+func (h *V1Handler) CreateBadge(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	mpForm, err := c.MultipartForm()
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	req, err := h.parseBadgeRequest(c, mpForm, &claims)
+	if err != nil {
+		return err
+	}
+
+	badge := req.ToBadge(claims.MiniAppID)
+
+	var isUpdated bool
+	var newFiles []string
+	defer func() {
+		h.flushFiles(isUpdated, newFiles, nil)
+	}()
+
+	if images := mpForm.File["image"]; 0 < len(images) {
+		filename, size, err := h.uploadBadgeImage(c.Context(), images[0], claims.MiniAppID)
+		if err != nil {
+			return err
+		}
+		newFiles = append(newFiles, filename)
+
+		badge.Image = filename
+		badge.ImageSize = size
+	}
+
+	if err := h.gamificationService.CreateBadge(c.Context(), badge); err != nil {
+		return apperrors.Internal("failed to create badge", err)
+	}
+
+	isUpdated = true
+
+	return c.JSON(fiber.Map{
+		"badge": badge,
+	})
+}
+
+func (h *V1Handler) EditBadge(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	badgeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	badge, err := h.gamificationService.GetBadge(c.Context(), badgeID)
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if badge.MiniAppID != claims.MiniAppID {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	mpForm, err := c.MultipartForm()
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	req, err := h.parseBadgeRequest(c, mpForm, &claims)
+	if err != nil {
+		return err
+	}
+
+	isChanged := req.UpdateBadge(badge)
+
+	var isUpdated bool
+	var newFiles []string
+	var oldFiles []string
+	defer func() {
+		h.flushFiles(isUpdated, newFiles, oldFiles)
+	}()
+
+	if images := mpForm.File["image"]; 0 < len(images) {
+		filename, size, err := h.uploadBadgeImage(c.Context(), images[0], claims.MiniAppID)
+		if err != nil {
+			return err
+		}
+		oldFiles = append(oldFiles, badge.Image)
+		newFiles = append(newFiles, filename)
+
+		badge.Image = filename
+		badge.ImageSize = size
+		isChanged = true
+	}
+
+	if isChanged {
+		if err := h.gamificationService.UpdateBadge(c.Context(), badge); err != nil {
+			return apperrors.Internal("failed to update badge", err)
+		}
+	}
+
+	isUpdated = true
+
+	return c.JSON(fiber.Map{
+		"badge": badge,
+	})
+}
+
+func (h *V1Handler) DeleteBadge(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	badgeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	badge, err := h.gamificationService.GetBadge(c.Context(), badgeID)
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if badge.MiniAppID != claims.MiniAppID {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	if err := h.gamificationService.DeleteBadge(c.Context(), claims.MiniAppID, badgeID); err != nil {
+		return apperrors.Internal("failed to delete badge", err)
+	}
+
+	h.flushFiles(true, nil, []string{badge.Image})
+
+	return nil
+}
+
+func (h *V1Handler) parseBadgeRequest(
+	c fiber.Ctx,
+	mpForm *multipart.Form,
+	claims *jwt.TokenClaims,
+) (*model.BadgeRequest, error) {
+
+	badges := mpForm.Value["badge"]
+	if len(badges) != 1 {
+		return nil, apperrors.BadRequest("badge not provided")
+	}
+
+	var req model.BadgeRequest
+	if err := json.Unmarshal([]byte(badges[0]), &req); err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+	if badgeTitleLimit < utf8.RuneCountInString(req.Title) {
+		return nil, apperrors.BadRequest("badge title exceeds the limit")
+	}
+	if badgeDescriptionLimit < utf8.RuneCountInString(req.Description) {
+		return nil, apperrors.BadRequest("badge description exceeds the limit")
+	}
+
+	if req.ProductID != uuid.Nil {
+		if err := h.checkProduct(c.Context(), claims.MiniAppID, req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &req, nil
+}
+
+func (h *V1Handler) uploadBadgeImage(
+	ctx context.Context,
+	image *multipart.FileHeader,
+	miniAppID uuid.UUID,
+) (string, int64, error) {
+
+	fileExt := strings.ToLower(filepath.Ext(image.Filename))
+
+	if !isPictureAllowed(image.Header.Get("Content-Type"), fileExt) {
+		return "", 0, apperrors.BadRequest("image type is not allowed")
+	}
+	if badgeImageSizeLimit < image.Size {
+		return "", 0, apperrors.BadRequest("badge image size exceeds the limit")
+	}
+
+	f, err := image.Open()
+	if err != nil {
+		return "", 0, apperrors.BadRequest("error while opening image", err)
+	}
+	defer f.Close()
+
+	miniAppPath := upload.MaterialFilePath{MiniAppID: miniAppID}
+
+	filename, size, err := h.uploadService.UploadImage(ctx, miniAppPath.String(), f)
+	if err != nil {
+		return "", 0, apperrors.BadRequest("error while uploading image", err)
+	}
+
+	return filename, size, nil
+}
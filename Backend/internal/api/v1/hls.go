@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/upload"
+	"errors"
+	"path"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var hlsContentTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+	".jpg":  "image/jpeg",
+	".vtt":  "text/vtt",
+}
+
+// hlsURL returns URL of the file of the transcoded video signed by the token.
+func hlsURL(c fiber.Ctx, metadata *model.HLSVideoMetadata, token, name string) string {
+	return c.BaseURL() + "/v1/" + metadata.AssetID + "/" + token + "/" + name
+}
+
+// GetHLSFile serves files of transcoded videos to holders of the token of the
+// asset. Playlists are always served by the API, so relative URLs of their
+// segments keep the token, segments of remote storages are downloaded by
+// presigned URLs.
+func (h *V1Handler) GetHLSFile(c fiber.Ctx) error {
+	assetID, err := upload.HLSAssetID(c.Params("id"))
+	if err != nil {
+		return apperrors.NotFound("file not found", err)
+	}
+
+	key, err := h.uploadService.HLSFile(assetID, c.Params("token"), c.Params("*"))
+	if errors.Is(err, upload.ErrInvalidHLSToken) {
+		return apperrors.Unauthorized("invalid token", err)
+	}
+	if err != nil {
+		return apperrors.NotFound("file not found", err)
+	}
+
+	ext := path.Ext(key)
+
+	if ext != ".m3u8" {
+		fileURL, err := h.uploadService.PresignedURL(c.Context(), key)
+		if err == nil {
+			return c.Redirect().Status(fiber.StatusTemporaryRedirect).To(fileURL)
+		}
+		if !errors.Is(err, upload.ErrPresignNotSupported) {
+			return apperrors.Internal("failed to get file url", err)
+		}
+	}
+
+	file, info, err := h.uploadService.Open(c.Context(), key, nil)
+	if errors.Is(err, upload.ErrObjectNotFound) {
+		return apperrors.NotFound("file not found", err)
+	}
+	if err != nil {
+		return apperrors.Internal("failed to open file", err)
+	}
+
+	c.Set(fiber.HeaderContentType, hlsContentTypes[ext])
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+
+	return c.SendStream(file, int(info.Size))
+}
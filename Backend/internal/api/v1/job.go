@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// JobsAuthMiddleware checks the key of operators of the job queue, requests
+// are rejected if the key is not configured.
+func (h *V1Handler) JobsAuthMiddleware(c fiber.Ctx) error {
+	key := h.config.Auth.JobsAPIKey
+	if key == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-API-Key")), []byte(key)) != 1 {
+		return apperrors.Unauthorized("invalid api key")
+	}
+
+	return c.Next()
+}
+
+func (h *V1Handler) Jobs(c fiber.Ctx) error {
+	var req model.FilterJobsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	req.Limit = validateLimit(req.Limit)
+
+	jobs, total, err := h.jobService.Find(c.Context(), &req)
+	if err != nil {
+		return apperrors.Internal("failed to get jobs", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"jobs":  jobs,
+		"total": total,
+	})
+}
+
+func (h *V1Handler) RetryJob(c fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	err = h.jobService.Retry(c.Context(), jobID)
+	if errors.Is(err, service.ErrJobNotFound) {
+		return apperrors.NotFound("dead job not found")
+	}
+	if errors.Is(err, service.ErrJobActive) {
+		return apperrors.Conflict("active job with the same key exists")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to retry job", err)
+	}
+
+	return nil
+}
@@ -3,12 +3,16 @@ package v1
 import (
 	"academy/internal/api/apperrors"
 	"academy/internal/model"
+	"academy/internal/service"
 	"academy/internal/service/jwt"
 	"academy/internal/service/upload"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v3"
@@ -22,10 +26,6 @@ func (h *V1Handler) CreateLesson(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	var req model.CreateLessonRequest
 	if err := c.Bind().JSON(&req); err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -35,11 +35,21 @@ func (h *V1Handler) CreateLesson(c fiber.Ctx) error {
 		return apperrors.BadRequest("module name exceeds the limit")
 	}
 
+	if eventMeetingURLLimit < len(req.MeetingURL) {
+		return apperrors.BadRequest("meeting url exceeds the limit")
+	}
+
 	lesson, err := req.ToLesson()
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 
+	if lesson.ContentType == model.LessonTypeEvent {
+		if err := h.checkEventsLimit(c.Context(), claims.MiniAppID); err != nil {
+			return err
+		}
+	}
+
 	product, err := h.productService.GetByID(c.Context(), req.ProductID, true)
 	if err != nil {
 		return apperrors.Internal("failed to get product", err)
@@ -49,6 +59,10 @@ func (h *V1Handler) CreateLesson(c fiber.Ctx) error {
 		return apperrors.Unauthorized("user is not permitted")
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, product.ID, model.PermissionContentEditing) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	err = h.lessonService.Create(c.Context(), lesson, req.Index, product, req.ProductLevelID)
 	if err != nil {
 		return apperrors.Internal("failed to create lesson", err)
@@ -86,10 +100,6 @@ func (h *V1Handler) EditLesson(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	lessonID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -113,6 +123,20 @@ func (h *V1Handler) EditLesson(c fiber.Ctx) error {
 		return err
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, lesson.ProductID, model.PermissionContentEditing) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	if eventMeetingURLLimit < len(req.MeetingURL) {
+		return apperrors.BadRequest("meeting url exceeds the limit")
+	}
+
+	if lesson.ContentType != model.LessonTypeEvent && req.ContentType == model.LessonTypeEvent {
+		if err := h.checkEventsLimit(c.Context(), claims.MiniAppID); err != nil {
+			return err
+		}
+	}
+
 	isChanged, err := req.UpdateLesson(lesson)
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -136,10 +160,6 @@ func (h *V1Handler) DeleteLesson(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionProductsControl) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	lessonID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -163,6 +183,10 @@ func (h *V1Handler) DeleteLesson(c fiber.Ctx) error {
 		return apperrors.Unauthorized("user is not permitted")
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, product.ID, model.PermissionContentEditing) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	err = h.lessonService.Delete(c.Context(), lessonID, product)
 	if err != nil {
 		return apperrors.Internal("error while deleting the lesson", err)
@@ -187,7 +211,7 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if claims.IsOwner || claims.IsMod {
+	if claims.IsStaff() {
 		return apperrors.Unauthorized("only students can submit the lesson")
 	}
 
@@ -224,6 +248,11 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 			return apperrors.Internal("error while creating progress", err)
 		}
 
+		err = h.gamificationService.AwardLessonProgress(c.Context(), claims.MiniAppID, lessonProgress, false)
+		if err != nil {
+			h.logger.Error("failed to award points", zap.Error(err))
+		}
+
 		return c.JSON(model.LessonSubmitionResponce{
 			LessonResult: lessonProgress,
 		})
@@ -290,6 +319,11 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 		if err != nil {
 			return apperrors.Internal("error while creating progress", err)
 		}
+
+		err = h.gamificationService.AwardLessonProgress(c.Context(), claims.MiniAppID, lessonProgress, true)
+		if err != nil {
+			h.logger.Error("failed to award points", zap.Error(err))
+		}
 	}
 
 	var isUpdated bool
@@ -315,6 +349,7 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 		progressData.Links = req.Links
 
 		var totalFilesSize int64
+		var quarantined bool
 		if openQuestionMetadata.AllowFileAnswer {
 			files := mpForm.File["file"]
 			if submitLessonFilesLimit < len(files) {
@@ -350,10 +385,25 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 
 				newFiles = append(newFiles, fileURL)
 
+				// Answers are checked by staff, so they are quarantined till
+				// the scheduled scan if the scanner is unavailable.
+				scan, err := h.uploadService.ScanFile(c.Context(), fileURL)
+				if err != nil {
+					h.logger.Error("failed to scan file, answer is quarantined",
+						zap.String("lesson_id", lesson.ID.String()),
+						zap.Error(err),
+					)
+					quarantined = true
+				}
+				if scan.IsInfected() {
+					return apperrors.BadRequest(fmt.Sprintf("file %s is infected: %s", f.Filename, scan.Signature))
+				}
+
 				progressData.FilesMetadata = append(progressData.FilesMetadata, &model.FileMetadata{
 					Filename:         fileURL,
 					OriginalFilename: f.Filename,
 					Size:             fileSize,
+					Scan:             scan,
 				})
 				totalFilesSize += fileSize
 			}
@@ -369,6 +419,9 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 			lessonID,
 			data, totalFilesSize,
 		)
+		if quarantined {
+			lessonProgress.Status = model.LessonProgressStatusQuarantined
+		}
 
 		err = h.lessonProgressService.CreateOrUpdate(c.Context(), lessonProgress)
 		if err != nil {
@@ -378,6 +431,12 @@ func (h *V1Handler) SubmitLesson(c fiber.Ctx) error {
 
 	isUpdated = true
 
+	if lessonProgress.Status == model.LessonProgressStatusPending {
+		if err := h.staffNotificationService.NotifySubmission(c.Context(), lessonProgress); err != nil {
+			h.logger.Error("failed to notify about submission", zap.Error(err))
+		}
+	}
+
 	return c.JSON(model.LessonSubmitionResponce{
 		LessonResult: lessonProgress,
 	})
@@ -450,24 +509,84 @@ func (h *V1Handler) FeedbackHomework(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	var req model.FeedbackHomeworkRequest
 	if err := c.Bind().JSON(&req); err != nil {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 
-	if err := h.checkLesson(c.Context(), claims.MiniAppID, req.LessonID); err != nil {
+	lesson, err := h.lessonService.GetByID(c.Context(), req.LessonID, uuid.Nil)
+	if err != nil {
+		return apperrors.Internal("error while getting the lesson", err)
+	}
+
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, lesson.ProductID); err != nil {
 		return err
 	}
 
-	err := h.lessonProgressService.FeedbackHomework(c.Context(), &req)
+	if !h.isPermittedInProduct(c.Context(), &claims, lesson.ProductID, model.PermissionHomeworkReview) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	progress, err := h.lessonProgressService.FeedbackHomework(c.Context(), &req)
+	if errors.Is(err, service.ErrHomeworkQuarantined) {
+		return apperrors.Conflict("homework files are not scanned yet")
+	}
 	if err != nil {
 		return apperrors.Internal("error while getting homework by product", err)
 	}
 
+	err = h.gamificationService.AwardLessonProgress(c.Context(), claims.MiniAppID, progress, true)
+	if err != nil {
+		h.logger.Error("failed to award points", zap.Error(err))
+	}
+
+	if err := h.notifyHomeworkFeedback(c.Context(), claims.MiniAppID, progress); err != nil {
+		h.logger.Error("failed to notify about homework feedback", zap.Error(err))
+	}
+
+	return nil
+}
+
+// notifyHomeworkFeedback notifies the student that homework is reviewed.
+func (h *V1Handler) notifyHomeworkFeedback(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	progress *model.LessonProgress,
+) error {
+
+	var kind model.NotificationKind
+	switch progress.Status {
+	case model.LessonProgressStatusAccepted:
+		kind = model.NotificationKindHomeworkAccepted
+	case model.LessonProgressStatusFailed:
+		kind = model.NotificationKindHomeworkFailed
+	default:
+		return nil
+	}
+
+	lesson, err := h.lessonService.GetByID(ctx, progress.LessonID, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("failed to get lesson: %w", err)
+	}
+
+	notification := model.NewNotification(miniAppID, progress.UserID, kind, map[string]string{
+		"lesson": lesson.Title,
+	})
+
+	return h.notificationService.Notify(ctx, notification)
+}
+
+// checkEventsLimit checks that the plan of the mini-app allows one more event.
+func (h *V1Handler) checkEventsLimit(ctx context.Context, miniAppID uuid.UUID) error {
+	info, err := h.miniAppService.GetInfo(ctx, miniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get mini-app info", err)
+	}
+
+	if info.IsEventsLimitReached() {
+		return apperrors.BadRequest("number of events exceeds the limit")
+	}
+
 	return nil
 }
 
@@ -480,6 +599,8 @@ func (h *V1Handler) FeedbackHomework(c fiber.Ctx) error {
 // 6. Is lesson active for access.
 // 7. Is lesson released and accessible.
 // 8. Is lesson paid by the student (for paid lesson) or unlocked by invite.
+//
+// Meeting URL of the event is hidden until the event starts soon.
 func (h *V1Handler) validateLessonAccess(
 	ctx context.Context,
 	claims *jwt.TokenClaims,
@@ -504,6 +625,10 @@ func (h *V1Handler) validateLessonAccess(
 		return lesson, nil
 	}
 
+	if claims.APIKeyID != uuid.Nil {
+		return nil, apperrors.Unauthorized("user is not permitted")
+	}
+
 	if !product.IsActive {
 		return nil, apperrors.BadRequest("product not accessible")
 	}
@@ -519,6 +644,9 @@ func (h *V1Handler) validateLessonAccess(
 	if productAccess.DeletedAt != nil {
 		return nil, apperrors.Unauthorized("user deleted from accessing the product")
 	}
+	if len(productAccess.UnmetPrerequisites) != 0 {
+		return nil, apperrors.BadRequest(model.UnmetPrerequisitesMessage(productAccess.UnmetPrerequisites))
+	}
 
 	if lesson.PreviousLessonID != uuid.Nil {
 		if len(lesson.PrevLessonProgress) == 0 ||
@@ -532,8 +660,18 @@ func (h *V1Handler) validateLessonAccess(
 		return nil, apperrors.BadRequest("lesson not found")
 	}
 
+	if lesson.ReleaseDate.Valid || lesson.EventStart.Valid {
+		cohort, err := h.cohortService.StudentCohort(ctx, product.ID, claims.UserID)
+		if err != nil {
+			return nil, apperrors.Internal("failed to get student cohort", err)
+		}
+		if cohort != nil {
+			cohort.ShiftSchedule(lesson)
+		}
+	}
+
 	if lesson.ReleaseDate.Valid {
-		err := isAccessible(lesson.ReleaseDate, lesson.AccessTime)
+		err = isAccessible(lesson.ReleaseDate, lesson.AccessTime)
 		if err != nil {
 			return nil, apperrors.Unauthorized("lesson not accessible", err)
 		}
@@ -548,5 +686,7 @@ func (h *V1Handler) validateLessonAccess(
 		return nil, apperrors.Unauthorized("the lesson is locked for the user")
 	}
 
+	lesson.HideMeetingURL(time.Now())
+
 	return lesson, nil
 }
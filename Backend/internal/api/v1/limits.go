@@ -26,6 +26,23 @@ const (
 	tosSizeLimit              = 3_000_000
 )
 
+// Bot limits.
+const (
+	botWelcomeMessageLimit   = 1024 // Telegram limit of photo caption.
+	botWelcomeImageSizeLimit = 5_000_000
+	botButtonTextLimit       = 64
+	botButtonURLLimit        = 255
+)
+
+// Broadcast limits.
+const (
+	broadcastTextLimit      = 4096 // Telegram limit of message text.
+	broadcastCaptionLimit   = 1024 // Telegram limit of photo caption.
+	broadcastImageSizeLimit = 5_000_000
+)
+
+const supportReplyLimit = 4096 // Telegram limit of message text.
+
 // User limits.
 const (
 	ownerAvatarSizeLimit = 4_000_000
@@ -40,6 +57,11 @@ const (
 	productCoverSizeLimit   = 5_000_000
 
 	productLevelNameLimit = 45
+
+	cohortNameLimit = 55
+
+	modRoleNameLimit = 100
+	apiKeyNameLimit  = 100
 )
 
 // Lesson limits.
@@ -55,8 +77,11 @@ const (
 	audioLessonDescriptionLimit = 100
 	audioLessonSizeLimit        = 501_000_000
 
+	subtitlesSizeLimit = 2_000_000
+
 	// eventLessonDescriptionLimit = 450 // Same as videoLessonDescriptionLimit.
 	// eventLessonSizeLimit        = 4_001_000_000 // Same as videoLessonSizeLimit.
+	eventMeetingURLLimit = 512
 
 	materialSizeLimit  = 51_000_000
 	materialLinkLimit  = 5 // Checked on postgres using check_materials_limit function.
@@ -73,6 +98,10 @@ var allowedVideoExt map[string]struct{} = map[string]struct{}{
 var allowedAudioExt map[string]struct{} = map[string]struct{}{
 	".mp3": {},
 }
+var allowedSubtitlesExt map[string]struct{} = map[string]struct{}{
+	".vtt": {},
+	".srt": {},
+}
 var allowedMaterialExt map[string]struct{} = map[string]struct{}{
 	".zip":  {},
 	".txt":  {},
@@ -103,6 +132,15 @@ func isPictureAllowed(contentType, fileExt string) bool {
 	return true
 }
 
+// Gamification limits.
+const (
+	badgeTitleLimit       = 55
+	badgeDescriptionLimit = 255
+	badgeImageSizeLimit   = 1_000_000
+
+	pointReasonLimit = 255
+)
+
 // Limits on user uploaded homework materials.
 const (
 	submitLessonFileSizeLimit = 51_000_000
@@ -5,14 +5,17 @@ import (
 	"academy/internal/model"
 	"academy/internal/service/jwt"
 	"academy/internal/service/upload"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 func (h *V1Handler) CreateMaterial(c fiber.Ctx) error {
@@ -134,31 +137,38 @@ func (h *V1Handler) CreateMaterial(c fiber.Ctx) error {
 		fileExt := strings.ToLower(filepath.Ext(files[0].Filename))
 
 		fileContentType := files[0].Header.Get("Content-Type")
+		if err := checkMaterialFile(
+			req.Category, req.ContentType,
+			files[0].Size, fileExt, fileContentType,
+		); err != nil {
+			return err
+		}
+
 		f, err := files[0].Open()
 		if err != nil {
 			return apperrors.BadRequest("error while opening file", err)
 		}
 		originalFilename = files[0].Filename
 
-		filename, fileSize, err = h.uploadService.Upload(
-			materialPath, f, fileExt)
+		filename, fileSize, err = h.uploadMaterialFile(
+			c.Context(), req.Category, materialPath, f, fileExt)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading file", err)
 		}
 		newFiles = append(newFiles, filename)
-
-		if err := checkMaterialFile(
-			req.Category, req.ContentType,
-			fileSize, fileExt, fileContentType,
-		); err != nil {
-			return err
-		}
 	}
 
 	material, err := req.ToMaterial(originalFilename, filename, fileSize)
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
 	}
+	if err := material.CheckWatermark(); err != nil {
+		return apperrors.BadRequest(err.Error())
+	}
+
+	if err := h.scanMaterialFile(c.Context(), material); err != nil {
+		return err
+	}
 
 	err = h.materialService.Create(c.Context(), material)
 	if err != nil {
@@ -383,12 +393,19 @@ func (h *V1Handler) EditMaterial(c fiber.Ctx) error {
 		case model.MaterialTypeQuiz, model.MaterialTypeOpenQuestion:
 			return apperrors.BadRequest("this content type do not support files upload", err)
 		}
+		if err := checkMaterialFile(
+			material.Category, req.ContentType,
+			files[0].Size, fileExt, fileContentType,
+		); err != nil {
+			return err
+		}
+
 		f, err := files[0].Open()
 		if err != nil {
 			return apperrors.BadRequest("error while opening file", err)
 		}
-		filename, fileSize, err := h.uploadService.Upload(
-			materialPath, f, fileExt)
+		filename, fileSize, err := h.uploadMaterialFile(
+			c.Context(), material.Category, materialPath, f, fileExt)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading file", err)
 		}
@@ -399,14 +416,15 @@ func (h *V1Handler) EditMaterial(c fiber.Ctx) error {
 		material.Filename = filename
 		material.Size = fileSize
 
-		isChanged = true
-
-		if err := checkMaterialFile(
-			material.Category, req.ContentType,
-			fileSize, fileExt, fileContentType,
-		); err != nil {
+		if err := h.scanMaterialFile(c.Context(), material); err != nil {
 			return err
 		}
+
+		isChanged = true
+	}
+
+	if err := material.CheckWatermark(); err != nil {
+		return apperrors.BadRequest(err.Error())
 	}
 
 	if isChanged {
@@ -450,11 +468,28 @@ func (h *V1Handler) GetMaterialToken(c fiber.Ctx) error {
 		return apperrors.Unauthorized("material access restricted", err)
 	}
 
+	// Videos transcoded by the API are played by URLs signed for the asset.
+	var hlsMetadata model.HLSVideoMetadata
+	if err := json.Unmarshal(material.Metadata, &hlsMetadata); err == nil && hlsMetadata.Playlist != "" {
+		token := h.uploadService.SignHLSAsset(hlsMetadata.AssetID)
+
+		return c.JSON(fiber.Map{
+			"playlist_url":  hlsURL(c, &hlsMetadata, token, hlsMetadata.Playlist),
+			"thumbnail_url": hlsURL(c, &hlsMetadata, token, hlsMetadata.Thumbnail),
+			"token":         token,
+		})
+	}
+
 	var metadata model.MuxVideoMetadata
 	if err := json.Unmarshal(material.Metadata, &metadata); err != nil {
 		return apperrors.Internal("failed to load mux metadata", err)
 	}
 
+	// Videos played from their files have only the preview.
+	if metadata.PlaybackID == "" {
+		return apperrors.BadRequest("this material do not support generating token")
+	}
+
 	token, err := h.uploadService.MuxSignPrivateVideo(metadata.PlaybackID)
 	if err != nil {
 		return apperrors.Internal("failed to sign video", err)
@@ -511,12 +546,71 @@ func (h *V1Handler) DeleteMaterial(c fiber.Ctx) error {
 		return apperrors.Internal("unexpected case")
 	}
 
+	// Subtitles are deleted with the material.
+	subtitles, err := h.subtitleService.FindByMaterial(c.Context(), materialID)
+	if err != nil {
+		return apperrors.Internal("error while getting subtitles", err)
+	}
+
 	err = h.materialService.Delete(c.Context(), materialID)
 	if err != nil {
 		return apperrors.Internal("error while deleting the material", err)
 	}
 
-	h.flushFiles(true, nil, []string{material.Filename})
+	oldFiles := []string{material.Filename}
+	for _, subtitle := range subtitles {
+		oldFiles = append(oldFiles, subtitle.Filename)
+	}
+	h.flushFiles(true, nil, oldFiles)
+
+	return nil
+}
+
+// uploadMaterialFile uploads the file of the material, lesson covers are
+// processed as images.
+func (h *V1Handler) uploadMaterialFile(
+	ctx context.Context,
+	category model.MaterialCategory,
+	materialPath string,
+	file io.Reader,
+	fileExt string,
+) (string, int64, error) {
+
+	if category == model.MaterialCategoryLessonCover {
+		return h.uploadService.UploadImage(ctx, materialPath, file)
+	}
+
+	return h.uploadService.Upload(materialPath, file, fileExt)
+}
+
+// scanMaterialFile scans the document of the material for malware, infected
+// files are rejected. Material is quarantined till the scheduled scan if the
+// scanner is unavailable.
+func (h *V1Handler) scanMaterialFile(ctx context.Context, material *model.Material) error {
+	if _, ok := allowedMaterialExt[strings.ToLower(filepath.Ext(material.Filename))]; !ok {
+		return nil
+	}
+
+	scan, err := h.uploadService.ScanFile(ctx, material.Filename)
+	if err != nil {
+		h.logger.Error("failed to scan file, material is quarantined",
+			zap.String("material_id", material.ID.String()),
+			zap.Error(err),
+		)
+
+		material.Scan = nil
+		material.Status = model.MaterialStatusQuarantined
+		return nil
+	}
+
+	if scan.IsInfected() {
+		return apperrors.BadRequest(fmt.Sprintf("file is infected: %s", scan.Signature))
+	}
+
+	material.Scan = scan
+	if material.Status == model.MaterialStatusQuarantined {
+		material.Status = model.MaterialStatusReady
+	}
 
 	return nil
 }
@@ -69,7 +69,7 @@ func (h *V1Handler) CreateMiniApp(c fiber.Ctx) error {
 	miniApp := req.ToMiniApp(botID, initData.User.ID)
 
 	if h.config.App.EnableDemoProduct {
-		err := h.uploadService.AddDemoProduct(miniApp)
+		err := h.uploadService.AddDemoProduct(c.Context(), miniApp)
 		if err != nil {
 			return apperrors.Internal("failed to add demo product", err)
 		}
@@ -286,6 +286,9 @@ func (h *V1Handler) EditMiniAppAccount(c fiber.Ctx) error {
 		if !isPictureAllowed(avatars[0].Header.Get("Content-Type"), fileExt) {
 			return apperrors.BadRequest("image type is not allowed")
 		}
+		if ownerAvatarSizeLimit < avatars[0].Size {
+			return apperrors.BadRequest("avatar size exceeds the limit")
+		}
 
 		avatarFile, err := avatars[0].Open()
 		if err != nil {
@@ -294,7 +297,7 @@ func (h *V1Handler) EditMiniAppAccount(c fiber.Ctx) error {
 
 		miniAppPath := upload.MaterialFilePath{MiniAppID: claims.MiniAppID}
 
-		avatarURL, avatarSize, err := h.uploadService.Upload(miniAppPath.String(), avatarFile, fileExt)
+		avatarURL, avatarSize, err := h.uploadService.UploadImage(c.Context(), miniAppPath.String(), avatarFile)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading avatar", err)
 		}
@@ -305,10 +308,6 @@ func (h *V1Handler) EditMiniAppAccount(c fiber.Ctx) error {
 		miniApp.TeacherAvatar = avatarURL
 		miniApp.TeacherAvatarSize = avatarSize
 		isChangedMiniApp = true
-
-		if ownerAvatarSizeLimit < avatarSize {
-			return apperrors.BadRequest("avatar size exceeds the limit")
-		}
 	}
 
 	if req.TeacherDeleteAvatar {
@@ -424,6 +423,9 @@ func (h *V1Handler) EditMiniAppBranding(c fiber.Ctx) error {
 		if !isPictureAllowed(logos[0].Header.Get("Content-Type"), fileExt) {
 			return apperrors.BadRequest("image type is not allowed")
 		}
+		if miniAppLogoSizeLimit < logos[0].Size {
+			return apperrors.BadRequest("logo size exceeds the limit")
+		}
 
 		logoFile, err := logos[0].Open()
 		if err != nil {
@@ -431,7 +433,7 @@ func (h *V1Handler) EditMiniAppBranding(c fiber.Ctx) error {
 		}
 
 		miniAppPath := upload.MaterialFilePath{MiniAppID: claims.MiniAppID}
-		logoURL, logoSize, err := h.uploadService.Upload(miniAppPath.String(), logoFile, fileExt)
+		logoURL, logoSize, err := h.uploadService.UploadImage(c.Context(), miniAppPath.String(), logoFile)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading logo", err)
 		}
@@ -442,10 +444,6 @@ func (h *V1Handler) EditMiniAppBranding(c fiber.Ctx) error {
 		miniApp.Logo = logoURL
 		miniApp.LogoSize = logoSize
 		isChanged = true
-
-		if miniAppLogoSizeLimit < logoSize {
-			return apperrors.BadRequest("logo size exceeds the limit")
-		}
 	}
 
 	if req.DeleteLogo {
@@ -610,6 +608,9 @@ func (h *V1Handler) EditSlides(c fiber.Ctx) error {
 		if !isPictureAllowed(newSlides[0].Header.Get("Content-Type"), fileExt) {
 			return apperrors.BadRequest("image type is not allowed")
 		}
+		if slidesSizeLimit < newSlides[0].Size {
+			return apperrors.BadRequest("slide size exceeds the limit")
+		}
 
 		slideFile, err := newSlides[0].Open()
 		if err != nil {
@@ -617,17 +618,13 @@ func (h *V1Handler) EditSlides(c fiber.Ctx) error {
 		}
 
 		miniAppPath := upload.MaterialFilePath{MiniAppID: claims.MiniAppID}
-		slideURL, slideSize, err := h.uploadService.Upload(miniAppPath.String(), slideFile, fileExt)
+		slideURL, slideSize, err := h.uploadService.UploadImage(c.Context(), miniAppPath.String(), slideFile)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading logo", err)
 		}
 
 		newFiles = append(newFiles, slideURL)
 
-		if slidesSizeLimit < slideSize {
-			return apperrors.BadRequest("slide size exceeds the limit")
-		}
-
 		newSlide = req.ToMaterial(claims.MiniAppID, slideURL, slideSize)
 
 		if newSlide.Title == "" {
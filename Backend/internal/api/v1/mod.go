@@ -27,6 +27,9 @@ func (h *V1Handler) CreateModInvite(c fiber.Ctx) error {
 	if err := req.Validate(); err != nil {
 		return apperrors.BadRequest("invalid request data", err)
 	}
+	if err := h.checkModRole(c.Context(), claims.MiniAppID, req.RoleID); err != nil {
+		return err
+	}
 
 	invite, err := h.miniAppService.CreateModInvite(c.Context(), claims.MiniAppID, &req)
 	if err != nil {
@@ -79,11 +82,28 @@ func (h *V1Handler) DeleteModInvite(c fiber.Ctx) error {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 
+	invite, err := h.miniAppService.GetModInviteByID(c.Context(), inviteID)
+	if err != nil {
+		return apperrors.BadRequest("failed to get invite", err)
+	}
+
+	if invite.MiniAppID != claims.MiniAppID {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	err = h.miniAppService.DeleteModInvite(c.Context(), claims.MiniAppID, inviteID)
 	if err != nil {
 		return apperrors.Internal("failed to delete mod invite", err)
 	}
 
+	// Moderator is signed out on all devices.
+	if invite.UserID != uuid.Nil {
+		err = h.jwtService.RevokeUserSessions(c.Context(), invite.UserID)
+		if err != nil {
+			return apperrors.Internal("failed to sign out moderator", err)
+		}
+	}
+
 	return nil
 }
 
@@ -118,6 +138,9 @@ func (h *V1Handler) EditModInvite(c fiber.Ctx) error {
 	if err := req.Validate(); err != nil {
 		return apperrors.BadRequest("invalid request data", err)
 	}
+	if err := h.checkModRole(c.Context(), claims.MiniAppID, req.RoleID); err != nil {
+		return err
+	}
 
 	err = h.miniAppService.EditModInvite(c.Context(), inviteID, &req)
 	if err != nil {
@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service"
+	"academy/internal/service/jwt"
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func (h *V1Handler) ModRoles(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	roles, err := h.modRoleService.MiniAppRoles(c.Context(), claims.MiniAppID)
+	if err != nil {
+		return apperrors.Internal("failed to get mod roles", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"roles": roles,
+	})
+}
+
+func (h *V1Handler) CreateModRole(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.ModRoleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := h.validateModRoleRequest(c.Context(), claims.MiniAppID, &req); err != nil {
+		return err
+	}
+
+	role := req.ToModRole(claims.MiniAppID)
+
+	err := h.modRoleService.Create(c.Context(), role)
+	if errors.Is(err, service.ErrModRoleExists) {
+		return apperrors.AlreadyExist("role with the name already exists")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to create mod role", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"role": role,
+	})
+}
+
+func (h *V1Handler) EditModRole(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	role, err := h.getModRole(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	var req model.ModRoleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := h.validateModRoleRequest(c.Context(), claims.MiniAppID, &req); err != nil {
+		return err
+	}
+
+	req.UpdateModRole(role)
+
+	err = h.modRoleService.Update(c.Context(), role)
+	if errors.Is(err, service.ErrModRoleExists) {
+		return apperrors.AlreadyExist("role with the name already exists")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to update mod role", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"role": role,
+	})
+}
+
+func (h *V1Handler) DeleteModRole(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	role, err := h.getModRole(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	err = h.modRoleService.Delete(c.Context(), role)
+	if errors.Is(err, service.ErrModRoleInUse) {
+		return apperrors.BadRequest("role is assigned to mod invites")
+	}
+	if err != nil {
+		return apperrors.Internal("failed to delete mod role", err)
+	}
+
+	return nil
+}
+
+func (h *V1Handler) getModRole(c fiber.Ctx, miniAppID uuid.UUID) (*model.ModRole, error) {
+	roleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	role, err := h.modRoleService.GetByID(c.Context(), roleID)
+	if err != nil {
+		return nil, apperrors.NotFound("mod role not found", err)
+	}
+
+	if role.MiniAppID != miniAppID {
+		return nil, apperrors.Unauthorized("user is not permitted")
+	}
+
+	return role, nil
+}
+
+// checkModRole checks that the role of the invite belongs to the mini-app.
+func (h *V1Handler) checkModRole(ctx context.Context, miniAppID, roleID uuid.UUID) error {
+	role, err := h.modRoleService.GetByID(ctx, roleID)
+	if err != nil {
+		return apperrors.BadRequest("invalid mod role", err)
+	}
+
+	if role.MiniAppID != miniAppID {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	return nil
+}
+
+func (h *V1Handler) validateModRoleRequest(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	req *model.ModRoleRequest,
+) error {
+
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if modRoleNameLimit < utf8.RuneCountInString(req.Name) {
+		return apperrors.BadRequest("role name exceeds the limit")
+	}
+	if err := h.modRoleService.ValidateProducts(ctx, miniAppID, req.ProductIDs()); err != nil {
+		return apperrors.BadRequest("invalid role products", err)
+	}
+
+	return nil
+}
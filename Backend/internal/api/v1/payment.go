@@ -67,7 +67,7 @@ func (h *V1Handler) BuyProductLevelWithTON(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if claims.IsOwner || claims.IsMod {
+	if claims.IsStaff() {
 		return apperrors.Unauthorized("only students can purchase product level")
 	}
 
@@ -111,6 +111,14 @@ func (h *V1Handler) BuyProductLevelWithTON(c fiber.Ctx) error {
 	if productAccess.DeletedAt != nil {
 		return apperrors.Unauthorized("user deleted from accessing the product")
 	}
+	if len(productAccess.UnmetPrerequisites) != 0 {
+		return apperrors.BadRequest(model.UnmetPrerequisitesMessage(productAccess.UnmetPrerequisites))
+	}
+
+	cohort, err := h.purchaseCohort(c, claims.UserID, product.ID)
+	if err != nil {
+		return err
+	}
 
 	if len(miniApp.PaymentMetadata) == 0 {
 		return apperrors.BadRequest("payments not setup")
@@ -127,7 +135,7 @@ func (h *V1Handler) BuyProductLevelWithTON(c fiber.Ctx) error {
 	}
 
 	payment, err := h.paymentService.CreateTONPayment(c.Context(),
-		product, &paymentMetadata.PaymentMetadataTON, claims.UserID, productLevel)
+		product, &paymentMetadata.PaymentMetadataTON, claims.UserID, productLevel, cohort)
 
 	if err != nil {
 		return apperrors.Internal("error while creating payment", err)
@@ -144,7 +152,7 @@ func (h *V1Handler) BuyProductLevelWithWayForPay(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if claims.IsOwner || claims.IsMod {
+	if claims.IsStaff() {
 		return apperrors.Unauthorized("only students can purchase product level")
 	}
 
@@ -188,6 +196,14 @@ func (h *V1Handler) BuyProductLevelWithWayForPay(c fiber.Ctx) error {
 	if productAccess.DeletedAt != nil {
 		return apperrors.Unauthorized("user deleted from accessing the product")
 	}
+	if len(productAccess.UnmetPrerequisites) != 0 {
+		return apperrors.BadRequest(model.UnmetPrerequisitesMessage(productAccess.UnmetPrerequisites))
+	}
+
+	cohort, err := h.purchaseCohort(c, claims.UserID, product.ID)
+	if err != nil {
+		return err
+	}
 
 	if len(miniApp.PaymentMetadata) == 0 {
 		return apperrors.BadRequest("payments not setup")
@@ -216,7 +232,7 @@ func (h *V1Handler) BuyProductLevelWithWayForPay(c fiber.Ctx) error {
 	}
 
 	payment, err := h.paymentService.CreateWayForPayPayment(c.Context(),
-		product, &paymentMetadata.PaymentMetadataWayForPay, claims.UserID, productLevel, returnURL)
+		product, &paymentMetadata.PaymentMetadataWayForPay, claims.UserID, productLevel, returnURL, cohort)
 
 	if err != nil {
 		return apperrors.Internal("error while creating payment", err)
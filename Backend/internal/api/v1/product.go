@@ -60,6 +60,11 @@ func (h *V1Handler) CreateProduct(c fiber.Ctx) error {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 
+	err = h.productService.ValidatePrerequisites(c.Context(), product, product.Prerequisites)
+	if err != nil {
+		return apperrors.BadRequest("invalid product prerequisites", err)
+	}
+
 	var isUpdated bool
 	var newFiles []string
 	var oldFiles []string
@@ -73,6 +78,9 @@ func (h *V1Handler) CreateProduct(c fiber.Ctx) error {
 		if !isPictureAllowed(covers[0].Header.Get("Content-Type"), fileExt) {
 			return apperrors.BadRequest("image type is not allowed")
 		}
+		if productCoverSizeLimit < covers[0].Size {
+			return apperrors.BadRequest("product cover size exceeds the limit")
+		}
 
 		coverFile, err := covers[0].Open()
 		if err != nil {
@@ -83,7 +91,7 @@ func (h *V1Handler) CreateProduct(c fiber.Ctx) error {
 			MiniAppID: claims.MiniAppID,
 			ProductID: product.ID,
 		}
-		coverURL, coverSize, err := h.uploadService.Upload(productPath.String(), coverFile, fileExt)
+		coverURL, coverSize, err := h.uploadService.UploadImage(c.Context(), productPath.String(), coverFile)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading cover", err)
 		}
@@ -91,10 +99,6 @@ func (h *V1Handler) CreateProduct(c fiber.Ctx) error {
 		newFiles = append(newFiles, coverURL)
 		product.Cover = coverURL
 		product.CoverSize = coverSize
-
-		if productCoverSizeLimit < coverSize {
-			return apperrors.BadRequest("product cover size exceeds the limit")
-		}
 	}
 
 	err = h.productService.Create(c.Context(), product)
@@ -142,7 +146,7 @@ func (h *V1Handler) GetProduct(c fiber.Ctx) error {
 	}
 
 	isAdmin := h.isPermitted(c.Context(), &claims, model.PermissionProductsControl)
-	isStudent := !claims.IsOwner && !claims.IsMod
+	isStudent := !claims.IsStaff()
 
 	if !isAdmin && !product.IsActive {
 		return apperrors.BadRequest("product not active", err)
@@ -160,6 +164,7 @@ func (h *V1Handler) GetProduct(c fiber.Ctx) error {
 	var reviews []*model.Review
 	var unlockedLessons []model.UnlockedLesson
 	var progress []*model.LessonProgress
+	var cohort *model.Cohort
 
 	if isStudent {
 		unlockedLessons, err = h.lessonService.UnlockedLessons(
@@ -182,6 +187,28 @@ func (h *V1Handler) GetProduct(c fiber.Ctx) error {
 		if err != nil {
 			return apperrors.Internal("failed to get product reviews", err)
 		}
+
+		cohort, err = h.cohortService.StudentCohort(c.Context(), productID, claims.UserID)
+		if err != nil {
+			return apperrors.Internal("failed to get student cohort", err)
+		}
+
+		// Students see the schedule of their cohort.
+		if cohort != nil {
+			for _, l := range product.Lessons {
+				cohort.ShiftSchedule(l)
+			}
+
+			cohort.InviteID = uuid.Nil
+		}
+	}
+
+	// Meeting URLs are revealed only by the lesson itself to students with
+	// access.
+	if !isAdmin {
+		for _, l := range product.Lessons {
+			l.MeetingURL = ""
+		}
 	}
 
 	return c.JSON(fiber.Map{
@@ -190,6 +217,7 @@ func (h *V1Handler) GetProduct(c fiber.Ctx) error {
 		"unlocked_lessons": unlockedLessons,
 		"progress":         progress,
 		"access":           productAccess,
+		"cohort":           cohort,
 	})
 }
 
@@ -262,6 +290,21 @@ func (h *V1Handler) EditProduct(c fiber.Ctx) error {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 
+	var applyNewPrerequisites []*model.ProductPrerequisite
+	if req.Prerequisites != nil {
+		applyNewPrerequisites, err = model.ToProductPrerequisites(product.ID, req.Prerequisites)
+		if err != nil {
+			return apperrors.BadRequest("invalid product prerequisites", err)
+		}
+
+		err = h.productService.ValidatePrerequisites(c.Context(), product, applyNewPrerequisites)
+		if err != nil {
+			return apperrors.BadRequest("invalid product prerequisites", err)
+		}
+
+		isChanged = true
+	}
+
 	var isUpdated bool
 	var newFiles []string
 	var oldFiles []string
@@ -275,6 +318,9 @@ func (h *V1Handler) EditProduct(c fiber.Ctx) error {
 		if !isPictureAllowed(covers[0].Header.Get("Content-Type"), fileExt) {
 			return apperrors.BadRequest("image type is not allowed")
 		}
+		if productCoverSizeLimit < covers[0].Size {
+			return apperrors.BadRequest("product cover size exceeds the limit")
+		}
 
 		coverFile, err := covers[0].Open()
 		if err != nil {
@@ -285,7 +331,7 @@ func (h *V1Handler) EditProduct(c fiber.Ctx) error {
 			MiniAppID: claims.MiniAppID,
 			ProductID: product.ID,
 		}
-		coverURL, coverSize, err := h.uploadService.Upload(productPath.String(), coverFile, fileExt)
+		coverURL, coverSize, err := h.uploadService.UploadImage(c.Context(), productPath.String(), coverFile)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading cover", err)
 		}
@@ -296,10 +342,6 @@ func (h *V1Handler) EditProduct(c fiber.Ctx) error {
 		product.Cover = coverURL
 		product.CoverSize = coverSize
 		isChanged = true
-
-		if productCoverSizeLimit < coverSize {
-			return apperrors.BadRequest("product cover size exceeds the limit")
-		}
 	}
 
 	if req.DeleteCover {
@@ -316,6 +358,7 @@ func (h *V1Handler) EditProduct(c fiber.Ctx) error {
 			applyNewLessonAccess,
 			applyNewReleaseDate,
 			applyNewAccessTime,
+			applyNewPrerequisites,
 		)
 		if err != nil {
 			return apperrors.Internal("failed to update product", err)
@@ -418,10 +461,6 @@ func (h *V1Handler) ProductHomeworks(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentInteraction) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	productID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -435,12 +474,26 @@ func (h *V1Handler) ProductHomeworks(c fiber.Ctx) error {
 		return err
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, productID, model.PermissionHomeworkReview) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	var req model.FilterProductHomeworkRequest
 	if err := c.Bind().JSON(&req); err != nil {
 		return apperrors.BadRequest("invalid request data", err)
 	}
 	req.Limit = validateLimit(req.Limit)
 
+	// Moderators assigned to cohorts review only homework of their students.
+	cohortScope, err := h.cohortScope(c.Context(), &claims, productID)
+	if err != nil {
+		return err
+	}
+	req.CohortID, ok = model.ScopeCohorts(cohortScope, req.CohortID)
+	if !ok {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	homework, total, err := h.lessonProgressService.ProductHomework(c.Context(), productID, &req)
 	if err != nil {
 		return apperrors.Internal("error while getting homework by product", err)
@@ -552,10 +605,6 @@ func (h *V1Handler) ProductFeedback(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionAnalytics) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	productID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -569,6 +618,10 @@ func (h *V1Handler) ProductFeedback(c fiber.Ctx) error {
 		return err
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, productID, model.PermissionProductReports) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	feedback, err := h.productService.Feedback(c.Context(), productID)
 	if err != nil {
 		return apperrors.Internal("error while getting product feedback", err)
@@ -585,10 +638,6 @@ func (h *V1Handler) ProductStudents(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionAnalytics) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	productID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -602,6 +651,10 @@ func (h *V1Handler) ProductStudents(c fiber.Ctx) error {
 		return err
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, productID, model.PermissionProductReports) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	offset := fiber.Query[uint](c, "offset")
 
 	limit := fiber.Query[uint](c, "limit")
@@ -612,7 +665,26 @@ func (h *V1Handler) ProductStudents(c fiber.Ctx) error {
 		return apperrors.BadRequest("username is too long")
 	}
 
-	students, err := h.productService.Students(c.Context(), productID, usernameSearch, limit, offset)
+	var cohortIDs []uuid.UUID
+	if v := fiber.Query[string](c, "cohort_id"); v != "" {
+		cohortID, err := uuid.Parse(v)
+		if err != nil {
+			return apperrors.BadRequest("invalid cohort id", err)
+		}
+		cohortIDs = []uuid.UUID{cohortID}
+	}
+
+	// Moderators assigned to cohorts see only their students.
+	cohortScope, err := h.cohortScope(c.Context(), &claims, productID)
+	if err != nil {
+		return err
+	}
+	cohortIDs, ok = model.ScopeCohorts(cohortScope, cohortIDs)
+	if !ok {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	students, err := h.productService.Students(c.Context(), productID, usernameSearch, cohortIDs, limit, offset)
 	if err != nil {
 		return apperrors.Internal("error while getting product students", err)
 	}
@@ -628,10 +700,6 @@ func (h *V1Handler) ExportProductStudents(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionAnalytics) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	productID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -650,6 +718,10 @@ func (h *V1Handler) ExportProductStudents(c fiber.Ctx) error {
 		return apperrors.Unauthorized("user is not permitted")
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, product.ID, model.PermissionProductReports) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	var req model.ExportProductStudentsRequest
 	if err := c.Bind().JSON(&req); err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 func (h *V1Handler) ReviewLesson(c fiber.Ctx) error {
@@ -41,5 +42,14 @@ func (h *V1Handler) ReviewLesson(c fiber.Ctx) error {
 		return apperrors.Internal("failed to create lesson review", err)
 	}
 
+	err = h.gamificationService.AwardReview(c.Context(), claims.MiniAppID, review)
+	if err != nil {
+		h.logger.Error("failed to award points", zap.Error(err))
+	}
+
+	if err := h.staffNotificationService.NotifyReview(c.Context(), review); err != nil {
+		h.logger.Error("failed to notify about review", zap.Error(err))
+	}
+
 	return nil
 }
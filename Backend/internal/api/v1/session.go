@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/service"
+	"academy/internal/service/jwt"
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func (h *V1Handler) UserSessions(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	sessions, err := h.jwtService.Sessions(c.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		return apperrors.Internal("failed to get sessions", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"sessions": sessions,
+	})
+}
+
+func (h *V1Handler) RevokeSession(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	err = h.jwtService.RevokeSession(c.Context(), claims.UserID, sessionID)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		return apperrors.NotFound("session not found", err)
+	}
+	if err != nil {
+		return apperrors.Internal("failed to revoke session", err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions signs out the user on all devices except the current
+// one.
+func (h *V1Handler) RevokeOtherSessions(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	err := h.jwtService.RevokeOtherSessions(c.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		return apperrors.Internal("failed to revoke sessions", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// StaffNotificationSettings returns preferences of notifications that the
+// owner or moderator gets with the admin bot.
+func (h *V1Handler) StaffNotificationSettings(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner && !claims.IsMod {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	settings, err := h.staffNotificationService.GetSettings(c.Context(), claims.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to get notification settings", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"notification_settings": settings,
+	})
+}
+
+func (h *V1Handler) EditStaffNotificationSettings(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !claims.IsOwner && !claims.IsMod {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.EditStaffNotificationSettingsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	settings, err := h.staffNotificationService.GetSettings(c.Context(), claims.UserID)
+	if err != nil {
+		return apperrors.Internal("failed to get notification settings", err)
+	}
+
+	req.UpdateSettings(settings)
+
+	if err := h.staffNotificationService.SaveSettings(c.Context(), settings); err != nil {
+		return apperrors.Internal("failed to save notification settings", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"notification_settings": settings,
+	})
+}
@@ -0,0 +1,260 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+	"academy/internal/service/upload"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// UploadSubtitles adds the subtitle track to the video or audio of the lesson
+// or replaces the track of the same language. SRT is converted into WebVTT.
+func (h *V1Handler) UploadSubtitles(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	material, lesson, err := h.subtitledMaterial(c, &claims)
+	if err != nil {
+		return err
+	}
+
+	mpForm, err := c.MultipartForm()
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	subtitles := mpForm.Value["subtitles"]
+	if len(subtitles) != 1 {
+		return apperrors.BadRequest("subtitles not provided")
+	}
+
+	var req model.UploadSubtitleRequest
+	if err := json.Unmarshal([]byte(subtitles[0]), &req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	files := mpForm.File["file"]
+	if len(files) != 1 {
+		return apperrors.BadRequest("file not provided")
+	}
+
+	fileExt := strings.ToLower(filepath.Ext(files[0].Filename))
+	if _, ok := allowedSubtitlesExt[fileExt]; !ok {
+		return apperrors.BadRequest("subtitles extension is not supported")
+	}
+	if subtitlesSizeLimit < files[0].Size {
+		return apperrors.BadRequest("subtitles size exceeds the limit")
+	}
+
+	f, err := files[0].Open()
+	if err != nil {
+		return apperrors.BadRequest("error while opening file", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return apperrors.BadRequest("error while reading file", err)
+	}
+
+	vtt, transcript, err := model.ParseSubtitles(data)
+	if errors.Is(err, model.ErrInvalidSubtitles) {
+		return apperrors.BadRequest("invalid subtitles", err)
+	}
+	if err != nil {
+		return apperrors.Internal("failed to parse subtitles", err)
+	}
+
+	lessonPath := upload.MaterialFilePath{
+		MiniAppID: claims.MiniAppID,
+		ProductID: lesson.ProductID,
+		LessonID:  lesson.ID,
+	}
+
+	var isUpdated bool
+	var newFiles []string
+	var oldFiles []string
+	defer func() {
+		h.flushFiles(isUpdated, newFiles, oldFiles)
+	}()
+
+	filename, fileSize, err := h.uploadService.Upload(lessonPath.String(), bytes.NewReader(vtt), ".vtt")
+	if err != nil {
+		return apperrors.BadRequest("error while uploading file", err)
+	}
+	newFiles = append(newFiles, filename)
+
+	subtitle, err := h.subtitleService.GetByLanguage(c.Context(), material.ID, req.Language)
+	if err != nil {
+		return apperrors.Internal("failed to get subtitles", err)
+	}
+
+	if subtitle == nil {
+		subtitle = req.ToSubtitle(material, claims.MiniAppID)
+		subtitle.Filename = filename
+		subtitle.Size = fileSize
+		subtitle.Transcript = transcript
+
+		if err := h.subtitleService.Create(c.Context(), material, subtitle); err != nil {
+			return apperrors.Internal("failed to create subtitles", err)
+		}
+	} else {
+		oldFiles = append(oldFiles, subtitle.Filename)
+
+		updated := req.ToSubtitle(material, claims.MiniAppID)
+		subtitle.Label = updated.Label
+		subtitle.Filename = filename
+		subtitle.Size = fileSize
+		subtitle.Transcript = transcript
+		subtitle.UpdatedAt = updated.UpdatedAt
+
+		if err := h.subtitleService.Update(c.Context(), material, subtitle); err != nil {
+			return apperrors.Internal("failed to update subtitles", err)
+		}
+	}
+
+	isUpdated = true
+
+	return c.JSON(fiber.Map{
+		"subtitle": subtitle,
+	})
+}
+
+func (h *V1Handler) DeleteSubtitles(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	material, _, err := h.subtitledMaterial(c, &claims)
+	if err != nil {
+		return err
+	}
+
+	subtitle, err := h.subtitleService.GetByLanguage(c.Context(), material.ID, c.Params("language"))
+	if err != nil {
+		return apperrors.Internal("failed to get subtitles", err)
+	}
+	if subtitle == nil {
+		return apperrors.NotFound("subtitles not found")
+	}
+
+	if err := h.subtitleService.Delete(c.Context(), material, subtitle); err != nil {
+		return apperrors.Internal("failed to delete subtitles", err)
+	}
+
+	h.flushFiles(true, nil, []string{subtitle.Filename})
+
+	return nil
+}
+
+// SearchTranscripts returns lessons of the product which transcripts match
+// the query. Students get only lessons they have access to.
+func (h *V1Handler) SearchTranscripts(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if productID == uuid.Nil {
+		return apperrors.BadRequest("invalid product id")
+	}
+
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, productID); err != nil {
+		return err
+	}
+
+	query := strings.TrimSpace(fiber.Query[string](c, "query"))
+	if query == "" {
+		return apperrors.BadRequest("query not provided")
+	}
+
+	offset := fiber.Query[uint](c, "offset")
+
+	limit := fiber.Query[uint](c, "limit")
+	limit = validateLimit(limit)
+
+	isAdmin := h.isPermitted(c.Context(), &claims, model.PermissionProductsControl)
+
+	matches, err := h.subtitleService.Search(c.Context(), productID, query, !isAdmin, int(limit), int(offset))
+	if err != nil {
+		return apperrors.Internal("failed to search transcripts", err)
+	}
+
+	if !isAdmin {
+		// Locked lessons are skipped, so pages of students could be shorter.
+		accessible := make(map[uuid.UUID]bool)
+		filtered := make([]*model.TranscriptMatch, 0, len(matches))
+		for _, match := range matches {
+			isAccessible, ok := accessible[match.LessonID]
+			if !ok {
+				_, err := h.validateLessonAccess(c.Context(), &claims, match.LessonID)
+				isAccessible = err == nil
+				accessible[match.LessonID] = isAccessible
+			}
+			if isAccessible {
+				filtered = append(filtered, match)
+			}
+		}
+		matches = filtered
+	}
+
+	return c.JSON(fiber.Map{
+		"matches": matches,
+	})
+}
+
+// subtitledMaterial returns the video or audio of the lesson the user
+// manages.
+func (h *V1Handler) subtitledMaterial(c fiber.Ctx, claims *jwt.TokenClaims) (*model.Material, *model.Lesson, error) {
+	if !h.isPermitted(c.Context(), claims, model.PermissionProductsControl) {
+		return nil, nil, apperrors.Unauthorized("user is not permitted")
+	}
+
+	materialID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	material, err := h.materialService.GetByID(c.Context(), materialID)
+	if err != nil {
+		return nil, nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	if material.LessonID == uuid.Nil || material.Category != model.MaterialCategoryLessonContent {
+		return nil, nil, apperrors.BadRequest("this material do not support subtitles")
+	}
+	switch material.ContentType {
+	case model.MaterialTypeVideo, model.MaterialTypeCircleVideo, model.MaterialTypeAudio:
+	default:
+		return nil, nil, apperrors.BadRequest("this material do not support subtitles")
+	}
+
+	lesson, err := h.lessonService.GetByID(c.Context(), material.LessonID, uuid.Nil)
+	if err != nil {
+		return nil, nil, apperrors.BadRequest("error while getting lesson", err)
+	}
+	if err := h.checkProduct(c.Context(), claims.MiniAppID, lesson.ProductID); err != nil {
+		return nil, nil, err
+	}
+
+	return material, lesson, nil
+}
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// SupportTickets returns tickets of the mini-app, the most recently active
+// first.
+func (h *V1Handler) SupportTickets(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionSupportTickets) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.FilterSupportTicketsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	req.Limit = validateLimit(req.Limit)
+
+	tickets, total, err := h.supportService.Find(c.Context(), claims.MiniAppID, &req)
+	if err != nil {
+		return apperrors.Internal("failed to get support tickets", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"tickets": tickets,
+		"total":   total,
+	})
+}
+
+func (h *V1Handler) GetSupportTicket(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionSupportTickets) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	ticket, err := h.getSupportTicket(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"ticket": ticket,
+	})
+}
+
+// ReplySupportTicket sends the reply to the student with the mini-app bot.
+// Reply is saved only if it is delivered.
+func (h *V1Handler) ReplySupportTicket(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionSupportTickets) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.SupportReplyRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if supportReplyLimit < utf8.RuneCountInString(req.Text) {
+		return apperrors.BadRequest("text exceeds the limit")
+	}
+
+	ticket, err := h.getSupportTicket(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+	if ticket.User == nil {
+		return apperrors.BadRequest("student not found")
+	}
+
+	messageID, err := h.botManager.SendMessage(claims.MiniAppID, ticket.User.TelegramID, req.Text)
+	if err != nil {
+		return apperrors.BadRequest("failed to deliver the reply", err)
+	}
+
+	message := model.NewSupportMessage(ticket.ID, claims.UserID, true, req.Text)
+	message.TelegramMessageID = messageID
+
+	if err := h.supportService.Reply(c.Context(), ticket, message); err != nil {
+		return apperrors.Internal("failed to save the reply", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+	})
+}
+
+// EditSupportTicket changes status or assignee of the ticket.
+func (h *V1Handler) EditSupportTicket(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if !h.isPermitted(c.Context(), &claims, model.PermissionSupportTickets) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
+	var req model.EditSupportTicketRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid request data", err)
+	}
+
+	if req.AssigneeID != nil && *req.AssigneeID != uuid.Nil {
+		assignee, err := h.userService.GetByID(c.Context(), *req.AssigneeID)
+		if err != nil || assignee == nil {
+			return apperrors.BadRequest("assignee not found", err)
+		}
+		if assignee.MiniAppID != claims.MiniAppID ||
+			(assignee.Role != model.UserRoleOwner && assignee.Role != model.UserRoleModerator) {
+			return apperrors.BadRequest("assignee is not a staff member")
+		}
+	}
+
+	ticket, err := h.getSupportTicket(c, claims.MiniAppID)
+	if err != nil {
+		return err
+	}
+
+	if req.UpdateTicket(ticket) {
+		if err := h.supportService.Update(c.Context(), ticket); err != nil {
+			return apperrors.Internal("failed to update support ticket", err)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"ticket": ticket,
+	})
+}
+
+func (h *V1Handler) getSupportTicket(c fiber.Ctx, miniAppID uuid.UUID) (*model.SupportTicket, error) {
+	ticketID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	ticket, err := h.supportService.GetByID(c.Context(), ticketID)
+	if err != nil {
+		return nil, apperrors.NotFound("support ticket not found", err)
+	}
+
+	if ticket.MiniAppID != miniAppID {
+		return nil, apperrors.Unauthorized("user is not permitted")
+	}
+
+	return ticket, nil
+}
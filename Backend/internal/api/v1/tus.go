@@ -0,0 +1,388 @@
+package v1
+
+import (
+	"academy/internal/api/apperrors"
+	"academy/internal/model"
+	"academy/internal/service/jwt"
+	"academy/internal/service/upload"
+	"bytes"
+	"errors"
+	"hash"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// tusMaxSize is a max size of the upload, it is the largest size limit of
+// lesson materials.
+const tusMaxSize = videoLessonSizeLimit
+
+const tusContentType = "application/offset+octet-stream"
+
+// TusMiddleware sets headers of the tus protocol and rejects requests of
+// unsupported protocol versions.
+func (h *V1Handler) TusMiddleware(c fiber.Ctx) error {
+	c.Set("Tus-Resumable", model.TusVersion)
+
+	if c.Method() == fiber.MethodOptions {
+		return c.Next()
+	}
+
+	if c.Get("Tus-Resumable") != model.TusVersion {
+		c.Set("Tus-Version", model.TusVersion)
+		return apperrors.PreconditionFailed("unsupported tus version")
+	}
+
+	return c.Next()
+}
+
+func (h *V1Handler) TusOptions(c fiber.Ctx) error {
+	c.Set("Tus-Version", model.TusVersion)
+	c.Set("Tus-Extension", model.TusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(tusMaxSize, 10))
+	c.Set("Tus-Checksum-Algorithm", model.TusChecksumAlgorithms)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *V1Handler) CreateTusUpload(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	material, err := h.getTusMaterial(c, &claims)
+	if err != nil {
+		return err
+	}
+
+	if c.Get("Upload-Defer-Length") != "" {
+		return apperrors.BadRequest("deferred upload length is not supported")
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return apperrors.BadRequest("invalid upload length", err)
+	}
+	if tusMaxSize < length {
+		return apperrors.RequestEntityTooLarge("upload size exceeds the limit")
+	}
+
+	metadata, err := model.ParseTusMetadata(c.Get("Upload-Metadata"))
+	if err != nil {
+		return apperrors.BadRequest("invalid upload metadata", err)
+	}
+
+	req := model.SubmitChunksRequest{
+		OriginalFilename: metadata[model.TusMetadataFilename],
+		Status:           model.MaterialStatus(metadata[model.TusMetadataStatus]),
+	}
+	if err := req.Validate(); err != nil {
+		return apperrors.BadRequest("invalid upload metadata", err)
+	}
+
+	fileExt := strings.ToLower(filepath.Ext(req.OriginalFilename))
+
+	if err := checkMaterialFile(
+		material.Category, material.ContentType,
+		length, fileExt, "",
+	); err != nil {
+		return err
+	}
+
+	// Too large watermarked PDFs are refused before their data is received.
+	upload := *material
+	upload.Filename = req.OriginalFilename
+	upload.Size = length
+	if err := upload.CheckWatermark(); err != nil {
+		return apperrors.RequestEntityTooLarge(err.Error())
+	}
+
+	tusUpload := model.NewTusUpload(claims.MiniAppID, material.ID, length, metadata)
+
+	if err := h.uploadService.CreateTusUpload(c.Context(), tusUpload); err != nil {
+		return apperrors.Internal("failed to create upload", err)
+	}
+
+	c.Set(fiber.HeaderLocation, c.BaseURL()+strings.TrimSuffix(c.Path(), "/")+"/"+tusUpload.ID.String())
+	c.Set("Upload-Expires", tusUpload.ExpiresAt().Format(http.TimeFormat))
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+func (h *V1Handler) TusUploadOffset(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	material, err := h.getTusMaterial(c, &claims)
+	if err != nil {
+		return err
+	}
+
+	tusUpload, err := h.getTusUpload(c, &claims, material)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(tusUpload.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(tusUpload.Length, 10))
+	c.Set("Upload-Expires", tusUpload.ExpiresAt().Format(http.TimeFormat))
+	if len(tusUpload.Metadata) != 0 {
+		c.Set("Upload-Metadata", model.EncodeTusMetadata(tusUpload.Metadata))
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// PatchTusUpload receives the part of the upload. Material file is replaced
+// when the last part is received.
+func (h *V1Handler) PatchTusUpload(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	if c.Get(fiber.HeaderContentType) != tusContentType {
+		return apperrors.UnsupportedMediaType("invalid content type")
+	}
+
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return apperrors.BadRequest("invalid upload offset", err)
+	}
+
+	material, err := h.getTusMaterial(c, &claims)
+	if err != nil {
+		return err
+	}
+
+	tusUpload, err := h.getTusUpload(c, &claims, material)
+	if err != nil {
+		return err
+	}
+
+	if offset != tusUpload.Offset {
+		return apperrors.Conflict("upload offset does not match")
+	}
+
+	body := c.Body()
+	if tusUpload.Length-tusUpload.Offset < int64(len(body)) {
+		return apperrors.BadRequest("part exceeds the upload length")
+	}
+
+	checksum, sum, err := parseTusChecksum(c.Get("Upload-Checksum"))
+	if err != nil {
+		return err
+	}
+
+	err = h.uploadService.WriteTusPart(c.Context(), tusUpload,
+		offset, bytes.NewReader(body), int64(len(body)), checksum, sum)
+
+	switch {
+	case errors.Is(err, upload.ErrTusOffsetConflict):
+		return apperrors.Conflict("upload offset does not match", err)
+	case errors.Is(err, upload.ErrTusChecksumMismatch):
+		return apperrors.ChecksumMismatch("checksum does not match", err)
+	case errors.Is(err, upload.ErrStorageLimit):
+		return apperrors.RequestEntityTooLarge("storage size exceeds the limit", err)
+	case err != nil:
+		return apperrors.Internal("failed to save upload part", err)
+	}
+
+	if tusUpload.IsComplete() {
+		if err := h.completeTusUpload(c, &claims, material, tusUpload); err != nil {
+			return err
+		}
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(tusUpload.Offset, 10))
+	c.Set("Upload-Expires", tusUpload.ExpiresAt().Format(http.TimeFormat))
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// completeTusUpload sets the received file to the material the same way
+// submitted chunks are.
+func (h *V1Handler) completeTusUpload(
+	c fiber.Ctx,
+	claims *jwt.TokenClaims,
+	material *model.Material,
+	tusUpload *model.TusUpload,
+) error {
+
+	lesson, err := h.lessonService.GetByID(c.Context(), material.LessonID, uuid.Nil)
+	if err != nil {
+		return apperrors.BadRequest("error while getting lesson", err)
+	}
+
+	lessonPath := upload.MaterialFilePath{
+		MiniAppID: claims.MiniAppID,
+		ProductID: lesson.ProductID,
+		LessonID:  lesson.ID,
+	}
+
+	var isUpdated bool
+	var newFiles []string
+	var oldFiles []string
+	defer func() {
+		h.flushFiles(isUpdated, newFiles, oldFiles)
+	}()
+
+	originalFilename := tusUpload.Metadata[model.TusMetadataFilename]
+	fileExt := strings.ToLower(filepath.Ext(originalFilename))
+
+	filename, err := h.uploadService.CompleteTusUpload(
+		c.Context(), tusUpload, lessonPath.String(), fileExt)
+	if err != nil {
+		return apperrors.Internal("failed to complete upload", err)
+	}
+
+	oldFiles = append(oldFiles, material.Filename)
+	newFiles = append(newFiles, filename)
+
+	material.OriginalFilename = originalFilename
+	material.Filename = filename
+	material.Size = tusUpload.Length
+	material.UpdatedAt = time.Now().UTC()
+
+	// Mark with pending status to compress later by cron-job.
+	if _, ok := allowedVideoExt[fileExt]; ok {
+		material.Status = model.MaterialStatusPendingCompressing
+	}
+
+	if status := tusUpload.Metadata[model.TusMetadataStatus]; status != "" {
+		material.Status = h.uploadService.VideoStatus(model.MaterialStatus(status))
+	}
+
+	if err := material.CheckWatermark(); err != nil {
+		return apperrors.RequestEntityTooLarge(err.Error())
+	}
+
+	if err := h.scanMaterialFile(c.Context(), material); err != nil {
+		return err
+	}
+
+	err = h.materialService.Update(c.Context(), material)
+	if err != nil {
+		return apperrors.Internal("error while completing upload", err)
+	}
+
+	isUpdated = true
+
+	// Upload is kept until the file is set, so failed completion could be
+	// retried with an empty part at the final offset.
+	if err := h.uploadService.DeleteTusUpload(c.Context(), tusUpload); err != nil {
+		h.logger.Error("failed to delete completed upload",
+			zap.String("upload_id", tusUpload.ID.String()), zap.Error(err))
+	}
+
+	return nil
+}
+
+// DeleteTusUpload terminates the upload, received parts are deleted.
+func (h *V1Handler) DeleteTusUpload(c fiber.Ctx) error {
+	claims, ok := c.Locals("claims").(jwt.TokenClaims)
+	if !ok {
+		return apperrors.Unauthorized("claims not found")
+	}
+
+	material, err := h.getTusMaterial(c, &claims)
+	if err != nil {
+		return err
+	}
+
+	tusUpload, err := h.getTusUpload(c, &claims, material)
+	if err != nil {
+		return err
+	}
+
+	if err := h.uploadService.DeleteTusUpload(c.Context(), tusUpload); err != nil {
+		return apperrors.Internal("failed to delete upload", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// getTusMaterial returns the lesson material the file is uploaded to.
+func (h *V1Handler) getTusMaterial(c fiber.Ctx, claims *jwt.TokenClaims) (*model.Material, error) {
+	if !h.isPermitted(c.Context(), claims, model.PermissionProductsControl) {
+		return nil, apperrors.Unauthorized("user is not permitted")
+	}
+
+	materialID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	if materialID == uuid.Nil {
+		return nil, apperrors.BadRequest("invalid material id")
+	}
+
+	material, err := h.materialService.GetByID(c.Context(), materialID)
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid request data", err)
+	}
+
+	if material.MiniAppID != uuid.Nil {
+		return nil, apperrors.BadRequest("this type of material cant be uploaded by parts")
+	}
+
+	if len(material.Metadata) != 0 {
+		return nil, apperrors.BadRequest("this material include metadata and do not accept new files")
+	}
+
+	if err := h.checkLesson(c.Context(), claims.MiniAppID, material.LessonID); err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+func (h *V1Handler) getTusUpload(
+	c fiber.Ctx,
+	claims *jwt.TokenClaims,
+	material *model.Material,
+) (*model.TusUpload, error) {
+
+	uploadID, err := uuid.Parse(c.Params("upload_id"))
+	if err != nil {
+		return nil, apperrors.NotFound("upload not found", err)
+	}
+
+	tusUpload, err := h.uploadService.TusUpload(c.Context(), claims.MiniAppID, uploadID)
+	if errors.Is(err, upload.ErrTusUploadNotFound) {
+		return nil, apperrors.NotFound("upload not found", err)
+	}
+	if err != nil {
+		return nil, apperrors.Internal("failed to get upload", err)
+	}
+
+	if tusUpload.MaterialID != material.ID {
+		return nil, apperrors.NotFound("upload not found")
+	}
+
+	return tusUpload, nil
+}
+
+// parseTusChecksum returns nil hash if the checksum is not provided.
+func parseTusChecksum(header string) (hash.Hash, []byte, error) {
+	if header == "" {
+		return nil, nil, nil
+	}
+
+	checksum, sum, err := model.ParseTusChecksum(header)
+	if err != nil {
+		return nil, nil, apperrors.BadRequest("invalid upload checksum", err)
+	}
+
+	return checksum, sum, nil
+}
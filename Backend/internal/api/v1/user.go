@@ -86,6 +86,15 @@ func (h *V1Handler) EditUser(c fiber.Ctx) error {
 		if !isPictureAllowed(avatars[0].Header.Get("Content-Type"), fileExt) {
 			return apperrors.BadRequest("image type is not allowed")
 		}
+		if claims.IsOwner {
+			if ownerAvatarSizeLimit < avatars[0].Size {
+				return apperrors.BadRequest("avatar size exceeds the limit")
+			}
+		} else {
+			if avatarSizeLimit < avatars[0].Size {
+				return apperrors.BadRequest("avatar size exceeds the limit")
+			}
+		}
 
 		avatarFile, err := avatars[0].Open()
 		if err != nil {
@@ -94,7 +103,7 @@ func (h *V1Handler) EditUser(c fiber.Ctx) error {
 
 		miniAppPath := upload.MaterialFilePath{MiniAppID: claims.MiniAppID}
 
-		avatarURL, avatarSize, err := h.uploadService.Upload(miniAppPath.String(), avatarFile, fileExt)
+		avatarURL, avatarSize, err := h.uploadService.UploadImage(c.Context(), miniAppPath.String(), avatarFile)
 		if err != nil {
 			return apperrors.BadRequest("error while uploading avatar", err)
 		}
@@ -105,16 +114,6 @@ func (h *V1Handler) EditUser(c fiber.Ctx) error {
 		user.Avatar = avatarURL
 		user.AvatarSize = avatarSize
 		isChanged = true
-
-		if claims.IsOwner {
-			if ownerAvatarSizeLimit < avatarSize {
-				return apperrors.BadRequest("avatar size exceeds the limit")
-			}
-		} else {
-			if avatarSizeLimit < avatarSize {
-				return apperrors.BadRequest("avatar size exceeds the limit")
-			}
-		}
 	}
 
 	if req.DeleteAvatar {
@@ -195,10 +194,6 @@ func (h *V1Handler) BanUser(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	userID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -220,6 +215,10 @@ func (h *V1Handler) BanUser(c fiber.Ctx) error {
 		if err != nil {
 			return err
 		}
+
+		if !h.isPermittedInProduct(c.Context(), &claims, productID, model.PermissionStudentBans) {
+			return apperrors.Unauthorized("user is not permitted")
+		}
 	}
 
 	filesToDelete, err := h.userService.Ban(c.Context(), userID, &req)
@@ -243,10 +242,6 @@ func (h *V1Handler) UnbanUser(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	userID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -268,6 +263,17 @@ func (h *V1Handler) UnbanUser(c fiber.Ctx) error {
 		return apperrors.BadRequest("invalid request data")
 	}
 
+	for _, productID := range req.ProductID {
+		err := h.checkProduct(c.Context(), claims.MiniAppID, productID)
+		if err != nil {
+			return err
+		}
+
+		if !h.isPermittedInProduct(c.Context(), &claims, productID, model.PermissionStudentBans) {
+			return apperrors.Unauthorized("user is not permitted")
+		}
+	}
+
 	err = h.userService.Unban(c.Context(), userID, &req)
 	if err != nil {
 		return apperrors.Internal("error while banning user", err)
@@ -308,10 +314,6 @@ func (h *V1Handler) LevelUpUser(c fiber.Ctx) error {
 		return apperrors.Unauthorized("claims not found")
 	}
 
-	if !h.isPermitted(c.Context(), &claims, model.PermissionStudentManagement) {
-		return apperrors.Unauthorized("user is not permitted")
-	}
-
 	userID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return apperrors.BadRequest("invalid request data", err)
@@ -343,6 +345,10 @@ func (h *V1Handler) LevelUpUser(c fiber.Ctx) error {
 		return apperrors.Unauthorized("user is not permitted")
 	}
 
+	if !h.isPermittedInProduct(c.Context(), &claims, product.ID, model.PermissionStudentLevels) {
+		return apperrors.Unauthorized("user is not permitted")
+	}
+
 	productLevels := make([]*model.ProductLevel, 0, len(req.ProductLevelID))
 	for _, productLevelID := range req.ProductLevelID {
 		productLevel, err := h.productLevelService.GetByID(c.Context(), productLevelID)
@@ -4,6 +4,7 @@ import (
 	"academy/internal/config"
 	"academy/internal/model"
 	"academy/internal/service"
+	"academy/internal/service/bot"
 	"academy/internal/service/jwt"
 	"academy/internal/service/security"
 	"academy/internal/service/telegram"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/static"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -33,12 +35,26 @@ type V1Handler struct {
 	lessonProgressService *service.LessonProgressService
 	productLevelService   *service.ProductLevelService
 	reviewService         *service.ReviewService
+	gamificationService   *service.GamificationService
+	cohortService         *service.CohortService
+	botService            *service.BotService
+	notificationService   *service.NotificationService
+	broadcastService      *service.BroadcastService
+
+	staffNotificationService *service.StaffNotificationService
+	supportService           *service.SupportService
+	auditService             *service.AuditService
+	modRoleService           *service.ModRoleService
+	apiKeyService            *service.APIKeyService
+	jobService               *service.JobService
+	subtitleService          *service.SubtitleService
 
 	jwtService      *service.JWTService
 	telegramService *telegram.Service
 	paymentService  *service.PaymentService
 	uploadService   *upload.Service
 	securityService *security.Service
+	botManager      *bot.Manager
 }
 
 func NewV1Handler(
@@ -55,12 +71,25 @@ func NewV1Handler(
 	lessonProgressService *service.LessonProgressService,
 	productLevelService *service.ProductLevelService,
 	reviewService *service.ReviewService,
+	gamificationService *service.GamificationService,
+	cohortService *service.CohortService,
+	botService *service.BotService,
+	notificationService *service.NotificationService,
+	broadcastService *service.BroadcastService,
+	staffNotificationService *service.StaffNotificationService,
+	supportService *service.SupportService,
+	auditService *service.AuditService,
+	modRoleService *service.ModRoleService,
+	apiKeyService *service.APIKeyService,
+	jobService *service.JobService,
+	subtitleService *service.SubtitleService,
 
 	jwtService *service.JWTService,
 	tgService *telegram.Service,
 	uploadService *upload.Service,
 	paymentService *service.PaymentService,
 	securityService *security.Service,
+	botManager *bot.Manager,
 ) *V1Handler {
 
 	return &V1Handler{
@@ -77,12 +106,26 @@ func NewV1Handler(
 		lessonProgressService: lessonProgressService,
 		productLevelService:   productLevelService,
 		reviewService:         reviewService,
+		gamificationService:   gamificationService,
+		cohortService:         cohortService,
+		botService:            botService,
+		notificationService:   notificationService,
+		broadcastService:      broadcastService,
+
+		staffNotificationService: staffNotificationService,
+		supportService:           supportService,
+		auditService:             auditService,
+		modRoleService:           modRoleService,
+		apiKeyService:            apiKeyService,
+		jobService:               jobService,
+		subtitleService:          subtitleService,
 
 		jwtService:      jwtService,
 		telegramService: tgService,
 		uploadService:   uploadService,
 		paymentService:  paymentService,
 		securityService: securityService,
+		botManager:      botManager,
 	}
 }
 
@@ -95,6 +138,7 @@ func (h *V1Handler) RegisterRoutes(app *fiber.App) {
 	authGroup.Post("/mod/signin", h.ModSignIn)
 	authGroup.Post("/signin", h.SignIn)
 	authGroup.Post("/signin/invite", h.SignInWithInvite)
+	authGroup.Post("/signin/widget", h.SignInWithWidget)
 	authGroup.Post("/refresh", h.RefreshTokens)
 
 	userGroup := v1Group.Group("/user")
@@ -103,72 +147,207 @@ func (h *V1Handler) RegisterRoutes(app *fiber.App) {
 	userGroup.Post("/edit", h.EditUser)
 	userGroup.Post("/homeworks", h.UserHomeworks)
 	userGroup.Get("/:id/stats", h.StudentStats)
-	userGroup.Post("/:id/ban", h.BanUser)
-	userGroup.Post("/:id/unban", h.UnbanUser)
+	userGroup.Post("/:id/ban",
+		h.audit("user.ban", model.AuditEntityUser, auditPath),
+		h.BanUser)
+	userGroup.Post("/:id/unban",
+		h.audit("user.unban", model.AuditEntityUser, auditPath),
+		h.UnbanUser)
 	userGroup.Post("/banlist", h.ListBannedUser)
-	userGroup.Post("/:id/levelup", h.LevelUpUser)
+	userGroup.Post("/:id/levelup",
+		h.audit("user.level_up", model.AuditEntityUser, auditPath),
+		h.LevelUpUser)
 	userGroup.Post("/:id/levels", h.UserLevels)
+	userGroup.Get("/badges", h.UserBadges)
+	userGroup.Post("/cohort/join", h.JoinCohort)
+	userGroup.Get("/calendar", h.UserCalendar)
+	userGroup.Get("/sessions", h.UserSessions)
+	userGroup.Post("/sessions/revoke", h.RevokeOtherSessions)
+	userGroup.Delete("/session/:id", h.RevokeSession)
 
 	modGroup := v1Group.Group("/mod")
 	modGroup.Use(h.JWTAuthMiddleware)
-	modGroup.Post("/invite", h.CreateModInvite)
-	modGroup.Delete("/invite/:id", h.DeleteModInvite)
-	modGroup.Post("/invite/:id/edit", h.EditModInvite)
+	modGroup.Post("/invite",
+		h.audit("mod_invite.create", model.AuditEntityModInvite, auditResponse("invite")),
+		h.CreateModInvite)
+	modGroup.Delete("/invite/:id",
+		h.audit("mod_invite.delete", model.AuditEntityModInvite, auditPath),
+		h.DeleteModInvite)
+	modGroup.Post("/invite/:id/edit",
+		h.audit("mod_invite.edit", model.AuditEntityModInvite, auditPath),
+		h.EditModInvite)
 	modGroup.Post("/invites", h.ModInvites)
 	modGroup.Get("/permissions", h.ModPermissions)
+	modGroup.Get("/roles", h.ModRoles)
+	modGroup.Post("/role",
+		h.audit("mod_role.create", model.AuditEntityModRole, auditResponse("role")),
+		h.CreateModRole)
+	modGroup.Post("/role/:id/edit",
+		h.audit("mod_role.edit", model.AuditEntityModRole, auditPath),
+		h.EditModRole)
+	modGroup.Delete("/role/:id",
+		h.audit("mod_role.delete", model.AuditEntityModRole, auditPath),
+		h.DeleteModRole)
 
 	v1Group.Post("/app", h.CreateMiniApp)
 	appGroup := v1Group.Group("/app")
-	appGroup.Use(h.JWTAuthMiddleware)
+	appGroup.Use(h.AuthMiddleware)
 	appGroup.Get("/", h.GetMiniApp)
-	appGroup.Delete("/", h.DeleteMiniApp)
-	appGroup.Post("/edit/account", h.EditMiniAppAccount)
-	appGroup.Post("/edit/branding", h.EditMiniAppBranding)
-	appGroup.Post("/edit/analytics", h.EditMiniAppAnalytics)
-	appGroup.Get("/archive", h.ArchiveMiniApp)
-	appGroup.Get("/unarchive", h.UnarchiveMiniApp)
-	appGroup.Post("/slides", h.EditSlides)
+	appGroup.Delete("/",
+		h.audit("mini_app.delete", model.AuditEntityMiniApp, auditMiniApp),
+		h.DeleteMiniApp)
+	appGroup.Post("/edit/account",
+		h.audit("mini_app.edit_account", model.AuditEntityMiniApp, auditMiniApp),
+		h.EditMiniAppAccount)
+	appGroup.Post("/edit/branding",
+		h.audit("mini_app.edit_branding", model.AuditEntityMiniApp, auditMiniApp),
+		h.EditMiniAppBranding)
+	appGroup.Post("/edit/analytics",
+		h.audit("mini_app.edit_analytics", model.AuditEntityMiniApp, auditMiniApp),
+		h.EditMiniAppAnalytics)
+	appGroup.Get("/archive",
+		h.audit("mini_app.archive", model.AuditEntityMiniApp, auditMiniApp),
+		h.ArchiveMiniApp)
+	appGroup.Get("/unarchive",
+		h.audit("mini_app.unarchive", model.AuditEntityMiniApp, auditMiniApp),
+		h.UnarchiveMiniApp)
+	appGroup.Post("/slides",
+		h.audit("mini_app.edit_slides", model.AuditEntityMiniApp, auditMiniApp),
+		h.EditSlides)
 	appGroup.Post("/analytics", h.Analytics)
 	appGroup.Get("/info", h.Info)
 	appGroup.Get("/payment_metadata", h.PaymentMetadata)
-
-	appGroup.Post("/product", h.CreateProduct)
+	appGroup.Get("/bot", h.BotSettings)
+	appGroup.Post("/bot/edit",
+		h.audit("mini_app.edit_bot", model.AuditEntityMiniApp, auditMiniApp),
+		h.EditBotSettings)
+	appGroup.Get("/notifications", h.StaffNotificationSettings)
+	appGroup.Post("/notifications/edit",
+		h.audit("staff_notifications.edit", model.AuditEntityUser, auditSelf),
+		h.EditStaffNotificationSettings)
+	appGroup.Post("/audit", h.AuditLog)
+	appGroup.Post("/audit/export/excel", h.ExportAuditLog)
+	appGroup.Get("/api-keys", h.APIKeys)
+	appGroup.Post("/api-key",
+		h.audit("api_key.create", model.AuditEntityAPIKey, auditResponse("api_key")),
+		h.CreateAPIKey)
+	appGroup.Delete("/api-key/:id",
+		h.audit("api_key.revoke", model.AuditEntityAPIKey, auditPath),
+		h.RevokeAPIKey)
+
+	appGroup.Post("/product",
+		h.audit("product.create", model.AuditEntityProduct, auditResponse("product")),
+		h.CreateProduct)
 	appGroup.Get("/product/:id", h.GetProduct)
-	appGroup.Post("/product/:id/edit", h.EditProduct)
-	appGroup.Post("/product/:id/reorder/lessons", h.ReorderProductLessons)
-	appGroup.Post("/product/:id/reorder/levels", h.ReorderProductLevels)
-	appGroup.Delete("/product/:id", h.DeleteProduct)
+	appGroup.Post("/product/:id/edit",
+		h.audit("product.edit", model.AuditEntityProduct, auditPath),
+		h.EditProduct)
+	appGroup.Post("/product/:id/reorder/lessons",
+		h.audit("product.reorder_lessons", model.AuditEntityProduct, auditPath),
+		h.ReorderProductLessons)
+	appGroup.Post("/product/:id/reorder/levels",
+		h.audit("product.reorder_levels", model.AuditEntityProduct, auditPath),
+		h.ReorderProductLevels)
+	appGroup.Delete("/product/:id",
+		h.audit("product.delete", model.AuditEntityProduct, auditPath),
+		h.DeleteProduct)
 
 	appGroup.Post("/product/:id/invites", h.ProductInvites)
 	appGroup.Post("/product/:id/homeworks", h.ProductHomeworks)
 	appGroup.Get("/product/:id/feedback", h.ProductFeedback)
 	appGroup.Get("/product/:id/students", h.ProductStudents)
 	appGroup.Post("/product/:id/students/export/excel", h.ExportProductStudents)
-
-	appGroup.Post("/lesson", h.CreateLesson)
+	appGroup.Get("/product/:id/leaderboard", h.ProductLeaderboard)
+	appGroup.Get("/product/:id/cohorts", h.ProductCohorts)
+	appGroup.Post("/product/:id/cohort",
+		h.audit("cohort.create", model.AuditEntityCohort, auditResponse("cohort")),
+		h.CreateCohort)
+	appGroup.Get("/product/:id/calendar", h.ProductCalendar)
+	appGroup.Get("/product/:id/transcripts", h.SearchTranscripts)
+
+	appGroup.Post("/cohort/:id/edit",
+		h.audit("cohort.edit", model.AuditEntityCohort, auditPath),
+		h.EditCohort)
+	appGroup.Post("/cohort/:id/students",
+		h.audit("cohort.assign_students", model.AuditEntityCohort, auditPath),
+		h.AssignCohortStudents)
+	appGroup.Delete("/cohort/:id",
+		h.audit("cohort.delete", model.AuditEntityCohort, auditPath),
+		h.DeleteCohort)
+
+	appGroup.Post("/lesson",
+		h.audit("lesson.create", model.AuditEntityLesson, auditResponse("lesson")),
+		h.CreateLesson)
 	appGroup.Get("/lesson/:id", h.GetLesson)
-	appGroup.Post("/lesson/:id/edit", h.EditLesson)
-	appGroup.Post("/lesson/:id/submit", h.SubmitLesson)
-	appGroup.Post("/lesson/:id/submit/question", h.SubmitLessonQuestion)
-	appGroup.Post("/lesson/:id/review", h.ReviewLesson)
-	appGroup.Delete("/lesson/:id", h.DeleteLesson)
-	appGroup.Post("/homework/feedback", h.FeedbackHomework)
-
-	appGroup.Post("/homework", h.CreateHomework)
-	appGroup.Post("/homework/:id/edit", h.EditHomework)
-
-	appGroup.Post("/material", h.CreateMaterial)
-	appGroup.Post("/material/:id/edit", h.EditMaterial)
+	appGroup.Post("/lesson/:id/edit",
+		h.audit("lesson.edit", model.AuditEntityLesson, auditPath),
+		h.EditLesson)
+	appGroup.Post("/lesson/:id/submit", h.StudentMiddleware, h.SubmitLesson)
+	appGroup.Post("/lesson/:id/submit/question", h.StudentMiddleware, h.SubmitLessonQuestion)
+	appGroup.Post("/lesson/:id/review", h.StudentMiddleware, h.ReviewLesson)
+	appGroup.Post("/lesson/:id/join", h.JoinEvent)
+	appGroup.Get("/lesson/:id/attendance", h.EventAttendance)
+	appGroup.Post("/lesson/:id/recording",
+		h.audit("lesson.attach_recording", model.AuditEntityLesson, auditPath),
+		h.AttachRecording)
+	appGroup.Delete("/lesson/:id",
+		h.audit("lesson.delete", model.AuditEntityLesson, auditPath),
+		h.DeleteLesson)
+	appGroup.Post("/homework/feedback",
+		h.audit("homework.feedback", model.AuditEntityLesson, auditBody("lesson_id")),
+		h.FeedbackHomework)
+
+	appGroup.Post("/homework",
+		h.audit("homework.create", model.AuditEntityMaterial, auditResponse("material")),
+		h.CreateHomework)
+	appGroup.Post("/homework/:id/edit",
+		h.audit("homework.edit", model.AuditEntityMaterial, auditPath),
+		h.EditHomework)
+
+	appGroup.Post("/material",
+		h.audit("material.create", model.AuditEntityMaterial, auditResponse("material")),
+		h.CreateMaterial)
+	appGroup.Post("/material/:id/edit",
+		h.audit("material.edit", model.AuditEntityMaterial, auditPath),
+		h.EditMaterial)
 	appGroup.Get("/material/:id/token", h.GetMaterialToken)
 	appGroup.Post("/material/:id/chunk/:index", h.AddChunk)
-	appGroup.Post("/material/:id/chunks/submit", h.SubmitChunks)
-	appGroup.Post("/material/:id/chunks/clear", h.ClearChunks)
-	appGroup.Delete("/material/:id", h.DeleteMaterial)
-
-	appGroup.Post("/level", h.CreateProductLevel)
-	appGroup.Post("/level/:id/edit", h.EditProductLevel)
-	appGroup.Get("/level/:id/invite", h.CreateProductLevelInvite)
-	appGroup.Delete("/level/:id", h.DeleteProductLevel)
+	appGroup.Post("/material/:id/chunks/submit",
+		h.audit("material.upload", model.AuditEntityMaterial, auditPath),
+		h.SubmitChunks)
+	appGroup.Post("/material/:id/chunks/clear",
+		h.audit("material.clear_chunks", model.AuditEntityMaterial, auditPath),
+		h.ClearChunks)
+
+	tusGroup := appGroup.Group("/material/:id/tus", h.TusMiddleware)
+	tusGroup.Options("", h.TusOptions)
+	tusGroup.Post("", h.CreateTusUpload)
+	tusGroup.Options("/:upload_id", h.TusOptions)
+	tusGroup.Head("/:upload_id", h.TusUploadOffset)
+	tusGroup.Patch("/:upload_id", h.PatchTusUpload)
+	tusGroup.Delete("/:upload_id", h.DeleteTusUpload)
+	appGroup.Delete("/material/:id",
+		h.audit("material.delete", model.AuditEntityMaterial, auditPath),
+		h.DeleteMaterial)
+	appGroup.Post("/material/:id/subtitles",
+		h.audit("material.upload_subtitles", model.AuditEntityMaterial, auditPath),
+		h.UploadSubtitles)
+	appGroup.Delete("/material/:id/subtitles/:language",
+		h.audit("material.delete_subtitles", model.AuditEntityMaterial, auditPath),
+		h.DeleteSubtitles)
+
+	appGroup.Post("/level",
+		h.audit("product_level.create", model.AuditEntityProductLevel, auditResponse("product_level")),
+		h.CreateProductLevel)
+	appGroup.Post("/level/:id/edit",
+		h.audit("product_level.edit", model.AuditEntityProductLevel, auditPath),
+		h.EditProductLevel)
+	appGroup.Get("/level/:id/invite",
+		h.audit("product_level.invite", model.AuditEntityProductLevel, auditPath),
+		h.CreateProductLevelInvite)
+	appGroup.Delete("/level/:id",
+		h.audit("product_level.delete", model.AuditEntityProductLevel, auditPath),
+		h.DeleteProductLevel)
 	appGroup.Get("/level/:id/buy/ton", h.BuyProductLevelWithTON)
 	appGroup.Get("/level/:id/buy/wayforpay", h.BuyProductLevelWithWayForPay)
 
@@ -177,7 +356,55 @@ func (h *V1Handler) RegisterRoutes(app *fiber.App) {
 	appGroup.Post("/students/payments", h.GetStudentsPayments)
 	appGroup.Post("/students/payments/export/excel", h.ExportStudentsPayments)
 
+	appGroup.Get("/points/rules", h.PointRules)
+	appGroup.Post("/points/rules",
+		h.audit("points.edit_rules", model.AuditEntityPointRules, auditNone),
+		h.EditPointRules)
+	appGroup.Post("/points/adjust",
+		h.audit("points.adjust", model.AuditEntityUser, auditBody("user_id")),
+		h.AdjustPoints)
+	appGroup.Post("/points/history", h.PointHistory)
+
+	appGroup.Post("/broadcasts", h.Broadcasts)
+	appGroup.Post("/broadcast",
+		h.audit("broadcast.create", model.AuditEntityBroadcast, auditResponse("broadcast")),
+		h.CreateBroadcast)
+	appGroup.Post("/broadcast/segment", h.CountBroadcastSegment)
+	appGroup.Get("/broadcast/:id", h.GetBroadcast)
+	appGroup.Post("/broadcast/:id/cancel",
+		h.audit("broadcast.cancel", model.AuditEntityBroadcast, auditPath),
+		h.CancelBroadcast)
+	appGroup.Post("/broadcast/:id/recipients", h.BroadcastRecipients)
+
+	appGroup.Post("/support/tickets", h.SupportTickets)
+	appGroup.Get("/support/ticket/:id", h.GetSupportTicket)
+	appGroup.Post("/support/ticket/:id/reply",
+		h.audit("support_ticket.reply", model.AuditEntitySupport, auditPath),
+		h.ReplySupportTicket)
+	appGroup.Post("/support/ticket/:id/edit",
+		h.audit("support_ticket.edit", model.AuditEntitySupport, auditPath),
+		h.EditSupportTicket)
+
+	appGroup.Get("/badges", h.Badges)
+	appGroup.Post("/badge",
+		h.audit("badge.create", model.AuditEntityBadge, auditResponse("badge")),
+		h.CreateBadge)
+	appGroup.Post("/badge/:id/edit",
+		h.audit("badge.edit", model.AuditEntityBadge, auditPath),
+		h.EditBadge)
+	appGroup.Delete("/badge/:id",
+		h.audit("badge.delete", model.AuditEntityBadge, auditPath),
+		h.DeleteBadge)
+
 	v1Group.Post("/wayforpay/update", h.WayForPayUpdate)
+	v1Group.Get("/calendar/:token", h.CalendarFeed)
+	v1Group.Get("/hls/:id/:token/*", h.GetHLSFile)
+	v1Group.Post("/bot/:id/webhook", h.BotWebhook)
+
+	jobGroup := v1Group.Group("/jobs")
+	jobGroup.Use(h.JobsAuthMiddleware)
+	jobGroup.Post("/", h.Jobs)
+	jobGroup.Post("/:id/retry", h.RetryJob)
 
 	v1Group.Get("/static/*", static.New("./resources/static"))
 	v1Group.Get("/swagger/*", static.New("./resources/swagger"))
@@ -189,11 +416,16 @@ func (h *V1Handler) RegisterRoutes(app *fiber.App) {
 		Compress:      true,
 	}
 
+	// Files of remote storage are downloaded by presigned URLs, local files
+	// are served from the upload directory.
 	app.Get(uploadPath+"*", static.New(
 		h.config.App.UploadDirectory,
 		staticConfig,
 	),
 		h.MaterialAuthMiddleware,
+		h.ImageVariantMiddleware,
+		h.PDFWatermarkMiddleware,
+		h.StorageRedirectMiddleware,
 		// h.HandleMaterialHeaders,
 	)
 }
@@ -204,15 +436,31 @@ func (h *V1Handler) isPermitted(
 	permissionName ...model.PermissionName,
 ) bool {
 
+	return h.isPermittedInProduct(ctx, claims, uuid.Nil, permissionName...)
+}
+
+// isPermittedInProduct also accepts permissions of the moderator that are
+// limited to the product.
+func (h *V1Handler) isPermittedInProduct(
+	ctx context.Context,
+	claims *jwt.TokenClaims,
+	productID uuid.UUID,
+	permissionName ...model.PermissionName,
+) bool {
+
 	if claims.IsOwner {
 		return true
 	}
 
+	if claims.APIKeyID != uuid.Nil {
+		return model.HasPermission(claims.APIKeyScopes, permissionName...)
+	}
+
 	if !claims.IsMod {
 		return false
 	}
 
-	isPermitted, err := h.miniAppService.CheckPermission(ctx, claims.UserID, permissionName...)
+	isPermitted, err := h.miniAppService.CheckPermission(ctx, claims.UserID, productID, permissionName...)
 	if err != nil {
 		h.logger.Error("failed to check permissions", zap.Error(err))
 		return false
@@ -19,13 +19,16 @@ const (
 )
 
 type Config struct {
-	App   AppConfig
-	HTTP  HTTPConfig
-	Auth  AuthConfig
-	Mux   MuxConfig
-	PG    DBConfig
-	Redis RedisConfig
-	TON   TONConfig
+	App     AppConfig
+	HTTP    HTTPConfig
+	Auth    AuthConfig
+	Mux     MuxConfig
+	PG      DBConfig
+	Redis   RedisConfig
+	TON     TONConfig
+	Storage StorageConfig
+	Video   VideoConfig
+	Scan    ScanConfig
 }
 
 type AppConfig struct {
@@ -34,6 +37,7 @@ type AppConfig struct {
 	TempUploadDirectory string `env:"TEMP_UPLOAD_DIRECTORY,required"`
 	TelegramBotConfig   string `env:"TELEGRAM_BOT_CONFIG"`
 	TelegramBotImage    string `env:"TELEGRAM_BOT_IMAGE"`
+	TelegramWebhookURL  string `env:"TELEGRAM_WEBHOOK_URL"`
 
 	EnableDemoProduct bool `env:"ENABLE_DEMO_PRODUCT"`
 }
@@ -59,6 +63,9 @@ type AuthConfig struct {
 
 	SkipSecurityKey        string `env:"SKIP_SECURITY_KEY"`
 	WayForPayDisableRefund bool   `env:"WAYFORPAY_DISABLE_REFUND"`
+
+	// JobsAPIKey grants access to the job queue, it is disabled if empty.
+	JobsAPIKey string `env:"JOBS_API_KEY"`
 }
 
 type MuxConfig struct {
@@ -86,6 +93,37 @@ type TONConfig struct {
 	WayForPaySecretKey string `env:"WAYFORPAY_SECRET_KEY,required"`
 }
 
+// StorageConfig selects where uploaded files are kept, the upload directory
+// is used by default. S3 settings are used by any S3-compatible storage, path
+// style addressing is required by MinIO.
+type StorageConfig struct {
+	Backend string `env:"STORAGE_BACKEND"`
+
+	S3Endpoint   string        `env:"S3_ENDPOINT"`
+	S3Region     string        `env:"S3_REGION"`
+	S3Bucket     string        `env:"S3_BUCKET"`
+	S3AccessKey  string        `env:"S3_ACCESS_KEY"`
+	S3SecretKey  string        `env:"S3_SECRET_KEY"`
+	S3PathStyle  bool          `env:"S3_PATH_STYLE"`
+	S3PresignTTL time.Duration `env:"S3_PRESIGN_TTL"`
+}
+
+// VideoConfig selects where lesson videos are played from, Mux is used by
+// default. Videos are transcoded into HLS by ffmpeg with the "hls" backend.
+type VideoConfig struct {
+	Backend string `env:"VIDEO_BACKEND"`
+}
+
+// ScanConfig selects how uploaded documents are scanned for malware, files
+// are not scanned by default. Clamd address is "host:port" or a unix socket
+// path with the "unix:" prefix.
+type ScanConfig struct {
+	Backend string `env:"SCAN_BACKEND"`
+
+	ClamdAddress string        `env:"CLAMD_ADDRESS"`
+	ClamdTimeout time.Duration `env:"CLAMD_TIMEOUT"`
+}
+
 type DBConfig struct {
 	User     string `env:"POSTGRES_USER,required"`
 	Password string `env:"POSTGRES_PASSWORD,required"`
@@ -3,14 +3,22 @@ package cron
 import (
 	"academy/internal/model"
 	"academy/internal/service"
+	"academy/internal/service/bot"
+	"academy/internal/service/security"
+	"academy/internal/service/telegram"
 	"academy/internal/service/ton"
 	"academy/internal/service/upload"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	rcron "github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -19,15 +27,34 @@ type Cron struct {
 	logger *zap.Logger
 	cron   *rcron.Cron
 
-	videoProcessingMutex      sync.Mutex
-	muxClearAssetsMutex       sync.Mutex
-	muxUpdateReadyStatusMutex sync.Mutex
-	updateTonPaymentsMutex    sync.Mutex
+	// Jobs are run by workers of all replicas, bots are synced by every
+	// replica since they run in memory.
+	syncBotsMutex sync.Mutex
 
-	uploadService   *upload.Service
-	tonService      *ton.Service
-	miniAppService  *service.MiniAppService
-	materialService *service.MaterialService
+	workerID string
+	handlers map[model.JobKind]jobHandler
+	cancel   context.CancelFunc
+	workers  sync.WaitGroup
+
+	// rateLimiter is shared by jobs that send messages with mini-app bots.
+	rateLimiter *telegram.RateLimiter
+
+	uploadService       *upload.Service
+	tonService          *ton.Service
+	telegramService     *telegram.Service
+	securityService     *security.Service
+	miniAppService      *service.MiniAppService
+	materialService     *service.MaterialService
+	lessonService       *service.LessonService
+	progressService     *service.LessonProgressService
+	notificationService *service.NotificationService
+	broadcastService    *service.BroadcastService
+	botService          *service.BotService
+	botManager          *bot.Manager
+	jobService          *service.JobService
+	subtitleService     *service.SubtitleService
+
+	staffNotificationService *service.StaffNotificationService
 }
 
 const (
@@ -39,60 +66,133 @@ const (
 
 const daysBeforeDeletingArchivedMiniApp = 7
 
+const daysBeforeDeletingNotifications = 30
+
+// adminBotLimiterKey identifies the admin bot in the rate limiter.
+const adminBotLimiterKey = "admin"
+
+// broadcastBatchSize is a number of messages sent per broadcast in a run.
+// Batch fits in a minute with Telegram rate limits.
+const broadcastBatchSize = 1000
+
 func NewSomeCron(
 	logger *zap.Logger,
 	cron *rcron.Cron,
 
 	uploadService *upload.Service,
 	tonService *ton.Service,
+	telegramService *telegram.Service,
+	securityService *security.Service,
 	miniAppService *service.MiniAppService,
 	materialService *service.MaterialService,
+	lessonService *service.LessonService,
+	progressService *service.LessonProgressService,
+	notificationService *service.NotificationService,
+	broadcastService *service.BroadcastService,
+	botService *service.BotService,
+	botManager *bot.Manager,
+	staffNotificationService *service.StaffNotificationService,
+	jobService *service.JobService,
+	subtitleService *service.SubtitleService,
 ) (c *Cron, err error) {
 
 	c = &Cron{
 		logger: logger,
 		cron:   cron,
 
-		uploadService:   uploadService,
-		tonService:      tonService,
-		miniAppService:  miniAppService,
-		materialService: materialService,
-	}
+		rateLimiter: telegram.NewRateLimiter(),
 
-	// Uncomment to run cron-jobs before starting API.
-	// c.clearChunks()
-	// c.clearUploads()
-	// c.clearOldMiniApps()
-	// c.videoProcessing()
-	// c.muxClearAssets()
-	// c.muxUpdateReadyStatus()
-	// c.updateTonPayments()
+		uploadService:       uploadService,
+		tonService:          tonService,
+		telegramService:     telegramService,
+		securityService:     securityService,
+		miniAppService:      miniAppService,
+		materialService:     materialService,
+		lessonService:       lessonService,
+		progressService:     progressService,
+		notificationService: notificationService,
+		broadcastService:    broadcastService,
+		botService:          botService,
+		botManager:          botManager,
+		jobService:          jobService,
+		subtitleService:     subtitleService,
 
-	_, err = c.cron.AddFunc(RunningHourly, c.clearChunks)
-	if err != nil {
-		return nil, err
-	}
-	_, err = c.cron.AddFunc(RunningDailyAt11PM, c.clearUploads)
-	if err != nil {
-		return nil, err
-	}
-	_, err = c.cron.AddFunc(RunningDailyAt11PM, c.clearOldMiniApps)
-	if err != nil {
-		return nil, err
+		staffNotificationService: staffNotificationService,
+
+		workerID: newWorkerID(),
 	}
-	_, err = c.cron.AddFunc(RunningEveryMinute, c.videoProcessing)
-	if err != nil {
-		return nil, err
+
+	c.handlers = map[model.JobKind]jobHandler{
+		model.JobKindClearChunks:            periodic(c.clearChunks),
+		model.JobKindClearTusUploads:        periodic(c.clearTusUploads),
+		model.JobKindClearUploads:           periodic(c.clearUploads),
+		model.JobKindClearOldMiniApps:       periodic(c.clearOldMiniApps),
+		model.JobKindClearJobs:              periodic(c.clearJobs),
+		model.JobKindVideoProcessing:        periodic(c.videoProcessing),
+		model.JobKindMuxClearAssets:         periodic(c.muxClearAssets),
+		model.JobKindMuxUpdateReadyStatus:   periodic(c.muxUpdateReadyStatus),
+		model.JobKindUpdateTonPayments:      periodic(c.updateTonPayments),
+		model.JobKindSendEventReminders:     periodic(c.sendEventReminders),
+		model.JobKindSendNotifications:      periodic(c.sendNotifications),
+		model.JobKindClearNotifications:     periodic(c.clearNotifications),
+		model.JobKindSendBroadcasts:         periodic(c.sendBroadcasts),
+		model.JobKindCheckPlanLimits:        periodic(c.checkPlanLimits),
+		model.JobKindSendStaffNotifications: periodic(c.sendStaffNotifications),
+		model.JobKindScanQuarantinedFiles:   periodic(c.scanQuarantinedFiles),
+
+		model.JobKindMuxUploadVideo: c.materialJob(c.muxUploadVideo),
+		model.JobKindTranscodeVideo: c.materialJob(c.transcodeVideo),
+		model.JobKindVideoPreview:   c.materialJob(c.takeVideoPreview),
 	}
-	_, err = c.cron.AddFunc(RunningEveryMinute, c.muxClearAssets)
-	if err != nil {
-		return nil, err
+
+	// Uncomment to enqueue jobs before starting API.
+	// c.schedule(model.JobKindClearChunks)()
+	// c.schedule(model.JobKindClearTusUploads)()
+	// c.schedule(model.JobKindClearUploads)()
+	// c.schedule(model.JobKindClearOldMiniApps)()
+	// c.schedule(model.JobKindClearJobs)()
+	// c.schedule(model.JobKindVideoProcessing)()
+	// c.schedule(model.JobKindMuxClearAssets)()
+	// c.schedule(model.JobKindMuxUpdateReadyStatus)()
+	// c.schedule(model.JobKindUpdateTonPayments)()
+	// c.schedule(model.JobKindSendEventReminders)()
+	// c.syncBots()
+	// c.schedule(model.JobKindSendNotifications)()
+	// c.schedule(model.JobKindClearNotifications)()
+	// c.schedule(model.JobKindSendBroadcasts)()
+	// c.schedule(model.JobKindCheckPlanLimits)()
+	// c.schedule(model.JobKindSendStaffNotifications)()
+	// c.schedule(model.JobKindScanQuarantinedFiles)()
+
+	schedules := []struct {
+		spec string
+		kind model.JobKind
+	}{
+		{RunningHourly, model.JobKindClearChunks},
+		{RunningHourly, model.JobKindClearTusUploads},
+		{RunningDailyAt11PM, model.JobKindClearUploads},
+		{RunningDailyAt11PM, model.JobKindClearOldMiniApps},
+		{RunningDailyAt11PM, model.JobKindClearJobs},
+		{RunningEveryMinute, model.JobKindVideoProcessing},
+		{RunningEveryMinute, model.JobKindMuxClearAssets},
+		{RunningEveryMinute, model.JobKindMuxUpdateReadyStatus},
+		{RunningEvery2Minutes, model.JobKindUpdateTonPayments},
+		{RunningEveryMinute, model.JobKindSendEventReminders},
+		{RunningEveryMinute, model.JobKindSendNotifications},
+		{RunningDailyAt11PM, model.JobKindClearNotifications},
+		{RunningEveryMinute, model.JobKindSendBroadcasts},
+		{RunningHourly, model.JobKindCheckPlanLimits},
+		{RunningEveryMinute, model.JobKindSendStaffNotifications},
+		{RunningEvery2Minutes, model.JobKindScanQuarantinedFiles},
 	}
-	_, err = c.cron.AddFunc(RunningEveryMinute, c.muxUpdateReadyStatus)
-	if err != nil {
-		return nil, err
+	for _, sch := range schedules {
+		_, err = c.cron.AddFunc(sch.spec, c.schedule(sch.kind))
+		if err != nil {
+			return nil, err
+		}
 	}
-	_, err = c.cron.AddFunc(RunningEvery2Minutes, c.updateTonPayments)
+
+	_, err = c.cron.AddFunc(RunningEveryMinute, c.syncBots)
 	if err != nil {
 		return nil, err
 	}
@@ -100,38 +200,44 @@ func NewSomeCron(
 	return c, nil
 }
 
-func (c *Cron) clearChunks() {
-	ctx := context.Background()
-
+func (c *Cron) clearChunks(ctx context.Context) error {
 	err := c.uploadService.ClearOldChunks(ctx)
 	if err != nil {
-		c.logger.Error("clearChunks: cron job failed: failed to clear old chunks", zap.Error(err))
-		return
+		return fmt.Errorf("failed to clear old chunks: %w", err)
 	}
 
 	c.logger.Info("clearChunks: cron job successfully finished")
+
+	return nil
 }
 
-func (c *Cron) clearUploads() {
-	ctx := context.Background()
+func (c *Cron) clearTusUploads(ctx context.Context) error {
+	err := c.uploadService.ClearExpiredTusUploads(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear expired uploads: %w", err)
+	}
+
+	c.logger.Info("clearTusUploads: cron job successfully finished")
+
+	return nil
+}
 
+func (c *Cron) clearUploads(ctx context.Context) error {
 	err := c.uploadService.ClearDanglingUploads(ctx)
 	if err != nil {
-		c.logger.Error("clearUploads: cron job failed: failed to clear dangling uploads", zap.Error(err))
-		return
+		return fmt.Errorf("failed to clear dangling uploads: %w", err)
 	}
 
 	c.logger.Info("clearUploads: cron job successfully finished")
-}
 
-func (c *Cron) clearOldMiniApps() {
-	ctx := context.Background()
+	return nil
+}
 
+func (c *Cron) clearOldMiniApps(ctx context.Context) error {
 	miniAppIDs, err := c.miniAppService.DeleteOld(ctx, time.Now().AddDate(
 		0, 0, -daysBeforeDeletingArchivedMiniApp))
 	if err != nil {
-		c.logger.Error("clearOldMiniApps: cron job failed: failed to clear old mini apps", zap.Error(err))
-		return
+		return fmt.Errorf("failed to clear old mini apps: %w", err)
 	}
 
 	for _, miniAppID := range miniAppIDs {
@@ -145,20 +251,26 @@ func (c *Cron) clearOldMiniApps() {
 	}
 
 	c.logger.Info("clearOldMiniApps: cron job successfully finished")
+
+	return nil
 }
 
-func (c *Cron) updateTonPayments() {
-	if ok := c.updateTonPaymentsMutex.TryLock(); !ok {
-		return
+// clearJobs deletes completed jobs, dead ones are kept till they are retried.
+func (c *Cron) clearJobs(ctx context.Context) error {
+	err := c.jobService.DeleteCompleted(ctx, time.Now().AddDate(0, 0, -daysBeforeDeletingCompletedJobs))
+	if err != nil {
+		return fmt.Errorf("failed to delete completed jobs: %w", err)
 	}
-	defer c.updateTonPaymentsMutex.Unlock()
 
-	ctx := context.Background()
+	c.logger.Info("clearJobs: cron job successfully finished")
+
+	return nil
+}
 
+func (c *Cron) updateTonPayments(ctx context.Context) error {
 	addresses, err := c.miniAppService.FindTonAddresses(ctx)
 	if err != nil {
-		c.logger.Error("updateTonPayments: cron job failed: failed to find TON addresses", zap.Error(err))
-		return
+		return fmt.Errorf("failed to find TON addresses: %w", err)
 	}
 
 	for _, addr := range addresses {
@@ -179,98 +291,701 @@ func (c *Cron) updateTonPayments() {
 			)
 		}
 	}
+
+	return nil
 }
 
-func (c *Cron) videoProcessing() {
-	if ok := c.videoProcessingMutex.TryLock(); !ok {
-		// c.logger.Info("videoProcessing: cron job skipped")
-		return
+func (c *Cron) sendEventReminders(ctx context.Context) error {
+	reminders, err := c.lessonService.PendingEventReminders(ctx, time.Now(), 100)
+	if err != nil {
+		return fmt.Errorf("failed to find reminders: %w", err)
+	}
+
+	botTokens := make(map[uuid.UUID]string)
+	sent := make([]*model.EventReminder, 0, len(reminders))
+	for _, r := range reminders {
+		// Reminders are not retried, so students who blocked the bot are not
+		// messaged every minute.
+		sent = append(sent, r.ToEventReminder())
+
+		botToken, ok := botTokens[r.MiniAppID]
+		if !ok {
+			botToken, err = c.securityService.DecryptString(r.BotToken)
+			if err != nil {
+				c.logger.Error("sendEventReminders: failed to decrypt bot token",
+					zap.String("mini_app_id", r.MiniAppID.String()),
+					zap.Error(err),
+				)
+			}
+			botTokens[r.MiniAppID] = botToken
+		}
+		if botToken == "" {
+			continue
+		}
+
+		if err := c.rateLimiter.Wait(ctx, r.MiniAppID.String(), r.TelegramID); err != nil {
+			return fmt.Errorf("failed to wait for rate limit: %w", err)
+		}
+
+		err := c.telegramService.SendMessage(ctx, botToken, r.TelegramID, r.Message(), &telegram.InlineButton{
+			Text:         "Check in",
+			CallbackData: bot.CheckInCallbackData(r.LessonID),
+		})
+		if err != nil {
+			c.logger.Error("sendEventReminders: failed to send reminder",
+				zap.String("lesson_id", r.LessonID.String()),
+				zap.String("user_id", r.UserID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := c.lessonService.CreateEventReminders(ctx, sent); err != nil {
+		return fmt.Errorf("failed to save reminders: %w", err)
 	}
-	defer c.videoProcessingMutex.Unlock()
 
-	c.muxUploadVideos()
+	if len(sent) != 0 {
+		c.logger.Info("sendEventReminders: sent event reminders", zap.Int("count", len(sent)))
+	}
+
+	return nil
 }
 
-func (c *Cron) muxUploadVideos() {
-	ctx := context.Background()
+// sendNotifications queues scheduled notifications and sends pending ones with
+// mini-app bots. Bots that hit Telegram flood limits are skipped until the
+// next run, their notifications stay pending.
+func (c *Cron) sendNotifications(ctx context.Context) error {
+	if err := c.notificationService.EnqueueScheduled(ctx, time.Now()); err != nil {
+		c.logger.Error("sendNotifications: failed to enqueue scheduled notifications", zap.Error(err))
+	}
 
-	// false withMetadata means asset is not uploaded yet.
-	withMetadata := false
-	materials, err := c.materialService.FindPendingMoveToMux(ctx, withMetadata, 100)
+	notifications, err := c.notificationService.Pending(ctx, 500)
 	if err != nil {
-		c.logger.Error("muxUploadVideos: cron job failed: failed to find materials",
-			zap.Error(err),
+		return fmt.Errorf("failed to find notifications: %w", err)
+	}
+
+	botTokens := make(map[uuid.UUID]string)
+	limitedBots := make(map[uuid.UUID]struct{})
+	var sent int
+	for _, n := range notifications {
+		if _, ok := limitedBots[n.MiniAppID]; ok {
+			continue
+		}
+
+		botToken, ok := botTokens[n.MiniAppID]
+		if !ok && n.BotToken != "" {
+			botToken, err = c.securityService.DecryptString(n.BotToken)
+			if err != nil {
+				c.logger.Error("sendNotifications: failed to decrypt bot token",
+					zap.String("mini_app_id", n.MiniAppID.String()),
+					zap.Error(err),
+				)
+			}
+			botTokens[n.MiniAppID] = botToken
+		}
+
+		status := n.ToNotification(model.NotificationStatusSkipped, nil)
+		if !n.IsMuted() && botToken != "" {
+			if err := c.rateLimiter.Wait(ctx, n.MiniAppID.String(), n.TelegramID); err != nil {
+				return fmt.Errorf("failed to wait for rate limit: %w", err)
+			}
+
+			err := c.telegramService.SendMessage(ctx, botToken, n.TelegramID, n.Message())
+
+			var retryErr *telegram.RetryAfterError
+			if errors.As(err, &retryErr) {
+				c.logger.Warn("sendNotifications: bot is rate limited",
+					zap.String("mini_app_id", n.MiniAppID.String()),
+					zap.Duration("retry_after", retryErr.RetryAfter),
+				)
+				limitedBots[n.MiniAppID] = struct{}{}
+				continue
+			}
+			if err != nil {
+				c.logger.Error("sendNotifications: failed to send notification",
+					zap.String("notification_id", n.ID.String()),
+					zap.Error(err),
+				)
+				status = n.ToNotification(model.NotificationStatusFailed, err)
+			} else {
+				status = n.ToNotification(model.NotificationStatusSent, nil)
+				sent++
+			}
+		}
+
+		if err := c.notificationService.UpdateStatus(ctx, status); err != nil {
+			return fmt.Errorf("failed to update notification: %w", err)
+		}
+	}
+
+	if sent != 0 {
+		c.logger.Info("sendNotifications: sent notifications", zap.Int("count", sent))
+	}
+
+	return nil
+}
+
+func (c *Cron) clearNotifications(ctx context.Context) error {
+	err := c.notificationService.DeleteOld(ctx, time.Now().AddDate(0, 0, -daysBeforeDeletingNotifications))
+	if err != nil {
+		return fmt.Errorf("failed to delete old notifications: %w", err)
+	}
+
+	err = c.staffNotificationService.DeleteOld(ctx, time.Now().AddDate(0, 0, -daysBeforeDeletingNotifications))
+	if err != nil {
+		return fmt.Errorf("failed to delete old staff notifications: %w", err)
+	}
+
+	c.logger.Info("clearNotifications: cron job successfully finished")
+
+	return nil
+}
+
+// checkPlanLimits queues warnings to the staff of mini-apps that are close to
+// reach limits of their plans.
+func (c *Cron) checkPlanLimits(ctx context.Context) error {
+	if err := c.staffNotificationService.EnqueuePlanLimits(ctx, time.Now()); err != nil {
+		return fmt.Errorf("failed to enqueue plan limits: %w", err)
+	}
+
+	return nil
+}
+
+// sendStaffNotifications sends pending notifications to owners and moderators
+// with the admin bot. Notifications stay pending until the next run if the bot
+// hits Telegram flood limits.
+func (c *Cron) sendStaffNotifications(ctx context.Context) error {
+	notifications, err := c.staffNotificationService.Pending(ctx, 500)
+	if err != nil {
+		return fmt.Errorf("failed to find notifications: %w", err)
+	}
+
+	var sent int
+	for _, n := range notifications {
+		status := n.ToStaffNotification(model.NotificationStatusSkipped, nil)
+		if !n.IsMuted() {
+			if err := c.rateLimiter.Wait(ctx, adminBotLimiterKey, n.TelegramID); err != nil {
+				return fmt.Errorf("failed to wait for rate limit: %w", err)
+			}
+
+			err := c.telegramService.SendAdminMessage(ctx, n.TelegramID, n.Message())
+
+			var retryErr *telegram.RetryAfterError
+			if errors.As(err, &retryErr) {
+				c.logger.Warn("sendStaffNotifications: bot is rate limited",
+					zap.Duration("retry_after", retryErr.RetryAfter),
+				)
+				break
+			}
+			if err != nil {
+				c.logger.Error("sendStaffNotifications: failed to send notification",
+					zap.String("notification_id", n.ID.String()),
+					zap.Error(err),
+				)
+				status = n.ToStaffNotification(model.NotificationStatusFailed, err)
+			} else {
+				status = n.ToStaffNotification(model.NotificationStatusSent, nil)
+				sent++
+			}
+		}
+
+		if err := c.staffNotificationService.UpdateStatus(ctx, status); err != nil {
+			return fmt.Errorf("failed to update notification: %w", err)
+		}
+	}
+
+	if sent != 0 {
+		c.logger.Info("sendStaffNotifications: sent notifications", zap.Int("count", sent))
+	}
+
+	return nil
+}
+
+// sendBroadcasts starts scheduled broadcasts and sends a batch of each
+// broadcast in progress. Broadcasts of mini-apps without running bots are
+// completed with failed recipients.
+func (c *Cron) sendBroadcasts(ctx context.Context) error {
+	if err := c.broadcastService.StartDue(ctx, time.Now()); err != nil {
+		c.logger.Error("sendBroadcasts: failed to start due broadcasts", zap.Error(err))
+	}
+
+	broadcasts, err := c.broadcastService.Sending(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find broadcasts: %w", err)
+	}
+	if len(broadcasts) == 0 {
+		return nil
+	}
+
+	bots, err := c.botService.ActiveBots(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find active bots: %w", err)
+	}
+
+	activeBots := make(map[uuid.UUID]*model.ActiveBot, len(bots))
+	for _, b := range bots {
+		activeBots[b.MiniAppID] = b
+	}
+
+	for _, b := range broadcasts {
+		if err := c.sendBroadcast(ctx, b, activeBots[b.MiniAppID]); err != nil {
+			c.logger.Error("sendBroadcasts: failed to send broadcast",
+				zap.String("broadcast_id", b.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (c *Cron) sendBroadcast(ctx context.Context, b *model.Broadcast, activeBot *model.ActiveBot) error {
+	var botToken string
+	if activeBot != nil {
+		token, err := c.securityService.DecryptString(activeBot.BotToken)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt bot token: %w", err)
+		}
+		botToken = token
+	}
+
+	if botToken == "" {
+		if err := c.broadcastService.FailPendingRecipients(ctx, b.ID, "bot is not available"); err != nil {
+			return err
+		}
+
+		return c.broadcastService.Complete(ctx, b)
+	}
+
+	recipients, err := c.broadcastService.PendingRecipients(ctx, b.ID, broadcastBatchSize)
+	if err != nil {
+		return err
+	}
+
+	buttons := make([]*telegram.InlineButton, 0, len(b.Buttons))
+	for _, button := range b.Buttons {
+		link := button.URL
+		if link == "" {
+			link = activeBot.URL
+		}
+		if link == "" {
+			continue
+		}
+
+		buttons = append(buttons, &telegram.InlineButton{Text: button.Text, URL: link})
+	}
+
+	var photo *telegram.Photo
+	if b.Image != "" {
+		data, err := c.uploadService.ReadFile(ctx, b.Image)
+		if err != nil {
+			return fmt.Errorf("failed to read broadcast image: %w", err)
+		}
+		photo = &telegram.Photo{Name: b.Image, Data: data}
+	}
+
+	var sent int
+	for _, r := range recipients {
+		if err := c.rateLimiter.Wait(ctx, b.MiniAppID.String(), r.TelegramID); err != nil {
+			return fmt.Errorf("failed to wait for rate limit: %w", err)
+		}
+
+		if photo != nil {
+			// Image is uploaded once and then sent by file ID.
+			var fileID string
+			fileID, err = c.telegramService.SendPhoto(ctx, botToken, r.TelegramID, photo, b.Text, buttons...)
+			if fileID != "" {
+				photo.FileID = fileID
+			}
+		} else {
+			err = c.telegramService.SendMessage(ctx, botToken, r.TelegramID, b.Text, buttons...)
+		}
+
+		var retryErr *telegram.RetryAfterError
+		if errors.As(err, &retryErr) {
+			c.logger.Warn("sendBroadcasts: bot is rate limited",
+				zap.String("broadcast_id", b.ID.String()),
+				zap.Duration("retry_after", retryErr.RetryAfter),
+			)
+			return nil
+		}
+
+		status := model.BroadcastRecipientStatusSent
+		switch {
+		case errors.Is(err, telegram.ErrBotBlocked):
+			status = model.BroadcastRecipientStatusBlocked
+		case err != nil:
+			status = model.BroadcastRecipientStatusFailed
+		default:
+			sent++
+		}
+
+		if err := c.broadcastService.UpdateRecipient(ctx, r.ToRecipient(b.ID, status, err)); err != nil {
+			return err
+		}
+	}
+
+	if sent != 0 {
+		c.logger.Info("sendBroadcasts: sent broadcast messages",
+			zap.String("broadcast_id", b.ID.String()),
+			zap.Int("count", sent),
 		)
+	}
+
+	if len(recipients) < broadcastBatchSize {
+		return c.broadcastService.Complete(ctx, b)
+	}
+
+	return nil
+}
+
+// syncBots starts bots of new mini-apps and stops bots of deactivated ones.
+func (c *Cron) syncBots() {
+	if ok := c.syncBotsMutex.TryLock(); !ok {
 		return
 	}
+	defer c.syncBotsMutex.Unlock()
+
+	if err := c.botManager.Sync(context.Background()); err != nil {
+		c.logger.Error("syncBots: cron job failed: failed to sync bots", zap.Error(err))
+	}
+}
 
+// videoProcessing enqueues the job for each pending video, so a video that
+// fails does not block the others.
+func (c *Cron) videoProcessing(ctx context.Context) error {
+	// false withMetadata means asset is not uploaded yet.
+	withMetadata := false
+	muxUploads, err := c.materialService.FindPendingMoveToMux(ctx, withMetadata, 100)
+	if err != nil {
+		return fmt.Errorf("failed to find materials: %w", err)
+	}
+
+	transcodings, err := c.materialService.FindPendingTranscoding(ctx, 100)
+	if err != nil {
+		return fmt.Errorf("failed to find materials: %w", err)
+	}
+
+	previews, err := c.materialService.FindVideosWithoutPreview(ctx, 100)
+	if err != nil {
+		return fmt.Errorf("failed to find materials: %w", err)
+	}
+
+	return errors.Join(
+		c.enqueueMaterialJobs(ctx, model.JobKindMuxUploadVideo, muxUploads),
+		c.enqueueMaterialJobs(ctx, model.JobKindTranscodeVideo, transcodings),
+		c.enqueueMaterialJobs(ctx, model.JobKindVideoPreview, previews),
+	)
+}
+
+// enqueueMaterialJobs enqueues the job of the kind for each material. Active
+// jobs are kept, materials of dead jobs are skipped till the jobs are retried.
+func (c *Cron) enqueueMaterialJobs(ctx context.Context, kind model.JobKind, materials []*model.Material) error {
+	keys := make([]string, 0, len(materials))
 	for _, m := range materials {
-		c.logger.Info("muxUploadVideos: start uploading...",
-			zap.String("material_id", m.ID.String()),
-			zap.String("filename", m.Filename),
-		)
+		keys = append(keys, model.MaterialJobKey(kind, m.ID))
+	}
 
-		if m.Category != model.MaterialCategoryLessonContent || len(m.Metadata) != 0 {
+	dead, err := c.jobService.DeadKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range materials {
+		if slices.Contains(dead, model.MaterialJobKey(kind, m.ID)) {
 			continue
 		}
 
-		metadata, err := c.uploadService.MuxUpload(ctx, m.Filename)
+		job, err := model.NewMaterialJob(kind, m.ID)
 		if err != nil {
-			c.logger.Error("muxUploadVideos: cron job failed: uploading to mux",
-				zap.String("material_id", m.ID.String()),
+			return fmt.Errorf("failed to create %v job of material %v: %w", kind, m.ID, err)
+		}
+
+		if _, err := c.jobService.Enqueue(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takeVideoPreview takes the preview of the video that is played from its
+// file. Video that fails is saved with the empty preview, so it is not taken
+// again.
+func (c *Cron) takeVideoPreview(ctx context.Context, m *model.Material) error {
+	if m.Category != model.MaterialCategoryLessonContent ||
+		m.Status != model.MaterialStatusReady ||
+		m.Filename == "" ||
+		len(m.Metadata) != 0 {
+
+		return nil
+	}
+
+	preview, previewSize := c.videoPreview(ctx, m)
+
+	rawMetadata, err := json.Marshal(preview)
+	if err != nil {
+		c.deleteVideoPreview(preview)
+		return fmt.Errorf("failed to marshal metadata of material %v: %w", m.ID, err)
+	}
+
+	m.Metadata = rawMetadata
+	m.Size += previewSize
+	m.UpdatedAt = time.Now()
+
+	if err := c.materialService.Update(ctx, m); err != nil {
+		c.deleteVideoPreview(preview)
+		return fmt.Errorf("failed to update material %v: %w", m.ID, err)
+	}
+
+	return nil
+}
+
+// videoPreview takes the poster and the storyboard of the video of the
+// material and uses the poster as the cover of its lesson if there is none.
+// Preview is optional, so it is empty if it fails. Size of the preview files
+// is returned, it is counted in the size of the material.
+func (c *Cron) videoPreview(ctx context.Context, m *model.Material) (model.VideoPreview, int64) {
+	preview, size, err := c.uploadService.VideoPreview(ctx, m.Filename)
+	if err != nil {
+		c.logger.Error("videoPreview: failed to take video preview",
+			zap.String("material_id", m.ID.String()),
+			zap.Error(err),
+		)
+		return model.VideoPreview{}, 0
+	}
+
+	if err := c.createLessonCover(ctx, m, preview); err != nil {
+		c.logger.Error("videoPreview: failed to create lesson cover",
+			zap.String("lesson_id", m.LessonID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return *preview, size
+}
+
+// deleteVideoPreview deletes files of the preview that was not saved, so they
+// are not left behind when the job is retried.
+func (c *Cron) deleteVideoPreview(preview model.VideoPreview) {
+	files := []string{preview.Poster}
+	if preview.Storyboard != nil {
+		files = append(files, preview.Storyboard.Filename)
+	}
+
+	for _, file := range files {
+		if err := c.uploadService.Delete(file); err != nil {
+			c.logger.Error("videoPreview: failed to delete file",
+				zap.String("file", file),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func (c *Cron) createLessonCover(ctx context.Context, m *model.Material, preview *model.VideoPreview) error {
+	if m.LessonID == uuid.Nil {
+		return nil
+	}
+
+	cover, err := c.materialService.GetLessonCover(ctx, m.LessonID)
+	if err != nil {
+		return err
+	}
+	if cover != nil {
+		return nil
+	}
+
+	cover = model.NewMaterial()
+	cover.MiniAppID = m.MiniAppID
+	cover.LessonID = m.LessonID
+	cover.Category = model.MaterialCategoryLessonCover
+	cover.ContentType = model.MaterialTypePicture
+	cover.Title = m.Title
+	cover.OriginalFilename = "poster.jpg"
+	cover.Filename = path.Join(path.Dir(preview.Poster), uuid.NewString()+".jpg")
+
+	// Poster is copied, so it is kept if the cover is replaced.
+	cover.Size, err = c.uploadService.Copy(ctx, preview.Poster, cover.Filename)
+	if err != nil {
+		return err
+	}
+
+	if err := c.materialService.Create(ctx, cover); err != nil {
+		if err := c.uploadService.Delete(cover.Filename); err != nil {
+			c.logger.Error("videoPreview: failed to delete file",
+				zap.String("file", cover.Filename),
 				zap.Error(err),
 			)
-			return
 		}
-		c.logger.Info("muxUploadVideos: finished uploading to mux",
+		return err
+	}
+
+	return nil
+}
+
+// transcodeVideo transcodes the pending video into HLS. Video that fails to
+// be transcoded is kept as it is.
+func (c *Cron) transcodeVideo(ctx context.Context, m *model.Material) error {
+	if m.Category != model.MaterialCategoryLessonContent ||
+		m.Status != model.MaterialStatusPendingTranscoding ||
+		len(m.Metadata) != 0 {
+
+		return nil
+	}
+
+	c.logger.Info("transcodeVideo: start transcoding...",
+		zap.String("material_id", m.ID.String()),
+		zap.String("filename", m.Filename),
+	)
+
+	filename := m.Filename
+	preview, previewSize := c.videoPreview(ctx, m)
+
+	metadata, size, err := c.uploadService.TranscodeHLS(ctx, filename)
+	if err != nil {
+		c.logger.Error("transcodeVideo: failed to transcode video",
 			zap.String("material_id", m.ID.String()),
+			zap.Error(err),
 		)
 
-		rawMetadata, err := json.Marshal(metadata)
+		// Video is played from its file with the preview.
+		rawPreview, err := json.Marshal(preview)
 		if err != nil {
-			c.logger.Error("muxUploadVideos: cron job failed: failed to marshal metadata",
-				zap.String("material_id", m.ID.String()),
-				zap.Error(err),
-			)
-			return
+			c.deleteVideoPreview(preview)
+			return fmt.Errorf("failed to marshal metadata of material %v: %w", m.ID, err)
 		}
 
-		// TODO: Decide if file size should also be updated.
-		m.Filename = ""
-		m.Metadata = rawMetadata
+		m.Metadata = rawPreview
+		m.Size += previewSize
+		m.Status = model.MaterialStatusReady
 		m.UpdatedAt = time.Now()
 
 		if err := c.materialService.Update(ctx, m); err != nil {
-			c.logger.Error("muxUploadVideos: cron job failed: failed to update material",
-				zap.String("material_id", m.ID.String()),
+			c.deleteVideoPreview(preview)
+			return fmt.Errorf("failed to update material %v: %w", m.ID, err)
+		}
+		return nil
+	}
+
+	metadata.VideoPreview = preview
+
+	rawMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		c.deleteVideoPreview(preview)
+		return fmt.Errorf("failed to marshal metadata of material %v: %w", m.ID, err)
+	}
+
+	m.Filename = ""
+	m.Metadata = rawMetadata
+	m.Size = size + previewSize
+	m.Status = model.MaterialStatusReady
+	m.UpdatedAt = time.Now()
+
+	if err := c.materialService.Update(ctx, m); err != nil {
+		c.deleteVideoPreview(preview)
+		if err := c.uploadService.DeleteVideoAsset(ctx, metadata.AssetID); err != nil {
+			c.logger.Error("transcodeVideo: failed to delete asset",
+				zap.String("asset_id", metadata.AssetID),
 				zap.Error(err),
 			)
-			return
 		}
-		c.logger.Info("muxUploadVideos: finished updating the material",
+		return fmt.Errorf("failed to update material %v: %w", m.ID, err)
+	}
+
+	if err := c.uploadService.Delete(filename); err != nil {
+		c.logger.Error("transcodeVideo: failed to delete file",
+			zap.String("file", filename),
+			zap.Error(err),
+		)
+	}
+
+	if err := c.subtitleService.Sync(ctx, m); err != nil {
+		c.logger.Error("transcodeVideo: failed to sync subtitles",
 			zap.String("material_id", m.ID.String()),
+			zap.Error(err),
 		)
 	}
 
-	// c.logger.Info("muxUploadVideos: cron job successfully finished")
+	c.logger.Info("transcodeVideo: finished transcoding the material",
+		zap.String("material_id", m.ID.String()),
+		zap.String("asset_id", metadata.AssetID),
+	)
+
+	return nil
 }
 
-func (c *Cron) muxUpdateReadyStatus() {
-	if ok := c.muxUpdateReadyStatusMutex.TryLock(); !ok {
-		return
+// muxUploadVideo uploads the pending video to Mux.
+func (c *Cron) muxUploadVideo(ctx context.Context, m *model.Material) error {
+	if m.Category != model.MaterialCategoryLessonContent ||
+		m.Status != model.MaterialStatusPendingMoveToMux ||
+		len(m.Metadata) != 0 {
+
+		return nil
+	}
+
+	c.logger.Info("muxUploadVideo: start uploading...",
+		zap.String("material_id", m.ID.String()),
+		zap.String("filename", m.Filename),
+	)
+
+	filename := m.Filename
+
+	metadata, err := c.uploadService.MuxUpload(ctx, filename)
+	if err != nil {
+		return fmt.Errorf("failed to upload material %v to mux: %w", m.ID, err)
 	}
-	defer c.muxUpdateReadyStatusMutex.Unlock()
+	c.logger.Info("muxUploadVideo: finished uploading to mux",
+		zap.String("material_id", m.ID.String()),
+	)
 
-	ctx := context.Background()
+	// Preview is taken once the upload succeeds and before the file is
+	// deleted, so failed uploads that are retried do not leave previews.
+	preview, previewSize := c.videoPreview(ctx, m)
+	metadata.VideoPreview = preview
 
+	rawMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		c.deleteVideoPreview(preview)
+		return fmt.Errorf("failed to marshal metadata of material %v: %w", m.ID, err)
+	}
+
+	// TODO: Decide if file size should also be updated.
+	m.Filename = ""
+	m.Metadata = rawMetadata
+	m.Size += previewSize
+	m.UpdatedAt = time.Now()
+
+	if err := c.materialService.Update(ctx, m); err != nil {
+		c.deleteVideoPreview(preview)
+		if err := c.uploadService.DeleteVideoAsset(ctx, metadata.AssetID); err != nil {
+			c.logger.Error("muxUploadVideo: failed to delete asset",
+				zap.String("asset_id", metadata.AssetID),
+				zap.Error(err),
+			)
+		}
+		return fmt.Errorf("failed to update material %v: %w", m.ID, err)
+	}
+	c.logger.Info("muxUploadVideo: finished updating the material",
+		zap.String("material_id", m.ID.String()),
+	)
+
+	if err := c.uploadService.Delete(filename); err != nil {
+		c.logger.Error("muxUploadVideo: failed to delete file",
+			zap.String("file", filename),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+func (c *Cron) muxUpdateReadyStatus(ctx context.Context) error {
 	// true withMetadata means asset is already uploaded but could be not ready for playing yet.
 	withMetadata := true
 	materials, err := c.materialService.FindPendingMoveToMux(ctx, withMetadata, 100)
 	if err != nil {
-		c.logger.Error("muxUpdateReadyStatus: cron job failed: failed to find materials",
-			zap.Error(err),
-		)
-		return
+		return fmt.Errorf("failed to find materials: %w", err)
 	}
 
 	for _, m := range materials {
@@ -284,7 +999,7 @@ func (c *Cron) muxUpdateReadyStatus() {
 
 		var metadata model.MuxVideoMetadata
 		if err := json.Unmarshal(m.Metadata, &metadata); err != nil {
-			c.logger.Error("muxUpdateReadyStatus: cron job failed: decoding metadata",
+			c.logger.Error("muxUpdateReadyStatus: failed to decode metadata",
 				zap.String("material_id", m.ID.String()),
 				zap.Error(err),
 			)
@@ -293,7 +1008,7 @@ func (c *Cron) muxUpdateReadyStatus() {
 
 		assetResp, err := c.uploadService.MuxGetAsset(ctx, metadata.AssetID)
 		if err != nil {
-			c.logger.Error("muxUpdateReadyStatus: cron job failed: uploading to mux",
+			c.logger.Error("muxUpdateReadyStatus: failed to get mux asset",
 				zap.String("material_id", m.ID.String()),
 				zap.String("asset_id", metadata.AssetID),
 				zap.Error(err),
@@ -301,7 +1016,7 @@ func (c *Cron) muxUpdateReadyStatus() {
 			continue
 		}
 		if assetResp.Data.Status != "ready" {
-			c.logger.Error("muxUpdateReadyStatus: cron job failed: asset not ready",
+			c.logger.Error("muxUpdateReadyStatus: asset not ready",
 				zap.String("material_id", m.ID.String()),
 				zap.String("asset_id", metadata.AssetID),
 				zap.String("status", assetResp.Data.Status),
@@ -313,34 +1028,28 @@ func (c *Cron) muxUpdateReadyStatus() {
 		m.UpdatedAt = time.Now()
 
 		if err := c.materialService.Update(ctx, m); err != nil {
-			c.logger.Error("muxUpdateReadyStatus: cron job failed: failed to update material",
-				zap.String("material_id", m.ID.String()),
-				zap.Error(err),
-			)
-			return
+			return fmt.Errorf("failed to update material %v: %w", m.ID, err)
 		}
 		c.logger.Info("muxUpdateReadyStatus: finished updating the material",
 			zap.String("material_id", m.ID.String()),
 			zap.String("asset_id", metadata.AssetID),
 		)
-	}
-}
 
-func (c *Cron) muxClearAssets() {
-	if ok := c.muxClearAssetsMutex.TryLock(); !ok {
-		// c.logger.Info("muxClearAssets: cron job skipped")
-		return
+		if err := c.subtitleService.Sync(ctx, m); err != nil {
+			c.logger.Error("muxUpdateReadyStatus: failed to sync subtitles",
+				zap.String("material_id", m.ID.String()),
+				zap.Error(err),
+			)
+		}
 	}
-	defer c.muxClearAssetsMutex.Unlock()
 
-	ctx := context.Background()
+	return nil
+}
 
+func (c *Cron) muxClearAssets(ctx context.Context) error {
 	assets, err := c.materialService.FindMuxAssetsToDelete(ctx, 100)
 	if err != nil {
-		c.logger.Error("muxClearAssets: cron job failed: failed to find materials",
-			zap.Error(err),
-		)
-		return
+		return fmt.Errorf("failed to find materials: %w", err)
 	}
 
 	for _, assetID := range assets {
@@ -348,14 +1057,10 @@ func (c *Cron) muxClearAssets() {
 			zap.String("asset_id", assetID),
 		)
 
-		err := c.uploadService.MuxDeleteAsset(ctx, assetID)
+		err := c.uploadService.DeleteVideoAsset(ctx, assetID)
 
 		if err != nil && !errors.Is(err, upload.ErrNotFound) {
-			c.logger.Error("muxClearAssets: cron job failed: failed to delete asset",
-				zap.String("asset_id", assetID),
-				zap.Error(err),
-			)
-			return
+			return fmt.Errorf("failed to delete asset %v: %w", assetID, err)
 		}
 
 		if errors.Is(err, upload.ErrNotFound) {
@@ -365,11 +1070,7 @@ func (c *Cron) muxClearAssets() {
 		}
 
 		if err := c.materialService.FullyDeleleMuxAsset(ctx, assetID); err != nil {
-			c.logger.Error("muxClearAssets: cron job failed: failed to delete asset from DB",
-				zap.String("asset_id", assetID),
-				zap.Error(err),
-			)
-			return
+			return fmt.Errorf("failed to delete asset %v from DB: %w", assetID, err)
 		}
 
 		c.logger.Info("muxClearAssets: finished deleting the asset",
@@ -378,16 +1079,178 @@ func (c *Cron) muxClearAssets() {
 	}
 
 	// c.logger.Info("muxClearAssets: cron job successfully finished")
+
+	return nil
+}
+
+// scanQuarantinedFiles scans files of materials and answers that were not
+// scanned on upload.
+func (c *Cron) scanQuarantinedFiles(ctx context.Context) error {
+	return errors.Join(
+		c.scanQuarantinedMaterials(ctx),
+		c.scanQuarantinedAnswers(ctx),
+	)
+}
+
+// scanQuarantinedMaterials scans files of quarantined materials. Infected
+// files are deleted, materials are left without them.
+func (c *Cron) scanQuarantinedMaterials(ctx context.Context) error {
+	materials, err := c.materialService.FindQuarantined(ctx, 100)
+	if err != nil {
+		return fmt.Errorf("failed to find materials: %w", err)
+	}
+
+	for _, m := range materials {
+		// Materials are released if scanning is disabled.
+		scan, err := c.uploadService.ScanFile(ctx, m.Filename)
+		if err != nil {
+			return fmt.Errorf("failed to scan material %v: %w", m.ID, err)
+		}
+
+		infectedFile := ""
+		if scan.IsInfected() {
+			infectedFile = m.Filename
+			m.Filename = ""
+			m.Size = 0
+		}
+
+		m.Scan = scan
+		m.Status = model.MaterialStatusReady
+		m.UpdatedAt = time.Now()
+
+		if err := c.materialService.Update(ctx, m); err != nil {
+			return fmt.Errorf("failed to update material %v: %w", m.ID, err)
+		}
+
+		if infectedFile != "" {
+			c.logger.Warn("scanQuarantinedFiles: infected file is deleted",
+				zap.String("material_id", m.ID.String()),
+				zap.String("signature", scan.Signature),
+			)
+
+			if err := c.uploadService.Delete(infectedFile); err != nil {
+				c.logger.Error("scanQuarantinedFiles: failed to delete infected file",
+					zap.String("material_id", m.ID.String()),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// scanQuarantinedAnswers scans files of quarantined answers. Clean answers are
+// released for the review, answers with infected files are failed and the
+// files are deleted.
+func (c *Cron) scanQuarantinedAnswers(ctx context.Context) error {
+	answers, err := c.progressService.FindQuarantined(ctx, 100)
+	if err != nil {
+		return fmt.Errorf("failed to find answers: %w", err)
+	}
+
+	for _, p := range answers {
+		var data model.LessonProgressData
+		if err := json.Unmarshal(p.Data, &data); err != nil {
+			return fmt.Errorf("failed to decode answer of user %v to lesson %v: %w", p.UserID, p.LessonID, err)
+		}
+
+		files := make([]*model.FileMetadata, 0, len(data.FilesMetadata))
+		infected := make([]*model.FileMetadata, 0)
+		for _, f := range data.FilesMetadata {
+			if f.Scan == nil {
+				// Answers are released if scanning is disabled.
+				f.Scan, err = c.uploadService.ScanFile(ctx, f.Filename)
+				if err != nil {
+					return fmt.Errorf("failed to scan answer of user %v to lesson %v: %w", p.UserID, p.LessonID, err)
+				}
+			}
+
+			if f.Scan.IsInfected() {
+				infected = append(infected, f)
+				continue
+			}
+			files = append(files, f)
+		}
+
+		p.Status = model.LessonProgressStatusPending
+		if len(infected) != 0 {
+			names := make([]string, 0, len(infected))
+			for _, f := range infected {
+				names = append(names, f.OriginalFilename)
+				p.Size -= f.Size
+			}
+
+			data.FilesMetadata = files
+			data.Feedback = fmt.Sprintf("Infected files are removed: %s", strings.Join(names, ", "))
+			p.Status = model.LessonProgressStatusFailed
+			p.Score = 0
+		}
+
+		rawData, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode answer of user %v to lesson %v: %w", p.UserID, p.LessonID, err)
+		}
+
+		updatedAt := p.UpdatedAt
+		p.Data = rawData
+		p.UpdatedAt = time.Now().UTC()
+
+		ok, err := c.progressService.ReleaseQuarantined(ctx, p, updatedAt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		for _, f := range infected {
+			c.logger.Warn("scanQuarantinedFiles: infected answer file is deleted",
+				zap.String("user_id", p.UserID.String()),
+				zap.String("lesson_id", p.LessonID.String()),
+				zap.String("signature", f.Scan.Signature),
+			)
+
+			if err := c.uploadService.Delete(f.Filename); err != nil {
+				c.logger.Error("scanQuarantinedFiles: failed to delete infected file",
+					zap.String("file", f.Filename),
+					zap.Error(err),
+				)
+			}
+		}
+
+		if p.Status == model.LessonProgressStatusPending {
+			if err := c.staffNotificationService.NotifySubmission(ctx, p); err != nil {
+				c.logger.Error("scanQuarantinedFiles: failed to notify about submission", zap.Error(err))
+			}
+		}
+	}
+
+	return nil
 }
 
 func (c *Cron) start(_ context.Context) error {
-	c.logger.Info("cron job started")
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	for range jobWorkers {
+		c.workers.Add(1)
+		go func() {
+			defer c.workers.Done()
+			c.work(ctx)
+		}()
+	}
+
+	c.logger.Info("cron job started", zap.String("worker_id", c.workerID))
 	c.cron.Start()
 	return nil
 }
 
 func (c *Cron) stop(_ context.Context) error {
 	c.logger.Info("cron job stopped")
-	c.cron.Stop()
+	<-c.cron.Stop().Done()
+
+	c.cancel()
+	c.workers.Wait()
 	return nil
 }
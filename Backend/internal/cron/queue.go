@@ -0,0 +1,190 @@
+package cron
+
+import (
+	"academy/internal/model"
+	"academy/internal/service"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// jobWorkers is a number of jobs that are run at the same time by the
+	// replica.
+	jobWorkers      = 4
+	jobPollInterval = time.Second
+	// jobReleaseTimeout limits saving of results of jobs that were interrupted
+	// by the shutdown.
+	jobReleaseTimeout = 10 * time.Second
+
+	daysBeforeDeletingCompletedJobs = 7
+)
+
+type jobHandler func(ctx context.Context, job *model.Job) error
+
+// periodic adapts the job that does not use the payload.
+func periodic(fn func(ctx context.Context) error) jobHandler {
+	return func(ctx context.Context, _ *model.Job) error {
+		return fn(ctx)
+	}
+}
+
+// materialJob adapts the job of the material from the payload. Job of the
+// deleted material is completed.
+func (c *Cron) materialJob(fn func(ctx context.Context, m *model.Material) error) jobHandler {
+	return func(ctx context.Context, job *model.Job) error {
+		var payload model.MaterialJobPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode payload: %w", err)
+		}
+
+		m, err := c.materialService.GetByID(ctx, payload.MaterialID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, m)
+	}
+}
+
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
+// schedule returns the func that enqueues the periodic job. Schedules of all
+// replicas enqueue the same key, so the job is skipped while the previous one
+// is pending, running or retried.
+func (c *Cron) schedule(kind model.JobKind) func() {
+	return func() {
+		job := model.NewJob(kind, string(kind), nil, time.Now())
+
+		if _, err := c.jobService.Enqueue(context.Background(), job); err != nil {
+			c.logger.Error("failed to schedule job",
+				zap.String("kind", string(kind)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// work runs due jobs till the context is done.
+func (c *Cron) work(ctx context.Context) {
+	for {
+		job, err := c.jobService.Lease(ctx, c.workerID)
+		if err != nil && ctx.Err() == nil {
+			c.logger.Error("failed to lease job", zap.Error(err))
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(jobPollInterval):
+			}
+			continue
+		}
+
+		c.run(ctx, job)
+	}
+}
+
+func (c *Cron) run(ctx context.Context, job *model.Job) {
+	logger := c.logger.With(
+		zap.String("job_id", job.ID.String()),
+		zap.String("kind", string(job.Kind)),
+		zap.Int("attempt", job.Attempts),
+	)
+
+	var err error
+
+	handler, ok := c.handlers[job.Kind]
+	switch {
+	case !ok:
+		err = fmt.Errorf("unknown job kind: %v", job.Kind)
+	case job.MaxAttempts < job.Attempts:
+		// Lease of the last attempt expired, worker probably crashed.
+		err = errors.New("job lease expired")
+	default:
+		err = c.runLeased(ctx, job, handler)
+	}
+
+	releaseCtx, cancel := context.WithTimeout(context.Background(), jobReleaseTimeout)
+	defer cancel()
+
+	if err == nil {
+		if err := c.jobService.Complete(releaseCtx, job, c.workerID); err != nil {
+			logger.Error("failed to complete job", zap.Error(err))
+		}
+		return
+	}
+
+	if err := c.jobService.Fail(releaseCtx, job, c.workerID, err); err != nil {
+		logger.Error("failed to fail job", zap.Error(err))
+	}
+
+	if job.Status == model.JobStatusDead {
+		logger.Error("job is dead", zap.Error(err))
+		return
+	}
+
+	logger.Warn("job failed, it is retried later",
+		zap.Time("run_at", job.RunAt),
+		zap.Error(err),
+	)
+}
+
+// runLeased runs the job while its lease is extended. Job is cancelled if
+// the lease is lost.
+func (c *Cron) runLeased(ctx context.Context, job *model.Job, handler jobHandler) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(service.JobLease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			ok, err := c.jobService.ExtendLease(ctx, job, c.workerID)
+			if err != nil {
+				c.logger.Error("failed to extend job lease",
+					zap.String("job_id", job.ID.String()),
+					zap.Error(err),
+				)
+				continue
+			}
+			if !ok {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
@@ -8,8 +8,15 @@ import (
 
 const (
 	ErrDuplicateKeyViolation = "23505"
+	ErrRaiseException        = "P0001"
 )
 
+// errStorageLimitMessage is raised by triggers that account mini-app storage.
+const errStorageLimitMessage = "Storage size exceeds the limit"
+
+// errBlobDeletedMessage is raised by the trigger that locks blobs of materials.
+const errBlobDeletedMessage = "Blob of the file is deleted"
+
 func DuplicateKeyViolation(err error) bool {
 	if err == nil {
 		return false
@@ -23,6 +30,36 @@ func DuplicateKeyViolation(err error) bool {
 	return false
 }
 
+// StorageLimitExceeded reports whether the change is rejected because the
+// mini-app storage size exceeds the plan limit.
+func StorageLimitExceeded(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == ErrRaiseException && pgErr.Message == errStorageLimitMessage {
+		return true
+	}
+
+	return false
+}
+
+// BlobDeleted reports whether the material is rejected because the blob of its
+// file is deleted.
+func BlobDeleted(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == ErrRaiseException && pgErr.Message == errBlobDeletedMessage {
+		return true
+	}
+
+	return false
+}
+
 func IsErrNoRows(err error) bool {
 	if err == nil {
 		return false
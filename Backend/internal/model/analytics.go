@@ -107,6 +107,7 @@ type StudentDetails struct {
 
 	CompletedLessons int64 `bun:"completed_lessons"`
 	TotalLessons     int64 `bun:"total_lessons"`
+	Points           int64 `bun:"points"`
 
 	Lessons []bool `bun:"-"`
 }
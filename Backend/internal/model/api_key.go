@@ -0,0 +1,132 @@
+package model
+
+import (
+	"academy/internal/types"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// APIKeyTokenPrefix marks tokens of API keys, so leaked ones are easy to
+	// find by secret scanners.
+	APIKeyTokenPrefix = "ak_"
+	// apiKeyDisplayLength is a length of the token start that is stored to
+	// tell keys apart.
+	apiKeyDisplayLength = 10
+
+	// DefaultAPIKeyRateLimit is a number of requests per minute the key is
+	// allowed if the limit is not set.
+	DefaultAPIKeyRateLimit = 60
+	MaxAPIKeyRateLimit     = 1200
+)
+
+// APIKey authenticates server-to-server requests on behalf of the owner. Key
+// is allowed only the permissions of its scopes.
+type APIKey struct {
+	bun.BaseModel `bun:"table:api_keys,alias:api_key"`
+
+	ID        uuid.UUID        `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID        `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	UserID    uuid.UUID        `bun:"user_id,type:uuid,notnull" json:"user_id"`
+	Name      string           `bun:"name,type:varchar(100),notnull" json:"name"`
+	Prefix    string           `bun:"prefix,type:varchar(16),notnull" json:"prefix"`
+	KeyHash   string           `bun:"key_hash,type:varchar(64),notnull" json:"-"`
+	Scopes    []PermissionName `bun:"scopes,type:varchar(60)[],array,notnull" json:"scopes"`
+	// RateLimit is a number of requests per minute.
+	RateLimit  int        `bun:"rate_limit,type:int,notnull" json:"rate_limit"`
+	ExpiresAt  types.Time `bun:"expires_at,type:timestamptz,nullzero" json:"expires_at"`
+	LastUsedAt types.Time `bun:"last_used_at,type:timestamptz,nullzero" json:"last_used_at"`
+	RevokedAt  types.Time `bun:"revoked_at,type:timestamptz,nullzero" json:"revoked_at"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// NewAPIKey returns the key with the token that is shown to the owner once.
+func NewAPIKey(miniAppID, userID uuid.UUID) (*APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	token := APIKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	return &APIKey{
+		ID:        uuid.New(),
+		MiniAppID: miniAppID,
+		UserID:    userID,
+		Prefix:    token[:apiKeyDisplayLength],
+		KeyHash:   HashAPIKey(token),
+		RateLimit: DefaultAPIKeyRateLimit,
+		UpdatedAt: now,
+		CreatedAt: now,
+	}, token, nil
+}
+
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsActive reports whether the key is neither revoked nor expired.
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt.Valid {
+		return false
+	}
+
+	return !k.ExpiresAt.Valid || now.Before(k.ExpiresAt.Time)
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string           `json:"name"`
+	Scopes    []PermissionName `json:"scopes"`
+	RateLimit int              `json:"rate_limit"`
+	ExpiresAt types.Time       `json:"expires_at"`
+}
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("empty api key name")
+	}
+	if len(r.Scopes) == 0 {
+		return errors.New("no scopes provided for api key")
+	}
+	for _, scope := range r.Scopes {
+		if err := scope.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.RateLimit < 0 || MaxAPIKeyRateLimit < r.RateLimit {
+		return errors.New("invalid rate limit")
+	}
+	if r.ExpiresAt.Valid && !time.Now().Before(r.ExpiresAt.Time) {
+		return errors.New("expiration date is in the past")
+	}
+
+	return nil
+}
+
+func (r *CreateAPIKeyRequest) UpdateAPIKey(k *APIKey) {
+	k.Name = r.Name
+	k.ExpiresAt = r.ExpiresAt
+
+	k.Scopes = make([]PermissionName, 0, len(r.Scopes))
+	for _, scope := range r.Scopes {
+		if !slices.Contains(k.Scopes, scope) {
+			k.Scopes = append(k.Scopes, scope)
+		}
+	}
+
+	if r.RateLimit != 0 {
+		k.RateLimit = r.RateLimit
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"academy/internal/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, token, err := NewAPIKey(uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("NewAPIKey() error = %v", err)
+	}
+
+	if !strings.HasPrefix(token, APIKeyTokenPrefix) || !strings.HasPrefix(token, key.Prefix) {
+		t.Errorf("NewAPIKey() token = %q, prefix = %q", token, key.Prefix)
+	}
+	if key.KeyHash != HashAPIKey(token) || strings.Contains(key.KeyHash, token) {
+		t.Errorf("NewAPIKey() hash = %q", key.KeyHash)
+	}
+
+	_, other, _ := NewAPIKey(uuid.New(), uuid.New())
+	if other == token {
+		t.Errorf("NewAPIKey() returned the same token twice")
+	}
+}
+
+func TestAPIKeyIsActive(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		key  APIKey
+		want bool
+	}{
+		{name: "no expiration", key: APIKey{}, want: true},
+		{name: "not expired", key: APIKey{ExpiresAt: types.NewTime(now.Add(time.Hour))}, want: true},
+		{name: "expired", key: APIKey{ExpiresAt: types.NewTime(now.Add(-time.Hour))}, want: false},
+		{name: "revoked", key: APIKey{RevokedAt: types.NewTime(now.Add(-time.Hour))}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.IsActive(now); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	scopes := []PermissionName{PermissionStudentManagement, PermissionSupportTickets}
+
+	if !HasPermission(scopes, PermissionStudentBans) {
+		t.Errorf("HasPermission() of the part of the granted permission = false")
+	}
+	if !HasPermission(scopes, PermissionBranding, PermissionSupportTickets) {
+		t.Errorf("HasPermission() of any of the permissions = false")
+	}
+	if HasPermission(scopes, PermissionStudentInteraction) {
+		t.Errorf("HasPermission() of the parent of the granted permission = true")
+	}
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Audit entity types are kinds of entities the actions are performed on.
+const (
+	AuditEntityMiniApp      = "mini_app"
+	AuditEntityUser         = "user"
+	AuditEntityModInvite    = "mod_invite"
+	AuditEntityModRole      = "mod_role"
+	AuditEntityProduct      = "product"
+	AuditEntityProductLevel = "product_level"
+	AuditEntityLesson       = "lesson"
+	AuditEntityMaterial     = "material"
+	AuditEntityCohort       = "cohort"
+	AuditEntityBadge        = "badge"
+	AuditEntityBroadcast    = "broadcast"
+	AuditEntitySupport      = "support_ticket"
+	AuditEntityPointRules   = "point_rules"
+	AuditEntityAPIKey       = "api_key"
+)
+
+// AuditLog is a record of the action of the owner or moderator. Changes are
+// fields of the target entity that differ before and after the action, params
+// are the request data. API key is set when the owner acted through it.
+type AuditLog struct {
+	bun.BaseModel `bun:"table:audit_log,alias:audit_log"`
+
+	ID         uuid.UUID               `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID  uuid.UUID               `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	ActorID    uuid.UUID               `bun:"actor_id,type:uuid,notnull" json:"actor_id"`
+	ActorRole  UserRole                `bun:"actor_role,type:user_role,notnull" json:"actor_role"`
+	Action     string                  `bun:"action,type:varchar(50),notnull" json:"action"`
+	EntityType string                  `bun:"entity_type,type:varchar(50),notnull" json:"entity_type"`
+	EntityID   uuid.UUID               `bun:"entity_id,type:uuid,nullzero" json:"entity_id"`
+	Changes    map[string]*AuditChange `bun:"changes,type:jsonb,notnull" json:"changes"`
+	Params     map[string]any          `bun:"params,type:jsonb,notnull" json:"params"`
+	IP         string                  `bun:"ip,type:varchar(45),notnull" json:"ip"`
+	APIKeyID   uuid.UUID               `bun:"api_key_id,type:uuid,nullzero" json:"api_key_id,omitempty"`
+	CreatedAt  time.Time               `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	Actor *User `bun:"rel:belongs-to,join:actor_id=id" json:"actor,omitempty"`
+}
+
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// auditIgnoredFields change on every update and are not included in changes.
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// auditSecretMarkers are parts of names of fields that are not stored in the
+// audit log.
+var auditSecretMarkers = []string{"token", "secret", "password", "api_key", "private_key", "key_hash"}
+
+const auditRedacted = "[redacted]"
+
+func isAuditSecret(field string) bool {
+	field = strings.ToLower(field)
+	for _, marker := range auditSecretMarkers {
+		if strings.Contains(field, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DiffAuditSnapshots returns fields of the entity that differ. Nil snapshot
+// means that the entity does not exist, e.g. before creation.
+func DiffAuditSnapshots(before, after map[string]any) map[string]*AuditChange {
+	changes := make(map[string]*AuditChange)
+
+	add := func(field string) {
+		if auditIgnoredFields[field] || changes[field] != nil {
+			return
+		}
+
+		b, a := before[field], after[field]
+		if reflect.DeepEqual(b, a) {
+			return
+		}
+
+		if isAuditSecret(field) {
+			b, a = auditRedacted, auditRedacted
+		}
+		b, a = redactAuditValue(b), redactAuditValue(a)
+
+		changes[field] = &AuditChange{Before: b, After: a}
+	}
+
+	for field := range before {
+		add(field)
+	}
+	for field := range after {
+		add(field)
+	}
+
+	return changes
+}
+
+// RedactAuditParams replaces values of secret fields in the request data.
+func RedactAuditParams(params map[string]any) map[string]any {
+	for k, v := range params {
+		if isAuditSecret(k) {
+			params[k] = auditRedacted
+			continue
+		}
+
+		params[k] = redactAuditValue(v)
+	}
+
+	return params
+}
+
+// redactAuditValue redacts secret fields of nested objects.
+func redactAuditValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return RedactAuditParams(v)
+	case []any:
+		for i := range v {
+			v[i] = redactAuditValue(v[i])
+		}
+	}
+
+	return v
+}
+
+type FilterAuditLogRequest struct {
+	ActorID    uuid.UUID `json:"actor_id"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uuid.UUID `json:"entity_id"`
+	DateFrom   string    `json:"date_from"`
+	DateTo     string    `json:"date_to"`
+
+	Limit  uint `json:"limit"`
+	Offset uint `json:"offset"`
+
+	From time.Time `json:"-"`
+	To   time.Time `json:"-"`
+}
+
+// Validate parses dates, the date to is included.
+func (r *FilterAuditLogRequest) Validate() error {
+	var err error
+
+	if r.DateFrom != "" {
+		r.From, err = time.Parse(time.DateOnly, r.DateFrom)
+		if err != nil {
+			return fmt.Errorf("invalid date_from: %w", err)
+		}
+	}
+
+	if r.DateTo != "" {
+		r.To, err = time.Parse(time.DateOnly, r.DateTo)
+		if err != nil {
+			return fmt.Errorf("invalid date_to: %w", err)
+		}
+		r.To = r.To.AddDate(0, 0, 1)
+	}
+
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return fmt.Errorf("date_from is after date_to")
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffAuditSnapshots(t *testing.T) {
+	before := map[string]any{
+		"title":      "Course",
+		"price":      float64(10),
+		"bot_token":  "old",
+		"payment":    map[string]any{"secret_key": "old", "login": "a"},
+		"updated_at": "2024-01-01",
+	}
+	after := map[string]any{
+		"title":      "Course",
+		"price":      float64(20),
+		"bot_token":  "new",
+		"payment":    map[string]any{"secret_key": "new", "login": "b"},
+		"deleted_at": "2024-01-02",
+		"updated_at": "2024-01-02",
+	}
+
+	want := map[string]*AuditChange{
+		"price":     {Before: float64(10), After: float64(20)},
+		"bot_token": {Before: auditRedacted, After: auditRedacted},
+		"payment": {
+			Before: map[string]any{"secret_key": auditRedacted, "login": "a"},
+			After:  map[string]any{"secret_key": auditRedacted, "login": "b"},
+		},
+		"deleted_at": {Before: nil, After: "2024-01-02"},
+	}
+
+	if got := DiffAuditSnapshots(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffAuditSnapshots() = %v, want %v", got, want)
+	}
+
+	created := DiffAuditSnapshots(nil, map[string]any{"title": "Course"})
+	if c := created["title"]; c == nil || c.Before != nil || c.After != "Course" {
+		t.Errorf("DiffAuditSnapshots() of created entity = %v", created)
+	}
+}
+
+func TestRedactAuditParams(t *testing.T) {
+	params := map[string]any{
+		"name":      "app",
+		"bot_token": "123:abc",
+		"wayforpay": map[string]any{"secret_key": "key", "merchant": "m"},
+		"rules":     []any{map[string]any{"access_token": "t"}},
+	}
+
+	want := map[string]any{
+		"name":      "app",
+		"bot_token": auditRedacted,
+		"wayforpay": map[string]any{"secret_key": auditRedacted, "merchant": "m"},
+		"rules":     []any{map[string]any{"access_token": auditRedacted}},
+	}
+
+	if got := RedactAuditParams(params); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactAuditParams() = %v, want %v", got, want)
+	}
+}
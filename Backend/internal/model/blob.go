@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Blob is the file of materials of the mini-app that is stored once for the
+// same content. Hash is SHA-256 of the content, the file is deleted with the
+// last material that references it by filename.
+type Blob struct {
+	bun.BaseModel `bun:"table:blobs,alias:blob"`
+
+	ID        uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	Hash      string    `bun:"hash,type:char(64),notnull" json:"hash"`
+	Filename  string    `bun:"filename,type:varchar(255),notnull" json:"filename"`
+	Size      int64     `bun:"size,type:bigint,notnull,default:0" json:"size"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewBlob(miniAppID uuid.UUID, hash, filename string, size int64) *Blob {
+	return &Blob{
+		ID:        uuid.New(),
+		MiniAppID: miniAppID,
+		Hash:      hash,
+		Filename:  filename,
+		Size:      size,
+		CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// BotButtonsLimit is a maximum number of buttons under the bot message.
+const BotButtonsLimit = 5
+
+// BotSettings configures the mini-app bot. Default welcome message is sent
+// when settings are not saved yet.
+type BotSettings struct {
+	bun.BaseModel `bun:"table:bot_settings"`
+
+	MiniAppID        uuid.UUID    `bun:"mini_app_id,pk,type:uuid,notnull" json:"-"`
+	WelcomeMessage   string       `bun:"welcome_message,type:text,notnull" json:"welcome_message"`
+	WelcomeImage     string       `bun:"welcome_image,type:varchar(255),notnull" json:"welcome_image"`
+	WelcomeImageSize int64        `bun:"welcome_image_size,type:int,notnull" json:"-"`
+	Buttons          []*BotButton `bun:"buttons,type:jsonb,notnull,default:'[]'" json:"buttons"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewBotSettings(miniAppID uuid.UUID) *BotSettings {
+	now := time.Now().UTC()
+	return &BotSettings{
+		MiniAppID: miniAppID,
+		Buttons:   make([]*BotButton, 0),
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+}
+
+// BotButton is an inline button with a link. Empty URL opens the mini-app.
+type BotButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// ActiveBot is a mini-app which bot should be running. BotToken is encrypted.
+type ActiveBot struct {
+	MiniAppID uuid.UUID `bun:"id"`
+	Name      string    `bun:"name"`
+	URL       string    `bun:"url"`
+	BotToken  string    `bun:"bot_token"`
+}
+
+type EditBotSettingsRequest struct {
+	WelcomeMessage string       `json:"welcome_message"`
+	Buttons        []*BotButton `json:"buttons"`
+
+	DeleteImage bool `json:"delete_image"`
+}
+
+func (r *EditBotSettingsRequest) Validate() error {
+	return validateBotButtons(r.Buttons)
+}
+
+func validateBotButtons(buttons []*BotButton) error {
+	if BotButtonsLimit < len(buttons) {
+		return errors.New("too many buttons")
+	}
+
+	for _, b := range buttons {
+		if b == nil || b.Text == "" {
+			return errors.New("empty button text")
+		}
+		if b.URL == "" {
+			continue
+		}
+
+		u, err := url.Parse(b.URL)
+		if err != nil {
+			return fmt.Errorf("invalid button url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "tg":
+		default:
+			return fmt.Errorf("invalid button url scheme: %v", u.Scheme)
+		}
+	}
+
+	return nil
+}
+
+func (r *EditBotSettingsRequest) UpdateBotSettings(s *BotSettings) {
+	s.WelcomeMessage = r.WelcomeMessage
+	s.Buttons = r.Buttons
+	if s.Buttons == nil {
+		s.Buttons = make([]*BotButton, 0)
+	}
+	s.UpdatedAt = time.Now().UTC()
+}
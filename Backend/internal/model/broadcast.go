@@ -0,0 +1,235 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BroadcastStatus string
+
+const (
+	BroadcastStatusScheduled BroadcastStatus = "scheduled"
+	BroadcastStatusSending   BroadcastStatus = "sending"
+	BroadcastStatusCompleted BroadcastStatus = "completed"
+	BroadcastStatusCancelled BroadcastStatus = "cancelled"
+)
+
+var broadcastStatuses = []BroadcastStatus{
+	BroadcastStatusScheduled,
+	BroadcastStatusSending,
+	BroadcastStatusCompleted,
+	BroadcastStatusCancelled,
+}
+
+type BroadcastRecipientStatus string
+
+const (
+	BroadcastRecipientStatusPending BroadcastRecipientStatus = "pending"
+	BroadcastRecipientStatusSent    BroadcastRecipientStatus = "sent"
+	BroadcastRecipientStatusFailed  BroadcastRecipientStatus = "failed"
+	// BroadcastRecipientStatusBlocked is set when the student blocked the bot.
+	BroadcastRecipientStatusBlocked BroadcastRecipientStatus = "blocked"
+)
+
+var broadcastRecipientStatuses = []BroadcastRecipientStatus{
+	BroadcastRecipientStatusPending,
+	BroadcastRecipientStatusSent,
+	BroadcastRecipientStatusFailed,
+	BroadcastRecipientStatusBlocked,
+}
+
+// Broadcast is a message of the owner to a segment of students that is sent
+// with the mini-app bot.
+type Broadcast struct {
+	bun.BaseModel `bun:"table:broadcasts"`
+
+	ID        uuid.UUID         `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID         `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	AuthorID  uuid.UUID         `bun:"author_id,type:uuid,nullzero" json:"author_id"`
+	Segment   *BroadcastSegment `bun:"segment,type:jsonb,notnull" json:"segment"`
+	Text      string            `bun:"text,type:text,notnull" json:"text"`
+	Image     string            `bun:"image,type:varchar(255),notnull" json:"image"`
+	ImageSize int64             `bun:"image_size,type:int,notnull" json:"-"`
+	Buttons   []*BotButton      `bun:"buttons,type:jsonb,notnull,default:'[]'" json:"buttons"`
+	Status    BroadcastStatus   `bun:"status,type:broadcast_status,notnull" json:"status"`
+
+	ScheduledAt time.Time  `bun:"scheduled_at,type:timestamptz,notnull" json:"scheduled_at"`
+	StartedAt   *time.Time `bun:"started_at,type:timestamptz,nullzero" json:"started_at,omitempty"`
+	CompletedAt *time.Time `bun:"completed_at,type:timestamptz,nullzero" json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt   time.Time  `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	Stats *BroadcastStats `bun:"-" json:"stats,omitempty"`
+}
+
+// BroadcastSegment selects students of the mini-app. Empty segment selects
+// all students, other filters require the product. Progress is a percent of
+// product lessons, pending homework counts as a half.
+type BroadcastSegment struct {
+	ProductID       uuid.UUID   `json:"product_id"`
+	ProductLevelIDs []uuid.UUID `json:"product_level_ids"`
+	CohortIDs       []uuid.UUID `json:"cohort_ids"`
+	// MinProgress is inclusive, MaxProgress is exclusive.
+	MinProgress *int `json:"min_progress"`
+	MaxProgress *int `json:"max_progress"`
+}
+
+func (s *BroadcastSegment) Validate() error {
+	if s.ProductID == uuid.Nil &&
+		(len(s.ProductLevelIDs) != 0 || len(s.CohortIDs) != 0 || s.MinProgress != nil || s.MaxProgress != nil) {
+		return errors.New("product is required for the segment")
+	}
+
+	for _, p := range []*int{s.MinProgress, s.MaxProgress} {
+		if p != nil && (*p < 0 || 100 < *p) {
+			return fmt.Errorf("invalid progress: %v", *p)
+		}
+	}
+	if s.MinProgress != nil && s.MaxProgress != nil && *s.MaxProgress <= *s.MinProgress {
+		return errors.New("max progress must be greater than min progress")
+	}
+
+	return nil
+}
+
+// BroadcastStats is a number of broadcast recipients by delivery status.
+type BroadcastStats struct {
+	Total   int `bun:"total" json:"total"`
+	Pending int `bun:"pending" json:"pending"`
+	Sent    int `bun:"sent" json:"sent"`
+	Failed  int `bun:"failed" json:"failed"`
+	Blocked int `bun:"blocked" json:"blocked"`
+}
+
+type BroadcastRecipient struct {
+	bun.BaseModel `bun:"table:broadcast_recipients"`
+
+	BroadcastID uuid.UUID                `bun:"broadcast_id,pk,type:uuid,notnull" json:"-"`
+	UserID      uuid.UUID                `bun:"user_id,pk,type:uuid,notnull" json:"user_id"`
+	Status      BroadcastRecipientStatus `bun:"status,type:broadcast_recipient_status,notnull" json:"status"`
+	Error       string                   `bun:"error,type:text,notnull" json:"error"`
+	SentAt      *time.Time               `bun:"sent_at,type:timestamptz,nullzero" json:"sent_at,omitempty"`
+	CreatedAt   time.Time                `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+}
+
+// PendingBroadcastRecipient is a recipient that the broadcast is not sent to
+// yet.
+type PendingBroadcastRecipient struct {
+	UserID     uuid.UUID `bun:"user_id"`
+	TelegramID int64     `bun:"telegram_id"`
+}
+
+// ToRecipient returns recipient with the delivery status.
+func (r *PendingBroadcastRecipient) ToRecipient(
+	broadcastID uuid.UUID,
+	status BroadcastRecipientStatus,
+	err error,
+) *BroadcastRecipient {
+
+	recipient := &BroadcastRecipient{
+		BroadcastID: broadcastID,
+		UserID:      r.UserID,
+		Status:      status,
+	}
+
+	if err != nil {
+		recipient.Error = err.Error()
+	}
+	if status == BroadcastRecipientStatusSent {
+		now := time.Now().UTC()
+		recipient.SentAt = &now
+	}
+
+	return recipient
+}
+
+type BroadcastRequest struct {
+	Segment *BroadcastSegment `json:"segment"`
+	Text    string            `json:"text"`
+	Buttons []*BotButton      `json:"buttons"`
+	// ScheduledAt is a time to start sending, broadcast is sent immediately
+	// if not provided.
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+func (r *BroadcastRequest) Validate() error {
+	if r.Segment == nil {
+		r.Segment = &BroadcastSegment{}
+	}
+	if err := r.Segment.Validate(); err != nil {
+		return err
+	}
+
+	if r.Text == "" {
+		return errors.New("empty text")
+	}
+
+	return validateBotButtons(r.Buttons)
+}
+
+func (r *BroadcastRequest) ToBroadcast(miniAppID, authorID uuid.UUID) *Broadcast {
+	now := time.Now().UTC()
+
+	b := &Broadcast{
+		ID:          uuid.New(),
+		MiniAppID:   miniAppID,
+		AuthorID:    authorID,
+		Segment:     r.Segment,
+		Text:        r.Text,
+		Buttons:     r.Buttons,
+		Status:      BroadcastStatusScheduled,
+		ScheduledAt: now,
+		UpdatedAt:   now,
+		CreatedAt:   now,
+	}
+
+	if b.Buttons == nil {
+		b.Buttons = make([]*BotButton, 0)
+	}
+	if r.ScheduledAt != nil && r.ScheduledAt.After(now) {
+		b.ScheduledAt = r.ScheduledAt.UTC()
+	}
+
+	return b
+}
+
+type FilterBroadcastsRequest struct {
+	Status []BroadcastStatus `json:"status"`
+
+	Limit  uint `json:"limit"`
+	Offset uint `json:"offset"`
+}
+
+func (r *FilterBroadcastsRequest) Validate() error {
+	for _, s := range r.Status {
+		if !slices.Contains(broadcastStatuses, s) {
+			return fmt.Errorf("invalid status: %v", s)
+		}
+	}
+
+	return nil
+}
+
+type FilterBroadcastRecipientsRequest struct {
+	Status []BroadcastRecipientStatus `json:"status"`
+
+	Limit  uint `json:"limit"`
+	Offset uint `json:"offset"`
+}
+
+func (r *FilterBroadcastRecipientsRequest) Validate() error {
+	for _, s := range r.Status {
+		if !slices.Contains(broadcastRecipientStatuses, s) {
+			return fmt.Errorf("invalid status: %v", s)
+		}
+	}
+
+	return nil
+}
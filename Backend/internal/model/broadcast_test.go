@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBroadcastSegmentValidate(t *testing.T) {
+	progress := func(p int) *int { return &p }
+
+	tests := []struct {
+		name    string
+		segment BroadcastSegment
+		wantErr bool
+	}{
+		{
+			name: "All students",
+		},
+		{
+			name: "Product progress",
+			segment: BroadcastSegment{
+				ProductID:   uuid.New(),
+				MaxProgress: progress(30),
+			},
+		},
+		{
+			name: "Levels without product",
+			segment: BroadcastSegment{
+				ProductLevelIDs: []uuid.UUID{uuid.New()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Progress out of range",
+			segment: BroadcastSegment{
+				ProductID:   uuid.New(),
+				MinProgress: progress(101),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty progress range",
+			segment: BroadcastSegment{
+				ProductID:   uuid.New(),
+				MinProgress: progress(50),
+				MaxProgress: progress(50),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.segment.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
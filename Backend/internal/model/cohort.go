@@ -0,0 +1,199 @@
+package model
+
+import (
+	"academy/internal/types"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Cohort groups students of the product that study together. Start date is
+// used as access start of cohort payments and schedule offset shifts release
+// dates of scheduled lessons.
+type Cohort struct {
+	bun.BaseModel `bun:"table:cohorts"`
+
+	ID             uuid.UUID      `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ProductID      uuid.UUID      `bun:"product_id,type:uuid,notnull" json:"product_id"`
+	Name           string         `bun:"name,type:varchar(100),notnull" json:"name"`
+	StartDate      types.Time     `bun:"start_date,type:timestamptz,nullzero" json:"start_date"`
+	ScheduleOffset types.Interval `bun:"schedule_offset,type:interval,nullzero" json:"schedule_offset"`
+	// SeatLimit is a maximum number of students, 0 means unlimited.
+	SeatLimit int64     `bun:"seat_limit,type:int,notnull" json:"seat_limit"`
+	InviteID  uuid.UUID `bun:"invite_id,type:uuid,notnull" json:"invite_id,omitempty"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	TotalStudents int64 `bun:"total_students,scanonly" json:"total_students"`
+
+	Moderators []*CohortModerator `bun:"rel:has-many,join:id=cohort_id" json:"moderators,omitempty"`
+}
+
+func NewCohort(productID uuid.UUID) *Cohort {
+	now := time.Now().UTC()
+	return &Cohort{
+		ID:        uuid.New(),
+		ProductID: productID,
+		InviteID:  uuid.New(),
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+}
+
+func (c *Cohort) HasSeats() bool {
+	return c.HasSeatsFor(1)
+}
+
+// HasSeatsFor reports whether n more students fit into the cohort.
+func (c *Cohort) HasSeatsFor(n int64) bool {
+	return c.SeatLimit == 0 || c.TotalStudents+n <= c.SeatLimit
+}
+
+// ReleaseDate returns lesson release date shifted by the cohort schedule
+// offset.
+func (c *Cohort) ReleaseDate(releaseDate types.Time) types.Time {
+	if !releaseDate.Valid || !c.ScheduleOffset.Valid {
+		return releaseDate
+	}
+
+	return types.NewTime(releaseDate.Time.AddDate(
+		0,
+		int(c.ScheduleOffset.Months),
+		int(c.ScheduleOffset.Days),
+	).Add(
+		time.Duration(c.ScheduleOffset.Microseconds * 1000),
+	))
+}
+
+// ShiftSchedule shifts release date and event time of the lesson by the cohort
+// schedule offset.
+func (c *Cohort) ShiftSchedule(l *Lesson) {
+	l.ReleaseDate = c.ReleaseDate(l.ReleaseDate)
+	l.EventStart = c.ReleaseDate(l.EventStart)
+	l.EventEnd = c.ReleaseDate(l.EventEnd)
+}
+
+// ScopeCohorts limits requested cohorts to the cohorts of the moderator. Empty
+// scope means that the moderator is not limited to cohorts. If no cohorts are
+// requested then the whole scope is used. False is returned if any requested
+// cohort is out of the scope.
+func ScopeCohorts(scope, requested []uuid.UUID) ([]uuid.UUID, bool) {
+	if len(scope) == 0 {
+		return requested, true
+	}
+
+	if len(requested) == 0 {
+		return scope, true
+	}
+
+	for _, id := range requested {
+		if !slices.Contains(scope, id) {
+			return nil, false
+		}
+	}
+
+	return requested, true
+}
+
+type CohortStudent struct {
+	bun.BaseModel `bun:"table:cohort_students"`
+
+	ProductID uuid.UUID `bun:"product_id,pk,type:uuid,notnull" json:"product_id"`
+	UserID    uuid.UUID `bun:"user_id,pk,type:uuid,notnull" json:"user_id"`
+	CohortID  uuid.UUID `bun:"cohort_id,type:uuid,notnull" json:"cohort_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewCohortStudent(cohort *Cohort, userID uuid.UUID) *CohortStudent {
+	return &CohortStudent{
+		ProductID: cohort.ProductID,
+		UserID:    userID,
+		CohortID:  cohort.ID,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+type CohortModerator struct {
+	bun.BaseModel `bun:"table:cohort_moderators"`
+
+	CohortID  uuid.UUID `bun:"cohort_id,pk,type:uuid,notnull" json:"cohort_id"`
+	UserID    uuid.UUID `bun:"user_id,pk,type:uuid,notnull" json:"user_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+}
+
+type CohortRequest struct {
+	Name           string         `json:"name"`
+	StartDate      types.Time     `json:"start_date"`
+	ScheduleOffset types.Interval `json:"schedule_offset"`
+	SeatLimit      int64          `json:"seat_limit"`
+	ModeratorIDs   []uuid.UUID    `json:"moderator_ids"`
+}
+
+func (r *CohortRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("empty cohort name")
+	}
+	if r.SeatLimit < 0 {
+		return errors.New("invalid seat limit")
+	}
+	for _, id := range r.ModeratorIDs {
+		if id == uuid.Nil {
+			return errors.New("invalid moderator id")
+		}
+	}
+
+	return nil
+}
+
+func (r *CohortRequest) ToCohort(productID uuid.UUID) *Cohort {
+	c := NewCohort(productID)
+
+	c.Name = r.Name
+	c.StartDate = r.StartDate
+	c.ScheduleOffset = r.ScheduleOffset
+	c.SeatLimit = r.SeatLimit
+	c.Moderators = r.toModerators(c.ID)
+
+	return c
+}
+
+func (r *CohortRequest) UpdateCohort(c *Cohort) {
+	c.Name = r.Name
+	c.StartDate = r.StartDate
+	c.ScheduleOffset = r.ScheduleOffset
+	c.SeatLimit = r.SeatLimit
+	c.Moderators = r.toModerators(c.ID)
+	c.UpdatedAt = time.Now().UTC()
+}
+
+func (r *CohortRequest) toModerators(cohortID uuid.UUID) []*CohortModerator {
+	now := time.Now().UTC()
+	moderators := make([]*CohortModerator, 0, len(r.ModeratorIDs))
+	for _, id := range r.ModeratorIDs {
+		if slices.ContainsFunc(moderators, func(m *CohortModerator) bool { return m.UserID == id }) {
+			continue
+		}
+
+		moderators = append(moderators, &CohortModerator{
+			CohortID:  cohortID,
+			UserID:    id,
+			CreatedAt: now,
+		})
+	}
+
+	return moderators
+}
+
+type AssignCohortStudentsRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+type JoinCohortRequest struct {
+	InviteID uuid.UUID `json:"invite_id"`
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"academy/internal/types"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCohortHasSeatsFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		seatLimit     int64
+		totalStudents int64
+		n             int64
+		want          bool
+	}{
+		{name: "Unlimited", totalStudents: 1000, n: 10, want: true},
+		{name: "Fits exactly", seatLimit: 10, totalStudents: 8, n: 2, want: true},
+		{name: "Exceeds limit", seatLimit: 10, totalStudents: 9, n: 2},
+		{name: "Full cohort gets no new students", seatLimit: 10, totalStudents: 10, n: 1},
+		// Students already in the cohort are not counted as new ones.
+		{name: "Full cohort reassigns own students", seatLimit: 10, totalStudents: 10, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cohort{SeatLimit: tt.seatLimit, TotalStudents: tt.totalStudents}
+
+			if got := c.HasSeatsFor(tt.n); got != tt.want {
+				t.Errorf("HasSeatsFor(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCohortShiftSchedule(t *testing.T) {
+	releaseDate := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	c := &Cohort{ScheduleOffset: types.NewInterval(types.JsonInterval{
+		Months: 1,
+		Days:   2,
+		Hours:  1,
+	})}
+
+	lesson := &Lesson{
+		ReleaseDate: types.NewTime(releaseDate),
+		EventStart:  types.NewTime(releaseDate),
+	}
+	c.ShiftSchedule(lesson)
+
+	want := time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)
+	if !lesson.ReleaseDate.Time.Equal(want) {
+		t.Errorf("release date = %v, want %v", lesson.ReleaseDate.Time, want)
+	}
+	if !lesson.EventStart.Time.Equal(want) {
+		t.Errorf("event start = %v, want %v", lesson.EventStart.Time, want)
+	}
+	if lesson.EventEnd.Valid {
+		t.Error("empty event end is shifted")
+	}
+
+	lesson = &Lesson{ReleaseDate: types.NewTime(releaseDate)}
+	(&Cohort{}).ShiftSchedule(lesson)
+	if !lesson.ReleaseDate.Time.Equal(releaseDate) {
+		t.Errorf("release date without offset = %v, want %v", lesson.ReleaseDate.Time, releaseDate)
+	}
+}
+
+func TestScopeCohorts(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		scope     []uuid.UUID
+		requested []uuid.UUID
+		want      []uuid.UUID
+		wantOK    bool
+	}{
+		{name: "Not scoped, all cohorts", wantOK: true},
+		{name: "Not scoped, requested cohort", requested: []uuid.UUID{c}, want: []uuid.UUID{c}, wantOK: true},
+		{name: "Scoped, all cohorts", scope: []uuid.UUID{a, b}, want: []uuid.UUID{a, b}, wantOK: true},
+		{name: "Scoped, requested cohort", scope: []uuid.UUID{a, b}, requested: []uuid.UUID{b}, want: []uuid.UUID{b}, wantOK: true},
+		{name: "Scoped, foreign cohort", scope: []uuid.UUID{a, b}, requested: []uuid.UUID{b, c}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ScopeCohorts(tt.scope, tt.requested)
+
+			if ok != tt.wantOK {
+				t.Fatalf("ScopeCohorts() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ScopeCohorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CalendarEvent is an event lesson as it is shown in calendars. Event time is
+// shifted by the schedule of the student's cohort.
+type CalendarEvent struct {
+	LessonID     uuid.UUID `bun:"lesson_id"`
+	ProductID    uuid.UUID `bun:"product_id"`
+	ProductTitle string    `bun:"product_title"`
+	Title        string    `bun:"title"`
+	Description  string    `bun:"description"`
+	EventStart   time.Time `bun:"event_start"`
+	EventEnd     time.Time `bun:"event_end"`
+	TimeZone     string    `bun:"time_zone"`
+	UpdatedAt    time.Time `bun:"updated_at"`
+}
+
+// Location returns time zone of the event, UTC is used for invalid ones.
+func (e *CalendarEvent) Location() *time.Location {
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// EventReminder marks that the student was reminded about the event.
+type EventReminder struct {
+	bun.BaseModel `bun:"table:event_reminders"`
+
+	LessonID  uuid.UUID `bun:"lesson_id,pk,type:uuid,notnull" json:"lesson_id"`
+	UserID    uuid.UUID `bun:"user_id,pk,type:uuid,notnull" json:"user_id"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// PendingEventReminder is an upcoming event the student has not been reminded
+// about yet. BotToken is an encrypted token of the mini-app bot.
+type PendingEventReminder struct {
+	CalendarEvent
+
+	UserID     uuid.UUID `bun:"user_id"`
+	TelegramID int64     `bun:"telegram_id"`
+	MiniAppID  uuid.UUID `bun:"mini_app_id"`
+	BotToken   string    `bun:"bot_token"`
+}
+
+func (r *PendingEventReminder) ToEventReminder() *EventReminder {
+	return &EventReminder{
+		LessonID:  r.LessonID,
+		UserID:    r.UserID,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Message returns reminder text with event time in the event time zone.
+func (r *PendingEventReminder) Message() string {
+	return fmt.Sprintf("Reminder: %q of %q starts at %s.",
+		r.Title,
+		r.ProductTitle,
+		r.EventStart.In(r.Location()).Format("Jan 2, 15:04 MST"),
+	)
+}
+
+type AttendanceSource string
+
+const (
+	AttendanceSourceJoinLink AttendanceSource = "join_link"
+	AttendanceSourceBot      AttendanceSource = "bot"
+)
+
+// EventAttendance is a check-in of the student to the event. It is kept when
+// the event lesson gets a recording.
+type EventAttendance struct {
+	bun.BaseModel `bun:"table:event_attendance"`
+
+	LessonID  uuid.UUID        `bun:"lesson_id,pk,type:uuid,notnull" json:"lesson_id"`
+	UserID    uuid.UUID        `bun:"user_id,pk,type:uuid,notnull" json:"user_id"`
+	Source    AttendanceSource `bun:"source,type:attendance_source,notnull" json:"source"`
+	CreatedAt time.Time        `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+}
+
+func NewEventAttendance(lessonID, userID uuid.UUID, source AttendanceSource) *EventAttendance {
+	return &EventAttendance{
+		LessonID:  lessonID,
+		UserID:    userID,
+		Source:    source,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+type AttachRecordingRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// ToMaterial returns lesson content for the recording that files are uploaded
+// to with chunks.
+func (r *AttachRecordingRequest) ToMaterial(lessonID uuid.UUID) *Material {
+	m := NewMaterial()
+
+	m.LessonID = lessonID
+	m.Category = MaterialCategoryLessonContent
+	m.ContentType = MaterialTypeVideo
+	m.Title = r.Title
+	m.Description = r.Description
+
+	return m
+}
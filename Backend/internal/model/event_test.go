@@ -0,0 +1,80 @@
+package model
+
+import (
+	"academy/internal/types"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewEventAttendance(t *testing.T) {
+	lessonID, userID := uuid.New(), uuid.New()
+
+	attendance := NewEventAttendance(lessonID, userID, AttendanceSourceJoinLink)
+
+	if attendance.LessonID != lessonID || attendance.UserID != userID {
+		t.Errorf("attendance is not bound to the lesson and user: %+v", attendance)
+	}
+	if attendance.Source != AttendanceSourceJoinLink {
+		t.Errorf("source = %s, want %s", attendance.Source, AttendanceSourceJoinLink)
+	}
+	if attendance.CreatedAt.IsZero() {
+		t.Error("check-in time is not set")
+	}
+}
+
+func TestAttachRecording(t *testing.T) {
+	start := time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)
+
+	lesson := NewLesson()
+	lesson.ContentType = LessonTypeEvent
+	lesson.Title = "Live Q&A"
+	lesson.EventStart = types.NewTime(start)
+	lesson.EventEnd = types.NewTime(start.Add(time.Hour))
+	lesson.TimeZone = "Europe/Kyiv"
+	lesson.MeetingURL = "https://meet.example.com/qa"
+
+	req := AttachRecordingRequest{Title: lesson.Title}
+	recording := req.ToMaterial(lesson.ID)
+
+	if recording.LessonID != lesson.ID ||
+		recording.Category != MaterialCategoryLessonContent ||
+		recording.ContentType != MaterialTypeVideo {
+
+		t.Fatalf("recording is not a video of the lesson: %+v", recording)
+	}
+
+	// Lesson stays an event until the recording is uploaded and processed.
+	if recording.IsReadyVideo() {
+		t.Error("empty recording is ready")
+	}
+
+	recording.Filename = "recording.mp4"
+	recording.Status = MaterialStatusPendingCompressing
+	if recording.IsReadyVideo() {
+		t.Error("recording pending compressing is ready")
+	}
+
+	recording.Status = MaterialStatusPendingMoveToMux
+	if recording.IsReadyVideo() {
+		t.Error("recording pending move to Mux is ready")
+	}
+
+	recording.Filename = ""
+	recording.Metadata = json.RawMessage(`{"asset_id":"asset"}`)
+	recording.Status = MaterialStatusReady
+	if !recording.IsReadyVideo() {
+		t.Error("recording moved to Mux is not ready")
+	}
+
+	lesson.ConvertToVideo()
+
+	if lesson.ContentType != LessonTypeVideo {
+		t.Errorf("content type = %s, want %s", lesson.ContentType, LessonTypeVideo)
+	}
+	if lesson.EventStart.Valid || lesson.EventEnd.Valid || lesson.TimeZone != "" || lesson.MeetingURL != "" {
+		t.Errorf("event fields are kept: %+v", lesson)
+	}
+}
@@ -0,0 +1,372 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type PointEvent string
+
+const (
+	PointEventLessonAccepted PointEvent = "lesson_accepted"
+	PointEventQuizScore      PointEvent = "quiz_score"
+	PointEventStreak         PointEvent = "streak"
+	PointEventReview         PointEvent = "review"
+	PointEventManual         PointEvent = "manual"
+)
+
+const (
+	pointRulesLimit   = 20
+	pointRulePointMax = 10_000
+	maxStreakDays     = 365
+)
+
+// PointRule describes how many points are awarded for an event. Threshold is
+// interpreted depending on the event:
+//   - quiz_score: minimal homework score (0 - MaxScore);
+//   - streak: number of consecutive days with accepted lessons;
+//   - lesson_accepted and review: ignored.
+type PointRule struct {
+	bun.BaseModel `bun:"table:point_rules"`
+
+	ID        uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID  `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	Event     PointEvent `bun:"event,type:point_event,notnull" json:"event"`
+	Threshold int64      `bun:"threshold,type:int,notnull" json:"threshold"`
+	Points    int64      `bun:"points,type:int,notnull" json:"points"`
+	IsActive  bool       `bun:"is_active,type:boolean,notnull" json:"is_active"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewPointRule(miniAppID uuid.UUID, event PointEvent, threshold, points int64) *PointRule {
+	now := time.Now().UTC()
+	return &PointRule{
+		ID:        uuid.New(),
+		MiniAppID: miniAppID,
+		Event:     event,
+		Threshold: threshold,
+		Points:    points,
+		IsActive:  true,
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+}
+
+// DefaultPointRules used for mini-apps that did not configure own rules.
+// Default rules are not stored, so they have no ID and transactions awarded
+// by them have no rule.
+func DefaultPointRules(miniAppID uuid.UUID) []*PointRule {
+	rules := []*PointRule{
+		NewPointRule(miniAppID, PointEventLessonAccepted, 0, 10),
+		NewPointRule(miniAppID, PointEventQuizScore, MaxScore, 10),
+		NewPointRule(miniAppID, PointEventQuizScore, MaxScore*8/10, 5),
+		NewPointRule(miniAppID, PointEventStreak, 3, 15),
+		NewPointRule(miniAppID, PointEventStreak, 7, 50),
+		NewPointRule(miniAppID, PointEventReview, 0, 2),
+	}
+
+	for _, rule := range rules {
+		rule.ID = uuid.Nil
+	}
+
+	return rules
+}
+
+// IdempotencyKey identifies the single award of the rule, so the same rule is
+// never applied twice to the same subject (lesson or streak).
+func (r *PointRule) IdempotencyKey(subject string) string {
+	return fmt.Sprintf("%s:%d:%s", r.Event, r.Threshold, subject)
+}
+
+type PointTransaction struct {
+	bun.BaseModel `bun:"table:point_transactions"`
+
+	ID             uuid.UUID  `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID      uuid.UUID  `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	UserID         uuid.UUID  `bun:"user_id,type:uuid,notnull" json:"user_id"`
+	ProductID      uuid.UUID  `bun:"product_id,type:uuid,nullzero" json:"product_id,omitempty"`
+	LessonID       uuid.UUID  `bun:"lesson_id,type:uuid,nullzero" json:"lesson_id,omitempty"`
+	RuleID         uuid.UUID  `bun:"rule_id,type:uuid,nullzero" json:"rule_id,omitempty"`
+	ActorID        uuid.UUID  `bun:"actor_id,type:uuid,nullzero" json:"actor_id,omitempty"`
+	Event          PointEvent `bun:"event,type:point_event,notnull" json:"event"`
+	Points         int64      `bun:"points,type:int,notnull" json:"points"`
+	Reason         string     `bun:"reason,type:varchar(255),notnull" json:"reason"`
+	IdempotencyKey string     `bun:"idempotency_key,type:varchar(255),notnull" json:"-"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+}
+
+func NewPointTransaction(
+	miniAppID, userID, productID, lessonID uuid.UUID,
+	rule *PointRule, subject string,
+) *PointTransaction {
+
+	return &PointTransaction{
+		ID:             uuid.New(),
+		MiniAppID:      miniAppID,
+		UserID:         userID,
+		ProductID:      productID,
+		LessonID:       lessonID,
+		RuleID:         rule.ID,
+		Event:          rule.Event,
+		Points:         rule.Points,
+		Reason:         rule.IdempotencyKey(subject),
+		IdempotencyKey: rule.IdempotencyKey(subject),
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+// CurrentStreak takes distinct activity days sorted from the newest and
+// returns the number of consecutive days up to today (or yesterday, so that
+// the streak is not lost until the end of the day) and the first streak day.
+func CurrentStreak(days []time.Time, now time.Time) (int64, time.Time) {
+	today := truncateToDay(now)
+
+	if len(days) == 0 {
+		return 0, time.Time{}
+	}
+
+	expected := truncateToDay(days[0])
+	if !expected.Equal(today) && !expected.Equal(today.AddDate(0, 0, -1)) {
+		return 0, time.Time{}
+	}
+
+	var length int64
+	var start time.Time
+	for _, day := range days {
+		day = truncateToDay(day)
+		if !day.Equal(expected) {
+			break
+		}
+
+		length++
+		start = day
+		expected = expected.AddDate(0, 0, -1)
+	}
+
+	return length, start
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+type PointRuleRequest struct {
+	Event     PointEvent `json:"event"`
+	Threshold int64      `json:"threshold"`
+	Points    int64      `json:"points"`
+	IsActive  bool       `json:"is_active"`
+}
+
+type EditPointRulesRequest struct {
+	Rules []PointRuleRequest `json:"rules"`
+}
+
+func (r *EditPointRulesRequest) Validate() error {
+	if pointRulesLimit < len(r.Rules) {
+		return fmt.Errorf("number of rules exceeds the limit")
+	}
+
+	type ruleKey struct {
+		event     PointEvent
+		threshold int64
+	}
+	seen := make(map[ruleKey]struct{}, len(r.Rules))
+
+	for _, rule := range r.Rules {
+		switch rule.Event {
+		case PointEventLessonAccepted, PointEventReview:
+			if rule.Threshold != 0 {
+				return fmt.Errorf("threshold is not supported for %v", rule.Event)
+			}
+		case PointEventQuizScore:
+			if rule.Threshold < 0 || MaxScore < rule.Threshold {
+				return fmt.Errorf("invalid quiz score threshold: %v", rule.Threshold)
+			}
+		case PointEventStreak:
+			if rule.Threshold < 2 || maxStreakDays < rule.Threshold {
+				return fmt.Errorf("invalid streak threshold: %v", rule.Threshold)
+			}
+		default:
+			return fmt.Errorf("unsupported rule event: %v", rule.Event)
+		}
+
+		if rule.Points <= 0 || pointRulePointMax < rule.Points {
+			return fmt.Errorf("invalid points: %v", rule.Points)
+		}
+
+		key := ruleKey{rule.Event, rule.Threshold}
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicated rule: %v %v", rule.Event, rule.Threshold)
+		}
+		seen[key] = struct{}{}
+	}
+
+	return nil
+}
+
+func (r *EditPointRulesRequest) ToPointRules(miniAppID uuid.UUID) []*PointRule {
+	rules := make([]*PointRule, len(r.Rules))
+	for i, rule := range r.Rules {
+		rules[i] = NewPointRule(miniAppID, rule.Event, rule.Threshold, rule.Points)
+		rules[i].IsActive = rule.IsActive
+	}
+
+	return rules
+}
+
+type AdjustPointsRequest struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Points    int64     `json:"points"`
+	Reason    string    `json:"reason"`
+}
+
+func (r *AdjustPointsRequest) Validate() error {
+	if r.UserID == uuid.Nil {
+		return fmt.Errorf("user_id not provided")
+	}
+	if r.Points == 0 || pointRulePointMax < r.Points || r.Points < -pointRulePointMax {
+		return fmt.Errorf("invalid points: %v", r.Points)
+	}
+	if r.Reason == "" {
+		return fmt.Errorf("reason not provided")
+	}
+
+	return nil
+}
+
+func (r *AdjustPointsRequest) ToPointTransaction(miniAppID, actorID uuid.UUID) *PointTransaction {
+	id := uuid.New()
+	return &PointTransaction{
+		ID:             id,
+		MiniAppID:      miniAppID,
+		UserID:         r.UserID,
+		ProductID:      r.ProductID,
+		ActorID:        actorID,
+		Event:          PointEventManual,
+		Points:         r.Points,
+		Reason:         r.Reason,
+		IdempotencyKey: fmt.Sprintf("%s:%s", PointEventManual, id),
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+type FilterPointTransactionsRequest struct {
+	UserID    []uuid.UUID  `json:"user_id"`
+	ProductID []uuid.UUID  `json:"product_id"`
+	Event     []PointEvent `json:"event"`
+
+	Limit  uint `json:"limit"`
+	Offset uint `json:"offset"`
+}
+
+type Badge struct {
+	bun.BaseModel `bun:"table:badges"`
+
+	ID             uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID      uuid.UUID `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	ProductID      uuid.UUID `bun:"product_id,type:uuid,nullzero" json:"product_id,omitempty"`
+	Title          string    `bun:"title,type:varchar(55),notnull" json:"title"`
+	Description    string    `bun:"description,type:varchar(255),notnull" json:"description"`
+	Image          string    `bun:"image,type:varchar(255),notnull" json:"image"`
+	ImageSize      int64     `bun:"image_size,type:int,notnull" json:"-"`
+	RequiredPoints int64     `bun:"required_points,type:int,notnull" json:"required_points"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewBadge() *Badge {
+	now := time.Now().UTC()
+	return &Badge{
+		ID:        uuid.New(),
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+}
+
+type UserBadge struct {
+	bun.BaseModel `bun:"table:user_badges"`
+
+	UserID  uuid.UUID `bun:"user_id,pk,type:uuid,notnull" json:"user_id"`
+	BadgeID uuid.UUID `bun:"badge_id,pk,type:uuid,notnull" json:"badge_id"`
+
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	Badge *Badge `bun:"rel:belongs-to,join:badge_id=id" json:"badge,omitempty"`
+}
+
+type BadgeRequest struct {
+	ProductID      uuid.UUID `json:"product_id"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	RequiredPoints int64     `json:"required_points"`
+}
+
+func (r *BadgeRequest) Validate() error {
+	if r.Title == "" {
+		return fmt.Errorf("title not provided")
+	}
+	if r.RequiredPoints <= 0 {
+		return fmt.Errorf("invalid required points: %v", r.RequiredPoints)
+	}
+
+	return nil
+}
+
+func (r *BadgeRequest) ToBadge(miniAppID uuid.UUID) *Badge {
+	b := NewBadge()
+
+	b.MiniAppID = miniAppID
+	b.ProductID = r.ProductID
+	b.Title = r.Title
+	b.Description = r.Description
+	b.RequiredPoints = r.RequiredPoints
+
+	return b
+}
+
+func (r *BadgeRequest) UpdateBadge(b *Badge) bool {
+	isChanged := false
+
+	if r.ProductID != b.ProductID {
+		b.ProductID = r.ProductID
+		isChanged = true
+	}
+	if r.Title != b.Title {
+		b.Title = r.Title
+		isChanged = true
+	}
+	if r.Description != b.Description {
+		b.Description = r.Description
+		isChanged = true
+	}
+	if r.RequiredPoints != b.RequiredPoints {
+		b.RequiredPoints = r.RequiredPoints
+		isChanged = true
+	}
+
+	if isChanged {
+		b.UpdatedAt = time.Now().UTC()
+	}
+
+	return isChanged
+}
+
+type LeaderboardEntry struct {
+	Rank             int64     `bun:"rank" json:"rank"`
+	UserID           uuid.UUID `bun:"user_id" json:"user_id"`
+	TelegramUsername string    `bun:"telegram_username" json:"telegram_username"`
+	FirstName        string    `bun:"first_name" json:"first_name"`
+	Avatar           string    `bun:"avatar" json:"avatar"`
+	Points           int64     `bun:"points" json:"points"`
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCurrentStreak(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time {
+		return time.Date(2024, 5, 10+offset, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		days       []time.Time
+		wantLength int64
+		wantStart  time.Time
+	}{
+		{
+			name: "No activity",
+		},
+		{
+			name:       "Only today",
+			days:       []time.Time{day(0)},
+			wantLength: 1,
+			wantStart:  day(0),
+		},
+		{
+			name:       "Streak till yesterday is kept",
+			days:       []time.Time{day(-1), day(-2), day(-3)},
+			wantLength: 3,
+			wantStart:  day(-3),
+		},
+		{
+			name:       "Gap breaks streak",
+			days:       []time.Time{day(0), day(-1), day(-3), day(-4)},
+			wantLength: 2,
+			wantStart:  day(-1),
+		},
+		{
+			name: "Streak lost",
+			days: []time.Time{day(-2), day(-3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length, start := CurrentStreak(tt.days, now)
+
+			if length != tt.wantLength {
+				t.Errorf("length %d != %d", length, tt.wantLength)
+			}
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("start %v != %v", start, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestDefaultPointRules(t *testing.T) {
+	miniAppID := uuid.New()
+
+	for _, rule := range DefaultPointRules(miniAppID) {
+		transaction := NewPointTransaction(miniAppID, uuid.New(), uuid.Nil, uuid.Nil, rule, "subject")
+
+		if transaction.RuleID != uuid.Nil {
+			t.Errorf("rule %s:%d is not stored but referenced", rule.Event, rule.Threshold)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"academy/internal/types"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	// JobStatusDead is set when the job failed all its attempts, it is kept
+	// till it is retried manually.
+	JobStatusDead JobStatus = "dead"
+)
+
+type JobKind string
+
+// Periodic jobs, they are enqueued by the schedule of every replica and only
+// one job of the kind is active at a time.
+const (
+	JobKindClearChunks            JobKind = "clear_chunks"
+	JobKindClearTusUploads        JobKind = "clear_tus_uploads"
+	JobKindClearUploads           JobKind = "clear_uploads"
+	JobKindClearOldMiniApps       JobKind = "clear_old_mini_apps"
+	JobKindClearJobs              JobKind = "clear_jobs"
+	JobKindVideoProcessing        JobKind = "video_processing"
+	JobKindMuxClearAssets         JobKind = "mux_clear_assets"
+	JobKindMuxUpdateReadyStatus   JobKind = "mux_update_ready_status"
+	JobKindUpdateTonPayments      JobKind = "update_ton_payments"
+	JobKindSendEventReminders     JobKind = "send_event_reminders"
+	JobKindSendNotifications      JobKind = "send_notifications"
+	JobKindClearNotifications     JobKind = "clear_notifications"
+	JobKindSendBroadcasts         JobKind = "send_broadcasts"
+	JobKindCheckPlanLimits        JobKind = "check_plan_limits"
+	JobKindSendStaffNotifications JobKind = "send_staff_notifications"
+	JobKindScanQuarantinedFiles   JobKind = "scan_quarantined_files"
+)
+
+// Material jobs, they are enqueued by the video processing job for each
+// pending video, so a failing video is retried apart from the others.
+const (
+	JobKindMuxUploadVideo JobKind = "mux_upload_video"
+	JobKindTranscodeVideo JobKind = "transcode_video"
+	JobKindVideoPreview   JobKind = "video_preview"
+)
+
+const (
+	DefaultJobMaxAttempts = 5
+
+	// Failed attempts are retried after the backoff that is doubled with each
+	// attempt up to the max one.
+	JobBackoff    = 30 * time.Second
+	MaxJobBackoff = time.Hour
+)
+
+type Job struct {
+	bun.BaseModel `bun:"table:jobs,alias:job"`
+
+	ID      uuid.UUID       `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	Kind    JobKind         `bun:"kind,type:varchar(100),notnull" json:"kind"`
+	Key     string          `bun:"key,type:varchar(255),nullzero" json:"key,omitempty"`
+	Payload json.RawMessage `bun:"payload,type:jsonb,notnull" json:"payload"`
+	Status  JobStatus       `bun:"status,type:job_status,notnull" json:"status"`
+
+	Attempts    int        `bun:"attempts,type:int,notnull" json:"attempts"`
+	MaxAttempts int        `bun:"max_attempts,type:int,notnull" json:"max_attempts"`
+	RunAt       time.Time  `bun:"run_at,type:timestamptz,notnull" json:"run_at"`
+	LockedBy    string     `bun:"locked_by,type:varchar(100),nullzero" json:"locked_by,omitempty"`
+	LockedUntil types.Time `bun:"locked_until,type:timestamptz,nullzero" json:"locked_until"`
+	LastError   string     `bun:"last_error,type:text,notnull" json:"last_error"`
+	CompletedAt types.Time `bun:"completed_at,type:timestamptz,nullzero" json:"completed_at"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// NewJob returns the job that runs once at the time. Key prevents enqueueing
+// the same job while it is active, it can be empty.
+func NewJob(kind JobKind, key string, payload json.RawMessage, runAt time.Time) *Job {
+	if len(payload) == 0 {
+		payload = json.RawMessage(`{}`)
+	}
+
+	now := time.Now().UTC()
+	return &Job{
+		ID:          uuid.New(),
+		Kind:        kind,
+		Key:         key,
+		Payload:     payload,
+		Status:      JobStatusPending,
+		MaxAttempts: DefaultJobMaxAttempts,
+		RunAt:       runAt.UTC(),
+		UpdatedAt:   now,
+		CreatedAt:   now,
+	}
+}
+
+// MaterialJobPayload is the payload of material jobs.
+type MaterialJobPayload struct {
+	MaterialID uuid.UUID `json:"material_id"`
+}
+
+// NewMaterialJob returns the job of the material that is keyed by the kind
+// and the material, so only one such job of the material is active.
+func NewMaterialJob(kind JobKind, materialID uuid.UUID) (*Job, error) {
+	payload, err := json.Marshal(MaterialJobPayload{MaterialID: materialID})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJob(kind, MaterialJobKey(kind, materialID), payload, time.Now()), nil
+}
+
+// MaterialJobKey returns the key of the material job.
+func MaterialJobKey(kind JobKind, materialID uuid.UUID) string {
+	return string(kind) + ":" + materialID.String()
+}
+
+// Backoff returns the delay before the next attempt of the job.
+func (j *Job) Backoff() time.Duration {
+	backoff := JobBackoff
+	for i := 1; i < j.Attempts && backoff < MaxJobBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, MaxJobBackoff)
+}
+
+// IsExhausted is true when the job failed its last attempt.
+func (j *Job) IsExhausted() bool {
+	return j.MaxAttempts <= j.Attempts
+}
+
+type FilterJobsRequest struct {
+	Status JobStatus `json:"status"`
+	Kind   JobKind   `json:"kind"`
+
+	Limit  uint `json:"limit"`
+	Offset uint `json:"offset"`
+}
+
+func (r *FilterJobsRequest) Validate() error {
+	switch r.Status {
+	case "":
+	case JobStatusPending:
+	case JobStatusRunning:
+	case JobStatusCompleted:
+	case JobStatusDead:
+	default:
+		return fmt.Errorf("invalid status")
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestJobBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+	for _, tt := range tests {
+		job := &Job{Attempts: tt.attempts}
+		if got := job.Backoff(); got != tt.want {
+			t.Errorf("Backoff() of attempt %d = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestJobIsExhausted(t *testing.T) {
+	job := NewJob(JobKindClearChunks, string(JobKindClearChunks), nil, time.Now())
+	if string(job.Payload) != "{}" {
+		t.Errorf("NewJob() payload = %s, want {}", job.Payload)
+	}
+
+	job.Attempts = DefaultJobMaxAttempts - 1
+	if job.IsExhausted() {
+		t.Errorf("IsExhausted() = true before the last attempt")
+	}
+
+	job.Attempts = DefaultJobMaxAttempts
+	if !job.IsExhausted() {
+		t.Errorf("IsExhausted() = false after the last attempt")
+	}
+}
+
+func TestNewMaterialJob(t *testing.T) {
+	materialID := uuid.New()
+
+	job, err := NewMaterialJob(JobKindMuxUploadVideo, materialID)
+	if err != nil {
+		t.Fatalf("NewMaterialJob() error = %v", err)
+	}
+
+	if want := "mux_upload_video:" + materialID.String(); job.Key != want {
+		t.Errorf("NewMaterialJob() key = %s, want %s", job.Key, want)
+	}
+
+	var payload MaterialJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.MaterialID != materialID {
+		t.Errorf("NewMaterialJob() material = %v, want %v", payload.MaterialID, materialID)
+	}
+
+	// Jobs of other kinds of the same material do not block each other.
+	other, err := NewMaterialJob(JobKindVideoPreview, materialID)
+	if err != nil {
+		t.Fatalf("NewMaterialJob() error = %v", err)
+	}
+	if other.Key == job.Key {
+		t.Errorf("NewMaterialJob() keys of different kinds are equal: %s", job.Key)
+	}
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"academy/internal/types"
 	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +20,13 @@ const (
 	LessonTypeEvent LessonType = "event"
 )
 
+// MeetingURLRevealTime is how long before the event start students get the
+// meeting URL.
+const MeetingURLRevealTime = 15 * time.Minute
+
+// EventReminderTime is how long before the event start students get reminded.
+const EventReminderTime = time.Hour
+
 type Lesson struct {
 	bun.BaseModel `bun:"table:lessons"`
 
@@ -33,6 +42,13 @@ type Lesson struct {
 	AccessTime       types.Interval `bun:"access_time,type:interval,nullzero" json:"access_time"`
 	IsActive         bool           `bun:"is_active,type:boolean,notnull" json:"is_active"`
 
+	// Event fields are set for event lessons only. TimeZone is used to
+	// display the event time, empty value means UTC.
+	EventStart types.Time `bun:"event_start,type:timestamptz,nullzero" json:"event_start"`
+	EventEnd   types.Time `bun:"event_end,type:timestamptz,nullzero" json:"event_end"`
+	TimeZone   string     `bun:"time_zone,type:varchar(64),notnull" json:"time_zone"`
+	MeetingURL string     `bun:"meeting_url,type:varchar(512),notnull" json:"meeting_url,omitempty"`
+
 	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
 	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
 
@@ -53,6 +69,65 @@ func NewLesson() *Lesson {
 	}
 }
 
+// validateEvent checks event fields of event lessons and clears them for
+// lessons of other types.
+func (l *Lesson) validateEvent() error {
+	if l.ContentType != LessonTypeEvent {
+		l.EventStart = types.Time{}
+		l.EventEnd = types.Time{}
+		l.TimeZone = ""
+		l.MeetingURL = ""
+
+		return nil
+	}
+
+	if !l.EventStart.Valid || !l.EventEnd.Valid {
+		return errors.New("event start and end are required")
+	}
+	if !l.EventStart.Time.Before(l.EventEnd.Time) {
+		return errors.New("event must end after the start")
+	}
+	if l.TimeZone == "Local" {
+		return errors.New("invalid time zone")
+	}
+	if _, err := time.LoadLocation(l.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone: %w", err)
+	}
+
+	if l.MeetingURL != "" {
+		u, err := url.Parse(l.MeetingURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("invalid meeting url")
+		}
+	}
+
+	return nil
+}
+
+// ConvertToVideo turns the event lesson into a video lesson with the event
+// recording. Event fields are cleared, so it is not counted as an event.
+func (l *Lesson) ConvertToVideo() {
+	l.ContentType = LessonTypeVideo
+	l.EventStart = types.Time{}
+	l.EventEnd = types.Time{}
+	l.TimeZone = ""
+	l.MeetingURL = ""
+	l.UpdatedAt = time.Now().UTC()
+}
+
+// HideMeetingURL removes meeting URL from the lesson unless the event starts
+// soon or is in progress.
+func (l *Lesson) HideMeetingURL(now time.Time) {
+	if !l.EventStart.Valid || !l.EventEnd.Valid {
+		l.MeetingURL = ""
+		return
+	}
+
+	if now.Before(l.EventStart.Time.Add(-MeetingURLRevealTime)) || l.EventEnd.Time.Before(now) {
+		l.MeetingURL = ""
+	}
+}
+
 type UnlockedLesson struct {
 	LessonID  uuid.UUID `bun:"lesson_id"`
 	ExpiredAT time.Time `bun:"expired_at"`
@@ -68,6 +143,11 @@ type CreateLessonRequest struct {
 	AccessTime  types.Interval `json:"access_time"`
 	IsActive    bool           `json:"is_active"`
 
+	EventStart types.Time `json:"event_start"`
+	EventEnd   types.Time `json:"event_end"`
+	TimeZone   string     `json:"time_zone"`
+	MeetingURL string     `json:"meeting_url"`
+
 	Index *int64 `json:"index"`
 
 	ProductLevelID []uuid.UUID `json:"product_level_id"`
@@ -90,6 +170,15 @@ func (r *CreateLessonRequest) ToLesson() (*Lesson, error) {
 	l.AccessTime = r.AccessTime
 	l.IsActive = r.IsActive
 
+	l.EventStart = r.EventStart
+	l.EventEnd = r.EventEnd
+	l.TimeZone = r.TimeZone
+	l.MeetingURL = r.MeetingURL
+
+	if err := l.validateEvent(); err != nil {
+		return nil, err
+	}
+
 	return l, nil
 }
 
@@ -101,6 +190,11 @@ type EditLessonRequest struct {
 	ReleaseDate types.Time     `json:"release_date"`
 	AccessTime  types.Interval `json:"access_time"`
 	IsActive    bool           `json:"is_active"`
+
+	EventStart types.Time `json:"event_start"`
+	EventEnd   types.Time `json:"event_end"`
+	TimeZone   string     `json:"time_zone"`
+	MeetingURL string     `json:"meeting_url"`
 }
 
 func (r *EditLessonRequest) UpdateLesson(l *Lesson) (bool, error) {
@@ -134,6 +228,26 @@ func (r *EditLessonRequest) UpdateLesson(l *Lesson) (bool, error) {
 		l.IsActive = r.IsActive
 		isChanged = true
 	}
+	if !r.EventStart.IsEqual(l.EventStart) {
+		l.EventStart = r.EventStart
+		isChanged = true
+	}
+	if !r.EventEnd.IsEqual(l.EventEnd) {
+		l.EventEnd = r.EventEnd
+		isChanged = true
+	}
+	if r.TimeZone != l.TimeZone {
+		l.TimeZone = r.TimeZone
+		isChanged = true
+	}
+	if r.MeetingURL != l.MeetingURL {
+		l.MeetingURL = r.MeetingURL
+		isChanged = true
+	}
+
+	if err := l.validateEvent(); err != nil {
+		return false, err
+	}
 
 	l.UpdatedAt = time.Now().UTC()
 
@@ -16,6 +16,9 @@ const (
 	LessonProgressStatusPending  LessonProgressStatus = "pending"
 	LessonProgressStatusFailed   LessonProgressStatus = "failed"
 	LessonProgressStatusAccepted LessonProgressStatus = "accepted"
+	// LessonProgressStatusQuarantined is set to answers with files that are
+	// not scanned for malware yet, they are reviewed once the files are clean.
+	LessonProgressStatusQuarantined LessonProgressStatus = "quarantined"
 )
 
 type LessonProgress struct {
@@ -110,6 +113,8 @@ type FileMetadata struct {
 	Filename         string `json:"filename,omitempty"`
 	OriginalFilename string `json:"original_filename,omitempty"`
 	Size             int64  `json:"size,omitempty"`
+
+	Scan *FileScan `json:"scan,omitempty"`
 }
 
 type QuizResult struct {
@@ -120,6 +125,7 @@ type QuizResult struct {
 type FilterProductHomeworkRequest struct {
 	UserID   []uuid.UUID `json:"user_id"`
 	LessonID []uuid.UUID `json:"lesson_id"`
+	CohortID []uuid.UUID `json:"cohort_id"`
 
 	Limit  uint `json:"limit"`
 	Offset uint `json:"offset"`
@@ -138,6 +144,7 @@ type FilterLessonProgressRequest struct {
 	UserID    []uuid.UUID            `json:"user_id"`
 	LessonID  []uuid.UUID            `json:"lesson_id"`
 	Status    []LessonProgressStatus `json:"status"`
+	CohortID  []uuid.UUID            `json:"cohort_id"`
 
 	Limit  uint `json:"limit"`
 	Offset uint `json:"offset"`
@@ -3,7 +3,9 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"path"
 	"reflect"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -42,8 +44,31 @@ const (
 	MaterialStatusReady              MaterialStatus = "ready"
 	MaterialStatusPendingCompressing MaterialStatus = "pending_compressing"
 	MaterialStatusPendingMoveToMux   MaterialStatus = "pending_move_to_mux"
+	MaterialStatusPendingTranscoding MaterialStatus = "pending_transcoding"
+	// MaterialStatusQuarantined is set to files that are not scanned for
+	// malware yet, they are not served to students.
+	MaterialStatusQuarantined MaterialStatus = "quarantined"
 )
 
+type FileScanStatus string
+
+const (
+	FileScanStatusClean    FileScanStatus = "clean"
+	FileScanStatusInfected FileScanStatus = "infected"
+)
+
+// FileScan is a result of the malware scan of the uploaded file.
+type FileScan struct {
+	Status    FileScanStatus `json:"status"`
+	Signature string         `json:"signature,omitempty"`
+	Engine    string         `json:"engine"`
+	ScannedAt time.Time      `json:"scanned_at"`
+}
+
+func (s *FileScan) IsInfected() bool {
+	return s != nil && s.Status == FileScanStatusInfected
+}
+
 type Material struct {
 	bun.BaseModel `bun:"table:materials"`
 
@@ -63,6 +88,11 @@ type Material struct {
 	Metadata         json.RawMessage  `bun:"metadata,type:jsonb,nullzero" json:"metadata"`
 	HiddenMetadata   json.RawMessage  `bun:"hidden_metadata,type:jsonb,nullzero" json:"-"`
 	Status           MaterialStatus   `bun:"status,type:material_status,nullzero,notnull,default:'ready'" json:"status"`
+	Scan             *FileScan        `bun:"scan,type:jsonb,nullzero" json:"scan,omitempty"`
+	IsWatermarked    bool             `bun:"is_watermarked,type:boolean,notnull" json:"is_watermarked"`
+
+	// Subtitles are loaded with lessons.
+	Subtitles []*Subtitle `bun:"rel:has-many,join:id=material_id" json:"subtitles,omitempty"`
 
 	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
 	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
@@ -77,6 +107,20 @@ func NewMaterial() *Material {
 	}
 }
 
+// IsReadyVideo reports whether the video lesson content is uploaded and
+// processed, so it could be watched.
+func (m *Material) IsReadyVideo() bool {
+	if m.Category != MaterialCategoryLessonContent || m.ContentType != MaterialTypeVideo {
+		return false
+	}
+
+	if m.Status != "" && m.Status != MaterialStatusReady {
+		return false
+	}
+
+	return m.Filename != "" || len(m.Metadata) != 0
+}
+
 type CreateMaterialRequest struct {
 	MiniAppID      uuid.UUID `json:"mini_app_id"`
 	LessonID       uuid.UUID `json:"lesson_id"`
@@ -88,6 +132,8 @@ type CreateMaterialRequest struct {
 	Title       string           `json:"title"`
 	Description string           `json:"description"`
 	URL         string           `json:"url"`
+
+	IsWatermarked bool `json:"is_watermarked"`
 }
 
 func (r *CreateMaterialRequest) ToMaterial(originalFilename, filename string, size int64) (*Material, error) {
@@ -104,6 +150,11 @@ func (r *CreateMaterialRequest) ToMaterial(originalFilename, filename string, si
 	p.Description = r.Description
 	p.URL = r.URL
 
+	if r.IsWatermarked && !isWatermarkAllowed(r.Category) {
+		return nil, fmt.Errorf("watermark is not supported by the category")
+	}
+	p.IsWatermarked = r.IsWatermarked
+
 	p.OriginalFilename = originalFilename
 	p.Filename = filename
 	p.Size = size
@@ -118,6 +169,7 @@ type EditMaterialRequest struct {
 	Description      string       `json:"description"`
 	OriginalFilename string       `json:"original_filename"`
 	URL              string       `json:"url"`
+	IsWatermarked    bool         `json:"is_watermarked"`
 }
 
 func (r *EditMaterialRequest) UpdateMaterial(material *Material) (bool, error) {
@@ -155,6 +207,13 @@ func (r *EditMaterialRequest) UpdateMaterial(material *Material) (bool, error) {
 		material.URL = r.URL
 		isChanged = true
 	}
+	if r.IsWatermarked != material.IsWatermarked {
+		if r.IsWatermarked && !isWatermarkAllowed(material.Category) {
+			return false, fmt.Errorf("watermark is not supported by the category")
+		}
+		material.IsWatermarked = r.IsWatermarked
+		isChanged = true
+	}
 
 	if isChanged {
 		material.UpdatedAt = time.Now().UTC()
@@ -163,6 +222,29 @@ func (r *EditMaterialRequest) UpdateMaterial(material *Material) (bool, error) {
 	return isChanged, nil
 }
 
+// MaxWatermarkedPDFSize limits PDFs of watermarked materials, they are read
+// into memory on every download.
+const MaxWatermarkedPDFSize = 50 << 20 // 50 MiB.
+
+// CheckWatermark returns the error if the PDF of the watermarked material is
+// too large to be watermarked.
+func (m *Material) CheckWatermark() error {
+	if !m.IsWatermarked || strings.ToLower(path.Ext(m.Filename)) != ".pdf" {
+		return nil
+	}
+	if MaxWatermarkedPDFSize < m.Size {
+		return fmt.Errorf("watermarked pdf exceeds %d MiB", MaxWatermarkedPDFSize>>20)
+	}
+
+	return nil
+}
+
+// isWatermarkAllowed reports if PDFs of the category are watermarked with
+// students who download them.
+func isWatermarkAllowed(category MaterialCategory) bool {
+	return category == MaterialCategoryMaterials || category == MaterialCategoryBonus
+}
+
 type SubmitChunksRequest struct {
 	OriginalFilename string         `json:"original_filename"`
 	Status           MaterialStatus `json:"status"`
@@ -178,6 +260,7 @@ func (r *SubmitChunksRequest) Validate() error {
 	case MaterialStatusReady:
 	case MaterialStatusPendingCompressing:
 	case MaterialStatusPendingMoveToMux:
+	case MaterialStatusPendingTranscoding:
 	default:
 		return fmt.Errorf("invalid status")
 	}
@@ -523,4 +606,38 @@ func (c *QuizMetadata) ToQuestionResult(
 type MuxVideoMetadata struct {
 	AssetID    string `json:"asset_id"`
 	PlaybackID string `json:"playback_id"`
+
+	VideoPreview
+}
+
+// HLSVideoMetadata is the video transcoded into HLS renditions by the API.
+// AssetID is the directory of its files, replaced assets are deleted the same
+// way as Mux assets.
+type HLSVideoMetadata struct {
+	AssetID    string   `json:"asset_id"`
+	Playlist   string   `json:"playlist"`
+	Thumbnail  string   `json:"thumbnail"`
+	Renditions []string `json:"renditions"`
+
+	VideoPreview
+}
+
+// VideoPreview is the poster frame and the storyboard of the lesson video.
+// Files are stored next to the file of the video, it is the metadata of videos
+// that are not moved to video backends.
+type VideoPreview struct {
+	Poster     string           `json:"poster,omitempty"`
+	Storyboard *VideoStoryboard `json:"storyboard,omitempty"`
+}
+
+// VideoStoryboard is the sprite sheet of thumbnails taken every interval for
+// scrubbing. Thumbnails are placed by rows from the top left one.
+type VideoStoryboard struct {
+	Filename string `json:"filename"`
+	// Interval is a number of seconds between thumbnails.
+	Interval int `json:"interval"`
+	Count    int `json:"count"`
+	Columns  int `json:"columns"`
+	Width    int `json:"width"`
+	Height   int `json:"height"`
 }
@@ -338,6 +338,11 @@ type MiniAppInfo struct {
 	MaxTotalEvents   *int64 `bun:"max_total_events" json:"max_total_events"`
 }
 
+// IsEventsLimitReached reports whether new event lessons can not be created.
+func (i *MiniAppInfo) IsEventsLimitReached() bool {
+	return i.MaxTotalEvents != nil && *i.MaxTotalEvents <= i.TotalEvents
+}
+
 type ListMiniAppsRequest struct {
 	InitData string `json:"init_data"`
 }
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,30 +19,105 @@ const (
 	PermissionBranding               PermissionName = "Branding"
 	PermissionAccountSettings        PermissionName = "Account Settings"
 	PermissionStudentInteraction     PermissionName = "Student Interaction"
+
+	PermissionHomeworkReview PermissionName = "Homework Review"
+	PermissionSupportTickets PermissionName = "Support Tickets"
+	PermissionStudentBans    PermissionName = "Student Bans"
+	PermissionStudentLevels  PermissionName = "Student Levels"
+	PermissionContentEditing PermissionName = "Content Editing"
+	PermissionProductReports PermissionName = "Product Reports"
 )
 
+// permissionParents are coarse permissions that include the fine-grained
+// ones, so the role with the parent permission has all of its parts.
+var permissionParents = map[PermissionName]PermissionName{
+	PermissionHomeworkReview: PermissionStudentInteraction,
+	PermissionSupportTickets: PermissionStudentInteraction,
+	PermissionStudentBans:    PermissionStudentManagement,
+	PermissionStudentLevels:  PermissionStudentManagement,
+	PermissionContentEditing: PermissionProductsControl,
+	PermissionProductReports: PermissionAnalytics,
+}
+
+// productPermissions could be granted only in specific products.
+var productPermissions = []PermissionName{
+	PermissionStudentManagement,
+	PermissionProductsControl,
+	PermissionAnalytics,
+	PermissionStudentInteraction,
+	PermissionHomeworkReview,
+	PermissionStudentBans,
+	PermissionStudentLevels,
+	PermissionContentEditing,
+	PermissionProductReports,
+}
+
+func (p PermissionName) Validate() error {
+	switch p {
+	case PermissionStudentManagement:
+	case PermissionProductsControl:
+	case PermissionSubscriptionManagement:
+	case PermissionAnalytics:
+	case PermissionBranding:
+	case PermissionAccountSettings:
+	case PermissionStudentInteraction:
+	case PermissionHomeworkReview:
+	case PermissionSupportTickets:
+	case PermissionStudentBans:
+	case PermissionStudentLevels:
+	case PermissionContentEditing:
+	case PermissionProductReports:
+	default:
+		return fmt.Errorf("unsupported permission: %q", p)
+	}
+
+	return nil
+}
+
+// WithParentPermissions returns the permissions together with the parent
+// permissions that include them.
+func WithParentPermissions(names ...PermissionName) []PermissionName {
+	result := make([]PermissionName, 0, len(names)*2)
+	for _, name := range names {
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+
+		parent, ok := permissionParents[name]
+		if ok && !slices.Contains(result, parent) {
+			result = append(result, parent)
+		}
+	}
+
+	return result
+}
+
+// HasPermission reports whether granted permissions include any of the
+// required ones or their parents.
+func HasPermission(granted []PermissionName, required ...PermissionName) bool {
+	for _, name := range WithParentPermissions(required...) {
+		if slices.Contains(granted, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type ModInvite struct {
 	bun.BaseModel `bun:"table:mod_invites"`
 
 	ID        uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
 	MiniAppID uuid.UUID `bun:"mini_app_id,type:uuid,notnull" json:"mini_app_id"`
 	UserID    uuid.UUID `bun:"user_id,type:uuid,nullzero" json:"user_id"`
+	RoleID    uuid.UUID `bun:"role_id,type:uuid,nullzero" json:"role_id"`
 
 	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
 	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
 
-	MiniApp     *User                  `bun:"rel:belongs-to,join:mini_app_id=id" json:"mini_app,omitempty"`
-	User        *User                  `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
-	Permissions []*ModInvitePermission `bun:"rel:has-many,join:id=invite_id" json:"permissions,omitempty"`
-}
-
-type ModInvitePermission struct {
-	bun.BaseModel `bun:"table:mod_invite_permissions"`
-
-	InviteID       uuid.UUID      `bun:"invite_id,pk,type:uuid" json:"invite_id"`
-	PermissionName PermissionName `bun:"permission_name,pk,type:varchar(60)" json:"permission_name"`
-
-	Permission *Permission `bun:"rel:belongs-to,join:permission_name=name" json:"permission"`
+	MiniApp *User    `bun:"rel:belongs-to,join:mini_app_id=id" json:"mini_app,omitempty"`
+	User    *User    `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+	Role    *ModRole `bun:"rel:belongs-to,join:role_id=id" json:"role,omitempty"`
 }
 
 type Permission struct {
@@ -49,72 +125,43 @@ type Permission struct {
 
 	Name        PermissionName `bun:"name,pk,type:varchar(60)" json:"name"`
 	Description string         `bun:"description,type:text" json:"description"`
+	ParentName  PermissionName `bun:"parent_name,type:varchar(60),nullzero" json:"parent_name,omitempty"`
+
+	// ProductIDs are products the permission of the moderator is granted in,
+	// empty means all products.
+	ProductIDs []uuid.UUID `bun:"product_ids,type:uuid[],array,scanonly" json:"product_ids,omitempty"`
 }
 
-func NewModInvite(miniAppID uuid.UUID) *ModInvite {
+func NewModInvite(miniAppID, roleID uuid.UUID) *ModInvite {
 	now := time.Now()
 	return &ModInvite{
 		ID:        uuid.New(),
 		MiniAppID: miniAppID,
+		RoleID:    roleID,
 		UpdatedAt: now,
 		CreatedAt: now,
 	}
 }
 
-func NewModInvitePermission(inviteID uuid.UUID, permissionName PermissionName) *ModInvitePermission {
-	return &ModInvitePermission{
-		InviteID:       inviteID,
-		PermissionName: permissionName,
-	}
-}
-
 type CreateModInviteRequest struct {
-	Permissions []PermissionName `json:"permissions"`
+	RoleID uuid.UUID `json:"role_id"`
 }
 
 func (r *CreateModInviteRequest) Validate() error {
-	if len(r.Permissions) == 0 {
-		return fmt.Errorf("no permissions provided for mod invite")
-	}
-
-	for _, p := range r.Permissions {
-		switch p {
-		case PermissionStudentManagement:
-		case PermissionProductsControl:
-		case PermissionSubscriptionManagement:
-		case PermissionAnalytics:
-		case PermissionBranding:
-		case PermissionAccountSettings:
-		case PermissionStudentInteraction:
-		default:
-			return fmt.Errorf("unsupported permission: %q", p)
-		}
+	if r.RoleID == uuid.Nil {
+		return fmt.Errorf("no role provided for mod invite")
 	}
 
 	return nil
 }
 
 type EditModInviteRequest struct {
-	Permissions []PermissionName `json:"permissions"`
+	RoleID uuid.UUID `json:"role_id"`
 }
 
 func (r *EditModInviteRequest) Validate() error {
-	if len(r.Permissions) == 0 {
-		return fmt.Errorf("no permissions provided for mod invite")
-	}
-
-	for _, p := range r.Permissions {
-		switch p {
-		case PermissionStudentManagement:
-		case PermissionProductsControl:
-		case PermissionSubscriptionManagement:
-		case PermissionAnalytics:
-		case PermissionBranding:
-		case PermissionAccountSettings:
-		case PermissionStudentInteraction:
-		default:
-			return fmt.Errorf("unsupported permission: %q", p)
-		}
+	if r.RoleID == uuid.Nil {
+		return fmt.Errorf("no role provided for mod invite")
 	}
 
 	return nil
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ModRole is a named set of permissions of moderators in the mini-app.
+// Changes of the role apply to all moderators invited with it.
+type ModRole struct {
+	bun.BaseModel `bun:"table:mod_roles"`
+
+	ID        uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID `bun:"mini_app_id,type:uuid,notnull" json:"mini_app_id"`
+	Name      string    `bun:"name,type:varchar(255),notnull" json:"name"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	TotalInvites int64 `bun:"total_invites,scanonly" json:"total_invites"`
+
+	Permissions []*ModRolePermission `bun:"rel:has-many,join:id=role_id" json:"permissions,omitempty"`
+}
+
+// ModRolePermission grants the permission in the products of the role, empty
+// products mean all products of the mini-app.
+type ModRolePermission struct {
+	bun.BaseModel `bun:"table:mod_role_permissions"`
+
+	RoleID         uuid.UUID      `bun:"role_id,pk,type:uuid" json:"role_id"`
+	PermissionName PermissionName `bun:"permission_name,pk,type:varchar(60)" json:"permission_name"`
+	ProductIDs     []uuid.UUID    `bun:"product_ids,type:uuid[],array,notnull" json:"product_ids"`
+
+	Permission *Permission `bun:"rel:belongs-to,join:permission_name=name" json:"permission,omitempty"`
+}
+
+func NewModRole(miniAppID uuid.UUID) *ModRole {
+	now := time.Now().UTC()
+	return &ModRole{
+		ID:        uuid.New(),
+		MiniAppID: miniAppID,
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+}
+
+type ModRolePermissionRequest struct {
+	Name       PermissionName `json:"name"`
+	ProductIDs []uuid.UUID    `json:"product_ids"`
+}
+
+type ModRoleRequest struct {
+	Name        string                      `json:"name"`
+	Permissions []*ModRolePermissionRequest `json:"permissions"`
+}
+
+func (r *ModRoleRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("empty role name")
+	}
+	if len(r.Permissions) == 0 {
+		return errors.New("no permissions provided for role")
+	}
+
+	names := make([]PermissionName, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		if p == nil {
+			return errors.New("invalid permission")
+		}
+		if err := p.Name.Validate(); err != nil {
+			return err
+		}
+		if slices.Contains(names, p.Name) {
+			return fmt.Errorf("duplicate permission: %q", p.Name)
+		}
+		names = append(names, p.Name)
+
+		if len(p.ProductIDs) != 0 && !slices.Contains(productPermissions, p.Name) {
+			return fmt.Errorf("permission %q could not be limited to products", p.Name)
+		}
+		if slices.Contains(p.ProductIDs, uuid.Nil) {
+			return errors.New("invalid product id")
+		}
+	}
+
+	return nil
+}
+
+// ProductIDs returns all products the permissions are limited to.
+func (r *ModRoleRequest) ProductIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0)
+	for _, p := range r.Permissions {
+		for _, id := range p.ProductIDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+func (r *ModRoleRequest) ToModRole(miniAppID uuid.UUID) *ModRole {
+	role := NewModRole(miniAppID)
+
+	role.Name = r.Name
+	role.Permissions = r.toPermissions(role.ID)
+
+	return role
+}
+
+func (r *ModRoleRequest) UpdateModRole(role *ModRole) {
+	role.Name = r.Name
+	role.Permissions = r.toPermissions(role.ID)
+	role.UpdatedAt = time.Now().UTC()
+}
+
+func (r *ModRoleRequest) toPermissions(roleID uuid.UUID) []*ModRolePermission {
+	permissions := make([]*ModRolePermission, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		productIDs := make([]uuid.UUID, 0, len(p.ProductIDs))
+		for _, id := range p.ProductIDs {
+			if !slices.Contains(productIDs, id) {
+				productIDs = append(productIDs, id)
+			}
+		}
+
+		permissions = append(permissions, &ModRolePermission{
+			RoleID:         roleID,
+			PermissionName: p.Name,
+			ProductIDs:     productIDs,
+		})
+	}
+
+	return permissions
+}
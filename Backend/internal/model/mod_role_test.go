@@ -0,0 +1,108 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWithParentPermissions(t *testing.T) {
+	got := WithParentPermissions(PermissionHomeworkReview, PermissionSupportTickets, PermissionBranding)
+	want := []PermissionName{
+		PermissionHomeworkReview,
+		PermissionStudentInteraction,
+		PermissionSupportTickets,
+		PermissionBranding,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithParentPermissions() = %v, want %v", got, want)
+	}
+
+	for name, parent := range permissionParents {
+		if err := name.Validate(); err != nil {
+			t.Errorf("Validate() of %q: %v", name, err)
+		}
+		if err := parent.Validate(); err != nil {
+			t.Errorf("Validate() of parent %q: %v", parent, err)
+		}
+	}
+}
+
+func TestModRoleRequestValidate(t *testing.T) {
+	productID := uuid.New()
+
+	tests := []struct {
+		name    string
+		req     ModRoleRequest
+		wantErr bool
+	}{
+		{
+			name: "scoped",
+			req: ModRoleRequest{Name: "Reviewer", Permissions: []*ModRolePermissionRequest{
+				{Name: PermissionHomeworkReview, ProductIDs: []uuid.UUID{productID}},
+				{Name: PermissionSupportTickets},
+			}},
+		},
+		{
+			name:    "no name",
+			req:     ModRoleRequest{Permissions: []*ModRolePermissionRequest{{Name: PermissionBranding}}},
+			wantErr: true,
+		},
+		{
+			name:    "no permissions",
+			req:     ModRoleRequest{Name: "Empty"},
+			wantErr: true,
+		},
+		{
+			name: "unsupported permission",
+			req: ModRoleRequest{Name: "Role", Permissions: []*ModRolePermissionRequest{
+				{Name: "Everything"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate permission",
+			req: ModRoleRequest{Name: "Role", Permissions: []*ModRolePermissionRequest{
+				{Name: PermissionBranding},
+				{Name: PermissionBranding},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "mini-app permission in products",
+			req: ModRoleRequest{Name: "Role", Permissions: []*ModRolePermissionRequest{
+				{Name: PermissionBranding, ProductIDs: []uuid.UUID{productID}},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModRoleRequestToModRole(t *testing.T) {
+	productID := uuid.New()
+	req := ModRoleRequest{Name: "Reviewer", Permissions: []*ModRolePermissionRequest{
+		{Name: PermissionHomeworkReview, ProductIDs: []uuid.UUID{productID, productID}},
+	}}
+
+	role := req.ToModRole(uuid.New())
+
+	if len(role.Permissions) != 1 || role.Permissions[0].RoleID != role.ID {
+		t.Fatalf("ToModRole() permissions = %v", role.Permissions)
+	}
+	if ids := role.Permissions[0].ProductIDs; len(ids) != 1 || ids[0] != productID {
+		t.Errorf("ToModRole() product ids = %v, want [%v]", ids, productID)
+	}
+	if ids := req.ProductIDs(); len(ids) != 1 {
+		t.Errorf("ProductIDs() = %v, want [%v]", ids, productID)
+	}
+}
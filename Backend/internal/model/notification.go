@@ -0,0 +1,223 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// NotificationCategory groups notifications that students could opt out of.
+type NotificationCategory string
+
+const (
+	NotificationCategoryHomework NotificationCategory = "homework"
+	NotificationCategoryLessons  NotificationCategory = "lessons"
+	NotificationCategoryPayments NotificationCategory = "payments"
+	NotificationCategoryAccess   NotificationCategory = "access"
+	NotificationCategoryEvents   NotificationCategory = "events"
+)
+
+var notificationCategories = []NotificationCategory{
+	NotificationCategoryHomework,
+	NotificationCategoryLessons,
+	NotificationCategoryPayments,
+	NotificationCategoryAccess,
+	NotificationCategoryEvents,
+}
+
+func (c NotificationCategory) Validate() error {
+	if !slices.Contains(notificationCategories, c) {
+		return fmt.Errorf("invalid notification category: %v", c)
+	}
+
+	return nil
+}
+
+type NotificationKind string
+
+const (
+	NotificationKindHomeworkAccepted NotificationKind = "homework_accepted"
+	NotificationKindHomeworkFailed   NotificationKind = "homework_failed"
+	NotificationKindLessonReleased   NotificationKind = "lesson_released"
+	NotificationKindPaymentCompleted NotificationKind = "payment_completed"
+	NotificationKindAccessExpiring   NotificationKind = "access_expiring"
+)
+
+func (k NotificationKind) Category() NotificationCategory {
+	switch k {
+	case NotificationKindHomeworkAccepted, NotificationKindHomeworkFailed:
+		return NotificationCategoryHomework
+	case NotificationKindLessonReleased:
+		return NotificationCategoryLessons
+	case NotificationKindPaymentCompleted:
+		return NotificationCategoryPayments
+	case NotificationKindAccessExpiring:
+		return NotificationCategoryAccess
+	}
+
+	return ""
+}
+
+type NotificationStatus string
+
+const (
+	NotificationStatusPending NotificationStatus = "pending"
+	NotificationStatusSent    NotificationStatus = "sent"
+	NotificationStatusFailed  NotificationStatus = "failed"
+	// NotificationStatusSkipped is set when student opted out of the category
+	// or the mini-app has no bot.
+	NotificationStatusSkipped NotificationStatus = "skipped"
+)
+
+// Period before access end when student gets notified.
+const AccessExpiringNotificationTime = 3 * 24 * time.Hour
+
+// Period that scheduled notifications are created for, older releases are not
+// notified about.
+const NotificationScanPeriod = time.Hour
+
+const defaultNotificationLanguage = "en"
+
+// notificationTemplates are message templates by language. Placeholders in
+// braces are replaced with notification params.
+var notificationTemplates = map[string]map[NotificationKind]string{
+	"en": {
+		NotificationKindHomeworkAccepted: "Your homework for \"{lesson}\" was accepted.",
+		NotificationKindHomeworkFailed:   "Your homework for \"{lesson}\" needs changes. Check the feedback in the app.",
+		NotificationKindLessonReleased:   "New lesson \"{lesson}\" of \"{product}\" is available.",
+		NotificationKindPaymentCompleted: "Payment for \"{title}\" is completed. Enjoy learning!",
+		NotificationKindAccessExpiring:   "Your access to \"{title}\" ends on {date}.",
+	},
+	"ru": {
+		NotificationKindHomeworkAccepted: "Ваше домашнее задание к уроку «{lesson}» принято.",
+		NotificationKindHomeworkFailed:   "Ваше домашнее задание к уроку «{lesson}» нужно доработать. Посмотрите отзыв в приложении.",
+		NotificationKindLessonReleased:   "Доступен новый урок «{lesson}» курса «{product}».",
+		NotificationKindPaymentCompleted: "Оплата «{title}» прошла успешно. Приятного обучения!",
+		NotificationKindAccessExpiring:   "Ваш доступ к «{title}» заканчивается {date}.",
+	},
+	"uk": {
+		NotificationKindHomeworkAccepted: "Ваше домашнє завдання до уроку «{lesson}» прийнято.",
+		NotificationKindHomeworkFailed:   "Ваше домашнє завдання до уроку «{lesson}» потрібно доопрацювати. Перегляньте відгук у застосунку.",
+		NotificationKindLessonReleased:   "Доступний новий урок «{lesson}» курсу «{product}».",
+		NotificationKindPaymentCompleted: "Оплата «{title}» пройшла успішно. Приємного навчання!",
+		NotificationKindAccessExpiring:   "Ваш доступ до «{title}» закінчується {date}.",
+	},
+}
+
+// Notification is a message to the student that is sent with the mini-app
+// bot. Notifications with the same non-empty dedup key are created once per
+// student.
+type Notification struct {
+	bun.BaseModel `bun:"table:notifications"`
+
+	ID        uuid.UUID            `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID            `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	UserID    uuid.UUID            `bun:"user_id,type:uuid,notnull" json:"user_id"`
+	Category  NotificationCategory `bun:"category,type:notification_category,notnull" json:"category"`
+	Kind      NotificationKind     `bun:"kind,type:varchar(50),notnull" json:"kind"`
+	Params    map[string]string    `bun:"params,type:jsonb,notnull" json:"params"`
+	DedupKey  string               `bun:"dedup_key,type:varchar(255),notnull" json:"-"`
+	Status    NotificationStatus   `bun:"status,type:notification_status,notnull" json:"status"`
+	Error     string               `bun:"error,type:text,notnull" json:"-"`
+
+	SentAt    *time.Time `bun:"sent_at,type:timestamptz,nullzero" json:"sent_at,omitempty"`
+	CreatedAt time.Time  `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewNotification(
+	miniAppID, userID uuid.UUID,
+	kind NotificationKind,
+	params map[string]string,
+) *Notification {
+
+	return &Notification{
+		ID:        uuid.New(),
+		MiniAppID: miniAppID,
+		UserID:    userID,
+		Category:  kind.Category(),
+		Kind:      kind,
+		Params:    params,
+		Status:    NotificationStatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// PendingNotification is a notification with the recipient data. BotToken is
+// an encrypted token of the mini-app bot.
+type PendingNotification struct {
+	ID         uuid.UUID            `bun:"id"`
+	MiniAppID  uuid.UUID            `bun:"mini_app_id"`
+	UserID     uuid.UUID            `bun:"user_id"`
+	Category   NotificationCategory `bun:"category"`
+	Kind       NotificationKind     `bun:"kind"`
+	Params     map[string]string    `bun:"params,type:jsonb"`
+	TelegramID int64                `bun:"telegram_id"`
+	BotToken   string               `bun:"bot_token"`
+
+	UserLanguage       string                 `bun:"user_language"`
+	MiniAppLanguage    string                 `bun:"mini_app_language"`
+	MutedNotifications []NotificationCategory `bun:"muted_notifications,array"`
+}
+
+func (n *PendingNotification) IsMuted() bool {
+	return slices.Contains(n.MutedNotifications, n.Category)
+}
+
+// Message returns notification text in the language of the student. Language
+// of the mini-app is used if there are no templates for the student's one.
+func (n *PendingNotification) Message() string {
+	templates := templatesFor(notificationTemplates, n.UserLanguage, n.MiniAppLanguage, defaultNotificationLanguage)
+
+	tmpl, ok := templates[n.Kind]
+	if !ok {
+		tmpl = notificationTemplates[defaultNotificationLanguage][n.Kind]
+	}
+
+	return fillTemplate(tmpl, n.Params)
+}
+
+// templatesFor returns templates of the first supported language.
+func templatesFor[K comparable](templates map[string]map[K]string, languages ...string) map[K]string {
+	for _, lang := range languages {
+		// Telegram language codes could include region, e.g. "en-US".
+		lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+
+		if t, ok := templates[lang]; ok {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// fillTemplate replaces placeholders in braces with params.
+func fillTemplate(tmpl string, params map[string]string) string {
+	oldnew := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		oldnew = append(oldnew, "{"+k+"}", v)
+	}
+
+	return strings.NewReplacer(oldnew...).Replace(tmpl)
+}
+
+// ToNotification returns notification with the status update.
+func (n *PendingNotification) ToNotification(status NotificationStatus, err error) *Notification {
+	notification := &Notification{
+		ID:     n.ID,
+		Status: status,
+	}
+
+	if err != nil {
+		notification.Error = err.Error()
+	}
+	if status == NotificationStatusSent {
+		now := time.Now().UTC()
+		notification.SentAt = &now
+	}
+
+	return notification
+}
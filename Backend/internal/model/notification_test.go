@@ -0,0 +1,67 @@
+package model
+
+import "testing"
+
+func TestPendingNotificationMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		userLanguage    string
+		miniAppLanguage string
+		want            string
+	}{
+		{
+			name:         "User language",
+			userLanguage: "uk",
+			want:         "Доступний новий урок «Intro» курсу «Go».",
+		},
+		{
+			name:         "User language with region",
+			userLanguage: "en-US",
+			want:         "New lesson \"Intro\" of \"Go\" is available.",
+		},
+		{
+			name:            "Mini-app language",
+			userLanguage:    "de",
+			miniAppLanguage: "ru",
+			want:            "Доступен новый урок «Intro» курса «Go».",
+		},
+		{
+			name: "Default language",
+			want: "New lesson \"Intro\" of \"Go\" is available.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &PendingNotification{
+				Kind:            NotificationKindLessonReleased,
+				Params:          map[string]string{"lesson": "Intro", "product": "Go"},
+				UserLanguage:    tt.userLanguage,
+				MiniAppLanguage: tt.miniAppLanguage,
+			}
+
+			if got := n.Message(); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationTemplates(t *testing.T) {
+	for lang, templates := range notificationTemplates {
+		for _, kind := range []NotificationKind{
+			NotificationKindHomeworkAccepted,
+			NotificationKindHomeworkFailed,
+			NotificationKindLessonReleased,
+			NotificationKindPaymentCompleted,
+			NotificationKindAccessExpiring,
+		} {
+			if templates[kind] == "" {
+				t.Errorf("no %q template for %q language", kind, lang)
+			}
+			if kind.Category() == "" {
+				t.Errorf("no category for %q", kind)
+			}
+		}
+	}
+}
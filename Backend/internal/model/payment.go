@@ -29,6 +29,7 @@ type Payment struct {
 	UserID         uuid.UUID `bun:"user_id,type:uuid,nullzero" json:"user_id"`
 	PlanID         uuid.UUID `bun:"plan_id,type:uuid,nullzero" json:"plan_id,omitempty"`
 	ProductLevelID uuid.UUID `bun:"product_level_id,type:uuid,nullzero" json:"product_level_id,omitempty"`
+	CohortID       uuid.UUID `bun:"cohort_id,type:uuid,nullzero" json:"cohort_id,omitempty"`
 
 	AccessStart    types.Time      `bun:"access_start,type:timestamptz,notnull" json:"access_start"`
 	AccessDuration types.Interval  `bun:"access_duration,type:interval,nullzero" json:"access_duration"`
@@ -74,6 +75,19 @@ func NewPaymentForProductLevel(userID uuid.UUID, product *Product, productLevel
 	}
 }
 
+// ApplyCohort assigns payment to the cohort so that student joins it on
+// completion. Access starts not earlier than the cohort.
+func (p *Payment) ApplyCohort(cohort *Cohort) {
+	if cohort == nil {
+		return
+	}
+
+	p.CohortID = cohort.ID
+	if cohort.StartDate.Valid && p.AccessStart.Time.Before(cohort.StartDate.Time) {
+		p.AccessStart = cohort.StartDate
+	}
+}
+
 func NewFreePaymentForProductLevel(userID uuid.UUID, product *Product, productLevel *ProductLevel) *Payment {
 	now := time.Now().UTC()
 
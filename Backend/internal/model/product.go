@@ -40,6 +40,8 @@ type Product struct {
 
 	Lessons []*Lesson       `bun:"rel:has-many,join:id=product_id" json:"lessons,omitempty"`
 	Levels  []*ProductLevel `bun:"rel:has-many,join:id=product_id" json:"product_levels,omitempty"`
+
+	Prerequisites []*ProductPrerequisite `bun:"rel:has-many,join:id=product_id" json:"prerequisites,omitempty"`
 }
 
 func NewProduct() *Product {
@@ -61,6 +63,8 @@ type CreateProductRequest struct {
 	ReleaseDate  types.Time     `json:"release_date"`
 	AccessTime   types.Interval `json:"access_time"`
 	IsActive     bool           `json:"is_active"`
+
+	Prerequisites []*ProductPrerequisiteRequest `json:"prerequisites"`
 }
 
 func (r *CreateProductRequest) ToProduct(miniAppID uuid.UUID) (*Product, error) {
@@ -80,6 +84,12 @@ func (r *CreateProductRequest) ToProduct(miniAppID uuid.UUID) (*Product, error)
 	p.AccessTime = r.AccessTime
 	p.IsActive = r.IsActive
 
+	prerequisites, err := ToProductPrerequisites(p.ID, r.Prerequisites)
+	if err != nil {
+		return nil, err
+	}
+	p.Prerequisites = prerequisites
+
 	return p, nil
 }
 
@@ -94,6 +104,10 @@ type EditProductRequest struct {
 	AccessTime   types.Interval `json:"access_time"`
 	IsActive     bool           `json:"is_active"`
 	DeleteCover  bool           `json:"delete_cover"`
+
+	// Prerequisites replace the current ones if provided. Empty list removes
+	// all prerequisites.
+	Prerequisites []*ProductPrerequisiteRequest `json:"prerequisites"`
 }
 
 func (r *EditProductRequest) UpdateProduct(p *Product) (bool, error) {
@@ -18,6 +18,8 @@ type ProductAccess struct {
 	DeletedAt *time.Time `bun:"deleted_at,type:timestamptz,nullzero" json:"deleted_at,omitempty"`
 	UpdatedAt time.Time  `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
 	CreatedAt time.Time  `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	UnmetPrerequisites []*UnmetPrerequisite `bun:"-" json:"unmet_prerequisites,omitempty"`
 }
 
 func NewProductAccess(userID, productID uuid.UUID) *ProductAccess {
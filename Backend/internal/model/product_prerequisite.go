@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type PrerequisiteCondition string
+
+const (
+	PrerequisiteConditionCompleted PrerequisiteCondition = "completed"
+	PrerequisiteConditionPurchased PrerequisiteCondition = "purchased"
+)
+
+// ProductPrerequisite is a requirement the student must meet before getting
+// access to the product. If RequiredProductLevelID is set then the condition
+// applies to the level only, otherwise to the whole required product.
+type ProductPrerequisite struct {
+	bun.BaseModel `bun:"table:product_prerequisites"`
+
+	ID                     uuid.UUID             `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ProductID              uuid.UUID             `bun:"product_id,type:uuid,notnull" json:"product_id"`
+	RequiredProductID      uuid.UUID             `bun:"required_product_id,type:uuid,notnull" json:"required_product_id"`
+	RequiredProductLevelID uuid.UUID             `bun:"required_product_level_id,type:uuid,nullzero" json:"required_product_level_id"`
+	Condition              PrerequisiteCondition `bun:"condition,type:prerequisite_condition,notnull" json:"condition"`
+	CreatedAt              time.Time             `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+type ProductPrerequisiteRequest struct {
+	RequiredProductID      uuid.UUID             `json:"required_product_id"`
+	RequiredProductLevelID uuid.UUID             `json:"required_product_level_id"`
+	Condition              PrerequisiteCondition `json:"condition"`
+}
+
+func (r *ProductPrerequisiteRequest) Validate() error {
+	if r.RequiredProductID == uuid.Nil {
+		return fmt.Errorf("invalid required product id")
+	}
+
+	switch r.Condition {
+	case PrerequisiteConditionCompleted, PrerequisiteConditionPurchased:
+	default:
+		return fmt.Errorf("invalid prerequisite condition: %s", r.Condition)
+	}
+
+	return nil
+}
+
+// ToProductPrerequisites validates requests and converts them into product
+// prerequisites. Duplicated requirements are rejected.
+func ToProductPrerequisites(
+	productID uuid.UUID,
+	reqs []*ProductPrerequisiteRequest,
+) ([]*ProductPrerequisite, error) {
+
+	type requirement struct {
+		productID      uuid.UUID
+		productLevelID uuid.UUID
+		condition      PrerequisiteCondition
+	}
+
+	now := time.Now().UTC()
+	requirements := make(map[requirement]struct{}, len(reqs))
+	prerequisites := make([]*ProductPrerequisite, 0, len(reqs))
+	for i, r := range reqs {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("prerequisite#%d: %w", i, err)
+		}
+
+		if r.RequiredProductID == productID {
+			return nil, fmt.Errorf("product can not require itself")
+		}
+
+		key := requirement{r.RequiredProductID, r.RequiredProductLevelID, r.Condition}
+		if _, ok := requirements[key]; ok {
+			return nil, fmt.Errorf("duplicated prerequisite")
+		}
+		requirements[key] = struct{}{}
+
+		prerequisites = append(prerequisites, &ProductPrerequisite{
+			ID:                     uuid.New(),
+			ProductID:              productID,
+			RequiredProductID:      r.RequiredProductID,
+			RequiredProductLevelID: r.RequiredProductLevelID,
+			Condition:              r.Condition,
+			CreatedAt:              now,
+		})
+	}
+
+	return prerequisites, nil
+}
+
+// UnmetPrerequisite is a prerequisite that the student has not met yet with
+// titles to explain it.
+type UnmetPrerequisite struct {
+	ProductPrerequisite `bun:",extend"`
+
+	RequiredProductTitle     string `bun:"required_product_title" json:"required_product_title"`
+	RequiredProductLevelName string `bun:"required_product_level_name" json:"required_product_level_name,omitempty"`
+}
+
+func (p *UnmetPrerequisite) String() string {
+	target := fmt.Sprintf("%q", p.RequiredProductTitle)
+	if p.RequiredProductLevelID != uuid.Nil {
+		target = fmt.Sprintf("%q - %q", p.RequiredProductTitle, p.RequiredProductLevelName)
+	}
+
+	switch p.Condition {
+	case PrerequisiteConditionPurchased:
+		return "purchase " + target
+	default:
+		return "complete " + target
+	}
+}
+
+// UnmetPrerequisitesMessage explains to the student what should be done to
+// get access to the product.
+func UnmetPrerequisitesMessage(unmet []*UnmetPrerequisite) string {
+	requirements := make([]string, 0, len(unmet))
+	for _, p := range unmet {
+		requirements = append(requirements, p.String())
+	}
+
+	return "prerequisites not met: " + strings.Join(requirements, "; ")
+}
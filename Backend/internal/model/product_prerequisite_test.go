@@ -0,0 +1,119 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestToProductPrerequisites(t *testing.T) {
+	productID := uuid.New()
+	requiredID := uuid.New()
+	levelID := uuid.New()
+
+	tests := []struct {
+		name    string
+		reqs    []*ProductPrerequisiteRequest
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "No prerequisites",
+		},
+		{
+			name: "Product and level of the same product",
+			reqs: []*ProductPrerequisiteRequest{
+				{RequiredProductID: requiredID, Condition: PrerequisiteConditionCompleted},
+				{RequiredProductID: requiredID, RequiredProductLevelID: levelID, Condition: PrerequisiteConditionPurchased},
+			},
+			want: 2,
+		},
+		{
+			name: "Different conditions of the same product",
+			reqs: []*ProductPrerequisiteRequest{
+				{RequiredProductID: requiredID, Condition: PrerequisiteConditionCompleted},
+				{RequiredProductID: requiredID, Condition: PrerequisiteConditionPurchased},
+			},
+			want: 2,
+		},
+		{
+			name: "Duplicated prerequisite",
+			reqs: []*ProductPrerequisiteRequest{
+				{RequiredProductID: requiredID, Condition: PrerequisiteConditionCompleted},
+				{RequiredProductID: requiredID, Condition: PrerequisiteConditionCompleted},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Product requires itself",
+			reqs: []*ProductPrerequisiteRequest{
+				{RequiredProductID: productID, Condition: PrerequisiteConditionPurchased},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing required product",
+			reqs: []*ProductPrerequisiteRequest{
+				{Condition: PrerequisiteConditionPurchased},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown condition",
+			reqs: []*ProductPrerequisiteRequest{
+				{RequiredProductID: requiredID, Condition: "enrolled"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prerequisites, err := ToProductPrerequisites(productID, tt.reqs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToProductPrerequisites() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(prerequisites) != tt.want {
+				t.Fatalf("ToProductPrerequisites() = %d prerequisites, want %d", len(prerequisites), tt.want)
+			}
+
+			for i, p := range prerequisites {
+				if p.ID == uuid.Nil || p.ProductID != productID {
+					t.Errorf("prerequisite#%d is not bound to the product: %+v", i, p)
+				}
+				if p.RequiredProductLevelID != tt.reqs[i].RequiredProductLevelID ||
+					p.Condition != tt.reqs[i].Condition {
+
+					t.Errorf("prerequisite#%d does not match the request: %+v", i, p)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmetPrerequisitesMessage(t *testing.T) {
+	unmet := []*UnmetPrerequisite{
+		{
+			ProductPrerequisite: ProductPrerequisite{
+				RequiredProductID: uuid.New(),
+				Condition:         PrerequisiteConditionCompleted,
+			},
+			RequiredProductTitle: "Basics",
+		},
+		{
+			ProductPrerequisite: ProductPrerequisite{
+				RequiredProductID:      uuid.New(),
+				RequiredProductLevelID: uuid.New(),
+				Condition:              PrerequisiteConditionPurchased,
+			},
+			RequiredProductTitle:     "Advanced",
+			RequiredProductLevelName: "VIP",
+		},
+	}
+
+	want := `prerequisites not met: complete "Basics"; purchase "Advanced" - "VIP"`
+	if got := UnmetPrerequisitesMessage(unmet); got != want {
+		t.Errorf("UnmetPrerequisitesMessage() = %s, want %s", got, want)
+	}
+}
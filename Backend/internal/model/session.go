@@ -0,0 +1,70 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a sign in of the user on a device. Refresh token of the session
+// is rotated on each refresh, only the hash of the last one is stored.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"-"`
+	MiniAppID  uuid.UUID `json:"-"`
+	TokenHash  string    `json:"-"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	IsCurrent  bool      `json:"is_current"`
+}
+
+// SessionClient is the client the session is created or refreshed from.
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+func NewSession(userID, miniAppID uuid.UUID, client *SessionClient) *Session {
+	now := time.Now().UTC()
+	return &Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		MiniAppID:  miniAppID,
+		Device:     DeviceFromUserAgent(client.UserAgent),
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+}
+
+// sessionDevices are user agent substrings with device names, the first
+// matched is used, so more specific ones go first.
+var sessionDevices = []struct {
+	substr string
+	device string
+}{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Macintosh", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceFromUserAgent returns a short name of the device to show in the list
+// of sessions.
+func DeviceFromUserAgent(userAgent string) string {
+	for _, d := range sessionDevices {
+		if strings.Contains(userAgent, d.substr) {
+			return d.device
+		}
+	}
+
+	return "Unknown"
+}
@@ -0,0 +1,23 @@
+package model
+
+import "testing"
+
+func TestDeviceFromUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15", "iPhone"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36", "Android"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36", "Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15", "macOS"},
+		{"Mozilla/5.0 (X11; Linux x86_64) Gecko/20100101 Firefox/120.0", "Linux"},
+		{"", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := DeviceFromUserAgent(tt.userAgent); got != tt.want {
+			t.Errorf("DeviceFromUserAgent(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// StaffNotificationCategory groups notifications to the owner and moderators
+// that are sent with the admin bot.
+type StaffNotificationCategory string
+
+const (
+	StaffNotificationCategorySubmissions StaffNotificationCategory = "submissions"
+	StaffNotificationCategoryReviews     StaffNotificationCategory = "reviews"
+	StaffNotificationCategoryPayments    StaffNotificationCategory = "payments"
+	StaffNotificationCategoryLimits      StaffNotificationCategory = "limits"
+)
+
+var staffNotificationCategories = []StaffNotificationCategory{
+	StaffNotificationCategorySubmissions,
+	StaffNotificationCategoryReviews,
+	StaffNotificationCategoryPayments,
+	StaffNotificationCategoryLimits,
+}
+
+func (c StaffNotificationCategory) Validate() error {
+	if !slices.Contains(staffNotificationCategories, c) {
+		return fmt.Errorf("invalid staff notification category: %v", c)
+	}
+
+	return nil
+}
+
+// Permissions returns permissions that allow moderator to get notifications
+// of the category, any of them is enough. Owner gets all categories. Payments
+// permissions are also listed in the trigger that queues payment notifications.
+// Submissions and reviews are scoped to the product of the lesson.
+func (c StaffNotificationCategory) Permissions() []PermissionName {
+	switch c {
+	case StaffNotificationCategorySubmissions:
+		return []PermissionName{PermissionStudentInteraction, PermissionHomeworkReview}
+	case StaffNotificationCategoryReviews:
+		return []PermissionName{PermissionAnalytics, PermissionProductReports}
+	case StaffNotificationCategoryPayments:
+		return []PermissionName{PermissionAccountSettings, PermissionProductsControl}
+	case StaffNotificationCategoryLimits:
+		return []PermissionName{PermissionSubscriptionManagement}
+	}
+
+	return nil
+}
+
+type StaffNotificationKind string
+
+const (
+	StaffNotificationKindHomeworkSubmitted StaffNotificationKind = "homework_submitted"
+	StaffNotificationKindReviewReceived    StaffNotificationKind = "review_received"
+	StaffNotificationKindPaymentCompleted  StaffNotificationKind = "payment_completed"
+	StaffNotificationKindPaymentRefunded   StaffNotificationKind = "payment_refunded"
+	StaffNotificationKindStorageLimit      StaffNotificationKind = "storage_limit"
+	StaffNotificationKindStudentsLimit     StaffNotificationKind = "students_limit"
+	StaffNotificationKindProductsLimit     StaffNotificationKind = "products_limit"
+	StaffNotificationKindEventsLimit       StaffNotificationKind = "events_limit"
+)
+
+// PlanLimitWarningPercent is a usage of the plan limit when the owner gets
+// warned. Warning is sent once a month for each limit.
+const PlanLimitWarningPercent = 90
+
+// DefaultReviewScoreThreshold is 60% of the max score. Reviews with lower
+// score are notified about.
+const DefaultReviewScoreThreshold = MaxScore * 3 / 5
+
+// staffNotificationTemplates are message templates by language. Placeholders
+// in braces are replaced with notification params.
+var staffNotificationTemplates = map[string]map[StaffNotificationKind]string{
+	"en": {
+		StaffNotificationKindHomeworkSubmitted: "{app}: {student} submitted homework for \"{lesson}\" of \"{product}\".",
+		StaffNotificationKindReviewReceived:    "{app}: {student} rated \"{lesson}\" {score}%.{text}",
+		StaffNotificationKindPaymentCompleted:  "{app}: {student} paid {amount} {currency} for \"{title}\".",
+		StaffNotificationKindPaymentRefunded:   "{app}: payment of {student} for \"{title}\" ({amount} {currency}) is refunded.",
+		StaffNotificationKindStorageLimit:      "{app}: {percent}% of the plan storage is used.",
+		StaffNotificationKindStudentsLimit:     "{app}: {percent}% of the plan students limit is reached.",
+		StaffNotificationKindProductsLimit:     "{app}: {percent}% of the plan products limit is reached.",
+		StaffNotificationKindEventsLimit:       "{app}: {percent}% of the plan events limit is reached.",
+	},
+	"ru": {
+		StaffNotificationKindHomeworkSubmitted: "{app}: {student} отправил(а) домашнее задание к уроку «{lesson}» курса «{product}».",
+		StaffNotificationKindReviewReceived:    "{app}: {student} оценил(а) урок «{lesson}» на {score}%.{text}",
+		StaffNotificationKindPaymentCompleted:  "{app}: {student} оплатил(а) {amount} {currency} за «{title}».",
+		StaffNotificationKindPaymentRefunded:   "{app}: оплата {student} за «{title}» ({amount} {currency}) возвращена.",
+		StaffNotificationKindStorageLimit:      "{app}: использовано {percent}% хранилища тарифа.",
+		StaffNotificationKindStudentsLimit:     "{app}: достигнуто {percent}% лимита студентов тарифа.",
+		StaffNotificationKindProductsLimit:     "{app}: достигнуто {percent}% лимита курсов тарифа.",
+		StaffNotificationKindEventsLimit:       "{app}: достигнуто {percent}% лимита событий тарифа.",
+	},
+	"uk": {
+		StaffNotificationKindHomeworkSubmitted: "{app}: {student} надіслав(ла) домашнє завдання до уроку «{lesson}» курсу «{product}».",
+		StaffNotificationKindReviewReceived:    "{app}: {student} оцінив(ла) урок «{lesson}» на {score}%.{text}",
+		StaffNotificationKindPaymentCompleted:  "{app}: {student} сплатив(ла) {amount} {currency} за «{title}».",
+		StaffNotificationKindPaymentRefunded:   "{app}: оплату {student} за «{title}» ({amount} {currency}) повернено.",
+		StaffNotificationKindStorageLimit:      "{app}: використано {percent}% сховища тарифу.",
+		StaffNotificationKindStudentsLimit:     "{app}: досягнуто {percent}% ліміту студентів тарифу.",
+		StaffNotificationKindProductsLimit:     "{app}: досягнуто {percent}% ліміту курсів тарифу.",
+		StaffNotificationKindEventsLimit:       "{app}: досягнуто {percent}% ліміту подій тарифу.",
+	},
+}
+
+// StaffNotification is a message to the owner or moderator that is sent with
+// the admin bot. Recipients are chosen by permissions of moderators when the
+// notification is queued.
+type StaffNotification struct {
+	bun.BaseModel `bun:"table:staff_notifications"`
+
+	ID        uuid.UUID                 `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID uuid.UUID                 `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	UserID    uuid.UUID                 `bun:"user_id,type:uuid,notnull" json:"user_id"`
+	Category  StaffNotificationCategory `bun:"category,type:staff_notification_category,notnull" json:"category"`
+	Kind      StaffNotificationKind     `bun:"kind,type:varchar(50),notnull" json:"kind"`
+	Params    map[string]string         `bun:"params,type:jsonb,notnull" json:"params"`
+	DedupKey  string                    `bun:"dedup_key,type:varchar(255),notnull" json:"-"`
+	Status    NotificationStatus        `bun:"status,type:notification_status,notnull" json:"status"`
+	Error     string                    `bun:"error,type:text,notnull" json:"-"`
+
+	SentAt    *time.Time `bun:"sent_at,type:timestamptz,nullzero" json:"sent_at,omitempty"`
+	CreatedAt time.Time  `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// StaffNotificationSettings are preferences of the owner or moderator. Users
+// without settings get all notifications they are permitted to.
+type StaffNotificationSettings struct {
+	bun.BaseModel `bun:"table:staff_notification_settings"`
+
+	UserID          uuid.UUID                   `bun:"user_id,pk,type:uuid" json:"-"`
+	MutedCategories []StaffNotificationCategory `bun:"muted_categories,array,type:staff_notification_category[],notnull" json:"muted_categories"`
+	// ReviewScoreThreshold is a score that reviews below are notified about.
+	ReviewScoreThreshold int64 `bun:"review_score_threshold,type:int,notnull" json:"review_score_threshold"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewStaffNotificationSettings(userID uuid.UUID) *StaffNotificationSettings {
+	now := time.Now().UTC()
+	return &StaffNotificationSettings{
+		UserID:               userID,
+		MutedCategories:      []StaffNotificationCategory{},
+		ReviewScoreThreshold: DefaultReviewScoreThreshold,
+		UpdatedAt:            now,
+		CreatedAt:            now,
+	}
+}
+
+type EditStaffNotificationSettingsRequest struct {
+	MutedCategories      []StaffNotificationCategory `json:"muted_categories"`
+	ReviewScoreThreshold int64                       `json:"review_score_threshold"`
+}
+
+func (r *EditStaffNotificationSettingsRequest) Validate() error {
+	for _, c := range r.MutedCategories {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.ReviewScoreThreshold < 0 || MaxScore < r.ReviewScoreThreshold {
+		return fmt.Errorf("invalid review score threshold: %v", r.ReviewScoreThreshold)
+	}
+
+	return nil
+}
+
+func (r *EditStaffNotificationSettingsRequest) UpdateSettings(s *StaffNotificationSettings) {
+	s.MutedCategories = r.MutedCategories
+	if s.MutedCategories == nil {
+		s.MutedCategories = []StaffNotificationCategory{}
+	}
+	s.ReviewScoreThreshold = r.ReviewScoreThreshold
+	s.UpdatedAt = time.Now().UTC()
+}
+
+// PendingStaffNotification is a notification with the recipient data.
+type PendingStaffNotification struct {
+	ID          uuid.UUID                 `bun:"id"`
+	MiniAppID   uuid.UUID                 `bun:"mini_app_id"`
+	UserID      uuid.UUID                 `bun:"user_id"`
+	Category    StaffNotificationCategory `bun:"category"`
+	Kind        StaffNotificationKind     `bun:"kind"`
+	Params      map[string]string         `bun:"params,type:jsonb"`
+	TelegramID  int64                     `bun:"telegram_id"`
+	MiniAppName string                    `bun:"mini_app_name"`
+
+	UserLanguage    string                      `bun:"user_language"`
+	MiniAppLanguage string                      `bun:"mini_app_language"`
+	MutedCategories []StaffNotificationCategory `bun:"muted_categories,array"`
+}
+
+func (n *PendingStaffNotification) IsMuted() bool {
+	return slices.Contains(n.MutedCategories, n.Category)
+}
+
+// Message returns notification text in the language of the recipient. Name of
+// the mini-app is included since the user could manage several ones.
+func (n *PendingStaffNotification) Message() string {
+	templates := templatesFor(staffNotificationTemplates, n.UserLanguage, n.MiniAppLanguage, defaultNotificationLanguage)
+
+	tmpl, ok := templates[n.Kind]
+	if !ok {
+		tmpl = staffNotificationTemplates[defaultNotificationLanguage][n.Kind]
+	}
+
+	params := make(map[string]string, len(n.Params)+1)
+	for k, v := range n.Params {
+		params[k] = v
+	}
+	params["app"] = n.MiniAppName
+
+	return fillTemplate(tmpl, params)
+}
+
+// ToStaffNotification returns notification with the status update.
+func (n *PendingStaffNotification) ToStaffNotification(status NotificationStatus, err error) *StaffNotification {
+	notification := &StaffNotification{
+		ID:     n.ID,
+		Status: status,
+	}
+
+	if err != nil {
+		notification.Error = err.Error()
+	}
+	if status == NotificationStatusSent {
+		now := time.Now().UTC()
+		notification.SentAt = &now
+	}
+
+	return notification
+}
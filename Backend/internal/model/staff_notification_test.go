@@ -0,0 +1,47 @@
+package model
+
+import "testing"
+
+func TestPendingStaffNotificationMessage(t *testing.T) {
+	n := &PendingStaffNotification{
+		Kind: StaffNotificationKindReviewReceived,
+		Params: map[string]string{
+			"student": "Jane Doe",
+			"lesson":  "Intro",
+			"score":   "40",
+			"text":    "",
+		},
+		MiniAppName:  "Go School",
+		UserLanguage: "en-GB",
+	}
+
+	want := "Go School: Jane Doe rated \"Intro\" 40%."
+	if got := n.Message(); got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
+
+func TestStaffNotificationTemplates(t *testing.T) {
+	for lang, templates := range staffNotificationTemplates {
+		for _, kind := range []StaffNotificationKind{
+			StaffNotificationKindHomeworkSubmitted,
+			StaffNotificationKindReviewReceived,
+			StaffNotificationKindPaymentCompleted,
+			StaffNotificationKindPaymentRefunded,
+			StaffNotificationKindStorageLimit,
+			StaffNotificationKindStudentsLimit,
+			StaffNotificationKindProductsLimit,
+			StaffNotificationKindEventsLimit,
+		} {
+			if templates[kind] == "" {
+				t.Errorf("no %q template for %q language", kind, lang)
+			}
+		}
+	}
+
+	for _, c := range staffNotificationCategories {
+		if len(c.Permissions()) == 0 {
+			t.Errorf("no permissions for %q", c)
+		}
+	}
+}
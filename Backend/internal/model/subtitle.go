@@ -0,0 +1,180 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const subtitleLabelLimit = 100
+
+var ErrInvalidSubtitles = errors.New("invalid subtitles")
+
+var (
+	// subtitleLanguageRegexp matches BCP 47 tags like "en" or "pt-BR".
+	subtitleLanguageRegexp = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+	subtitleTimingRegexp   = regexp.MustCompile(
+		`^((?:\d+:)?\d{2}:\d{2})[,.](\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2})[,.](\d{3})(.*)$`)
+	subtitleTagRegexp = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Subtitle is the subtitle track of the video or audio material. The file is
+// WebVTT, the transcript is its text without timings.
+type Subtitle struct {
+	bun.BaseModel `bun:"table:material_subtitles,alias:subtitle"`
+
+	ID         uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID  uuid.UUID `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	MaterialID uuid.UUID `bun:"material_id,type:uuid,notnull" json:"material_id"`
+	Language   string    `bun:"language,type:varchar(35),notnull" json:"language"`
+	Label      string    `bun:"label,type:varchar(100),notnull" json:"label"`
+	Filename   string    `bun:"filename,type:varchar(255),notnull" json:"filename"`
+	Size       int64     `bun:"size,type:int,notnull,default:0" json:"size"`
+	Transcript string    `bun:"transcript,type:text,notnull" json:"transcript"`
+	// Track of the Mux asset, it is added again if the asset is replaced.
+	MuxAssetID string `bun:"mux_asset_id,type:varchar(255),notnull" json:"-"`
+	MuxTrackID string `bun:"mux_track_id,type:varchar(255),notnull" json:"-"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+type UploadSubtitleRequest struct {
+	Language string `json:"language"`
+	Label    string `json:"label"`
+}
+
+func (r *UploadSubtitleRequest) Validate() error {
+	if !subtitleLanguageRegexp.MatchString(r.Language) {
+		return fmt.Errorf("invalid language")
+	}
+	if subtitleLabelLimit < utf8.RuneCountInString(r.Label) {
+		return fmt.Errorf("label exceeds the limit")
+	}
+
+	return nil
+}
+
+func (r *UploadSubtitleRequest) ToSubtitle(material *Material, miniAppID uuid.UUID) *Subtitle {
+	now := time.Now().UTC()
+
+	label := r.Label
+	if label == "" {
+		label = r.Language
+	}
+
+	return &Subtitle{
+		ID:         uuid.New(),
+		MiniAppID:  miniAppID,
+		MaterialID: material.ID,
+		Language:   r.Language,
+		Label:      label,
+		UpdatedAt:  now,
+		CreatedAt:  now,
+	}
+}
+
+// TranscriptMatch is the subtitle track which transcript matches the search
+// query, the headline is the matched part of the transcript.
+type TranscriptMatch struct {
+	LessonID      uuid.UUID `bun:"lesson_id" json:"lesson_id"`
+	LessonTitle   string    `bun:"lesson_title" json:"lesson_title"`
+	MaterialID    uuid.UUID `bun:"material_id" json:"material_id"`
+	MaterialTitle string    `bun:"material_title" json:"material_title"`
+	Language      string    `bun:"language" json:"language"`
+	Headline      string    `bun:"headline" json:"headline"`
+}
+
+// ParseSubtitles converts SRT or WebVTT subtitles into WebVTT and returns
+// their transcript. Cues of WebVTT are kept as is, timings of SRT use dots.
+func ParseSubtitles(data []byte) ([]byte, string, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		return nil, "", fmt.Errorf("%w: not utf-8", ErrInvalidSubtitles)
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	isVTT := strings.HasPrefix(text, "WEBVTT")
+
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n")
+
+	var transcript []string
+	var cues int
+	for i, block := range strings.Split(text, "\n\n") {
+		block = strings.Trim(block, "\n")
+		if block == "" {
+			continue
+		}
+
+		lines := strings.Split(block, "\n")
+
+		if isVTT {
+			// Header of the file is kept with its settings.
+			if i == 0 {
+				vtt.Reset()
+				vtt.WriteString(block + "\n")
+				continue
+			}
+			if strings.HasPrefix(lines[0], "NOTE") ||
+				strings.HasPrefix(lines[0], "STYLE") ||
+				strings.HasPrefix(lines[0], "REGION") {
+
+				vtt.WriteString("\n" + block + "\n")
+				continue
+			}
+		}
+
+		// Identifier of the cue is optional.
+		timing := 0
+		if !strings.Contains(lines[0], "-->") {
+			timing = 1
+		}
+		if len(lines) <= timing {
+			return nil, "", fmt.Errorf("%w: cue without timing: %q", ErrInvalidSubtitles, lines[0])
+		}
+
+		m := subtitleTimingRegexp.FindStringSubmatch(strings.TrimSpace(lines[timing]))
+		if m == nil {
+			return nil, "", fmt.Errorf("%w: invalid timing: %q", ErrInvalidSubtitles, lines[timing])
+		}
+
+		vtt.WriteString("\n")
+		if isVTT && timing == 1 {
+			vtt.WriteString(lines[0] + "\n")
+		}
+		settings := ""
+		if isVTT {
+			settings = m[5]
+		}
+		fmt.Fprintf(&vtt, "%s.%s --> %s.%s%s\n", m[1], m[2], m[3], m[4], settings)
+
+		for _, line := range lines[timing+1:] {
+			vtt.WriteString(line + "\n")
+
+			line = strings.TrimSpace(html.UnescapeString(subtitleTagRegexp.ReplaceAllString(line, "")))
+			// Rolling captions repeat the previous line.
+			if line == "" || 0 < len(transcript) && transcript[len(transcript)-1] == line {
+				continue
+			}
+			transcript = append(transcript, line)
+		}
+		cues++
+	}
+
+	if cues == 0 {
+		return nil, "", fmt.Errorf("%w: no cues", ErrInvalidSubtitles)
+	}
+
+	return []byte(vtt.String()), strings.Join(transcript, "\n"), nil
+}
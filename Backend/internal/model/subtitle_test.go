@@ -0,0 +1,84 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSubtitles(t *testing.T) {
+	srt := "\ufeff1\r\n" +
+		"00:00:01,000 --> 00:00:03,500\r\n" +
+		"<i>Hello</i> &amp; welcome\r\n" +
+		"\r\n" +
+		"2\r\n" +
+		"00:00:03,500 --> 00:00:05,000\r\n" +
+		"<i>Hello</i> &amp; welcome\r\n" +
+		"to the lesson\r\n"
+
+	vtt, transcript, err := ParseSubtitles([]byte(srt))
+	if err != nil {
+		t.Fatalf("ParseSubtitles() of srt error = %v", err)
+	}
+
+	wantVTT := "WEBVTT\n" +
+		"\n" +
+		"00:00:01.000 --> 00:00:03.500\n" +
+		"<i>Hello</i> &amp; welcome\n" +
+		"\n" +
+		"00:00:03.500 --> 00:00:05.000\n" +
+		"<i>Hello</i> &amp; welcome\n" +
+		"to the lesson\n"
+	if string(vtt) != wantVTT {
+		t.Errorf("ParseSubtitles() of srt = %q, want %q", vtt, wantVTT)
+	}
+	if want := "Hello & welcome\nto the lesson"; transcript != want {
+		t.Errorf("ParseSubtitles() of srt transcript = %q, want %q", transcript, want)
+	}
+
+	webVTT := "WEBVTT - Lesson 1\n" +
+		"\n" +
+		"NOTE made by hand\n" +
+		"\n" +
+		"intro\n" +
+		"01:02.000 --> 01:04.000 align:start\n" +
+		"<v Teacher>First line\n"
+
+	vtt, transcript, err = ParseSubtitles([]byte(webVTT))
+	if err != nil {
+		t.Fatalf("ParseSubtitles() of vtt error = %v", err)
+	}
+	if string(vtt) != webVTT {
+		t.Errorf("ParseSubtitles() of vtt = %q, want %q", vtt, webVTT)
+	}
+	if want := "First line"; transcript != want {
+		t.Errorf("ParseSubtitles() of vtt transcript = %q, want %q", transcript, want)
+	}
+
+	for _, data := range []string{
+		"",
+		"WEBVTT\n",
+		"1\n00:00:01 --> 00:00:02\ntext\n",
+		"just some text\n",
+		"\xff\xfe1\n",
+	} {
+		if _, _, err := ParseSubtitles([]byte(data)); !errors.Is(err, ErrInvalidSubtitles) {
+			t.Errorf("ParseSubtitles(%q) error = %v, want %v", data, err, ErrInvalidSubtitles)
+		}
+	}
+}
+
+func TestUploadSubtitleRequestValidate(t *testing.T) {
+	for _, language := range []string{"en", "pt-BR", "zh-Hant-TW"} {
+		req := UploadSubtitleRequest{Language: language}
+		if err := req.Validate(); err != nil {
+			t.Errorf("Validate() of %q error = %v", language, err)
+		}
+	}
+
+	for _, language := range []string{"", "e", "english", "en_US", "en-"} {
+		req := UploadSubtitleRequest{Language: language}
+		if err := req.Validate(); err == nil {
+			t.Errorf("Validate() of %q error = nil", language)
+		}
+	}
+}
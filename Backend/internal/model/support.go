@@ -0,0 +1,170 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type SupportTicketStatus string
+
+const (
+	// SupportTicketStatusOpen is set when the student is waiting for a reply.
+	SupportTicketStatusOpen     SupportTicketStatus = "open"
+	SupportTicketStatusAnswered SupportTicketStatus = "answered"
+	SupportTicketStatusClosed   SupportTicketStatus = "closed"
+)
+
+var supportTicketStatuses = []SupportTicketStatus{
+	SupportTicketStatusOpen,
+	SupportTicketStatusAnswered,
+	SupportTicketStatusClosed,
+}
+
+// SupportTicket is a conversation of the student with the mini-app staff.
+// Student writes to the mini-app bot and gets replies there. Messages of the
+// student are added to the last ticket that is not closed.
+type SupportTicket struct {
+	bun.BaseModel `bun:"table:support_tickets"`
+
+	ID         uuid.UUID           `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID  uuid.UUID           `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	UserID     uuid.UUID           `bun:"user_id,type:uuid,notnull" json:"user_id"`
+	AssigneeID uuid.UUID           `bun:"assignee_id,type:uuid,nullzero" json:"assignee_id"`
+	Subject    string              `bun:"subject,type:varchar(100),notnull" json:"subject"`
+	Status     SupportTicketStatus `bun:"status,type:support_ticket_status,notnull" json:"status"`
+
+	LastMessageAt time.Time  `bun:"last_message_at,type:timestamptz,notnull" json:"last_message_at"`
+	ClosedAt      *time.Time `bun:"closed_at,type:timestamptz,nullzero" json:"closed_at,omitempty"`
+	UpdatedAt     time.Time  `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	User     *User             `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+	Assignee *User             `bun:"rel:belongs-to,join:assignee_id=id" json:"assignee,omitempty"`
+	Messages []*SupportMessage `bun:"rel:has-many,join:id=ticket_id" json:"messages,omitempty"`
+}
+
+// supportSubjectLength is a number of runes of the first message that the
+// ticket subject is made of.
+const supportSubjectLength = 100
+
+func NewSupportTicket(miniAppID, userID uuid.UUID, text string) *SupportTicket {
+	now := time.Now().UTC()
+
+	subject := []rune(text)
+	if supportSubjectLength < len(subject) {
+		subject = subject[:supportSubjectLength]
+	}
+
+	return &SupportTicket{
+		ID:            uuid.New(),
+		MiniAppID:     miniAppID,
+		UserID:        userID,
+		Subject:       string(subject),
+		Status:        SupportTicketStatusOpen,
+		LastMessageAt: now,
+		UpdatedAt:     now,
+		CreatedAt:     now,
+	}
+}
+
+// SupportMessage is a message of the ticket. AuthorID is the student or the
+// staff member who replied.
+type SupportMessage struct {
+	bun.BaseModel `bun:"table:support_messages"`
+
+	ID                uuid.UUID `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	TicketID          uuid.UUID `bun:"ticket_id,type:uuid,notnull" json:"-"`
+	AuthorID          uuid.UUID `bun:"author_id,type:uuid,nullzero" json:"author_id"`
+	IsReply           bool      `bun:"is_reply,type:boolean,notnull" json:"is_reply"`
+	Text              string    `bun:"text,type:text,notnull" json:"text"`
+	TelegramMessageID int       `bun:"telegram_message_id,type:int,notnull" json:"-"`
+	CreatedAt         time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+
+	Author *User `bun:"rel:belongs-to,join:author_id=id" json:"author,omitempty"`
+}
+
+func NewSupportMessage(ticketID, authorID uuid.UUID, isReply bool, text string) *SupportMessage {
+	return &SupportMessage{
+		ID:        uuid.New(),
+		TicketID:  ticketID,
+		AuthorID:  authorID,
+		IsReply:   isReply,
+		Text:      text,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+type SupportReplyRequest struct {
+	Text string `json:"text"`
+}
+
+func (r *SupportReplyRequest) Validate() error {
+	if r.Text == "" {
+		return errors.New("empty text")
+	}
+
+	return nil
+}
+
+type EditSupportTicketRequest struct {
+	Status *SupportTicketStatus `json:"status"`
+	// AssigneeID is the owner or moderator, nil UUID removes the assignee.
+	AssigneeID *uuid.UUID `json:"assignee_id"`
+}
+
+func (r *EditSupportTicketRequest) Validate() error {
+	if r.Status != nil && !slices.Contains(supportTicketStatuses, *r.Status) {
+		return fmt.Errorf("invalid status: %v", *r.Status)
+	}
+
+	return nil
+}
+
+func (r *EditSupportTicketRequest) UpdateTicket(t *SupportTicket) (isChanged bool) {
+	now := time.Now().UTC()
+
+	if r.Status != nil && *r.Status != t.Status {
+		t.Status = *r.Status
+		t.ClosedAt = nil
+		if t.Status == SupportTicketStatusClosed {
+			t.ClosedAt = &now
+		}
+		isChanged = true
+	}
+
+	if r.AssigneeID != nil && *r.AssigneeID != t.AssigneeID {
+		t.AssigneeID = *r.AssigneeID
+		isChanged = true
+	}
+
+	if isChanged {
+		t.UpdatedAt = now
+	}
+
+	return isChanged
+}
+
+type FilterSupportTicketsRequest struct {
+	Status     []SupportTicketStatus `json:"status"`
+	AssigneeID uuid.UUID             `json:"assignee_id"`
+	// Search matches messages, names and usernames of students.
+	Search string `json:"search"`
+
+	Limit  uint `json:"limit"`
+	Offset uint `json:"offset"`
+}
+
+func (r *FilterSupportTicketsRequest) Validate() error {
+	for _, s := range r.Status {
+		if !slices.Contains(supportTicketStatuses, s) {
+			return fmt.Errorf("invalid status: %v", s)
+		}
+	}
+
+	return nil
+}
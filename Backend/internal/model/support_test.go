@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestNewSupportTicketSubject(t *testing.T) {
+	text := strings.Repeat("ю", supportSubjectLength+10)
+
+	ticket := NewSupportTicket(uuid.New(), uuid.New(), text)
+
+	if n := utf8.RuneCountInString(ticket.Subject); n != supportSubjectLength {
+		t.Errorf("subject length = %d, want %d", n, supportSubjectLength)
+	}
+}
+
+func TestEditSupportTicketRequestUpdateTicket(t *testing.T) {
+	closed := SupportTicketStatusClosed
+	open := SupportTicketStatusOpen
+	noAssignee := uuid.Nil
+
+	ticket := NewSupportTicket(uuid.New(), uuid.New(), "help")
+	ticket.AssigneeID = uuid.New()
+
+	req := EditSupportTicketRequest{Status: &closed, AssigneeID: &noAssignee}
+	if !req.UpdateTicket(ticket) {
+		t.Fatal("UpdateTicket() = false, want true")
+	}
+	if ticket.ClosedAt == nil || ticket.AssigneeID != uuid.Nil {
+		t.Errorf("ticket is not closed and unassigned: %+v", ticket)
+	}
+
+	if req.UpdateTicket(ticket) {
+		t.Error("UpdateTicket() of unchanged ticket = true, want false")
+	}
+
+	req = EditSupportTicketRequest{Status: &open}
+	req.UpdateTicket(ticket)
+	if ticket.ClosedAt != nil {
+		t.Error("reopened ticket has closing time")
+	}
+}
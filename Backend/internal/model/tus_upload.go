@@ -0,0 +1,152 @@
+package model
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Features of the tus protocol that are supported.
+const (
+	TusVersion            = "1.0.0"
+	TusExtensions         = "creation,termination,checksum,expiration"
+	TusChecksumAlgorithms = "sha1,sha256,md5"
+
+	// TusUploadTTL is a time the upload is kept after the last received part.
+	TusUploadTTL = 24 * time.Hour
+)
+
+// Keys of the upload metadata that are used.
+const (
+	TusMetadataFilename = "filename"
+	TusMetadataStatus   = "status"
+)
+
+var (
+	ErrTusMetadata          = errors.New("invalid upload metadata")
+	ErrTusChecksum          = errors.New("invalid upload checksum")
+	ErrTusChecksumAlgorithm = errors.New("unsupported checksum algorithm")
+)
+
+// TusUpload is a resumable upload of the material file. Parts are keys of
+// received parts in the storage in order of offsets.
+type TusUpload struct {
+	bun.BaseModel `bun:"table:tus_uploads,alias:tus_upload"`
+
+	ID         uuid.UUID         `bun:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	MiniAppID  uuid.UUID         `bun:"mini_app_id,type:uuid,notnull" json:"-"`
+	MaterialID uuid.UUID         `bun:"material_id,type:uuid,notnull" json:"material_id"`
+	Length     int64             `bun:"length,type:bigint,notnull" json:"length"`
+	Offset     int64             `bun:"offset,type:bigint,notnull,default:0" json:"offset"`
+	Parts      []string          `bun:"parts,type:text[],array,notnull" json:"-"`
+	Metadata   map[string]string `bun:"metadata,type:jsonb,notnull" json:"metadata"`
+
+	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
+	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
+}
+
+func NewTusUpload(miniAppID, materialID uuid.UUID, length int64, metadata map[string]string) *TusUpload {
+	now := time.Now().UTC()
+	return &TusUpload{
+		ID:         uuid.New(),
+		MiniAppID:  miniAppID,
+		MaterialID: materialID,
+		Length:     length,
+		Parts:      []string{},
+		Metadata:   metadata,
+		UpdatedAt:  now,
+		CreatedAt:  now,
+	}
+}
+
+func (u *TusUpload) IsComplete() bool {
+	return u.Offset == u.Length
+}
+
+func (u *TusUpload) ExpiresAt() time.Time {
+	return u.UpdatedAt.Add(TusUploadTTL)
+}
+
+// ParseTusMetadata parses the Upload-Metadata header. It is a comma separated
+// list of keys with base64 encoded values, value can be omitted.
+func ParseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		if key == "" {
+			return nil, ErrTusMetadata
+		}
+		if _, ok := metadata[key]; ok {
+			return nil, fmt.Errorf("%w: duplicated key %s", ErrTusMetadata, key)
+		}
+
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTusMetadata, err)
+		}
+
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+// EncodeTusMetadata returns the Upload-Metadata header, keys are sorted.
+func EncodeTusMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(metadata[key])))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// ParseTusChecksum parses the Upload-Checksum header. It returns the hash of
+// the algorithm and the expected sum.
+func ParseTusChecksum(header string) (hash.Hash, []byte, error) {
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, nil, ErrTusChecksum
+	}
+
+	var h hash.Hash
+	switch algorithm {
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return nil, nil, ErrTusChecksumAlgorithm
+	}
+
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sum) != h.Size() {
+		return nil, nil, ErrTusChecksum
+	}
+
+	return h, sum, nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	metadata, err := ParseTusMetadata("filename bGVzc29uLm1wNA==,status cmVhZHk=, is_confidential")
+	if err != nil {
+		t.Fatalf("ParseTusMetadata() error = %v", err)
+	}
+
+	want := map[string]string{
+		"filename":        "lesson.mp4",
+		"status":          "ready",
+		"is_confidential": "",
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("ParseTusMetadata() = %v, want %v", metadata, want)
+	}
+
+	if got := EncodeTusMetadata(map[string]string{"status": "ready", "filename": "lesson.mp4"}); got !=
+		"filename bGVzc29uLm1wNA==,status cmVhZHk=" {
+
+		t.Errorf("EncodeTusMetadata() = %s", got)
+	}
+
+	for _, header := range []string{"filename bad!", "filename YQ==,filename YQ=="} {
+		if _, err := ParseTusMetadata(header); !errors.Is(err, ErrTusMetadata) {
+			t.Errorf("ParseTusMetadata(%q) error = %v, want %v", header, err, ErrTusMetadata)
+		}
+	}
+}
+
+func TestParseTusChecksum(t *testing.T) {
+	// SHA-1 of "hello".
+	h, sum, err := ParseTusChecksum("sha1 qvTGHdzF6KLavt4PO0gs2a6pQ00=")
+	if err != nil {
+		t.Fatalf("ParseTusChecksum() error = %v", err)
+	}
+	h.Write([]byte("hello"))
+	if !bytes.Equal(h.Sum(nil), sum) {
+		t.Errorf("ParseTusChecksum() sum does not match")
+	}
+
+	tests := []struct {
+		header string
+		want   error
+	}{
+		{header: "crc32 AAAAAA==", want: ErrTusChecksumAlgorithm},
+		{header: "sha1", want: ErrTusChecksum},
+		{header: "sha1 YQ==", want: ErrTusChecksum},
+	}
+	for _, tt := range tests {
+		if _, _, err := ParseTusChecksum(tt.header); !errors.Is(err, tt.want) {
+			t.Errorf("ParseTusChecksum(%q) error = %v, want %v", tt.header, err, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -35,6 +36,14 @@ type User struct {
 	ColorTheme       json.RawMessage `bun:"color_theme,type:jsonb,notnull" json:"color_theme"`
 	IsActive         bool            `bun:"is_active,type:boolean,notnull" json:"is_active"`
 
+	HideFromLeaderboard bool `bun:"hide_from_leaderboard,type:boolean,notnull" json:"hide_from_leaderboard"`
+
+	// MutedNotifications are categories that the student opted out of.
+	MutedNotifications []NotificationCategory `bun:"muted_notifications,array,type:notification_category[],notnull" json:"muted_notifications"`
+
+	// CalendarToken authorizes access to the user's calendar feed.
+	CalendarToken uuid.UUID `bun:"calendar_token,type:uuid,nullzero" json:"-"`
+
 	UpdatedAt time.Time `bun:"updated_at,type:timestamptz,notnull,default:current_timestamp" json:"updated_at"`
 	CreatedAt time.Time `bun:"created_at,type:timestamptz,notnull,default:current_timestamp" json:"created_at"`
 
@@ -44,9 +53,10 @@ type User struct {
 func NewUser() *User {
 	now := time.Now().UTC()
 	return &User{
-		ID:        uuid.New(),
-		UpdatedAt: now,
-		CreatedAt: now,
+		ID:                 uuid.New(),
+		MutedNotifications: []NotificationCategory{},
+		UpdatedAt:          now,
+		CreatedAt:          now,
 	}
 }
 
@@ -125,6 +135,24 @@ func (r *SignInRequest) Validate() error {
 	return nil
 }
 
+// SignInWithWidgetRequest is a sign in outside of Telegram. AuthData is data
+// of the Telegram Login Widget of the mini-app bot as a query string.
+type SignInWithWidgetRequest struct {
+	MiniAppName string `json:"mini_app_name"`
+	AuthData    string `json:"auth_data"`
+}
+
+func (r *SignInWithWidgetRequest) Validate() error {
+	if r.MiniAppName == "" {
+		return fmt.Errorf("invalid mini-app name")
+	}
+	if r.AuthData == "" {
+		return fmt.Errorf("invalid auth_data")
+	}
+
+	return nil
+}
+
 type SignInWithInviteRequest struct {
 	MiniAppName string    `json:"mini_app_name"`
 	InitData    string    `json:"init_data"`
@@ -174,6 +202,10 @@ type EditUserRequest struct {
 	Language     string          `json:"language"`
 	ColorTheme   json.RawMessage `json:"color_theme"`
 	DeleteAvatar bool            `json:"delete_avatar"`
+
+	HideFromLeaderboard *bool `json:"hide_from_leaderboard"`
+	// MutedNotifications are kept if not provided.
+	MutedNotifications []NotificationCategory `json:"muted_notifications"`
 }
 
 func (r *EditUserRequest) UpdateUser(u *User) (bool, error) {
@@ -195,6 +227,20 @@ func (r *EditUserRequest) UpdateUser(u *User) (bool, error) {
 		u.ColorTheme = r.ColorTheme
 		isChanged = true
 	}
+	if r.HideFromLeaderboard != nil && *r.HideFromLeaderboard != u.HideFromLeaderboard {
+		u.HideFromLeaderboard = *r.HideFromLeaderboard
+		isChanged = true
+	}
+	if r.MutedNotifications != nil && !slices.Equal(r.MutedNotifications, u.MutedNotifications) {
+		for _, c := range r.MutedNotifications {
+			if err := c.Validate(); err != nil {
+				return false, err
+			}
+		}
+
+		u.MutedNotifications = r.MutedNotifications
+		isChanged = true
+	}
 
 	u.UpdatedAt = time.Now().UTC()
 
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"academy/internal/model"
+	"academy/internal/storage/cache"
+	"academy/internal/storage/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAPIKeyInvalid     = errors.New("api key is invalid")
+	ErrAPIKeyRateLimited = errors.New("api key rate limit exceeded")
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+)
+
+const (
+	apiKeyRateWindow = time.Minute
+	// apiKeyTouchInterval is how often last used time of the key is updated.
+	apiKeyTouchInterval = time.Minute
+)
+
+type APIKeyService struct {
+	apiKeyRepository *repository.APIKeyRepository
+	requestLimiter   *cache.RequestLimiter
+}
+
+func NewAPIKeyService(
+	apiKeyRepository *repository.APIKeyRepository,
+	requestLimiter *cache.RequestLimiter,
+) *APIKeyService {
+
+	return &APIKeyService{
+		apiKeyRepository: apiKeyRepository,
+		requestLimiter:   requestLimiter,
+	}
+}
+
+// Create creates the key of the owner and returns its token, only the hash
+// of the token is stored.
+func (s *APIKeyService) Create(
+	ctx context.Context,
+	miniAppID, userID uuid.UUID,
+	req *model.CreateAPIKeyRequest,
+) (*model.APIKey, string, error) {
+
+	key, token, err := model.NewAPIKey(miniAppID, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	req.UpdateAPIKey(key)
+
+	if err := s.apiKeyRepository.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return key, token, nil
+}
+
+// Authenticate returns the active key of the token and counts the request
+// against the rate limit of the key.
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*model.APIKey, error) {
+	if !strings.HasPrefix(token, model.APIKeyTokenPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	key, err := s.apiKeyRepository.GetByHash(ctx, model.HashAPIKey(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if key == nil || !key.IsActive(now) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	ok, err := s.requestLimiter.Allow(ctx, "api_key:"+key.ID.String(), key.RateLimit, apiKeyRateWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	if !ok {
+		return nil, ErrAPIKeyRateLimited
+	}
+
+	err = s.apiKeyRepository.TouchLastUsed(ctx, key.ID, now, apiKeyTouchInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update api key last used time: %w", err)
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyService) MiniAppKeys(ctx context.Context, miniAppID uuid.UUID) ([]*model.APIKey, error) {
+	keys, err := s.apiKeyRepository.MiniAppKeys(ctx, miniAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, miniAppID, id uuid.UUID) error {
+	ok, err := s.apiKeyRepository.Revoke(ctx, miniAppID, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
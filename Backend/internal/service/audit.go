@@ -0,0 +1,134 @@
+package service
+
+import (
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// auditExportLimit is a max number of records in the exported file.
+const auditExportLimit = 10000
+
+type AuditService struct {
+	auditRepository *repository.AuditRepository
+}
+
+func NewAuditService(auditRepository *repository.AuditRepository) *AuditService {
+	return &AuditService{
+		auditRepository: auditRepository,
+	}
+}
+
+// Snapshot returns the current state of the entity to compare it after the
+// action, nil is returned for entities without state.
+func (s *AuditService) Snapshot(ctx context.Context, entityType string, id uuid.UUID) (map[string]any, error) {
+	snapshot, err := s.auditRepository.Snapshot(ctx, entityType, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v snapshot: %w", entityType, err)
+	}
+
+	return snapshot, nil
+}
+
+func (s *AuditService) Record(ctx context.Context, log *model.AuditLog) error {
+	if err := s.auditRepository.Create(ctx, log); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuditService) Find(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterAuditLogRequest,
+) ([]*model.AuditLog, int, error) {
+
+	logs, total, err := s.auditRepository.Find(ctx, miniAppID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find audit log: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+// Export returns the filtered audit log as Excel file, changes and params
+// are written as JSON.
+func (s *AuditService) Export(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterAuditLogRequest,
+) ([]byte, error) {
+
+	filter.Limit = auditExportLimit
+	filter.Offset = 0
+
+	logs, _, err := s.auditRepository.Find(ctx, miniAppID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit log: %w", err)
+	}
+
+	if len(logs) == 0 {
+		return nil, ErrNoData
+	}
+
+	f := excelize.NewFile()
+
+	headers := []string{
+		"Time", "Actor Telegram Username", "Actor Name", "Actor Role",
+		"Action", "Entity", "Entity ID", "Changes", "Params", "IP",
+	}
+
+	cell, _ := excelize.CoordinatesToCellName(1, 1)
+	err = f.SetSheetRow("Sheet1", cell, &headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set headers: %w", err)
+	}
+	for i, l := range logs {
+		var username, name string
+		if l.Actor != nil {
+			username = l.Actor.TelegramUsername
+			name = l.Actor.FirstName + " " + l.Actor.LastName
+		}
+
+		var entityID string
+		if l.EntityID != uuid.Nil {
+			entityID = l.EntityID.String()
+		}
+
+		changes, _ := json.Marshal(l.Changes)
+		params, _ := json.Marshal(l.Params)
+
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		logRow := []any{
+			l.CreatedAt.UTC().Format(time.DateTime),
+			username,
+			name,
+			l.ActorRole,
+
+			l.Action,
+			l.EntityType,
+			entityID,
+			string(changes),
+			string(params),
+			l.IP,
+		}
+		err = f.SetSheetRow("Sheet1", cell, &logRow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set audit log %v: %w", l.ID.String(), err)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("could not write excel to buffer: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type BotService struct {
+	botRepository *repository.BotRepository
+}
+
+func NewBotService(
+	botRepository *repository.BotRepository,
+) *BotService {
+
+	return &BotService{
+		botRepository: botRepository,
+	}
+}
+
+// Settings returns bot settings of the mini-app. Empty settings are returned
+// if the mini-app has not configured the bot yet.
+func (s *BotService) Settings(ctx context.Context, miniAppID uuid.UUID) (*model.BotSettings, error) {
+	settings, err := s.botRepository.Settings(ctx, miniAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bot settings: %w", err)
+	}
+
+	if settings == nil {
+		return model.NewBotSettings(miniAppID), nil
+	}
+
+	return settings, nil
+}
+
+func (s *BotService) SaveSettings(ctx context.Context, settings *model.BotSettings) error {
+	if err := s.botRepository.SaveSettings(ctx, settings); err != nil {
+		return fmt.Errorf("failed to save bot settings: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BotService) ActiveBots(ctx context.Context) ([]*model.ActiveBot, error) {
+	bots, err := s.botRepository.ActiveBots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active bots: %w", err)
+	}
+
+	return bots, nil
+}
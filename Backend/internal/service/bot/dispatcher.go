@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+// Context is an update received by the mini-app bot.
+type Context struct {
+	MiniAppID uuid.UUID
+	API       *tgbotapi.BotAPI
+	Update    *tgbotapi.Update
+
+	// Payload is a command argument or a callback data after the name.
+	Payload string
+}
+
+type HandlerFunc func(ctx context.Context, c *Context) error
+
+// Dispatcher routes updates to handlers. Callback data is expected to be in
+// the "name:payload" format.
+type Dispatcher struct {
+	commands    map[string]HandlerFunc
+	callbacks   map[string]HandlerFunc
+	preCheckout HandlerFunc
+	payment     HandlerFunc
+	message     HandlerFunc
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		commands:  make(map[string]HandlerFunc),
+		callbacks: make(map[string]HandlerFunc),
+	}
+}
+
+func (d *Dispatcher) Command(name string, h HandlerFunc) {
+	d.commands[name] = h
+}
+
+func (d *Dispatcher) Callback(name string, h HandlerFunc) {
+	d.callbacks[name] = h
+}
+
+func (d *Dispatcher) PreCheckout(h HandlerFunc) {
+	d.preCheckout = h
+}
+
+func (d *Dispatcher) Payment(h HandlerFunc) {
+	d.payment = h
+}
+
+// Message handles messages that are not commands.
+func (d *Dispatcher) Message(h HandlerFunc) {
+	d.message = h
+}
+
+// Dispatch calls handler of the update. Updates without handlers are ignored.
+func (d *Dispatcher) Dispatch(ctx context.Context, c *Context) error {
+	h := d.route(c)
+	if h == nil {
+		return nil
+	}
+
+	return h(ctx, c)
+}
+
+func (d *Dispatcher) route(c *Context) HandlerFunc {
+	u := c.Update
+
+	switch {
+	case u.PreCheckoutQuery != nil:
+		return d.preCheckout
+
+	case u.CallbackQuery != nil:
+		name, payload, _ := strings.Cut(u.CallbackQuery.Data, ":")
+		c.Payload = payload
+		return d.callbacks[name]
+
+	case u.Message != nil && u.Message.SuccessfulPayment != nil:
+		return d.payment
+
+	case u.Message != nil && u.Message.IsCommand():
+		c.Payload = u.Message.CommandArguments()
+		return d.commands[u.Message.Command()]
+
+	case u.Message != nil:
+		return d.message
+	}
+
+	return nil
+}
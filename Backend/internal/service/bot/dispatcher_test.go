@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestDispatch(t *testing.T) {
+	var called string
+	handler := func(name string) HandlerFunc {
+		return func(ctx context.Context, c *Context) error {
+			called = name + "|" + c.Payload
+			return nil
+		}
+	}
+
+	d := NewDispatcher()
+	d.Command("start", handler("start"))
+	d.Callback("checkin", handler("checkin"))
+	d.PreCheckout(handler("pre_checkout"))
+	d.Payment(handler("payment"))
+	d.Message(handler("message"))
+
+	command := func(text string) *tgbotapi.Message {
+		return &tgbotapi.Message{
+			Text: text,
+			Entities: []tgbotapi.MessageEntity{
+				{Type: "bot_command", Offset: 0, Length: len(text)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		update tgbotapi.Update
+		want   string
+	}{
+		{
+			name:   "Command",
+			update: tgbotapi.Update{Message: command("/start")},
+			want:   "start|",
+		},
+		{
+			name: "Command with payload",
+			update: tgbotapi.Update{Message: &tgbotapi.Message{
+				Text:     "/start ref",
+				Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Length: 6}},
+			}},
+			want: "start|ref",
+		},
+		{
+			name:   "Unknown command",
+			update: tgbotapi.Update{Message: command("/help")},
+			want:   "",
+		},
+		{
+			name:   "Text message",
+			update: tgbotapi.Update{Message: &tgbotapi.Message{Text: "hello"}},
+			want:   "message|",
+		},
+		{
+			name:   "Callback",
+			update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "checkin:42"}},
+			want:   "checkin|42",
+		},
+		{
+			name:   "Unknown callback",
+			update: tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "vote:1"}},
+			want:   "",
+		},
+		{
+			name:   "Pre-checkout",
+			update: tgbotapi.Update{PreCheckoutQuery: &tgbotapi.PreCheckoutQuery{}},
+			want:   "pre_checkout|",
+		},
+		{
+			name: "Payment",
+			update: tgbotapi.Update{Message: &tgbotapi.Message{
+				SuccessfulPayment: &tgbotapi.SuccessfulPayment{},
+			}},
+			want: "payment|",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = ""
+
+			err := d.Dispatch(context.Background(), &Context{Update: &tt.update})
+			if err != nil {
+				t.Fatalf("Dispatch() error = %v", err)
+			}
+
+			if called != tt.want {
+				t.Errorf("called = %q, want %q", called, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package bot
+
+import (
+	"academy/internal/model"
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWelcomeMessage = "Welcome to %s!"
+	defaultButtonText     = "Open"
+)
+
+const callbackCheckIn = "checkin"
+
+// CheckInCallbackData returns callback data of the button that checks the
+// student in to the event.
+func CheckInCallbackData(lessonID uuid.UUID) string {
+	return callbackCheckIn + ":" + lessonID.String()
+}
+
+// handleStart sends welcome message configured by the mini-app owner. Buttons
+// without links open the mini-app.
+func (m *Manager) handleStart(ctx context.Context, c *Context) error {
+	b := m.activeBot(c.MiniAppID)
+	if b == nil {
+		return nil
+	}
+
+	settings, err := m.botService.Settings(ctx, c.MiniAppID)
+	if err != nil {
+		return fmt.Errorf("failed to get bot settings: %w", err)
+	}
+
+	text := settings.WelcomeMessage
+	if text == "" {
+		text = fmt.Sprintf(defaultWelcomeMessage, b.Name)
+	}
+
+	buttons := settings.Buttons
+	if len(buttons) == 0 {
+		buttons = []*model.BotButton{{Text: defaultButtonText}}
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(buttons))
+	for _, button := range buttons {
+		link := button.URL
+		if link == "" {
+			link = b.URL
+		}
+		if link == "" {
+			continue
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(button.Text, link),
+		))
+	}
+
+	chatID := c.Update.Message.Chat.ID
+
+	var msg tgbotapi.Chattable
+	if settings.WelcomeImage != "" {
+		image, err := m.uploadService.ReadFile(ctx, settings.WelcomeImage)
+		if err != nil {
+			return fmt.Errorf("failed to read welcome image: %w", err)
+		}
+
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+			Name:  filepath.Base(settings.WelcomeImage),
+			Bytes: image,
+		})
+		photo.Caption = text
+		if len(rows) != 0 {
+			photo.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		}
+		msg = photo
+	} else {
+		message := tgbotapi.NewMessage(chatID, text)
+		if len(rows) != 0 {
+			message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		}
+		msg = message
+	}
+
+	if _, err := c.API.Send(msg); err != nil {
+		return fmt.Errorf("failed to send welcome message: %w", err)
+	}
+
+	return nil
+}
+
+// handleCheckIn checks the student in to the event from the reminder button.
+// Check-in is open at the same time as the meeting link.
+func (m *Manager) handleCheckIn(ctx context.Context, c *Context) error {
+	query := c.Update.CallbackQuery
+
+	text, err := m.checkIn(ctx, c.MiniAppID, query.From.ID, c.Payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.API.Request(tgbotapi.NewCallback(query.ID, text)); err != nil {
+		return fmt.Errorf("failed to answer callback: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) checkIn(ctx context.Context, miniAppID uuid.UUID, telegramID int64, payload string) (string, error) {
+	lessonID, err := uuid.Parse(payload)
+	if err != nil {
+		return "Event not found.", nil
+	}
+
+	user, err := m.userService.GetByTelegramID(ctx, telegramID, miniAppID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return "Open the app to sign in first.", nil
+	}
+
+	now := time.Now()
+
+	events, err := m.lessonService.UserEvents(ctx, user.ID, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user events: %w", err)
+	}
+
+	var event *model.CalendarEvent
+	for _, e := range events {
+		if e.LessonID == lessonID {
+			event = e
+			break
+		}
+	}
+	if event == nil {
+		return "Event is over or not available.", nil
+	}
+
+	if now.Before(event.EventStart.Add(-model.MeetingURLRevealTime)) {
+		return fmt.Sprintf("Check-in opens %d minutes before the event.",
+			int(model.MeetingURLRevealTime.Minutes())), nil
+	}
+
+	attendance := model.NewEventAttendance(lessonID, user.ID, model.AttendanceSourceBot)
+	if err := m.lessonService.CheckIn(ctx, attendance); err != nil {
+		return "", fmt.Errorf("failed to check in: %w", err)
+	}
+
+	return "You are checked in.", nil
+}
+
+// handleSupportMessage adds message of the student to the support ticket.
+// Staff replies are sent back to the same chat.
+func (m *Manager) handleSupportMessage(ctx context.Context, c *Context) error {
+	msg := c.Update.Message
+	if msg.From == nil || !msg.Chat.IsPrivate() {
+		return nil
+	}
+
+	text, err := m.supportMessage(ctx, c.MiniAppID, msg)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return nil
+	}
+
+	if _, err := c.API.Send(tgbotapi.NewMessage(msg.Chat.ID, text)); err != nil {
+		return fmt.Errorf("failed to answer support message: %w", err)
+	}
+
+	return nil
+}
+
+// supportMessage saves the message and returns answer to the student. Answer
+// is empty for messages of the ongoing conversation.
+func (m *Manager) supportMessage(ctx context.Context, miniAppID uuid.UUID, msg *tgbotapi.Message) (string, error) {
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	if text == "" {
+		return "Only text messages are delivered to support.", nil
+	}
+
+	user, err := m.userService.GetByTelegramID(ctx, msg.From.ID, miniAppID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return "Open the app to sign in first.", nil
+	}
+
+	_, isNew, err := m.supportService.AddStudentMessage(ctx, miniAppID, user.ID, text, msg.MessageID)
+	if err != nil {
+		return "", fmt.Errorf("failed to add support message: %w", err)
+	}
+	if !isNew {
+		return "", nil
+	}
+
+	return "Your message is sent to support. The reply will come to this chat.", nil
+}
+
+// handlePreCheckout declines payments since mini-app products are not sold
+// with bot invoices. Telegram requires answer to every pre-checkout query.
+func (m *Manager) handlePreCheckout(ctx context.Context, c *Context) error {
+	_, err := c.API.Request(tgbotapi.PreCheckoutConfig{
+		PreCheckoutQueryID: c.Update.PreCheckoutQuery.ID,
+		OK:                 false,
+		ErrorMessage:       "Payments are accepted in the app.",
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to answer pre-checkout query: %w", err)
+	}
+
+	return nil
+}
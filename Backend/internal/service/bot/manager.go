@@ -0,0 +1,368 @@
+package bot
+
+import (
+	"academy/internal/config"
+	"academy/internal/model"
+	"academy/internal/service"
+	"academy/internal/service/security"
+	"academy/internal/service/upload"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const pollingTimeout = 60
+
+var allowedUpdates = []string{"message", "callback_query", "pre_checkout_query"}
+
+var (
+	ErrInvalidSecret = errors.New("invalid webhook secret")
+	ErrBotNotRunning = errors.New("bot is not running")
+)
+
+type runner struct {
+	api *tgbotapi.BotAPI
+	bot *model.ActiveBot
+}
+
+// Manager runs bots of active mini-apps. Bots receive updates with webhooks
+// if webhook URL is configured, otherwise long polling is used.
+type Manager struct {
+	logger *zap.Logger
+
+	webhookURL string
+	secretKey  []byte
+
+	dispatcher *Dispatcher
+
+	botService      *service.BotService
+	userService     *service.UserService
+	lessonService   *service.LessonService
+	supportService  *service.SupportService
+	securityService *security.Service
+	uploadService   *upload.Service
+
+	syncMutex sync.Mutex
+	mu        sync.RWMutex
+	runners   map[uuid.UUID]*runner
+	// failed keeps encrypted tokens that bots failed to start with, so they
+	// are not retried until the token is changed.
+	failed map[uuid.UUID]string
+}
+
+func NewManager(
+	cfg *config.Config,
+	logger *zap.Logger,
+
+	botService *service.BotService,
+	userService *service.UserService,
+	lessonService *service.LessonService,
+	supportService *service.SupportService,
+	securityService *security.Service,
+	uploadService *upload.Service,
+) *Manager {
+
+	m := &Manager{
+		logger: logger,
+
+		webhookURL: cfg.App.TelegramWebhookURL,
+		secretKey:  []byte(cfg.Auth.EncryptionKey),
+
+		dispatcher: NewDispatcher(),
+
+		botService:      botService,
+		userService:     userService,
+		lessonService:   lessonService,
+		supportService:  supportService,
+		securityService: securityService,
+		uploadService:   uploadService,
+
+		runners: make(map[uuid.UUID]*runner),
+		failed:  make(map[uuid.UUID]string),
+	}
+
+	m.dispatcher.Command("start", m.handleStart)
+	m.dispatcher.Callback(callbackCheckIn, m.handleCheckIn)
+	m.dispatcher.PreCheckout(m.handlePreCheckout)
+	m.dispatcher.Message(m.handleSupportMessage)
+
+	return m
+}
+
+func (m *Manager) start(_ context.Context) error {
+	go func() {
+		if err := m.Sync(context.Background()); err != nil {
+			m.logger.Error("failed to sync bots", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// stop stops receiving updates. Webhooks are kept, so updates are delivered
+// once the app is started again.
+func (m *Manager) stop(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, r := range m.runners {
+		if m.webhookURL == "" {
+			r.api.StopReceivingUpdates()
+		}
+		delete(m.runners, id)
+	}
+
+	return nil
+}
+
+// Sync starts bots of active mini-apps and stops the rest. Bots are restarted
+// when the mini-app token is changed.
+func (m *Manager) Sync(ctx context.Context) error {
+	m.syncMutex.Lock()
+	defer m.syncMutex.Unlock()
+
+	bots, err := m.botService.ActiveBots(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get active bots: %w", err)
+	}
+
+	active := make(map[uuid.UUID]struct{}, len(bots))
+	for _, b := range bots {
+		active[b.MiniAppID] = struct{}{}
+
+		m.mu.Lock()
+		r, failedToken := m.runners[b.MiniAppID], m.failed[b.MiniAppID]
+		isRunning := r != nil && r.bot.BotToken == b.BotToken
+		if isRunning {
+			r.bot = b
+		}
+		m.mu.Unlock()
+
+		if isRunning || (r == nil && failedToken == b.BotToken) {
+			continue
+		}
+
+		if r != nil {
+			m.stopRunner(r)
+		}
+
+		if err := m.startRunner(ctx, b); err != nil {
+			m.logger.Error("failed to start bot",
+				zap.String("mini_app_id", b.MiniAppID.String()),
+				zap.Error(err),
+			)
+
+			m.mu.Lock()
+			delete(m.runners, b.MiniAppID)
+			m.failed[b.MiniAppID] = b.BotToken
+			m.mu.Unlock()
+		}
+	}
+
+	m.mu.Lock()
+	stopped := make([]*runner, 0)
+	for id, r := range m.runners {
+		if _, ok := active[id]; !ok {
+			stopped = append(stopped, r)
+			delete(m.runners, id)
+		}
+	}
+	for id := range m.failed {
+		if _, ok := active[id]; !ok {
+			delete(m.failed, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, r := range stopped {
+		m.stopRunner(r)
+	}
+
+	return nil
+}
+
+func (m *Manager) startRunner(ctx context.Context, b *model.ActiveBot) error {
+	token, err := m.securityService.DecryptString(b.BotToken)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt bot token: %w", err)
+	}
+
+	api, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return fmt.Errorf("failed to create bot: %w", err)
+	}
+
+	r := &runner{api: api, bot: b}
+
+	if m.webhookURL != "" {
+		if err := m.setWebhook(r); err != nil {
+			return err
+		}
+	} else {
+		if _, err := api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+
+		updateConfig := tgbotapi.NewUpdate(0)
+		updateConfig.Timeout = pollingTimeout
+		updateConfig.AllowedUpdates = allowedUpdates
+
+		updates := api.GetUpdatesChan(updateConfig)
+		go func() {
+			for update := range updates {
+				m.dispatch(context.Background(), b.MiniAppID, api, &update)
+			}
+		}()
+	}
+
+	m.mu.Lock()
+	m.runners[b.MiniAppID] = r
+	delete(m.failed, b.MiniAppID)
+	m.mu.Unlock()
+
+	m.logger.Info("started bot",
+		zap.String("mini_app_id", b.MiniAppID.String()),
+		zap.Int64("bot_id", api.Self.ID),
+	)
+
+	return nil
+}
+
+// stopRunner stops receiving updates by the bot of deactivated mini-app or
+// the one which token is replaced.
+func (m *Manager) stopRunner(r *runner) {
+	if m.webhookURL == "" {
+		r.api.StopReceivingUpdates()
+	} else if _, err := r.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		m.logger.Error("failed to delete webhook",
+			zap.String("mini_app_id", r.bot.MiniAppID.String()),
+			zap.Error(err),
+		)
+	}
+
+	m.logger.Info("stopped bot", zap.String("mini_app_id", r.bot.MiniAppID.String()))
+}
+
+func (m *Manager) setWebhook(r *runner) error {
+	link, err := url.JoinPath(m.webhookURL, "v1", "bot", r.bot.MiniAppID.String(), "webhook")
+	if err != nil {
+		return fmt.Errorf("url.JoinPath: %w", err)
+	}
+
+	rawAllowedUpdates, err := json.Marshal(allowedUpdates)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	params := tgbotapi.Params{
+		"url":             link,
+		"secret_token":    m.webhookSecret(r.bot.MiniAppID),
+		"allowed_updates": string(rawAllowedUpdates),
+	}
+
+	if _, err := r.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	return nil
+}
+
+// webhookSecret returns secret token that Telegram sends with webhooks of the
+// mini-app bot.
+func (m *Manager) webhookSecret(miniAppID uuid.UUID) string {
+	mac := hmac.New(sha256.New, m.secretKey)
+	mac.Write(miniAppID[:])
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HandleWebhook dispatches update received with the webhook of the mini-app
+// bot.
+func (m *Manager) HandleWebhook(ctx context.Context, miniAppID uuid.UUID, secret string, body []byte) error {
+	if !hmac.Equal([]byte(secret), []byte(m.webhookSecret(miniAppID))) {
+		return ErrInvalidSecret
+	}
+
+	m.mu.RLock()
+	r := m.runners[miniAppID]
+	m.mu.RUnlock()
+
+	if r == nil {
+		return ErrBotNotRunning
+	}
+
+	var update tgbotapi.Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		return fmt.Errorf("failed to decode update: %w", err)
+	}
+
+	m.dispatch(ctx, miniAppID, r.api, &update)
+
+	return nil
+}
+
+// dispatch handles the update, errors are only logged since updates are not
+// redelivered.
+func (m *Manager) dispatch(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	api *tgbotapi.BotAPI,
+	update *tgbotapi.Update,
+) {
+
+	err := m.dispatcher.Dispatch(ctx, &Context{
+		MiniAppID: miniAppID,
+		API:       api,
+		Update:    update,
+	})
+
+	if err != nil {
+		m.logger.Error("failed to handle bot update",
+			zap.String("mini_app_id", miniAppID.String()),
+			zap.Int("update_id", update.UpdateID),
+			zap.Error(err),
+		)
+	}
+}
+
+// SendMessage sends text message to the chat with the running bot of the
+// mini-app. It returns ID of the sent message.
+func (m *Manager) SendMessage(miniAppID uuid.UUID, chatID int64, text string) (int, error) {
+	m.mu.RLock()
+	r := m.runners[miniAppID]
+	m.mu.RUnlock()
+
+	if r == nil {
+		return 0, ErrBotNotRunning
+	}
+
+	msg, err := r.api.Send(tgbotapi.NewMessage(chatID, text))
+	if err != nil {
+		return 0, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return msg.MessageID, nil
+}
+
+// activeBot returns mini-app of the running bot or nil if it is stopped.
+func (m *Manager) activeBot(miniAppID uuid.UUID) *model.ActiveBot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r := m.runners[miniAppID]
+	if r == nil {
+		return nil
+	}
+
+	return r.bot
+}
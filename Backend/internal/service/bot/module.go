@@ -0,0 +1,19 @@
+package bot
+
+import (
+	"go.uber.org/fx"
+)
+
+func Module() fx.Option {
+	return fx.Module("bot",
+		fx.Provide(NewManager),
+		fx.Invoke(
+			func(lc fx.Lifecycle, m *Manager) {
+				lc.Append(fx.Hook{
+					OnStart: m.start,
+					OnStop:  m.stop,
+				})
+			},
+		),
+	)
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BroadcastService struct {
+	broadcastRepository *repository.BroadcastRepository
+	transactionManager  *repo.TransactionManager
+}
+
+func NewBroadcastService(
+	broadcastRepository *repository.BroadcastRepository,
+	transactionManager *repo.TransactionManager,
+) *BroadcastService {
+
+	return &BroadcastService{
+		broadcastRepository: broadcastRepository,
+		transactionManager:  transactionManager,
+	}
+}
+
+func (s *BroadcastService) Create(ctx context.Context, broadcast *model.Broadcast) error {
+	if err := s.broadcastRepository.Create(ctx, broadcast); err != nil {
+		return fmt.Errorf("failed to create broadcast: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns broadcast with delivery stats.
+func (s *BroadcastService) GetByID(ctx context.Context, id uuid.UUID) (*model.Broadcast, error) {
+	broadcast, err := s.broadcastRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcast: %w", err)
+	}
+
+	if err := s.fillStats(ctx, []*model.Broadcast{broadcast}); err != nil {
+		return nil, err
+	}
+
+	return broadcast, nil
+}
+
+func (s *BroadcastService) Find(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterBroadcastsRequest,
+) ([]*model.Broadcast, int, error) {
+
+	broadcasts, total, err := s.broadcastRepository.Find(ctx, miniAppID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find broadcasts: %w", err)
+	}
+
+	if err := s.fillStats(ctx, broadcasts); err != nil {
+		return nil, 0, err
+	}
+
+	return broadcasts, total, nil
+}
+
+func (s *BroadcastService) fillStats(ctx context.Context, broadcasts []*model.Broadcast) error {
+	if len(broadcasts) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(broadcasts))
+	for _, b := range broadcasts {
+		ids = append(ids, b.ID)
+	}
+
+	stats, err := s.broadcastRepository.Stats(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get broadcast stats: %w", err)
+	}
+
+	for _, b := range broadcasts {
+		b.Stats = stats[b.ID]
+		if b.Stats == nil {
+			b.Stats = &model.BroadcastStats{}
+		}
+	}
+
+	return nil
+}
+
+func (s *BroadcastService) CountSegment(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	segment *model.BroadcastSegment,
+) (int, error) {
+
+	count, err := s.broadcastRepository.CountSegment(ctx, miniAppID, segment)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count segment: %w", err)
+	}
+
+	return count, nil
+}
+
+// Cancel stops sending of the broadcast. It reports whether the broadcast is
+// cancelled, completed broadcasts are not.
+func (s *BroadcastService) Cancel(ctx context.Context, broadcast *model.Broadcast) (bool, error) {
+	broadcast.Status = model.BroadcastStatusCancelled
+	broadcast.UpdatedAt = time.Now().UTC()
+
+	ok, err := s.broadcastRepository.UpdateStatus(ctx, broadcast,
+		model.BroadcastStatusScheduled, model.BroadcastStatusSending)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel broadcast: %w", err)
+	}
+
+	return ok, nil
+}
+
+// StartDue selects recipients of scheduled broadcasts which sending time has
+// come. Segments are evaluated at this moment, so students who joined after
+// scheduling get the broadcast too.
+func (s *BroadcastService) StartDue(ctx context.Context, now time.Time) error {
+	broadcasts, err := s.broadcastRepository.Due(ctx, now, 100)
+	if err != nil {
+		return fmt.Errorf("failed to get due broadcasts: %w", err)
+	}
+
+	for _, b := range broadcasts {
+		err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+			startedAt := now.UTC()
+			b.Status = model.BroadcastStatusSending
+			b.StartedAt = &startedAt
+			b.UpdatedAt = startedAt
+
+			ok, err := s.broadcastRepository.WithTx(tx).UpdateStatus(ctx, b, model.BroadcastStatusScheduled)
+			if err != nil {
+				return fmt.Errorf("failed to update broadcast status: %w", err)
+			}
+			if !ok {
+				return nil
+			}
+
+			if err := s.broadcastRepository.WithTx(tx).CreateRecipients(ctx, b); err != nil {
+				return fmt.Errorf("failed to create recipients: %w", err)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BroadcastService) Sending(ctx context.Context) ([]*model.Broadcast, error) {
+	broadcasts, err := s.broadcastRepository.Sending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sending broadcasts: %w", err)
+	}
+
+	return broadcasts, nil
+}
+
+func (s *BroadcastService) PendingRecipients(
+	ctx context.Context,
+	broadcastID uuid.UUID,
+	limit int,
+) ([]*model.PendingBroadcastRecipient, error) {
+
+	recipients, err := s.broadcastRepository.PendingRecipients(ctx, broadcastID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending recipients: %w", err)
+	}
+
+	return recipients, nil
+}
+
+func (s *BroadcastService) UpdateRecipient(ctx context.Context, recipient *model.BroadcastRecipient) error {
+	if err := s.broadcastRepository.UpdateRecipient(ctx, recipient); err != nil {
+		return fmt.Errorf("failed to update recipient: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BroadcastService) FailPendingRecipients(ctx context.Context, broadcastID uuid.UUID, reason string) error {
+	if err := s.broadcastRepository.FailPendingRecipients(ctx, broadcastID, reason); err != nil {
+		return fmt.Errorf("failed to fail pending recipients: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BroadcastService) Complete(ctx context.Context, broadcast *model.Broadcast) error {
+	now := time.Now().UTC()
+	broadcast.Status = model.BroadcastStatusCompleted
+	broadcast.CompletedAt = &now
+	broadcast.UpdatedAt = now
+
+	if _, err := s.broadcastRepository.UpdateStatus(ctx, broadcast, model.BroadcastStatusSending); err != nil {
+		return fmt.Errorf("failed to complete broadcast: %w", err)
+	}
+
+	return nil
+}
+
+func (s *BroadcastService) Recipients(
+	ctx context.Context,
+	broadcastID uuid.UUID,
+	filter *model.FilterBroadcastRecipientsRequest,
+) ([]*model.BroadcastRecipient, int, error) {
+
+	recipients, total, err := s.broadcastRepository.Recipients(ctx, broadcastID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get recipients: %w", err)
+	}
+
+	return recipients, total, nil
+}
@@ -0,0 +1,86 @@
+// Package calendar generates iCalendar (RFC 5545) feeds of event lessons.
+package calendar
+
+import (
+	"academy/internal/model"
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	prodID        = "-//Academy//Events//EN"
+	uidDomain     = "academy"
+	timeFormat    = "20060102T150405Z"
+	maxLineLength = 75
+)
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Encode returns calendar with given name that includes all events.
+func Encode(name string, events []*model.CalendarEvent) []byte {
+	var b bytes.Buffer
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+prodID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:"+escapeText(name))
+
+	for _, e := range events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.LessonID.String()+"@"+uidDomain)
+		writeLine(&b, "DTSTAMP:"+formatTime(e.UpdatedAt))
+		writeLine(&b, "DTSTART:"+formatTime(e.EventStart))
+		writeLine(&b, "DTEND:"+formatTime(e.EventEnd))
+		writeLine(&b, "SUMMARY:"+escapeText(e.Title))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		writeLine(&b, "CATEGORIES:"+escapeText(e.ProductTitle))
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+
+	return b.Bytes()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine writes content line folding it into lines of maxLineLength octets
+// without splitting UTF-8 characters.
+func writeLine(b *bytes.Buffer, line string) {
+	limit := maxLineLength
+	for limit < len(line) {
+		i := limit
+		for 0 < i && !utf8.RuneStart(line[i]) {
+			i--
+		}
+
+		b.WriteString(line[:i])
+		b.WriteString("\r\n ")
+		line = line[i:]
+
+		// Continuation lines start with a space.
+		limit = maxLineLength - 1
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
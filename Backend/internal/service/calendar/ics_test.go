@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"academy/internal/model"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2025, 3, 10, 18, 30, 0, 0, time.FixedZone("EET", 2*60*60))
+
+	event := &model.CalendarEvent{
+		LessonID:     uuid.MustParse("7a4f5d62-2f1e-4b8e-9a51-1c0c35b0f6de"),
+		ProductTitle: "Go, basics",
+		Title:        "Q&A; live",
+		Description:  "First line\nSecond line",
+		EventStart:   start,
+		EventEnd:     start.Add(time.Hour),
+		UpdatedAt:    start,
+	}
+
+	got := string(Encode("School", []*model.CalendarEvent{event}))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:School\r\n",
+		"UID:7a4f5d62-2f1e-4b8e-9a51-1c0c35b0f6de@academy\r\n",
+		"DTSTART:20250310T163000Z\r\n",
+		"DTEND:20250310T173000Z\r\n",
+		"SUMMARY:Q&A\\; live\r\n",
+		"DESCRIPTION:First line\\nSecond line\r\n",
+		"CATEGORIES:Go\\, basics\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("calendar does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{
+			name: "Short line",
+			line: "SUMMARY:Live",
+		},
+		{
+			name: "Long ASCII line",
+			line: "DESCRIPTION:" + strings.Repeat("a", 200),
+		},
+		{
+			name: "Long multibyte line",
+			line: "DESCRIPTION:" + strings.Repeat("ї", 100),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			writeLine(&b, tt.line)
+
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+			unfolded := lines[0]
+			for i, l := range lines {
+				if maxLineLength < len(l) {
+					t.Errorf("line %d has %d octets", i, len(l))
+				}
+				if i == 0 {
+					continue
+				}
+				if !strings.HasPrefix(l, " ") {
+					t.Errorf("line %d is not folded: %q", i, l)
+				}
+				unfolded += l[1:]
+			}
+
+			if unfolded != tt.line {
+				t.Errorf("unfolded line = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
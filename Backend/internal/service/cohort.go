@@ -0,0 +1,291 @@
+package service
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrCohortFull      = errors.New("cohort has no free seats")
+	ErrAlreadyInCohort = errors.New("student already assigned to a cohort")
+)
+
+type CohortService struct {
+	cohortRepository   *repository.CohortRepository
+	transactionManager *repo.TransactionManager
+}
+
+func NewCohortService(
+	cohortRepository *repository.CohortRepository,
+	transactionManager *repo.TransactionManager,
+) *CohortService {
+
+	return &CohortService{
+		cohortRepository:   cohortRepository,
+		transactionManager: transactionManager,
+	}
+}
+
+func (s *CohortService) Create(ctx context.Context, cohort *model.Cohort) error {
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.cohortRepository.WithTx(tx).Create(ctx, cohort); err != nil {
+			return fmt.Errorf("failed to create cohort: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *CohortService) Update(ctx context.Context, cohort *model.Cohort) error {
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.cohortRepository.WithTx(tx).Update(ctx, cohort); err != nil {
+			return fmt.Errorf("failed to update cohort: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *CohortService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.cohortRepository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete cohort: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CohortService) GetByID(ctx context.Context, id uuid.UUID) (*model.Cohort, error) {
+	cohort, err := s.cohortRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cohort by id: %w", err)
+	}
+
+	return cohort, nil
+}
+
+func (s *CohortService) ProductCohorts(ctx context.Context, productID uuid.UUID) ([]*model.Cohort, error) {
+	cohorts, err := s.cohortRepository.ProductCohorts(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product cohorts: %w", err)
+	}
+
+	return cohorts, nil
+}
+
+func (s *CohortService) StudentCohort(ctx context.Context, productID, userID uuid.UUID) (*model.Cohort, error) {
+	cohort, err := s.cohortRepository.StudentCohort(ctx, productID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student cohort: %w", err)
+	}
+
+	return cohort, nil
+}
+
+func (s *CohortService) ModeratorCohortIDs(ctx context.Context, productID, userID uuid.UUID) ([]uuid.UUID, error) {
+	ids, err := s.cohortRepository.ModeratorCohortIDs(ctx, productID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderator cohorts: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ValidateModerators checks that all users are moderators of the mini-app.
+func (s *CohortService) ValidateModerators(ctx context.Context, miniAppID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	count, err := s.cohortRepository.CountModerators(ctx, miniAppID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to count moderators: %w", err)
+	}
+
+	unique := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+
+	if count != len(unique) {
+		return fmt.Errorf("moderator not found")
+	}
+
+	return nil
+}
+
+// PurchaseCohort returns cohort that student gets assigned to on purchase.
+// Already assigned students keep their cohort. If cohort is not chosen then
+// the upcoming one is used, nil is returned if product has no such cohort.
+func (s *CohortService) PurchaseCohort(
+	ctx context.Context,
+	productID, userID, cohortID uuid.UUID,
+) (*model.Cohort, error) {
+
+	cohort, err := s.cohortRepository.StudentCohort(ctx, productID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student cohort: %w", err)
+	}
+
+	if cohort != nil {
+		return cohort, nil
+	}
+
+	if cohortID == uuid.Nil {
+		cohort, err := s.cohortRepository.UpcomingCohort(ctx, productID, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get upcoming cohort: %w", err)
+		}
+
+		return cohort, nil
+	}
+
+	cohort, err = s.cohortRepository.GetByID(ctx, cohortID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cohort by id: %w", err)
+	}
+
+	if cohort.ProductID != productID {
+		return nil, fmt.Errorf("cohort is not included in the product: %v", cohortID)
+	}
+
+	if !cohort.HasSeats() {
+		return nil, ErrCohortFull
+	}
+
+	return cohort, nil
+}
+
+// Join assigns the student to the cohort using cohort invite.
+func (s *CohortService) Join(ctx context.Context, miniAppID, inviteID, userID uuid.UUID) (*model.Cohort, error) {
+	cohort, err := s.cohortRepository.GetByInviteID(ctx, miniAppID, inviteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cohort by invite: %w", err)
+	}
+
+	err = s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		cohortRepository := s.cohortRepository.WithTx(tx)
+
+		cohort.TotalStudents, err = cohortRepository.LockSeats(ctx, cohort.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock cohort seats: %w", err)
+		}
+
+		current, err := cohortRepository.StudentCohort(ctx, cohort.ProductID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get student cohort: %w", err)
+		}
+
+		if current != nil {
+			if current.ID == cohort.ID {
+				return nil
+			}
+
+			return ErrAlreadyInCohort
+		}
+
+		if !cohort.HasSeats() {
+			return ErrCohortFull
+		}
+
+		err = cohortRepository.AssignStudents(ctx, []*model.CohortStudent{
+			model.NewCohortStudent(cohort, userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to assign student: %w", err)
+		}
+
+		cohort.TotalStudents++
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cohort, nil
+}
+
+// AssignStudents assigns mini-app students to the cohort moving them from
+// other product cohorts.
+func (s *CohortService) AssignStudents(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	cohort *model.Cohort,
+	userIDs []uuid.UUID,
+) error {
+
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	unique := make(map[uuid.UUID]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		unique[id] = struct{}{}
+	}
+
+	count, err := s.cohortRepository.CountStudents(ctx, miniAppID, userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to count students: %w", err)
+	}
+	if count != len(unique) {
+		return fmt.Errorf("student not found")
+	}
+
+	return s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		cohortRepository := s.cohortRepository.WithTx(tx)
+
+		cohort.TotalStudents, err = cohortRepository.LockSeats(ctx, cohort.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lock cohort seats: %w", err)
+		}
+
+		// Students of the cohort keep their seats.
+		assignedIDs, err := cohortRepository.CohortStudentIDs(ctx, cohort.ID, userIDs)
+		if err != nil {
+			return fmt.Errorf("failed to get cohort students: %w", err)
+		}
+		for _, id := range assignedIDs {
+			delete(unique, id)
+		}
+
+		if len(unique) == 0 {
+			return nil
+		}
+
+		if !cohort.HasSeatsFor(int64(len(unique))) {
+			return ErrCohortFull
+		}
+
+		students := make([]*model.CohortStudent, 0, len(unique))
+		for id := range unique {
+			students = append(students, model.NewCohortStudent(cohort, id))
+		}
+
+		if err := cohortRepository.AssignStudents(ctx, students); err != nil {
+			return fmt.Errorf("failed to assign students: %w", err)
+		}
+
+		return nil
+	})
+}
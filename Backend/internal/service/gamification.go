@@ -0,0 +1,329 @@
+package service
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Number of days loaded to calculate the current streak.
+const streakDaysLimit = 366
+
+type GamificationService struct {
+	gamificationRepository *repository.GamificationRepository
+	lessonRepository       *repository.LessonRepository
+	transactionManager     *repo.TransactionManager
+}
+
+func NewGamificationService(
+	gamificationRepository *repository.GamificationRepository,
+	lessonRepository *repository.LessonRepository,
+	transactionManager *repo.TransactionManager,
+) *GamificationService {
+
+	return &GamificationService{
+		gamificationRepository: gamificationRepository,
+		lessonRepository:       lessonRepository,
+		transactionManager:     transactionManager,
+	}
+}
+
+// PointRules returns mini-app rules or default rules if mini-app has not
+// configured own ones.
+func (s *GamificationService) PointRules(ctx context.Context, miniAppID uuid.UUID) ([]*model.PointRule, error) {
+	rules, err := s.gamificationRepository.GetPointRules(ctx, miniAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get point rules: %w", err)
+	}
+
+	if len(rules) == 0 {
+		return model.DefaultPointRules(miniAppID), nil
+	}
+
+	return rules, nil
+}
+
+func (s *GamificationService) EditPointRules(
+	ctx context.Context, miniAppID uuid.UUID, rules []*model.PointRule,
+) error {
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.gamificationRepository.WithTx(tx).DeletePointRules(ctx, miniAppID); err != nil {
+			return fmt.Errorf("failed to delete point rules: %w", err)
+		}
+
+		if err := s.gamificationRepository.WithTx(tx).CreatePointRules(ctx, rules); err != nil {
+			return fmt.Errorf("failed to create point rules: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AwardLessonProgress applies rules for accepted lesson progress. Score based
+// rules are applied only for scored homework (quiz or reviewed open question).
+func (s *GamificationService) AwardLessonProgress(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	progress *model.LessonProgress,
+	isScored bool,
+) error {
+
+	if progress.Status != model.LessonProgressStatusAccepted {
+		return nil
+	}
+
+	lesson, err := s.lessonRepository.GetByID(ctx, progress.LessonID, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("failed to get lesson: %w", err)
+	}
+
+	rules, err := s.PointRules(ctx, miniAppID)
+	if err != nil {
+		return err
+	}
+
+	transactions := make([]*model.PointTransaction, 0)
+	for _, rule := range rules {
+		if !rule.IsActive {
+			continue
+		}
+
+		switch rule.Event {
+		case model.PointEventLessonAccepted:
+		case model.PointEventQuizScore:
+			if !isScored || progress.Score < rule.Threshold {
+				continue
+			}
+		default:
+			continue
+		}
+
+		transactions = append(transactions, model.NewPointTransaction(
+			miniAppID, progress.UserID, lesson.ProductID, lesson.ID,
+			rule, lesson.ID.String(),
+		))
+	}
+
+	err = s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		gamificationRepository := s.gamificationRepository.WithTx(tx)
+
+		if _, err := gamificationRepository.CreatePointTransactions(ctx, transactions); err != nil {
+			return fmt.Errorf("failed to create point transactions: %w", err)
+		}
+
+		days, err := gamificationRepository.ActivityDays(ctx, progress.UserID, streakDaysLimit)
+		if err != nil {
+			return fmt.Errorf("failed to get activity days: %w", err)
+		}
+
+		streak, streakStart := model.CurrentStreak(days, time.Now())
+
+		streakTransactions := make([]*model.PointTransaction, 0)
+		for _, rule := range rules {
+			if !rule.IsActive || rule.Event != model.PointEventStreak || streak < rule.Threshold {
+				continue
+			}
+
+			streakTransactions = append(streakTransactions, model.NewPointTransaction(
+				miniAppID, progress.UserID, lesson.ProductID, uuid.Nil,
+				rule, streakStart.Format(time.DateOnly),
+			))
+		}
+
+		if _, err := gamificationRepository.CreatePointTransactions(ctx, streakTransactions); err != nil {
+			return fmt.Errorf("failed to create streak point transactions: %w", err)
+		}
+
+		if err := gamificationRepository.AwardBadges(ctx, miniAppID, progress.UserID); err != nil {
+			return fmt.Errorf("failed to award badges: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *GamificationService) AwardReview(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	review *model.Review,
+) error {
+
+	lesson, err := s.lessonRepository.GetByID(ctx, review.LessonID, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("failed to get lesson: %w", err)
+	}
+
+	rules, err := s.PointRules(ctx, miniAppID)
+	if err != nil {
+		return err
+	}
+
+	transactions := make([]*model.PointTransaction, 0)
+	for _, rule := range rules {
+		if !rule.IsActive || rule.Event != model.PointEventReview {
+			continue
+		}
+
+		transactions = append(transactions, model.NewPointTransaction(
+			miniAppID, review.UserID, lesson.ProductID, lesson.ID,
+			rule, lesson.ID.String(),
+		))
+	}
+
+	return s.createTransactions(ctx, miniAppID, review.UserID, transactions)
+}
+
+func (s *GamificationService) AdjustPoints(ctx context.Context, transaction *model.PointTransaction) error {
+	return s.createTransactions(ctx, transaction.MiniAppID, transaction.UserID,
+		[]*model.PointTransaction{transaction})
+}
+
+func (s *GamificationService) createTransactions(
+	ctx context.Context,
+	miniAppID, userID uuid.UUID,
+	transactions []*model.PointTransaction,
+) error {
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		_, err := s.gamificationRepository.WithTx(tx).CreatePointTransactions(ctx, transactions)
+		if err != nil {
+			return fmt.Errorf("failed to create point transactions: %w", err)
+		}
+
+		if err := s.gamificationRepository.WithTx(tx).AwardBadges(ctx, miniAppID, userID); err != nil {
+			return fmt.Errorf("failed to award badges: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *GamificationService) TotalPoints(ctx context.Context, userID, productID uuid.UUID) (int64, error) {
+	total, err := s.gamificationRepository.TotalPoints(ctx, userID, productID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get total points: %w", err)
+	}
+
+	return total, nil
+}
+
+func (s *GamificationService) PointHistory(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterPointTransactionsRequest,
+) ([]*model.PointTransaction, int, error) {
+
+	transactions, total, err := s.gamificationRepository.FindPointTransactions(ctx, miniAppID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find point transactions: %w", err)
+	}
+
+	return transactions, total, nil
+}
+
+func (s *GamificationService) Leaderboard(
+	ctx context.Context,
+	productID uuid.UUID,
+	limit, offset uint,
+) ([]*model.LeaderboardEntry, int, error) {
+
+	entries, total, err := s.gamificationRepository.Leaderboard(ctx, productID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+func (s *GamificationService) LeaderboardPosition(
+	ctx context.Context,
+	productID, userID uuid.UUID,
+) (*model.LeaderboardEntry, error) {
+
+	entry, err := s.gamificationRepository.LeaderboardPosition(ctx, productID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard position: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (s *GamificationService) CreateBadge(ctx context.Context, badge *model.Badge) error {
+	if err := s.gamificationRepository.CreateBadge(ctx, badge); err != nil {
+		return fmt.Errorf("failed to create badge: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GamificationService) GetBadge(ctx context.Context, id uuid.UUID) (*model.Badge, error) {
+	badge, err := s.gamificationRepository.GetBadge(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get badge: %w", err)
+	}
+
+	return badge, nil
+}
+
+func (s *GamificationService) Badges(ctx context.Context, miniAppID uuid.UUID) ([]*model.Badge, error) {
+	badges, err := s.gamificationRepository.Badges(ctx, miniAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get badges: %w", err)
+	}
+
+	return badges, nil
+}
+
+func (s *GamificationService) UserBadges(ctx context.Context, userID uuid.UUID) ([]*model.UserBadge, error) {
+	badges, err := s.gamificationRepository.UserBadges(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user badges: %w", err)
+	}
+
+	return badges, nil
+}
+
+func (s *GamificationService) UpdateBadge(ctx context.Context, badge *model.Badge) error {
+	if err := s.gamificationRepository.UpdateBadge(ctx, badge); err != nil {
+		return fmt.Errorf("failed to update badge: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GamificationService) DeleteBadge(ctx context.Context, miniAppID, id uuid.UUID) error {
+	if err := s.gamificationRepository.DeleteBadge(ctx, miniAppID, id); err != nil {
+		return fmt.Errorf("failed to delete badge: %w", err)
+	}
+
+	return nil
+}
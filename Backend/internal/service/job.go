@@ -0,0 +1,152 @@
+package service
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"academy/internal/types"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobActive   = errors.New("active job with the same key exists")
+)
+
+// JobLease is a time the job is locked by the worker. Lease of the running
+// job is extended, so only jobs of crashed workers are leased again.
+const JobLease = 5 * time.Minute
+
+type JobService struct {
+	jobRepository *repository.JobRepository
+}
+
+func NewJobService(jobRepository *repository.JobRepository) *JobService {
+	return &JobService{
+		jobRepository: jobRepository,
+	}
+}
+
+// Enqueue creates the job, false is returned if the active job with the same
+// key already exists.
+func (s *JobService) Enqueue(ctx context.Context, job *model.Job) (bool, error) {
+	ok, err := s.jobRepository.Enqueue(ctx, job)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue %v job: %w", job.Kind, err)
+	}
+
+	return ok, nil
+}
+
+// Lease returns the next job locked for the worker, nil is returned if there
+// are no due jobs.
+func (s *JobService) Lease(ctx context.Context, workerID string) (*model.Job, error) {
+	job, err := s.jobRepository.Lease(ctx, workerID, time.Now().UTC(), JobLease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ExtendLease returns false if the job was leased by another worker after
+// the lease expired.
+func (s *JobService) ExtendLease(ctx context.Context, job *model.Job, workerID string) (bool, error) {
+	ok, err := s.jobRepository.ExtendLease(ctx, job.ID, workerID, time.Now().UTC().Add(JobLease))
+	if err != nil {
+		return false, fmt.Errorf("failed to extend job lease: %w", err)
+	}
+
+	return ok, nil
+}
+
+func (s *JobService) Complete(ctx context.Context, job *model.Job, workerID string) error {
+	now := time.Now().UTC()
+
+	job.Status = model.JobStatusCompleted
+	job.LastError = ""
+	job.CompletedAt = types.NewTime(now)
+	job.UpdatedAt = now
+
+	if err := s.jobRepository.Release(ctx, job, workerID); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+
+	return nil
+}
+
+// Fail schedules the next attempt of the job after the backoff. Job that
+// failed all its attempts is dead.
+func (s *JobService) Fail(ctx context.Context, job *model.Job, workerID string, jobErr error) error {
+	now := time.Now().UTC()
+
+	job.Status = model.JobStatusPending
+	job.RunAt = now.Add(job.Backoff())
+	job.LastError = jobErr.Error()
+	job.UpdatedAt = now
+
+	if job.IsExhausted() {
+		job.Status = model.JobStatusDead
+	}
+
+	if err := s.jobRepository.Release(ctx, job, workerID); err != nil {
+		return fmt.Errorf("failed to fail job: %w", err)
+	}
+
+	return nil
+}
+
+func (s *JobService) Find(ctx context.Context, filter *model.FilterJobsRequest) ([]*model.Job, int, error) {
+	jobs, total, err := s.jobRepository.Find(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+// Retry enqueues the dead job again.
+func (s *JobService) Retry(ctx context.Context, id uuid.UUID) error {
+	ok, err := s.jobRepository.Retry(ctx, id, time.Now().UTC())
+	if repo.DuplicateKeyViolation(err) {
+		return ErrJobActive
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// DeadKeys returns the keys of dead jobs, so jobs that failed all their
+// attempts are not enqueued again till they are retried.
+func (s *JobService) DeadKeys(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	dead, err := s.jobRepository.DeadKeys(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead jobs: %w", err)
+	}
+
+	return dead, nil
+}
+
+// DeleteCompleted deletes jobs completed before the time.
+func (s *JobService) DeleteCompleted(ctx context.Context, before time.Time) error {
+	if err := s.jobRepository.DeleteCompleted(ctx, before); err != nil {
+		return fmt.Errorf("failed to delete completed jobs: %w", err)
+	}
+
+	return nil
+}
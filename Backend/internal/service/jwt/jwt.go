@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"academy/internal/config"
+	"academy/internal/model"
 	"errors"
 	"fmt"
 	"strings"
@@ -16,6 +17,18 @@ type TokenClaims struct {
 	MiniAppID uuid.UUID `json:"mini_app_id"`
 	IsOwner   bool      `json:"is_owner"`
 	IsMod     bool      `json:"is_mod"`
+	SessionID uuid.UUID `json:"session_id"`
+
+	// APIKeyID is set when the request is authenticated with the API key of
+	// the owner instead of the token, the key is allowed only its scopes.
+	APIKeyID     uuid.UUID              `json:"-"`
+	APIKeyScopes []model.PermissionName `json:"-"`
+}
+
+// IsStaff reports whether the request is made by the owner, moderator or API
+// key of the mini-app rather than by the student.
+func (c *TokenClaims) IsStaff() bool {
+	return c.IsOwner || c.IsMod || c.APIKeyID != uuid.Nil
 }
 
 type TokenPair struct {
@@ -198,10 +211,24 @@ func extractTokenClaims(claims jwt.MapClaims) (*TokenClaims, bool) {
 		return nil, false
 	}
 
+	// Tokens issued before sessions have no session id.
+	var sessionID uuid.UUID
+	if v, ok = claims["session_id"]; ok {
+		stringUUID, ok = v.(string)
+		if !ok {
+			return nil, false
+		}
+		sessionID, err = uuid.Parse(stringUUID)
+		if err != nil {
+			return nil, false
+		}
+	}
+
 	return &TokenClaims{
 		UserID:    userID,
 		MiniAppID: miniAppID,
 		IsOwner:   isOwner,
 		IsMod:     isMod,
+		SessionID: sessionID,
 	}, true
 }
@@ -1,33 +1,101 @@
 package service
 
 import (
+	"academy/internal/model"
 	"academy/internal/service/jwt"
 	"academy/internal/storage/cache"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrSessionNotFound = cache.ErrSessionNotFound
+	ErrTokenReused     = cache.ErrTokenReused
+)
+
 type JWTService struct {
 	jwtAuth *jwt.JWTAuthenticator
-	storage *cache.JWTStorage
+	storage *cache.SessionStorage
 }
 
-func NewJWTService(storage *cache.JWTStorage, jwtAuth *jwt.JWTAuthenticator) *JWTService {
+func NewJWTService(storage *cache.SessionStorage, jwtAuth *jwt.JWTAuthenticator) *JWTService {
 	return &JWTService{
 		storage: storage,
 		jwtAuth: jwtAuth,
 	}
 }
 
-func (s *JWTService) GenerateTokenPair(ctx context.Context, claims *jwt.TokenClaims) (*jwt.TokenPair, error) {
+// GenerateTokenPair starts a new session of the user on the client.
+func (s *JWTService) GenerateTokenPair(
+	ctx context.Context,
+	claims *jwt.TokenClaims,
+	client *model.SessionClient,
+) (*jwt.TokenPair, error) {
+
+	session := model.NewSession(claims.UserID, claims.MiniAppID, client)
+
+	sessionClaims := *claims
+	sessionClaims.SessionID = session.ID
+
+	tokenPair, err := s.jwtAuth.GenerateTokenPair(&sessionClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	session.TokenHash = hashToken(tokenPair.RefreshToken)
+
+	err = s.storage.Save(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenPair, nil
+}
+
+// RefreshTokenPair rotates the refresh token of the session. Refresh token
+// that was already rotated revokes the session.
+func (s *JWTService) RefreshTokenPair(
+	ctx context.Context,
+	refreshToken string,
+	client *model.SessionClient,
+) (*jwt.TokenPair, error) {
+
+	claims, err := s.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SessionID == uuid.Nil {
+		return nil, ErrSessionNotFound
+	}
+
+	session, err := s.storage.Get(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.UserID != claims.UserID {
+		return nil, ErrSessionNotFound
+	}
+
 	tokenPair, err := s.jwtAuth.GenerateTokenPair(claims)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.storage.Save(ctx, claims.UserID, tokenPair.RefreshToken)
+	session.TokenHash = hashToken(tokenPair.RefreshToken)
+	session.Device = model.DeviceFromUserAgent(client.UserAgent)
+	session.UserAgent = client.UserAgent
+	session.IP = client.IP
+	session.LastUsedAt = time.Now().UTC()
+
+	err = s.storage.Rotate(ctx, session, hashToken(refreshToken))
 	if err != nil {
 		return nil, err
 	}
@@ -35,17 +103,89 @@ func (s *JWTService) GenerateTokenPair(ctx context.Context, claims *jwt.TokenCla
 	return tokenPair, nil
 }
 
-func (s *JWTService) GetRefreshByUserID(
+// Sessions returns sessions of the user, recently used go first.
+func (s *JWTService) Sessions(
 	ctx context.Context,
 	userID uuid.UUID,
-) (string, error) {
+	currentSessionID uuid.UUID,
+) ([]*model.Session, error) {
 
-	refreshToken, err := s.storage.GetRefreshToken(ctx, userID)
+	sessions, err := s.storage.ListByUser(ctx, userID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return refreshToken, nil
+	for _, session := range sessions {
+		session.IsCurrent = session.ID == currentSessionID
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+func (s *JWTService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.storage.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return s.storage.Delete(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions signs out the user everywhere except the current
+// session.
+func (s *JWTService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID uuid.UUID) error {
+	sessions, err := s.storage.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		if session.ID != currentSessionID {
+			ids = append(ids, session.ID)
+		}
+	}
+
+	return s.storage.Delete(ctx, userID, ids...)
+}
+
+// RevokeUserSessions signs out the user everywhere. Access tokens that are
+// already issued are rejected by CheckSession.
+func (s *JWTService) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	err := s.RevokeOtherSessions(ctx, userID, uuid.Nil)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// CheckSession returns ErrSessionNotFound if the session of the access token is
+// revoked or expired, so revoked sessions are signed out before their access
+// tokens expire.
+func (s *JWTService) CheckSession(ctx context.Context, claims *jwt.TokenClaims) error {
+	if claims.SessionID == uuid.Nil {
+		return ErrSessionNotFound
+	}
+
+	session, err := s.storage.Get(ctx, claims.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != claims.UserID {
+		return ErrSessionNotFound
+	}
+
+	return nil
 }
 
 func (s *JWTService) ParseAccessToken(authToken string) (*jwt.TokenClaims, error) {
@@ -65,3 +205,8 @@ func (s *JWTService) ParseRefreshToken(authToken string) (*jwt.TokenClaims, erro
 
 	return claims, nil
 }
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
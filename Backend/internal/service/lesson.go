@@ -6,6 +6,7 @@ import (
 	"academy/internal/storage/repository"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -15,6 +16,7 @@ type LessonService struct {
 	lessonRepository       *repository.LessonRepository
 	productRepository      *repository.ProductRepository
 	productLevelRepository *repository.ProductLevelRepository
+	materialRepository     *repository.MaterialRepository
 	transactionManager     *repo.TransactionManager
 }
 
@@ -22,6 +24,7 @@ func NewLessonService(
 	lessonRepository *repository.LessonRepository,
 	productRepository *repository.ProductRepository,
 	productLevelRepository *repository.ProductLevelRepository,
+	materialRepository *repository.MaterialRepository,
 	transactionManager *repo.TransactionManager,
 ) *LessonService {
 
@@ -29,6 +32,7 @@ func NewLessonService(
 		lessonRepository:       lessonRepository,
 		productRepository:      productRepository,
 		productLevelRepository: productLevelRepository,
+		materialRepository:     materialRepository,
 		transactionManager:     transactionManager,
 	}
 }
@@ -186,3 +190,81 @@ func (s *LessonService) Delete(ctx context.Context, id uuid.UUID, product *model
 
 	return nil
 }
+
+func (s *LessonService) UserEvents(
+	ctx context.Context,
+	userID uuid.UUID,
+	since time.Time,
+) ([]*model.CalendarEvent, error) {
+
+	events, err := s.lessonRepository.UserEvents(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *LessonService) ProductEvents(
+	ctx context.Context,
+	productID, userID uuid.UUID,
+) ([]*model.CalendarEvent, error) {
+
+	events, err := s.lessonRepository.ProductEvents(ctx, productID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *LessonService) PendingEventReminders(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*model.PendingEventReminder, error) {
+
+	reminders, err := s.lessonRepository.PendingEventReminders(ctx, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending event reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+func (s *LessonService) CreateEventReminders(ctx context.Context, reminders []*model.EventReminder) error {
+	if err := s.lessonRepository.CreateEventReminders(ctx, reminders); err != nil {
+		return fmt.Errorf("failed to create event reminders: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LessonService) CheckIn(ctx context.Context, attendance *model.EventAttendance) error {
+	if err := s.lessonRepository.CreateAttendance(ctx, attendance); err != nil {
+		return fmt.Errorf("failed to create attendance: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LessonService) Attendance(ctx context.Context, lessonID uuid.UUID) ([]*model.EventAttendance, error) {
+	attendance, err := s.lessonRepository.Attendance(ctx, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attendance: %w", err)
+	}
+
+	return attendance, nil
+}
+
+// AttachRecording creates the recording material of the event lesson. The
+// lesson is converted into a video lesson once the recording is ready, see
+// MaterialService.Update.
+func (s *LessonService) AttachRecording(ctx context.Context, recording *model.Material) error {
+
+	if err := s.materialRepository.Create(ctx, recording); err != nil {
+		return fmt.Errorf("failed to create recording material: %w", err)
+	}
+
+	return nil
+}
@@ -6,12 +6,17 @@ import (
 	"academy/internal/storage/repository"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrHomeworkQuarantined is returned when the homework is reviewed before its
+// files are scanned.
+var ErrHomeworkQuarantined = errors.New("homework files are not scanned yet")
+
 type LessonProgressService struct {
 	lessonProgressRepository *repository.LessonProgressRepository
 	transactionManager       *repo.TransactionManager
@@ -78,6 +83,7 @@ func (s *LessonProgressService) ProductHomework(
 		UserID:    filter.UserID,
 		LessonID:  filter.LessonID,
 		Status:    []model.LessonProgressStatus{model.LessonProgressStatusPending},
+		CohortID:  filter.CohortID,
 
 		Limit:  filter.Limit,
 		Offset: filter.Offset,
@@ -117,28 +123,34 @@ func (s *LessonProgressService) UserHomework(
 func (s *LessonProgressService) FeedbackHomework(
 	ctx context.Context,
 	req *model.FeedbackHomeworkRequest,
-) error {
+) (*model.LessonProgress, error) {
 
 	if req.Score < 0 || model.MaxScore < req.Score {
-		return fmt.Errorf("invalid score: %v", req.Score)
+		return nil, fmt.Errorf("invalid score: %v", req.Score)
 	}
 
 	progress, err := s.lessonProgressRepository.GetByID(ctx, req.UserID, req.LessonID)
 	if err != nil {
-		return fmt.Errorf("failed to get lesson progress: %w", err)
+		return nil, fmt.Errorf("failed to get lesson progress: %w", err)
+	}
+
+	if progress.Status == model.LessonProgressStatusQuarantined ||
+		req.NewStatus == model.LessonProgressStatusQuarantined {
+
+		return nil, ErrHomeworkQuarantined
 	}
 
 	var progressData model.LessonProgressData
 	err = json.Unmarshal(progress.Data, &progressData)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal progress data: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal progress data: %w", err)
 	}
 
 	progressData.Feedback = req.Feedback
 
 	newData, err := json.Marshal(progressData)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal progress data: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal progress data: %w", err)
 	}
 
 	progress.Data = newData
@@ -148,8 +160,40 @@ func (s *LessonProgressService) FeedbackHomework(
 
 	err = s.lessonProgressRepository.Update(ctx, progress)
 	if err != nil {
-		return fmt.Errorf("failed to find lesson progress: %w", err)
+		return nil, fmt.Errorf("failed to find lesson progress: %w", err)
 	}
 
-	return nil
+	return progress, nil
+}
+
+// FindQuarantined returns answers with files that are not scanned yet.
+func (s *LessonProgressService) FindQuarantined(ctx context.Context, limit uint) ([]*model.LessonProgress, error) {
+	filter := &model.FilterLessonProgressRequest{
+		Status: []model.LessonProgressStatus{model.LessonProgressStatusQuarantined},
+		Limit:  limit,
+	}
+
+	progress, _, err := s.lessonProgressRepository.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find quarantined lesson progress: %w", err)
+	}
+
+	return progress, nil
+}
+
+// ReleaseQuarantined saves the result of the scan of the quarantined answer.
+// False is returned if the answer was submitted again since it was updated at
+// the time, the new answer is scanned on its own.
+func (s *LessonProgressService) ReleaseQuarantined(
+	ctx context.Context,
+	progress *model.LessonProgress,
+	updatedAt time.Time,
+) (bool, error) {
+
+	ok, err := s.lessonProgressRepository.UpdateQuarantined(ctx, progress, updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update lesson progress: %w", err)
+	}
+
+	return ok, nil
 }
@@ -5,23 +5,32 @@ import (
 	"academy/internal/model"
 	"academy/internal/storage/repository"
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/uptrace/bun"
 )
 
+// ErrBlobDeleted is returned if the shared file of the material is deleted
+// with the last material that referenced it before the material is saved.
+var ErrBlobDeleted = errors.New("blob of the material file is deleted")
+
 type MaterialService struct {
 	materialRepository *repository.MaterialRepository
+	lessonRepository   *repository.LessonRepository
 	transactionManager *repo.TransactionManager
 }
 
 func NewMaterialService(
 	materialRepository *repository.MaterialRepository,
+	lessonRepository *repository.LessonRepository,
 	transactionManager *repo.TransactionManager,
 ) *MaterialService {
 
 	return &MaterialService{
 		materialRepository: materialRepository,
+		lessonRepository:   lessonRepository,
 		transactionManager: transactionManager,
 	}
 }
@@ -53,6 +62,15 @@ func (s *MaterialService) GetByFilename(ctx context.Context, filename string) (*
 	return material, nil
 }
 
+func (s *MaterialService) FindByFilename(ctx context.Context, filename string) ([]*model.Material, error) {
+	materials, err := s.materialRepository.FindByFilename(ctx, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find materials by filename: %w", err)
+	}
+
+	return materials, nil
+}
+
 func (s *MaterialService) FindPendingCompressing(ctx context.Context, limit int) ([]*model.Material, error) {
 	material, err := s.materialRepository.FindByStatus(
 		ctx, model.MaterialStatusPendingCompressing, false, limit, 0)
@@ -78,6 +96,46 @@ func (s *MaterialService) FindPendingMoveToMux(
 	return material, nil
 }
 
+func (s *MaterialService) FindPendingTranscoding(ctx context.Context, limit int) ([]*model.Material, error) {
+	material, err := s.materialRepository.FindByStatus(
+		ctx, model.MaterialStatusPendingTranscoding, false, limit, 0)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending transcoding materials: %w", err)
+	}
+
+	return material, nil
+}
+
+func (s *MaterialService) FindQuarantined(ctx context.Context, limit int) ([]*model.Material, error) {
+	material, err := s.materialRepository.FindByStatus(
+		ctx, model.MaterialStatusQuarantined, false, limit, 0)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find quarantined materials: %w", err)
+	}
+
+	return material, nil
+}
+
+func (s *MaterialService) FindVideosWithoutPreview(ctx context.Context, limit int) ([]*model.Material, error) {
+	materials, err := s.materialRepository.FindVideosWithoutPreview(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find videos without preview: %w", err)
+	}
+
+	return materials, nil
+}
+
+func (s *MaterialService) GetLessonCover(ctx context.Context, lessonID uuid.UUID) (*model.Material, error) {
+	material, err := s.materialRepository.GetLessonCover(ctx, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lesson cover: %w", err)
+	}
+
+	return material, nil
+}
+
 func (s *MaterialService) FindMuxAssetsToDelete(ctx context.Context, limit int) ([]string, error) {
 	assets, err := s.materialRepository.FindMuxAssetsToDelete(ctx, limit)
 	if err != nil {
@@ -96,10 +154,50 @@ func (s *MaterialService) FullyDeleleMuxAsset(ctx context.Context, assetID strin
 	return nil
 }
 
+// Update saves the material. Event lessons with the ready video content are
+// converted into video lessons, it is how recordings get attached.
 func (s *MaterialService) Update(ctx context.Context, material *model.Material) error {
-	err := s.materialRepository.Update(ctx, material)
+	if material.LessonID == uuid.Nil || !material.IsReadyVideo() {
+		err := s.materialRepository.Update(ctx, material)
+		if repo.BlobDeleted(err) {
+			return ErrBlobDeleted
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update material: %w", err)
+		}
+
+		return nil
+	}
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		err := s.materialRepository.WithTx(tx).Update(ctx, material)
+		if repo.BlobDeleted(err) {
+			return ErrBlobDeleted
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update material: %w", err)
+		}
+
+		lesson, err := s.lessonRepository.WithTx(tx).GetByID(ctx, material.LessonID, uuid.Nil)
+		if err != nil {
+			return fmt.Errorf("failed to get lesson: %w", err)
+		}
+
+		if lesson.ContentType != model.LessonTypeEvent {
+			return nil
+		}
+
+		lesson.ConvertToVideo()
+
+		if err := s.lessonRepository.WithTx(tx).Update(ctx, lesson); err != nil {
+			return fmt.Errorf("failed to update lesson: %w", err)
+		}
+
+		return nil
+	})
+
 	if err != nil {
-		return fmt.Errorf("failed to update material: %w", err)
+		return err
 	}
 
 	return nil
@@ -313,28 +313,11 @@ func (s *MiniAppService) CreateModInvite(
 	ctx context.Context, miniAppID uuid.UUID, req *model.CreateModInviteRequest,
 ) (*model.ModInvite, error) {
 
-	invite := model.NewModInvite(miniAppID)
-
-	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
-		err := s.miniAppRepository.WithTx(tx).CreateModInvite(ctx, invite)
-		if err != nil {
-			return fmt.Errorf("failed to create mod invite: %w", err)
-		}
-
-		for _, p := range req.Permissions {
-			mip := model.NewModInvitePermission(invite.ID, p)
-
-			err := s.miniAppRepository.WithTx(tx).CreateModInvitePermission(ctx, mip)
-			if err != nil {
-				return fmt.Errorf("failed to create mod invite permission: %w", err)
-			}
-		}
-
-		return nil
-	})
+	invite := model.NewModInvite(miniAppID, req.RoleID)
 
+	err := s.miniAppRepository.CreateModInvite(ctx, invite)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create mod invite: %w", err)
 	}
 
 	return invite, nil
@@ -344,26 +327,9 @@ func (s *MiniAppService) EditModInvite(
 	ctx context.Context, inviteID uuid.UUID, req *model.EditModInviteRequest,
 ) error {
 
-	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
-		err := s.miniAppRepository.WithTx(tx).DeleteModInvitePermissions(ctx, inviteID)
-		if err != nil {
-			return fmt.Errorf("failed to delete old permissions: %w", err)
-		}
-
-		for _, p := range req.Permissions {
-			mip := model.NewModInvitePermission(inviteID, p)
-
-			err := s.miniAppRepository.WithTx(tx).CreateModInvitePermission(ctx, mip)
-			if err != nil {
-				return fmt.Errorf("failed to create mod invite with new permission: %w", err)
-			}
-		}
-
-		return nil
-	})
-
+	err := s.miniAppRepository.SetModInviteRole(ctx, inviteID, req.RoleID)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to set mod invite role: %w", err)
 	}
 
 	return nil
@@ -434,11 +400,13 @@ func (s *MiniAppService) GetPermissions(
 	return permissions, nil
 }
 
+// CheckPermission checks the permission of the moderator in the product, nil
+// product requires the permission in all products.
 func (s *MiniAppService) CheckPermission(
-	ctx context.Context, userID uuid.UUID, permissionName ...model.PermissionName,
+	ctx context.Context, userID, productID uuid.UUID, permissionName ...model.PermissionName,
 ) (bool, error) {
 
-	ok, err := s.miniAppRepository.CheckPermission(ctx, userID, permissionName...)
+	ok, err := s.miniAppRepository.CheckPermission(ctx, userID, productID, permissionName...)
 	if err != nil {
 		return false, fmt.Errorf("failed to get permission: %w", err)
 	}
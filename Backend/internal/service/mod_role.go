@@ -0,0 +1,140 @@
+package service
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrModRoleExists = errors.New("role with the name already exists")
+	ErrModRoleInUse  = errors.New("role is assigned to mod invites")
+)
+
+type ModRoleService struct {
+	modRoleRepository  *repository.ModRoleRepository
+	productRepository  *repository.ProductRepository
+	transactionManager *repo.TransactionManager
+}
+
+func NewModRoleService(
+	modRoleRepository *repository.ModRoleRepository,
+	productRepository *repository.ProductRepository,
+	transactionManager *repo.TransactionManager,
+) *ModRoleService {
+
+	return &ModRoleService{
+		modRoleRepository:  modRoleRepository,
+		productRepository:  productRepository,
+		transactionManager: transactionManager,
+	}
+}
+
+func (s *ModRoleService) Create(ctx context.Context, role *model.ModRole) error {
+	if err := s.checkName(ctx, role); err != nil {
+		return err
+	}
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.modRoleRepository.WithTx(tx).Create(ctx, role); err != nil {
+			return fmt.Errorf("failed to create mod role: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *ModRoleService) Update(ctx context.Context, role *model.ModRole) error {
+	if err := s.checkName(ctx, role); err != nil {
+		return err
+	}
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.modRoleRepository.WithTx(tx).Update(ctx, role); err != nil {
+			return fmt.Errorf("failed to update mod role: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *ModRoleService) checkName(ctx context.Context, role *model.ModRole) error {
+	exists, err := s.modRoleRepository.ExistsByName(ctx, role.MiniAppID, role.Name, role.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check mod role name: %w", err)
+	}
+
+	if exists {
+		return ErrModRoleExists
+	}
+
+	return nil
+}
+
+// Delete deletes the role that is not assigned to any invite.
+func (s *ModRoleService) Delete(ctx context.Context, role *model.ModRole) error {
+	if role.TotalInvites != 0 {
+		return ErrModRoleInUse
+	}
+
+	if err := s.modRoleRepository.Delete(ctx, role.ID); err != nil {
+		return fmt.Errorf("failed to delete mod role: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ModRoleService) GetByID(ctx context.Context, id uuid.UUID) (*model.ModRole, error) {
+	role, err := s.modRoleRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mod role by id: %w", err)
+	}
+
+	return role, nil
+}
+
+func (s *ModRoleService) MiniAppRoles(ctx context.Context, miniAppID uuid.UUID) ([]*model.ModRole, error) {
+	roles, err := s.modRoleRepository.MiniAppRoles(ctx, miniAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mod roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// ValidateProducts checks that all products the role is limited to belong to
+// the mini-app.
+func (s *ModRoleService) ValidateProducts(ctx context.Context, miniAppID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	count, err := s.productRepository.CountProducts(ctx, miniAppID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to count products: %w", err)
+	}
+
+	if count != len(ids) {
+		return fmt.Errorf("product not found")
+	}
+
+	return nil
+}
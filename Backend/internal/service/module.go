@@ -26,6 +26,18 @@ func Module() fx.Option {
 
 			NewPaymentService,
 			NewReviewService,
+			NewGamificationService,
+			NewCohortService,
+			NewBotService,
+			NewNotificationService,
+			NewBroadcastService,
+			NewStaffNotificationService,
+			NewSupportService,
+			NewAuditService,
+			NewModRoleService,
+			NewAPIKeyService,
+			NewJobService,
+			NewSubtitleService,
 
 			ton.NewService,
 			upload.NewService,
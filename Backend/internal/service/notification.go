@@ -0,0 +1,73 @@
+package service
+
+import (
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+type NotificationService struct {
+	notificationRepository *repository.NotificationRepository
+}
+
+func NewNotificationService(
+	notificationRepository *repository.NotificationRepository,
+) *NotificationService {
+
+	return &NotificationService{
+		notificationRepository: notificationRepository,
+	}
+}
+
+// Notify queues the notification to be sent with the mini-app bot.
+func (s *NotificationService) Notify(ctx context.Context, notification *model.Notification) error {
+	if err := s.notificationRepository.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueueScheduled queues notifications about lessons released within
+// model.NotificationScanPeriod and access that ends within
+// model.AccessExpiringNotificationTime.
+func (s *NotificationService) EnqueueScheduled(ctx context.Context, now time.Time) error {
+	err := s.notificationRepository.EnqueueLessonReleases(ctx, now.Add(-model.NotificationScanPeriod), now)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue lesson releases: %w", err)
+	}
+
+	err = s.notificationRepository.EnqueueAccessExpiring(ctx, now, now.Add(model.AccessExpiringNotificationTime))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue access expiring: %w", err)
+	}
+
+	return nil
+}
+
+func (s *NotificationService) Pending(ctx context.Context, limit int) ([]*model.PendingNotification, error) {
+	notifications, err := s.notificationRepository.Pending(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (s *NotificationService) UpdateStatus(ctx context.Context, notification *model.Notification) error {
+	if err := s.notificationRepository.UpdateStatus(ctx, notification); err != nil {
+		return fmt.Errorf("failed to update notification status: %w", err)
+	}
+
+	return nil
+}
+
+func (s *NotificationService) DeleteOld(ctx context.Context, olderThan time.Time) error {
+	if err := s.notificationRepository.DeleteOld(ctx, olderThan); err != nil {
+		return fmt.Errorf("failed to delete old notifications: %w", err)
+	}
+
+	return nil
+}
@@ -49,6 +49,7 @@ func (s *PaymentService) CreateTONPayment(
 	paymentMetadata *model.PaymentMetadataTON,
 	userID uuid.UUID,
 	productLevel *model.ProductLevel,
+	cohort *model.Cohort,
 ) (*model.Payment, error) {
 
 	rates, err := wayforpay.CurrencyRates(ctx, s.adminWayForPayLogin, s.adminWayForPaySecretKey)
@@ -57,6 +58,7 @@ func (s *PaymentService) CreateTONPayment(
 	}
 
 	payment := model.NewPaymentForProductLevel(userID, product, productLevel)
+	payment.ApplyCohort(cohort)
 	payment.URL = paymentMetadata.TONAddress
 
 	var amountBLG decimal.Decimal
@@ -94,6 +96,7 @@ func (s *PaymentService) CreateWayForPayPayment(
 	userID uuid.UUID,
 	productLevel *model.ProductLevel,
 	returnURL string,
+	cohort *model.Cohort,
 ) (*model.Payment, error) {
 
 	rates, err := wayforpay.CurrencyRates(
@@ -104,6 +107,7 @@ func (s *PaymentService) CreateWayForPayPayment(
 	}
 
 	payment := model.NewPaymentForProductLevel(userID, product, productLevel)
+	payment.ApplyCohort(cohort)
 
 	invoiceURL, err := wayforpay.CreateInvoice(
 		ctx,
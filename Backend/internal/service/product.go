@@ -44,9 +44,24 @@ func NewProductService(
 }
 
 func (s *ProductService) Create(ctx context.Context, product *model.Product) error {
-	err := s.productRepository.Create(ctx, product)
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		err := s.productRepository.WithTx(tx).Create(ctx, product)
+		if err != nil {
+			return fmt.Errorf("failed to create a product: %w", err)
+		}
+
+		if len(product.Prerequisites) != 0 {
+			err := s.productRepository.WithTx(tx).SetPrerequisites(ctx, product.ID, product.Prerequisites)
+			if err != nil {
+				return fmt.Errorf("failed to create product prerequisites: %w", err)
+			}
+		}
+
+		return nil
+	})
+
 	if err != nil {
-		return fmt.Errorf("failed to create a product: %w", err)
+		return err
 	}
 
 	return nil
@@ -67,6 +82,7 @@ func (s *ProductService) Update(
 	applyNewLessonAccess *model.LessonAccess,
 	applyNewReleaseDate *types.Time,
 	applyNewAccessTime *types.Interval,
+	applyNewPrerequisites []*model.ProductPrerequisite,
 ) error {
 
 	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
@@ -112,6 +128,14 @@ func (s *ProductService) Update(
 			}
 		}
 
+		if applyNewPrerequisites != nil {
+			err := s.productRepository.WithTx(tx).SetPrerequisites(ctx, product.ID, applyNewPrerequisites)
+			if err != nil {
+				return fmt.Errorf("failed to update product prerequisites: %w", err)
+			}
+			product.Prerequisites = applyNewPrerequisites
+		}
+
 		return nil
 	})
 
@@ -122,6 +146,71 @@ func (s *ProductService) Update(
 	return nil
 }
 
+// ValidatePrerequisites checks that required products and levels belong to
+// the product mini-app and that prerequisites do not create a cycle.
+func (s *ProductService) ValidatePrerequisites(
+	ctx context.Context,
+	product *model.Product,
+	prerequisites []*model.ProductPrerequisite,
+) error {
+
+	if len(prerequisites) == 0 {
+		return nil
+	}
+
+	requiredProducts := make(map[uuid.UUID]struct{}, len(prerequisites))
+	requiredProductIDs := make([]uuid.UUID, 0, len(prerequisites))
+	requiredLevels := make(map[uuid.UUID]uuid.UUID)
+	requiredLevelIDs := make([]uuid.UUID, 0)
+	for _, p := range prerequisites {
+		if _, ok := requiredProducts[p.RequiredProductID]; !ok {
+			requiredProducts[p.RequiredProductID] = struct{}{}
+			requiredProductIDs = append(requiredProductIDs, p.RequiredProductID)
+		}
+
+		if p.RequiredProductLevelID != uuid.Nil {
+			if productID, ok := requiredLevels[p.RequiredProductLevelID]; ok && productID != p.RequiredProductID {
+				return fmt.Errorf("product level is required with different products: %v", p.RequiredProductLevelID)
+			}
+			requiredLevels[p.RequiredProductLevelID] = p.RequiredProductID
+			requiredLevelIDs = append(requiredLevelIDs, p.RequiredProductLevelID)
+		}
+	}
+
+	count, err := s.productRepository.CountProducts(ctx, product.MiniAppID, requiredProductIDs)
+	if err != nil {
+		return fmt.Errorf("failed to count required products: %w", err)
+	}
+	if count != len(requiredProductIDs) {
+		return fmt.Errorf("required product not found")
+	}
+
+	if len(requiredLevelIDs) != 0 {
+		levels, err := s.productLevelRepository.GetByID(ctx, requiredLevelIDs...)
+		if err != nil {
+			return fmt.Errorf("failed to get required product levels: %w", err)
+		}
+		if len(levels) != len(requiredLevels) {
+			return fmt.Errorf("required product level not found")
+		}
+		for _, l := range levels {
+			if requiredLevels[l.ID] != l.ProductID {
+				return fmt.Errorf("product level is not included in the required product: %v", l.ID)
+			}
+		}
+	}
+
+	isCycle, err := s.productRepository.RequiresProduct(ctx, product.ID, requiredProductIDs)
+	if err != nil {
+		return fmt.Errorf("failed to check prerequisites cycle: %w", err)
+	}
+	if isCycle {
+		return fmt.Errorf("required product depends on the product")
+	}
+
+	return nil
+}
+
 func (s *ProductService) ReorderLessons(
 	ctx context.Context,
 	productID uuid.UUID,
@@ -281,10 +370,10 @@ func (s *ProductService) Feedback(ctx context.Context, productID uuid.UUID) (*mo
 
 func (s *ProductService) Students(
 	ctx context.Context, productID uuid.UUID, usernameSearch string,
-	limit, offset uint,
+	cohortIDs []uuid.UUID, limit, offset uint,
 ) (*model.ProductStudents, error) {
 
-	students, err := s.productRepository.Students(ctx, productID, usernameSearch, limit, offset)
+	students, err := s.productRepository.Students(ctx, productID, usernameSearch, cohortIDs, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to product students: %w", err)
 	}
@@ -321,7 +410,7 @@ func (s *ProductService) StudentsExportToExcel(
 	headers := []string{
 		"User ID", "First Name", "Last Name",
 		"Telegram ID", "Telegram Username",
-		"Completed Lessons", "Total Lessons",
+		"Completed Lessons", "Total Lessons", "Points",
 	}
 	headers = append(headers, lessonNames...)
 
@@ -340,6 +429,7 @@ func (s *ProductService) StudentsExportToExcel(
 			student.TelegramUsername,
 			student.CompletedLessons,
 			student.TotalLessons,
+			student.Points,
 		}
 		for _, l := range student.Lessons {
 			studentRow = append(studentRow, l)
@@ -359,7 +449,8 @@ func (s *ProductService) StudentsExportToExcel(
 }
 
 // CheckProductAccess creates new product access record. If exists then just
-// updates updated_at field.
+// updates updated_at field. Unmet product prerequisites are set to the
+// returned access and should be enforced by the caller.
 func (s *ProductService) CheckProductAccess(
 	ctx context.Context,
 	productAccess *model.ProductAccess,
@@ -370,6 +461,17 @@ func (s *ProductService) CheckProductAccess(
 		return nil, fmt.Errorf("failed to check product access: %w", err)
 	}
 
+	if productAccess.DeletedAt != nil {
+		return productAccess, nil
+	}
+
+	unmet, err := s.productRepository.UnmetPrerequisites(ctx, productAccess.UserID, productAccess.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unmet prerequisites: %w", err)
+	}
+
+	productAccess.UnmetPrerequisites = unmet
+
 	return productAccess, nil
 }
 
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StaffNotificationService struct {
+	staffNotificationRepository *repository.StaffNotificationRepository
+}
+
+func NewStaffNotificationService(
+	staffNotificationRepository *repository.StaffNotificationRepository,
+) *StaffNotificationService {
+
+	return &StaffNotificationService{
+		staffNotificationRepository: staffNotificationRepository,
+	}
+}
+
+// NotifySubmission queues notifications about homework that waits for the
+// review.
+func (s *StaffNotificationService) NotifySubmission(ctx context.Context, progress *model.LessonProgress) error {
+	if err := s.staffNotificationRepository.EnqueueSubmission(ctx, progress.UserID, progress.LessonID); err != nil {
+		return fmt.Errorf("failed to enqueue submission: %w", err)
+	}
+
+	return nil
+}
+
+// NotifyReview queues notifications about the review with low score.
+func (s *StaffNotificationService) NotifyReview(ctx context.Context, review *model.Review) error {
+	if err := s.staffNotificationRepository.EnqueueReview(ctx, review.ID); err != nil {
+		return fmt.Errorf("failed to enqueue review: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueuePlanLimits queues monthly warnings about plan limits that are close
+// to be reached.
+func (s *StaffNotificationService) EnqueuePlanLimits(ctx context.Context, now time.Time) error {
+	if err := s.staffNotificationRepository.EnqueuePlanLimits(ctx, now.UTC().Format("2006-01")); err != nil {
+		return fmt.Errorf("failed to enqueue plan limits: %w", err)
+	}
+
+	return nil
+}
+
+func (s *StaffNotificationService) Pending(ctx context.Context, limit int) ([]*model.PendingStaffNotification, error) {
+	notifications, err := s.staffNotificationRepository.Pending(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending staff notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (s *StaffNotificationService) UpdateStatus(ctx context.Context, notification *model.StaffNotification) error {
+	if err := s.staffNotificationRepository.UpdateStatus(ctx, notification); err != nil {
+		return fmt.Errorf("failed to update staff notification status: %w", err)
+	}
+
+	return nil
+}
+
+func (s *StaffNotificationService) DeleteOld(ctx context.Context, olderThan time.Time) error {
+	if err := s.staffNotificationRepository.DeleteOld(ctx, olderThan); err != nil {
+		return fmt.Errorf("failed to delete old staff notifications: %w", err)
+	}
+
+	return nil
+}
+
+func (s *StaffNotificationService) GetSettings(
+	ctx context.Context,
+	userID uuid.UUID,
+) (*model.StaffNotificationSettings, error) {
+
+	settings, err := s.staffNotificationRepository.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staff notification settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (s *StaffNotificationService) SaveSettings(ctx context.Context, settings *model.StaffNotificationSettings) error {
+	if err := s.staffNotificationRepository.SaveSettings(ctx, settings); err != nil {
+		return fmt.Errorf("failed to save staff notification settings: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"academy/internal/model"
+	"academy/internal/service/upload"
+	"academy/internal/storage/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type SubtitleService struct {
+	logger *zap.Logger
+
+	subtitleRepository *repository.SubtitleRepository
+
+	uploadService *upload.Service
+}
+
+func NewSubtitleService(
+	logger *zap.Logger,
+
+	subtitleRepository *repository.SubtitleRepository,
+
+	uploadService *upload.Service,
+) *SubtitleService {
+
+	return &SubtitleService{
+		logger: logger,
+
+		subtitleRepository: subtitleRepository,
+
+		uploadService: uploadService,
+	}
+}
+
+func (s *SubtitleService) Create(ctx context.Context, material *model.Material, subtitle *model.Subtitle) error {
+	if err := s.subtitleRepository.Create(ctx, subtitle); err != nil {
+		return fmt.Errorf("failed to create subtitles: %w", err)
+	}
+
+	s.syncOrLog(ctx, material)
+
+	return nil
+}
+
+// Update saves the replaced track, its previous Mux track is deleted.
+func (s *SubtitleService) Update(ctx context.Context, material *model.Material, subtitle *model.Subtitle) error {
+	s.deleteMuxTrack(ctx, subtitle)
+	subtitle.MuxAssetID = ""
+	subtitle.MuxTrackID = ""
+
+	if err := s.subtitleRepository.Update(ctx, subtitle); err != nil {
+		return fmt.Errorf("failed to update subtitles: %w", err)
+	}
+
+	s.syncOrLog(ctx, material)
+
+	return nil
+}
+
+func (s *SubtitleService) Delete(ctx context.Context, material *model.Material, subtitle *model.Subtitle) error {
+	if err := s.subtitleRepository.Delete(ctx, subtitle.ID); err != nil {
+		return fmt.Errorf("failed to delete subtitles: %w", err)
+	}
+
+	s.deleteMuxTrack(ctx, subtitle)
+	s.syncOrLog(ctx, material)
+
+	return nil
+}
+
+func (s *SubtitleService) GetByLanguage(ctx context.Context, materialID uuid.UUID, language string) (*model.Subtitle, error) {
+	subtitle, err := s.subtitleRepository.GetByLanguage(ctx, materialID, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtitles by language: %w", err)
+	}
+
+	return subtitle, nil
+}
+
+func (s *SubtitleService) GetByFilename(ctx context.Context, filename string) (*model.Subtitle, error) {
+	subtitle, err := s.subtitleRepository.GetByFilename(ctx, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtitles by filename: %w", err)
+	}
+
+	return subtitle, nil
+}
+
+func (s *SubtitleService) FindByMaterial(ctx context.Context, materialID uuid.UUID) ([]*model.Subtitle, error) {
+	subtitles, err := s.subtitleRepository.FindByMaterial(ctx, materialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subtitles by material: %w", err)
+	}
+
+	return subtitles, nil
+}
+
+// Search returns transcripts of lessons of the product that match the query.
+func (s *SubtitleService) Search(
+	ctx context.Context,
+	productID uuid.UUID,
+	query string,
+	onlyActive bool,
+	limit, offset int,
+) ([]*model.TranscriptMatch, error) {
+
+	matches, err := s.subtitleRepository.Search(ctx, productID, query, onlyActive, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transcripts: %w", err)
+	}
+
+	return matches, nil
+}
+
+// Sync passes tracks of the material to its video backend. Transcoded videos
+// list them in the master playlist, Mux assets get text tracks once they are
+// ready. Videos played from files are left as they are, players load tracks
+// by their files.
+func (s *SubtitleService) Sync(ctx context.Context, material *model.Material) error {
+	if len(material.Metadata) == 0 {
+		return nil
+	}
+
+	var hlsMetadata model.HLSVideoMetadata
+	if err := json.Unmarshal(material.Metadata, &hlsMetadata); err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	var muxMetadata model.MuxVideoMetadata
+	if err := json.Unmarshal(material.Metadata, &muxMetadata); err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	isHLS := hlsMetadata.Playlist != ""
+	isMux := muxMetadata.PlaybackID != "" && material.Status == model.MaterialStatusReady
+	if !isHLS && !isMux {
+		return nil
+	}
+
+	subtitles, err := s.subtitleRepository.FindByMaterial(ctx, material.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find subtitles: %w", err)
+	}
+
+	if isHLS {
+		if err := s.uploadService.SyncHLSSubtitles(ctx, hlsMetadata.AssetID, subtitles); err != nil {
+			return fmt.Errorf("failed to sync hls subtitles: %w", err)
+		}
+		return nil
+	}
+
+	for _, subtitle := range subtitles {
+		// Tracks of replaced assets are deleted with them.
+		if subtitle.MuxAssetID == muxMetadata.AssetID {
+			continue
+		}
+
+		trackID, err := s.uploadService.MuxCreateTextTrack(ctx, muxMetadata.AssetID, subtitle)
+		if errors.Is(err, upload.ErrPresignNotSupported) {
+			s.logger.Warn("mux text tracks need presigned urls, subtitles are served as files",
+				zap.String("material_id", material.ID.String()),
+			)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create mux text track: %w", err)
+		}
+
+		subtitle.MuxAssetID = muxMetadata.AssetID
+		subtitle.MuxTrackID = trackID
+
+		if err := s.subtitleRepository.UpdateMuxTrack(ctx, subtitle); err != nil {
+			return fmt.Errorf("failed to update mux track of subtitles: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// syncOrLog syncs tracks after they are changed. Tracks are saved anyway,
+// the backend gets them with the next change or transcoding.
+func (s *SubtitleService) syncOrLog(ctx context.Context, material *model.Material) {
+	if err := s.Sync(ctx, material); err != nil {
+		s.logger.Error("failed to sync subtitles",
+			zap.String("material_id", material.ID.String()),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *SubtitleService) deleteMuxTrack(ctx context.Context, subtitle *model.Subtitle) {
+	if subtitle.MuxTrackID == "" {
+		return
+	}
+
+	err := s.uploadService.MuxDeleteTrack(ctx, subtitle.MuxAssetID, subtitle.MuxTrackID)
+	if err != nil && !errors.Is(err, upload.ErrNotFound) {
+		s.logger.Error("failed to delete mux text track",
+			zap.String("subtitle_id", subtitle.ID.String()),
+			zap.String("track_id", subtitle.MuxTrackID),
+			zap.Error(err),
+		)
+	}
+}
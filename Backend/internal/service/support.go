@@ -0,0 +1,141 @@
+package service
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"academy/internal/storage/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type SupportService struct {
+	supportRepository  *repository.SupportRepository
+	transactionManager *repo.TransactionManager
+}
+
+func NewSupportService(
+	supportRepository *repository.SupportRepository,
+	transactionManager *repo.TransactionManager,
+) *SupportService {
+
+	return &SupportService{
+		supportRepository:  supportRepository,
+		transactionManager: transactionManager,
+	}
+}
+
+// AddStudentMessage adds message of the student to the last ticket that is
+// not closed or opens a new one. It reports whether the ticket is new.
+func (s *SupportService) AddStudentMessage(
+	ctx context.Context,
+	miniAppID, userID uuid.UUID,
+	text string,
+	telegramMessageID int,
+) (*model.SupportTicket, bool, error) {
+
+	var ticket *model.SupportTicket
+	var isNew bool
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		supportRepository := s.supportRepository.WithTx(tx)
+
+		var err error
+		ticket, err = supportRepository.LastActive(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get active ticket: %w", err)
+		}
+
+		if ticket == nil {
+			isNew = true
+			ticket = model.NewSupportTicket(miniAppID, userID, text)
+			if err := supportRepository.Create(ctx, ticket); err != nil {
+				return fmt.Errorf("failed to create ticket: %w", err)
+			}
+		} else {
+			now := time.Now().UTC()
+			ticket.Status = model.SupportTicketStatusOpen
+			ticket.LastMessageAt = now
+			ticket.UpdatedAt = now
+			if err := supportRepository.UpdateTicket(ctx, ticket); err != nil {
+				return fmt.Errorf("failed to update ticket: %w", err)
+			}
+		}
+
+		message := model.NewSupportMessage(ticket.ID, userID, false, text)
+		message.TelegramMessageID = telegramMessageID
+
+		if err := supportRepository.CreateMessage(ctx, message); err != nil {
+			return fmt.Errorf("failed to create message: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ticket, isNew, nil
+}
+
+// Reply saves the reply that is delivered to the student. Ticket without
+// assignee is assigned to the author of the reply.
+func (s *SupportService) Reply(ctx context.Context, ticket *model.SupportTicket, message *model.SupportMessage) error {
+	return s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		supportRepository := s.supportRepository.WithTx(tx)
+
+		if err := supportRepository.CreateMessage(ctx, message); err != nil {
+			return fmt.Errorf("failed to create message: %w", err)
+		}
+
+		ticket.Status = model.SupportTicketStatusAnswered
+		ticket.ClosedAt = nil
+		ticket.LastMessageAt = message.CreatedAt
+		ticket.UpdatedAt = message.CreatedAt
+		if ticket.AssigneeID == uuid.Nil {
+			ticket.AssigneeID = message.AuthorID
+		}
+
+		if err := supportRepository.UpdateTicket(ctx, ticket); err != nil {
+			return fmt.Errorf("failed to update ticket: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetByID returns ticket with the student, the assignee and messages.
+func (s *SupportService) GetByID(ctx context.Context, id uuid.UUID) (*model.SupportTicket, error) {
+	ticket, err := s.supportRepository.GetWithMessages(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	return ticket, nil
+}
+
+func (s *SupportService) Find(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterSupportTicketsRequest,
+) ([]*model.SupportTicket, int, error) {
+
+	tickets, total, err := s.supportRepository.Find(ctx, miniAppID, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find tickets: %w", err)
+	}
+
+	return tickets, total, nil
+}
+
+func (s *SupportService) Update(ctx context.Context, ticket *model.SupportTicket) error {
+	if err := s.supportRepository.UpdateTicket(ctx, ticket); err != nil {
+		return fmt.Errorf("failed to update ticket: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// ParseLoginWidget parses data of the Telegram Login Widget passed as a query
+// string, the same way it is sent to the widget auth URL. User of the widget
+// is returned as init data, so it signs in as in the mini-app.
+// https://core.telegram.org/widgets/login#checking-authorization
+func (s *Service) ParseLoginWidget(
+	botToken string,
+	rawAuthData string,
+	validate bool,
+) (*initdata.InitData, error) {
+
+	values, err := url.ParseQuery(rawAuthData)
+	if err != nil {
+		return nil, fmt.Errorf("url.ParseQuery: %w", err)
+	}
+
+	if validate {
+		if err := validateLoginWidget(values, botToken, s.tgTokenTTL, time.Now()); err != nil {
+			return nil, fmt.Errorf("validateLoginWidget: %w", err)
+		}
+	}
+
+	id, err := strconv.ParseInt(values.Get("id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+
+	authDate, _ := strconv.Atoi(values.Get("auth_date"))
+
+	return &initdata.InitData{
+		AuthDateRaw: authDate,
+		Hash:        values.Get("hash"),
+		User: initdata.User{
+			ID:        id,
+			FirstName: values.Get("first_name"),
+			LastName:  values.Get("last_name"),
+			Username:  values.Get("username"),
+			PhotoURL:  values.Get("photo_url"),
+		},
+	}, nil
+}
+
+// validateLoginWidget checks the hash of the data-check-string, the key is
+// SHA256 of the bot token. Zero expIn disables expiration check.
+func validateLoginWidget(values url.Values, botToken string, expIn time.Duration, now time.Time) error {
+	hash := values.Get("hash")
+	if hash == "" {
+		return initdata.ErrSignMissing
+	}
+
+	authDateRaw := values.Get("auth_date")
+	if authDateRaw == "" {
+		return initdata.ErrAuthDateMissing
+	}
+
+	authDate, err := strconv.ParseInt(authDateRaw, 10, 64)
+	if err != nil {
+		return initdata.ErrUnexpectedFormat
+	}
+
+	if expIn > 0 && time.Unix(authDate, 0).Add(expIn).Before(now) {
+		return initdata.ErrExpired
+	}
+
+	if !hmac.Equal([]byte(hash), []byte(signLoginWidget(values, botToken))) {
+		return initdata.ErrSignInvalid
+	}
+
+	return nil
+}
+
+// signLoginWidget returns the hash of all fields except the hash itself
+// sorted by key.
+func signLoginWidget(values url.Values, botToken string) string {
+	pairs := make([]string, 0, len(values))
+	for k := range values {
+		if k == "hash" {
+			continue
+		}
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+	sort.Strings(pairs)
+
+	secret := sha256.Sum256([]byte(botToken))
+
+	h := hmac.New(sha256.New, secret[:])
+	h.Write([]byte(strings.Join(pairs, "\n")))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
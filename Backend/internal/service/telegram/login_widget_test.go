@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+func TestValidateLoginWidget(t *testing.T) {
+	const botToken = "123456:ABC-DEF"
+	const hash = "f4ee930538e38818d131fade2cd8ec1439dfe2260dade4ede80f413f2c4e8fe4"
+
+	authDate := time.Unix(1704067200, 0)
+
+	tests := []struct {
+		name  string
+		data  string
+		token string
+		now   time.Time
+		want  error
+	}{
+		{
+			name:  "Valid",
+			data:  "id=42&first_name=John&username=john&auth_date=1704067200&hash=" + hash,
+			token: botToken,
+			now:   authDate.Add(time.Minute),
+		},
+		{
+			name:  "Other bot",
+			data:  "id=42&first_name=John&username=john&auth_date=1704067200&hash=" + hash,
+			token: "654321:ABC-DEF",
+			now:   authDate.Add(time.Minute),
+			want:  initdata.ErrSignInvalid,
+		},
+		{
+			name:  "Changed field",
+			data:  "id=43&first_name=John&username=john&auth_date=1704067200&hash=" + hash,
+			token: botToken,
+			now:   authDate.Add(time.Minute),
+			want:  initdata.ErrSignInvalid,
+		},
+		{
+			name:  "Expired",
+			data:  "id=42&first_name=John&username=john&auth_date=1704067200&hash=" + hash,
+			token: botToken,
+			now:   authDate.Add(2 * time.Hour),
+			want:  initdata.ErrExpired,
+		},
+		{
+			name:  "No hash",
+			data:  "id=42&first_name=John&username=john&auth_date=1704067200",
+			token: botToken,
+			now:   authDate,
+			want:  initdata.ErrSignMissing,
+		},
+		{
+			name:  "No auth date",
+			data:  "id=42&first_name=John&username=john&hash=" + hash,
+			token: botToken,
+			now:   authDate,
+			want:  initdata.ErrAuthDateMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := validateLoginWidget(values, tt.token, time.Hour, tt.now); !errors.Is(got, tt.want) {
+				t.Errorf("validateLoginWidget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
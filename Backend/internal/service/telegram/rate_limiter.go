@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Telegram limits bots to about 30 messages per second in total and one
+// message per second in a single chat.
+const (
+	botMessageInterval  = time.Second / 25
+	chatMessageInterval = time.Second
+)
+
+// maxLimiterKeys is the number of tracked bots and chats after which expired
+// ones are removed.
+const maxLimiterKeys = 10_000
+
+type chatKey struct {
+	bot    string
+	chatID int64
+}
+
+// RateLimiter spaces messages of bots so they are not rejected by Telegram.
+// Bots are identified by any unique key, e.g. mini-app ID.
+type RateLimiter struct {
+	mu   sync.Mutex
+	now  func() time.Time
+	bots map[string]time.Time
+	chat map[chatKey]time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		now:  time.Now,
+		bots: make(map[string]time.Time),
+		chat: make(map[chatKey]time.Time),
+	}
+}
+
+// Wait blocks until the bot is allowed to send message to the chat.
+func (l *RateLimiter) Wait(ctx context.Context, bot string, chatID int64) error {
+	delay := l.reserve(bot, chatID)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve books the earliest time slot for the message and returns delay
+// before it.
+func (l *RateLimiter) reserve(bot string, chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	key := chatKey{bot: bot, chatID: chatID}
+
+	at := now
+	if next := l.bots[bot]; next.After(at) {
+		at = next
+	}
+	if next := l.chat[key]; next.After(at) {
+		at = next
+	}
+
+	l.bots[bot] = at.Add(botMessageInterval)
+	l.chat[key] = at.Add(chatMessageInterval)
+
+	if maxLimiterKeys < len(l.chat) {
+		for k, next := range l.chat {
+			if next.Before(now) {
+				delete(l.chat, k)
+			}
+		}
+		for k, next := range l.bots {
+			if next.Before(now) {
+				delete(l.bots, k)
+			}
+		}
+	}
+
+	return at.Sub(now)
+}
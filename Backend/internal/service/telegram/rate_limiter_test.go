@@ -0,0 +1,34 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l := NewRateLimiter()
+	l.now = func() time.Time { return now }
+
+	tests := []struct {
+		name   string
+		bot    string
+		chatID int64
+		want   time.Duration
+	}{
+		{name: "First message", bot: "a", chatID: 1, want: 0},
+		{name: "Same bot, other chat", bot: "a", chatID: 2, want: botMessageInterval},
+		{name: "Other bot", bot: "b", chatID: 1, want: 0},
+		{name: "Same chat", bot: "a", chatID: 1, want: chatMessageInterval},
+		{name: "Same bot after chat wait", bot: "a", chatID: 3, want: chatMessageInterval + botMessageInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.reserve(tt.bot, tt.chatID); got != tt.want {
+				t.Errorf("reserve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ErrBotBlocked is returned when the user blocked the bot or has not started
+// it yet.
+var ErrBotBlocked = errors.New("bot is blocked by the user")
+
+type sendMessageRequest struct {
+	ChatID      int64           `json:"chat_id"`
+	Text        string          `json:"text"`
+	ReplyMarkup *inlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+type sendPhotoRequest struct {
+	ChatID      int64           `json:"chat_id"`
+	Photo       string          `json:"photo"`
+	Caption     string          `json:"caption"`
+	ReplyMarkup *inlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+type inlineKeyboard struct {
+	InlineKeyboard [][]*InlineButton `json:"inline_keyboard"`
+}
+
+// InlineButton is a button under the message. It either opens the URL or
+// sends callback data to the bot.
+type InlineButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// Photo is an image of the message. FileID of the image that is already
+// uploaded to Telegram is used instead of the file content.
+type Photo struct {
+	Name   string
+	Data   []byte
+	FileID string
+}
+
+type sendMessageResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+	Result struct {
+		Photo []struct {
+			FileID string `json:"file_id"`
+		} `json:"photo"`
+	} `json:"result"`
+}
+
+// RetryAfterError is returned when the bot exceeds Telegram flood limits.
+// Message could be sent again after the delay.
+type RetryAfterError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %v", e.RetryAfter)
+}
+
+// SendMessage sends text message to the chat on behalf of the bot with given
+// token. Buttons are placed one per row under the message.
+func (s *Service) SendMessage(
+	ctx context.Context,
+	token string,
+	chatID int64,
+	text string,
+	buttons ...*InlineButton,
+) error {
+
+	msg := sendMessageRequest{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: newInlineKeyboard(buttons),
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	_, err = s.callBot(ctx, token, "sendMessage", "application/json", bytes.NewReader(body))
+
+	return err
+}
+
+// SendAdminMessage sends text message to the chat on behalf of the admin bot
+// that owners and moderators sign in with.
+func (s *Service) SendAdminMessage(ctx context.Context, chatID int64, text string) error {
+	return s.SendMessage(ctx, s.adminBotToken, chatID, text)
+}
+
+// SendPhoto sends image with the caption to the chat on behalf of the bot
+// with given token. It returns file ID of the image, so it could be sent
+// again without uploading.
+func (s *Service) SendPhoto(
+	ctx context.Context,
+	token string,
+	chatID int64,
+	photo *Photo,
+	caption string,
+	buttons ...*InlineButton,
+) (string, error) {
+
+	if photo.FileID != "" {
+		msg := sendPhotoRequest{
+			ChatID:      chatID,
+			Photo:       photo.FileID,
+			Caption:     caption,
+			ReplyMarkup: newInlineKeyboard(buttons),
+		}
+
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return "", fmt.Errorf("json.Marshal: %w", err)
+		}
+
+		if _, err := s.callBot(ctx, token, "sendPhoto", "application/json", bytes.NewReader(body)); err != nil {
+			return "", err
+		}
+
+		return photo.FileID, nil
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	_ = w.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	_ = w.WriteField("caption", caption)
+	if keyboard := newInlineKeyboard(buttons); keyboard != nil {
+		rawKeyboard, err := json.Marshal(keyboard)
+		if err != nil {
+			return "", fmt.Errorf("json.Marshal: %w", err)
+		}
+		_ = w.WriteField("reply_markup", string(rawKeyboard))
+	}
+
+	part, err := w.CreateFormFile("photo", filepath.Base(photo.Name))
+	if err != nil {
+		return "", fmt.Errorf("w.CreateFormFile: %w", err)
+	}
+	if _, err := part.Write(photo.Data); err != nil {
+		return "", fmt.Errorf("part.Write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("w.Close: %w", err)
+	}
+
+	result, err := s.callBot(ctx, token, "sendPhoto", w.FormDataContentType(), &body)
+	if err != nil {
+		return "", err
+	}
+
+	// The last photo size is the original one.
+	var fileID string
+	if sizes := result.Result.Photo; len(sizes) != 0 {
+		fileID = sizes[len(sizes)-1].FileID
+	}
+
+	return fileID, nil
+}
+
+func newInlineKeyboard(buttons []*InlineButton) *inlineKeyboard {
+	if len(buttons) == 0 {
+		return nil
+	}
+
+	keyboard := &inlineKeyboard{}
+	for _, b := range buttons {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []*InlineButton{b})
+	}
+
+	return keyboard
+}
+
+// callBot calls the method of Bot API. Errors of flood limits and blocked
+// bots are returned as RetryAfterError and ErrBotBlocked.
+func (s *Service) callBot(
+	ctx context.Context,
+	token, method, contentType string,
+	body io.Reader,
+) (*sendMessageResponse, error) {
+
+	u, err := url.JoinPath(baseURL, "bot"+token, method)
+	if err != nil {
+		return nil, fmt.Errorf("url.JoinPath: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s.client.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result sendMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	if !result.OK && result.Parameters.RetryAfter != 0 {
+		return nil, &RetryAfterError{RetryAfter: time.Duration(result.Parameters.RetryAfter) * time.Second}
+	}
+	if !result.OK && result.ErrorCode == http.StatusForbidden {
+		return nil, fmt.Errorf("%w: %v", ErrBotBlocked, result.Description)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("error in response: %v", result.Description)
+	}
+
+	return &result, nil
+}
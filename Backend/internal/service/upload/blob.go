@@ -0,0 +1,74 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// BlobFilename returns the name of the blob of the mini-app with the content
+// of the hash.
+func BlobFilename(miniAppID uuid.UUID, hash, fileExtension string) string {
+	blobPath := MaterialFilePath{MiniAppID: miniAppID, Blob: true}
+
+	return path.Join(blobPath.String(), hash+fileExtension)
+}
+
+// IsBlob reports whether the file is the blob shared by materials.
+func IsBlob(filename string) bool {
+	materialPath, err := ParseMaterialFilePath(filename)
+
+	return err == nil && materialPath.Blob
+}
+
+func hashFile(h hash.Hash, filePath string) (int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	return io.Copy(h, f)
+}
+
+// deleteBlob deletes the blob if no material references it anymore. Files in
+// the directory of blobs without blobs, like previews of their videos, are
+// deleted as usual.
+//
+// Materials lock the blob they reference until they are saved, so the blob
+// is locked before references are checked. Material saved after the blob is
+// deleted is rejected, see ErrBlobDeleted of the material service.
+func (s *Service) deleteBlob(ctx context.Context, filename string) (bool, error) {
+	isDeleted := true
+
+	err := s.transactionManager.WithinTransaction(ctx, func(ctx context.Context, tx bun.Tx) error {
+		blobRepository := s.blobRepository.WithTx(tx)
+
+		isLocked, err := blobRepository.Lock(ctx, filename)
+		if err != nil {
+			return fmt.Errorf("failed to lock blob: %w", err)
+		}
+		if !isLocked {
+			return nil
+		}
+
+		isDeleted, err = blobRepository.DeleteUnreferenced(ctx, filename)
+		if err != nil {
+			return fmt.Errorf("failed to delete blob: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return isDeleted, nil
+}
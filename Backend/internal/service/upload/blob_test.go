@@ -0,0 +1,31 @@
+package upload
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBlobFilename(t *testing.T) {
+	miniAppID := uuid.New()
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	filename := BlobFilename(miniAppID, hash, ".mp4")
+	if want := "ma/" + miniAppID.String() + "/b/" + hash + ".mp4"; filename != want {
+		t.Errorf("BlobFilename() = %s, want %s", filename, want)
+	}
+	if !IsBlob(filename) {
+		t.Errorf("IsBlob(%s) = false", filename)
+	}
+
+	lessonPath := MaterialFilePath{MiniAppID: miniAppID, ProductID: uuid.New(), LessonID: uuid.New()}
+	for _, filename := range []string{
+		lessonPath.String() + "/" + uuid.NewString() + ".mp4",
+		"ma/" + miniAppID.String() + "/" + uuid.NewString() + ".png",
+		"hls/" + uuid.NewString() + "/master.m3u8",
+	} {
+		if IsBlob(filename) {
+			t.Errorf("IsBlob(%s) = true", filename)
+		}
+	}
+}
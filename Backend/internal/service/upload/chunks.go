@@ -58,9 +58,12 @@ func (s *Service) AddChunk(
 	return size, nil
 }
 
+// UploadChunks joins chunks into the blob of the mini-app and returns its
+// filename. Blobs are named by SHA-256 of their content, so the file that is
+// already stored by the mini-app is not stored again.
 func (s *Service) UploadChunks(
 	ctx context.Context,
-	materialFilenameDir string, fileExtension string,
+	miniAppID uuid.UUID, fileExtension string,
 	chunks []*model.Chunk,
 ) (filename string, size int64, err error) {
 
@@ -68,46 +71,79 @@ func (s *Service) UploadChunks(
 		return filename, size, fmt.Errorf("no chunks provided")
 	}
 
-	if err := os.MkdirAll(filepath.Join(s.uploadDir, materialFilenameDir), 0755); err != nil {
-		return filename, size, fmt.Errorf("failed to create directory: %v", err)
+	hasher := sha256.New()
+
+	chunkPaths := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunkPath := filepath.Join(s.tempDir, chunk.MaterialID.String(), strconv.Itoa(int(chunk.Index)))
+
+		n, err := hashFile(hasher, chunkPath)
+		if err != nil {
+			return filename, size, fmt.Errorf("failed to hash chunk %s: %w", chunkPath, err)
+		}
+
+		chunkPaths = append(chunkPaths, chunkPath)
+		size += n
 	}
 
-	filename = filepath.Join(materialFilenameDir, uuid.New().String()+fileExtension)
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	filename = BlobFilename(miniAppID, hash, fileExtension)
+
+	materialID := chunks[0].MaterialID
 
-	dst, err := os.Create(filepath.Join(s.uploadDir, filename))
+	// Reused blob could be deleted with its last material before the material
+	// of chunks is saved, the material is rejected then, see deleteBlob.
+	blob, err := s.blobRepository.GetByFilename(ctx, filename)
 	if err != nil {
-		return filename, size, fmt.Errorf("os.Create: %w", err)
+		return filename, size, fmt.Errorf("failed to get blob: %w", err)
 	}
-	defer dst.Close()
-
-	var materialID uuid.UUID
-	for _, chunk := range chunks {
-		select {
-		case <-ctx.Done():
-			return filename, size, ctx.Err()
-		default:
+	if blob != nil {
+		if err := s.ClearChunks(materialID); err != nil {
+			return filename, size, fmt.Errorf("error deleting chunks: %w", err)
 		}
 
-		materialID = chunk.MaterialID
-		chunkPath := filepath.Join(s.tempDir, chunk.MaterialID.String(), strconv.Itoa(int(chunk.Index)))
+		s.logger.Info("reused blob", zap.String("file", filename))
+		return blob.Filename, blob.Size, nil
+	}
 
-		src, err := os.Open(chunkPath)
-		if err != nil {
-			return filename, size, fmt.Errorf("failed to open file %s: %w", chunkPath, err)
-		}
+	// Chunks are streamed one by one, so only one of them is open at a time.
+	pr, pw := io.Pipe()
+	go func() {
+		for _, chunkPath := range chunkPaths {
+			select {
+			case <-ctx.Done():
+				pw.CloseWithError(ctx.Err())
+				return
+			default:
+			}
+
+			src, err := os.Open(chunkPath)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to open file %s: %w", chunkPath, err))
+				return
+			}
 
-		chunkSize, err := io.Copy(dst, src)
+			_, err = io.Copy(pw, src)
 
-		src.Close()
+			src.Close()
 
-		if err != nil {
-			return filename, size, fmt.Errorf("failed to copy file %s: %w", chunkPath, err)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to copy file %s: %w", chunkPath, err))
+				return
+			}
 		}
+		pw.Close()
+	}()
 
-		size += chunkSize
+	err = s.storage.Put(ctx, filename, pr, size)
+	pr.Close()
+	if err != nil {
+		return filename, size, fmt.Errorf("failed to save file: %w", err)
 	}
 
-	dst.Close()
+	if err := s.blobRepository.Create(ctx, model.NewBlob(miniAppID, hash, filename, size)); err != nil {
+		return filename, size, fmt.Errorf("failed to create blob: %w", err)
+	}
 
 	if err := s.ClearChunks(materialID); err != nil {
 		return filename, size, fmt.Errorf("error deleting chunks: %w", err)
@@ -3,9 +3,10 @@ package upload
 import (
 	"academy/internal/model"
 	"academy/internal/types"
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -29,14 +30,19 @@ const audioOriginalFilename = "audio_lesson_file.mp3"
 // Moved to Mux video hosting service.
 // const circularVideoOriginalFilename = "circular_video_lesson_file.mp4"
 
-func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
+// demoKey returns key of the demo product file.
+func demoKey(filename string) string {
+	return path.Join(demoDir, filename)
+}
+
+func (s *Service) AddDemoProduct(ctx context.Context, miniApp *model.MiniApp) error {
 	var tempFiles []string
 	defer func() {
 		for _, filename := range tempFiles {
 			if filename == "" {
 				continue
 			}
-			err := s.Delete(filepath.Join(s.uploadDir, filename))
+			err := s.storage.Delete(ctx, filename)
 			if err != nil {
 				s.logger.Error("error while deleting file", zap.String("err", err.Error()))
 			}
@@ -47,30 +53,16 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 
 	materialPath := MaterialFilePath{MiniAppID: miniApp.ID}
 
-	if err := os.MkdirAll(filepath.Join(s.uploadDir, materialPath.String()), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
-	}
-
 	miniApp.Logo = filepath.Join(materialPath.String(), uuid.NewString()+filepath.Ext(miniAppLogoFilename))
 
-	err := os.Link(
-		filepath.Join(s.uploadDir, demoDir, miniAppLogoFilename),
-		filepath.Join(s.uploadDir, miniApp.Logo),
-	)
+	logoSize, err := s.copyObject(ctx, demoKey(miniAppLogoFilename), miniApp.Logo)
 	if err != nil {
-		return fmt.Errorf("failed to create link for default mini-app logo: %w", err)
+		return fmt.Errorf("failed to copy default mini-app logo: %w", err)
 	}
 	tempFiles = append(tempFiles, miniApp.Logo)
+	miniApp.LogoSize = logoSize
 
-	miniAppLogoStat, err := os.Stat(filepath.Join(s.uploadDir, miniApp.Logo))
-	if err != nil {
-		return fmt.Errorf("os.Stat: %w", err)
-	}
-	miniApp.LogoSize = miniAppLogoStat.Size()
-
-	productFilename := filepath.Join(s.uploadDir, demoDir, productStructureFilename)
-
-	productFile, err := os.Open(productFilename)
+	productFile, _, err := s.storage.Get(ctx, demoKey(productStructureFilename), nil)
 	if err != nil {
 		return fmt.Errorf("failed to open demo product: %w", err)
 	}
@@ -88,26 +80,15 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 	product.CreatedAt = now
 
 	materialPath.ProductID = product.ID
-	if err := os.MkdirAll(filepath.Join(s.uploadDir, materialPath.String()), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
-	}
 
 	product.Cover = filepath.Join(materialPath.String(), uuid.NewString()+filepath.Ext(productCoverFilename))
 
-	err = os.Link(
-		filepath.Join(s.uploadDir, demoDir, productCoverFilename),
-		filepath.Join(s.uploadDir, product.Cover),
-	)
+	coverSize, err := s.copyObject(ctx, demoKey(productCoverFilename), product.Cover)
 	if err != nil {
-		return fmt.Errorf("failed to create link for demo product cover: %w", err)
+		return fmt.Errorf("failed to copy demo product cover: %w", err)
 	}
 	tempFiles = append(tempFiles, product.Cover)
-
-	productCoverStat, err := os.Stat(filepath.Join(s.uploadDir, product.Cover))
-	if err != nil {
-		return fmt.Errorf("os.Stat: %w", err)
-	}
-	product.CoverSize = productCoverStat.Size()
+	product.CoverSize = coverSize
 
 	for _, lesson := range product.Lessons {
 		lesson.ID = uuid.New()
@@ -120,6 +101,8 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 				AddDate(0, 0, 1)
 			lesson.ReleaseDate = types.NewTime(releaseDate)
 			lesson.AccessTime = types.NewInterval(types.JsonInterval{Days: 1})
+			lesson.EventStart = types.NewTime(releaseDate.Add(18 * time.Hour))
+			lesson.EventEnd = types.NewTime(releaseDate.Add(19 * time.Hour))
 		}
 
 		for _, material := range lesson.Materials {
@@ -133,9 +116,6 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 			}
 
 			materialPath.LessonID = lesson.ID
-			if err := os.MkdirAll(filepath.Join(s.uploadDir, materialPath.String()), 0755); err != nil {
-				return fmt.Errorf("failed to create directory: %v", err)
-			}
 
 			switch material.ContentType {
 			case model.MaterialTypeVideo:
@@ -151,31 +131,19 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 					UpdatedAt:        now,
 					CreatedAt:        now,
 				}
-				err = os.Link(
-					filepath.Join(s.uploadDir, demoDir, videoCoverFilename),
-					filepath.Join(s.uploadDir, coverMaterial.Filename),
-				)
+				coverMaterial.Size, err = s.copyObject(ctx, demoKey(videoCoverFilename), coverMaterial.Filename)
 				if err != nil {
-					return fmt.Errorf("failed to create link for demo video cover: %w", err)
+					return fmt.Errorf("failed to copy demo video cover: %w", err)
 				}
 				lesson.Materials = append(lesson.Materials, coverMaterial)
 				tempFiles = append(tempFiles, coverMaterial.Filename)
 			case model.MaterialTypeAudio:
-				audioFile, err := os.Open(filepath.Join(s.uploadDir, demoDir, audioOriginalFilename))
-				if err != nil {
-					return fmt.Errorf("failed to open demo audio: %w", err)
-				}
-				defer audioFile.Close()
-
 				material.OriginalFilename = audioOriginalFilename
 				material.Filename = filepath.Join(materialPath.String(), uuid.NewString()+filepath.Ext(audioOriginalFilename))
 
-				err = os.Link(
-					filepath.Join(s.uploadDir, demoDir, audioOriginalFilename),
-					filepath.Join(s.uploadDir, material.Filename),
-				)
+				material.Size, err = s.copyObject(ctx, demoKey(audioOriginalFilename), material.Filename)
 				if err != nil {
-					return fmt.Errorf("failed to create link for demo audio: %w", err)
+					return fmt.Errorf("failed to copy demo audio: %w", err)
 				}
 
 				coverMaterial := &model.Material{
@@ -190,12 +158,9 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 					UpdatedAt:        now,
 					CreatedAt:        now,
 				}
-				err = os.Link(
-					filepath.Join(s.uploadDir, demoDir, audioCoverFilename),
-					filepath.Join(s.uploadDir, coverMaterial.Filename),
-				)
+				coverMaterial.Size, err = s.copyObject(ctx, demoKey(audioCoverFilename), coverMaterial.Filename)
 				if err != nil {
-					return fmt.Errorf("failed to create link for demo audio cover: %w", err)
+					return fmt.Errorf("failed to copy demo audio cover: %w", err)
 				}
 				lesson.Materials = append(lesson.Materials, coverMaterial)
 				tempFiles = append(tempFiles, coverMaterial.Filename)
@@ -212,12 +177,9 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 					UpdatedAt:        now,
 					CreatedAt:        now,
 				}
-				err = os.Link(
-					filepath.Join(s.uploadDir, demoDir, textCoverFilename),
-					filepath.Join(s.uploadDir, coverMaterial.Filename),
-				)
+				coverMaterial.Size, err = s.copyObject(ctx, demoKey(textCoverFilename), coverMaterial.Filename)
 				if err != nil {
-					return fmt.Errorf("failed to create link for demo text cover: %w", err)
+					return fmt.Errorf("failed to copy demo text cover: %w", err)
 				}
 				lesson.Materials = append(lesson.Materials, coverMaterial)
 				tempFiles = append(tempFiles, coverMaterial.Filename)
@@ -235,26 +197,15 @@ func (s *Service) AddDemoProduct(miniApp *model.MiniApp) error {
 					UpdatedAt:        now,
 					CreatedAt:        now,
 				}
-				err = os.Link(
-					filepath.Join(s.uploadDir, demoDir, circularVideoCoverFilename),
-					filepath.Join(s.uploadDir, coverMaterial.Filename),
-				)
+				coverMaterial.Size, err = s.copyObject(ctx, demoKey(circularVideoCoverFilename), coverMaterial.Filename)
 				if err != nil {
-					return fmt.Errorf("failed to create link for demo circular video cover: %w", err)
+					return fmt.Errorf("failed to copy demo circular video cover: %w", err)
 				}
 				lesson.Materials = append(lesson.Materials, coverMaterial)
 				tempFiles = append(tempFiles, coverMaterial.Filename)
 			}
 			tempFiles = append(tempFiles, material.Filename)
 		}
-
-		for _, m := range lesson.Materials {
-			materialStat, err := os.Stat(filepath.Join(s.uploadDir, m.Filename))
-			if err != nil {
-				return fmt.Errorf("os.Stat: %w", err)
-			}
-			m.Size = materialStat.Size()
-		}
 	}
 
 	miniApp.Products = append(miniApp.Products, product)
@@ -0,0 +1,395 @@
+package upload
+
+import (
+	"academy/internal/model"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Backends lesson videos are played from.
+const (
+	VideoBackendMux = "mux"
+	VideoBackendHLS = "hls"
+)
+
+// hlsDir keeps transcoded videos, every asset is a directory of its files.
+const hlsDir = "hls"
+
+const (
+	hlsPlaylist          = "master.m3u8"
+	hlsThumbnail         = "thumbnail.jpg"
+	hlsSegmentDuration   = 6
+	hlsTokenExpiration   = 12 * time.Hour
+	hlsThumbnailHeight   = 360
+	hlsRenditionPlaylist = "index.m3u8"
+)
+
+var (
+	ErrUnknownVideoBackend = errors.New("unknown video backend")
+	ErrInvalidHLSToken     = errors.New("invalid token")
+	ErrInvalidHLSFile      = errors.New("invalid file")
+)
+
+// hlsRendition is a quality of the transcoded video.
+type hlsRendition struct {
+	Name         string
+	Height       int
+	VideoBitrate string
+	MaxRate      string
+	BufSize      string
+	AudioBitrate string
+}
+
+// hlsLadder is ordered from the highest quality.
+var hlsLadder = []hlsRendition{
+	{Name: "1080p", Height: 1080, VideoBitrate: "5000k", MaxRate: "5350k", BufSize: "7500k", AudioBitrate: "192k"},
+	{Name: "720p", Height: 720, VideoBitrate: "2800k", MaxRate: "2996k", BufSize: "4200k", AudioBitrate: "128k"},
+	{Name: "480p", Height: 480, VideoBitrate: "1400k", MaxRate: "1498k", BufSize: "2100k", AudioBitrate: "128k"},
+	{Name: "360p", Height: 360, VideoBitrate: "800k", MaxRate: "856k", BufSize: "1200k", AudioBitrate: "96k"},
+}
+
+// hlsRenditions returns renditions that do not upscale the video, the lowest
+// one is always returned.
+func hlsRenditions(height int) []hlsRendition {
+	renditions := make([]hlsRendition, 0, len(hlsLadder))
+	for _, r := range hlsLadder {
+		if r.Height <= height {
+			renditions = append(renditions, r)
+		}
+	}
+
+	if len(renditions) == 0 {
+		renditions = append(renditions, hlsLadder[len(hlsLadder)-1])
+	}
+
+	return renditions
+}
+
+// hlsArgs returns ffmpeg arguments to transcode the video into renditions
+// with the master playlist in the output directory. Key frames are forced at
+// segment boundaries, so segments of renditions are aligned.
+func hlsArgs(src, outDir string, renditions []hlsRendition, hasAudio bool) []string {
+	var filter strings.Builder
+	fmt.Fprintf(&filter, "[0:v]split=%d", len(renditions))
+	for i := range renditions {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	for i, r := range renditions {
+		fmt.Fprintf(&filter, ";[v%d]scale=-2:%d[v%dout]", i, r.Height, i)
+	}
+
+	args := []string{
+		"-i", src,
+		"-filter_complex", filter.String(),
+	}
+
+	streams := make([]string, 0, len(renditions))
+	for i, r := range renditions {
+		args = append(args,
+			"-map", fmt.Sprintf("[v%dout]", i),
+			fmt.Sprintf("-c:v:%d", i), "libx264",
+			fmt.Sprintf("-b:v:%d", i), r.VideoBitrate,
+			fmt.Sprintf("-maxrate:v:%d", i), r.MaxRate,
+			fmt.Sprintf("-bufsize:v:%d", i), r.BufSize,
+		)
+		stream := fmt.Sprintf("v:%d", i)
+
+		if hasAudio {
+			args = append(args,
+				"-map", "0:a:0",
+				fmt.Sprintf("-c:a:%d", i), "aac",
+				fmt.Sprintf("-b:a:%d", i), r.AudioBitrate,
+			)
+			stream += fmt.Sprintf(",a:%d", i)
+		}
+
+		streams = append(streams, stream+",name:"+r.Name)
+	}
+
+	if hasAudio {
+		args = append(args, "-ac", "2")
+	}
+
+	return append(args,
+		"-preset", "faster",
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", hlsSegmentDuration),
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(hlsSegmentDuration),
+		"-hls_playlist_type", "vod",
+		"-hls_flags", "independent_segments",
+		"-hls_segment_filename", filepath.Join(outDir, "%v", "segment_%05d.ts"),
+		"-master_pl_name", hlsPlaylist,
+		"-var_stream_map", strings.Join(streams, " "),
+		filepath.Join(outDir, "%v", hlsRenditionPlaylist),
+	)
+}
+
+type videoProbe struct {
+	Width    int
+	Height   int
+	HasAudio bool
+	// Duration is a number of seconds, it is 0 if it is unknown.
+	Duration float64
+}
+
+// parseVideoProbe parses the JSON output of ffprobe with streams.
+func parseVideoProbe(data []byte) (*videoProbe, error) {
+	var output struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+
+	probe := new(videoProbe)
+	for _, stream := range output.Streams {
+		switch stream.CodecType {
+		case "video":
+			if probe.Height < stream.Height {
+				probe.Width, probe.Height = stream.Width, stream.Height
+			}
+		case "audio":
+			probe.HasAudio = true
+		}
+	}
+
+	if probe.Height == 0 {
+		return nil, fmt.Errorf("no video stream")
+	}
+
+	if output.Format.Duration != "" {
+		duration, err := strconv.ParseFloat(output.Format.Duration, 64)
+		if err == nil {
+			probe.Duration = duration
+		}
+	}
+
+	return probe, nil
+}
+
+func probeVideo(ctx context.Context, src string) (*videoProbe, error) {
+	cmd := exec.CommandContext(ctx,
+		"ffprobe",
+		"-v", "error",
+		"-show_entries", "stream=codec_type,width,height:format=duration",
+		"-of", "json",
+		src,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe error: %w: %v", err, stderr.String())
+	}
+
+	return parseVideoProbe(output)
+}
+
+func runFFmpeg(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg error: %w: %v", err, stderr.String())
+	}
+
+	return nil
+}
+
+// VideoStatus returns the pending status of the video backend of the
+// deployment instead of pending statuses of other backends.
+func (s *Service) VideoStatus(status model.MaterialStatus) model.MaterialStatus {
+	switch status {
+	case model.MaterialStatusPendingMoveToMux, model.MaterialStatusPendingTranscoding:
+	default:
+		return status
+	}
+
+	if s.videoBackend == VideoBackendHLS {
+		return model.MaterialStatusPendingTranscoding
+	}
+
+	return model.MaterialStatusPendingMoveToMux
+}
+
+// TranscodeHLS transcodes the video into renditions that are not larger than
+// the video and takes its thumbnail. Files are saved as the new asset, the
+// size of its files is returned.
+func (s *Service) TranscodeHLS(ctx context.Context, filename string) (*model.HLSVideoMetadata, int64, error) {
+	s.transcodeMu.Lock()
+	defer s.transcodeMu.Unlock()
+
+	src, release, err := s.fetch(ctx, filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get file: %w", err)
+	}
+	defer release()
+
+	probe, err := probeVideo(ctx, src)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to probe video: %w", err)
+	}
+
+	outDir, err := os.MkdirTemp(s.tempDir, "hls-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("os.MkdirTemp: %w", err)
+	}
+	defer os.RemoveAll(outDir)
+
+	renditions := hlsRenditions(probe.Height)
+
+	if err := runFFmpeg(ctx, hlsArgs(src, outDir, renditions, probe.HasAudio)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to transcode video: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, hlsPlaylist)); err != nil {
+		return nil, 0, fmt.Errorf("master playlist not found: %w", err)
+	}
+
+	// Thumbnail is the most representative of the first frames.
+	err = runFFmpeg(ctx,
+		"-i", src,
+		"-vf", fmt.Sprintf("thumbnail,scale=-2:%d", hlsThumbnailHeight),
+		"-frames:v", "1",
+		filepath.Join(outDir, hlsThumbnail),
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to take thumbnail: %w", err)
+	}
+
+	metadata := &model.HLSVideoMetadata{
+		AssetID:    path.Join(hlsDir, uuid.New().String()),
+		Playlist:   hlsPlaylist,
+		Thumbnail:  hlsThumbnail,
+		Renditions: make([]string, 0, len(renditions)),
+	}
+	for _, r := range renditions {
+		metadata.Renditions = append(metadata.Renditions, r.Name)
+	}
+
+	var size int64
+	err = filepath.WalkDir(outDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(outDir, filePath)
+		if err != nil {
+			return err
+		}
+
+		n, err := s.store(ctx, path.Join(metadata.AssetID, filepath.ToSlash(rel)), filePath)
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", rel, err)
+		}
+		size += n
+
+		return nil
+	})
+	if err != nil {
+		if err := s.deleteHLSAsset(ctx, metadata.AssetID); err != nil {
+			s.logger.Error("failed to delete asset", zap.String("asset_id", metadata.AssetID), zap.Error(err))
+		}
+		return nil, 0, err
+	}
+
+	return metadata, size, nil
+}
+
+// DeleteVideoAsset deletes the asset of Mux or the transcoded one.
+func (s *Service) DeleteVideoAsset(ctx context.Context, assetID string) error {
+	if strings.HasPrefix(assetID, hlsDir+"/") {
+		return s.deleteHLSAsset(ctx, assetID)
+	}
+
+	return s.MuxDeleteAsset(ctx, assetID)
+}
+
+func (s *Service) deleteHLSAsset(ctx context.Context, assetID string) error {
+	return s.storage.List(ctx, assetID+"/", func(object *ObjectInfo) error {
+		return s.storage.Delete(ctx, object.Key)
+	})
+}
+
+// SignHLSAsset returns the token files of the asset are downloaded with. The
+// token is a part of the path, so URLs in playlists that are relative keep it.
+func (s *Service) SignHLSAsset(assetID string) string {
+	expiresAt := strconv.FormatInt(time.Now().Add(hlsTokenExpiration).Unix(), 10)
+
+	return expiresAt + "." + s.hlsSignature(assetID, expiresAt)
+}
+
+func (s *Service) hlsSignature(assetID, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.secretKey)
+	mac.Write([]byte(assetID + "." + expiresAt))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HLSFile returns the key of the file of the asset if the token is valid.
+func (s *Service) HLSFile(assetID, token, name string) (string, error) {
+	expiresAt, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidHLSToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.hlsSignature(assetID, expiresAt))) {
+		return "", ErrInvalidHLSToken
+	}
+
+	unix, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || time.Unix(unix, 0).Before(time.Now()) {
+		return "", ErrInvalidHLSToken
+	}
+
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	switch path.Ext(name) {
+	case ".m3u8", ".ts", ".jpg", ".vtt":
+	default:
+		return "", ErrInvalidHLSFile
+	}
+
+	return path.Join(assetID, name), nil
+}
+
+// HLSAssetID returns ID of the asset by the name of its directory.
+func HLSAssetID(dir string) (string, error) {
+	if _, err := uuid.Parse(dir); err != nil {
+		return "", fmt.Errorf("invalid asset: %w", err)
+	}
+
+	return path.Join(hlsDir, dir), nil
+}
@@ -0,0 +1,96 @@
+package upload
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHLSRenditions(t *testing.T) {
+	tests := []struct {
+		height int
+		want   string
+	}{
+		{height: 2160, want: "1080p,720p,480p,360p"},
+		{height: 720, want: "720p,480p,360p"},
+		{height: 600, want: "480p,360p"},
+		{height: 240, want: "360p"},
+	}
+	for _, tt := range tests {
+		names := make([]string, 0)
+		for _, r := range hlsRenditions(tt.height) {
+			names = append(names, r.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("hlsRenditions(%d) = %s, want %s", tt.height, got, tt.want)
+		}
+	}
+
+	args := strings.Join(hlsArgs("in.mp4", "out", hlsRenditions(720), true), " ")
+	for _, want := range []string{
+		"[0:v]split=3[v0][v1][v2];[v0]scale=-2:720[v0out];[v1]scale=-2:480[v1out];[v2]scale=-2:360[v2out]",
+		"-var_stream_map v:0,a:0,name:720p v:1,a:1,name:480p v:2,a:2,name:360p",
+		"-master_pl_name master.m3u8",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("hlsArgs() = %s, want %s", args, want)
+		}
+	}
+
+	args = strings.Join(hlsArgs("in.mp4", "out", hlsRenditions(360), false), " ")
+	if strings.Contains(args, "0:a:0") || !strings.Contains(args, "-var_stream_map v:0,name:360p") {
+		t.Errorf("hlsArgs() without audio = %s", args)
+	}
+}
+
+func TestParseVideoProbe(t *testing.T) {
+	probe, err := parseVideoProbe([]byte(`{"streams": [
+		{"codec_type": "video", "width": 1920, "height": 1080},
+		{"codec_type": "audio"}
+	], "format": {"duration": "125.480000"}}`))
+	if err != nil {
+		t.Fatalf("parseVideoProbe() error = %v", err)
+	}
+	if probe.Width != 1920 || probe.Height != 1080 || !probe.HasAudio || probe.Duration != 125.48 {
+		t.Errorf("parseVideoProbe() = %+v", probe)
+	}
+
+	if _, err := parseVideoProbe([]byte(`{"streams": [{"codec_type": "audio"}]}`)); err == nil {
+		t.Errorf("parseVideoProbe() of audio expected error")
+	}
+}
+
+func TestHLSFile(t *testing.T) {
+	s := &Service{secretKey: []byte("secret")}
+
+	assetID, err := HLSAssetID("0b8f5b8e-3c1a-4a4e-9f3e-2d6f1e7c9a10")
+	if err != nil {
+		t.Fatalf("HLSAssetID() error = %v", err)
+	}
+	token := s.SignHLSAsset(assetID)
+
+	key, err := s.HLSFile(assetID, token, "720p/../../../ma/segment_00001.ts")
+	if err != nil {
+		t.Fatalf("HLSFile() error = %v", err)
+	}
+	if key != assetID+"/ma/segment_00001.ts" {
+		t.Errorf("HLSFile() = %s, want file of the asset", key)
+	}
+
+	if _, err := s.HLSFile(assetID, token, "720p/index.mp4"); !errors.Is(err, ErrInvalidHLSFile) {
+		t.Errorf("HLSFile() of other file error = %v, want %v", err, ErrInvalidHLSFile)
+	}
+
+	otherID, _ := HLSAssetID("5a1c2e3d-4b5f-4c6d-8e7f-9a0b1c2d3e4f")
+	if _, err := s.HLSFile(otherID, token, hlsPlaylist); !errors.Is(err, ErrInvalidHLSToken) {
+		t.Errorf("HLSFile() of other asset error = %v, want %v", err, ErrInvalidHLSToken)
+	}
+
+	expiresAt, signature, _ := strings.Cut(token, ".")
+	if _, err := s.HLSFile(assetID, "1."+signature, hlsPlaylist); !errors.Is(err, ErrInvalidHLSToken) {
+		t.Errorf("HLSFile() of forged expiration error = %v, want %v", err, ErrInvalidHLSToken)
+	}
+	if _, err := s.HLSFile(assetID, expiresAt+".", hlsPlaylist); !errors.Is(err, ErrInvalidHLSToken) {
+		t.Errorf("HLSFile() without signature error = %v, want %v", err, ErrInvalidHLSToken)
+	}
+}
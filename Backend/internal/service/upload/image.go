@@ -0,0 +1,433 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+)
+
+// Sizes of image variants, the image fits into the square of the side.
+const (
+	ImageSizeSmall  = "small"
+	ImageSizeMedium = "medium"
+	ImageSizeLarge  = "large"
+	// ImageSizeFull is the uploaded image itself.
+	ImageSizeFull = "full"
+)
+
+const ImageFormatWebP = "webp"
+
+var imageSizes = []struct {
+	Name string
+	Side int
+}{
+	{Name: ImageSizeSmall, Side: 320},
+	{Name: ImageSizeMedium, Side: 640},
+	{Name: ImageSizeLarge, Side: 1280},
+}
+
+const (
+	// maxImageFileSize limits images that are read into memory, limits of
+	// kinds of images are checked by the API.
+	maxImageFileSize = 20_000_000
+	maxImagePixels   = 40_000_000
+	maxImageSide     = 10_000
+	// Uploaded images are scaled down to the side.
+	fullImageSide = 2048
+
+	jpegQuality = 85
+	webpQuality = 80
+)
+
+var (
+	ErrInvalidImage  = errors.New("invalid image")
+	ErrImageTooLarge = errors.New("image is too large")
+)
+
+// ImageVariant returns the key of the variant of the image. Variants are
+// stored next to the image with the image key as the prefix, so they are
+// deleted with it. Format of the image is used if the format is empty.
+func ImageVariant(filename, size, format string) string {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(filename)), ".")
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+
+	switch format {
+	case "":
+		format = ext
+	case "jpeg":
+		format = "jpg"
+	}
+	if size == "" {
+		size = ImageSizeFull
+	}
+
+	if size == ImageSizeFull && format == ext {
+		return filename
+	}
+
+	return filename + "_" + size + "." + format
+}
+
+// imageOriginal returns the key of the image of the variant.
+func imageOriginal(key string) (string, bool) {
+	i := strings.LastIndex(key, "_")
+	if i == -1 {
+		return "", false
+	}
+
+	original := key[:i]
+	size, format, ok := strings.Cut(key[i+1:], ".")
+	if !ok || ImageVariant(original, size, format) != key {
+		return "", false
+	}
+
+	switch size {
+	case ImageSizeSmall, ImageSizeMedium, ImageSizeLarge, ImageSizeFull:
+	default:
+		return "", false
+	}
+
+	switch format {
+	case "jpg", "png", ImageFormatWebP:
+	default:
+		return "", false
+	}
+
+	switch strings.ToLower(path.Ext(original)) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		return "", false
+	}
+
+	return original, true
+}
+
+// decodeImage decodes the JPEG or PNG image by its content, EXIF orientation
+// is applied. Dimensions are checked before decoding, so images that are
+// too large are not allocated.
+func decodeImage(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	switch format {
+	case "jpeg", "png":
+	default:
+		return nil, "", fmt.Errorf("%w: unsupported format %s", ErrInvalidImage, format)
+	}
+
+	if config.Width == 0 || config.Height == 0 {
+		return nil, "", fmt.Errorf("%w: empty image", ErrInvalidImage)
+	}
+	if maxImageSide < config.Width || maxImageSide < config.Height ||
+		maxImagePixels < config.Width*config.Height {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	// Image is scaled down before it is rotated, so fewer pixels are moved.
+	img = scaleImage(img, fullImageSide)
+
+	if format == "jpeg" {
+		img = orientImage(img, jpegOrientation(data))
+	}
+
+	return img, format, nil
+}
+
+// jpegOrientation returns the EXIF orientation of the JPEG image, 1 is
+// returned if it is not set.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// Image data starts, metadata is before it.
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || len(data) < i+2+length {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag of the first IFD of the TIFF
+// structure of EXIF.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || len(tiff) < offset+2 {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[offset:]))
+	for i := range entries {
+		entry := offset + 2 + i*12
+		if len(tiff) < entry+12 {
+			return 1
+		}
+
+		const orientationTag = 0x0112
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || 8 < orientation {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// orientImage transforms the image, so it is displayed as intended by the
+// EXIF orientation.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || 8 < orientation {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// Orientations from 5 to 8 swap width and height.
+	dw, dh := w, h
+	if 5 <= orientation {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+
+	return dst
+}
+
+// scaleImage scales the image down to fit into the square of the side.
+func scaleImage(img image.Image, side int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= side && h <= side {
+		return img
+	}
+
+	if h < w {
+		w, h = side, max(h*side/w, 1)
+	} else {
+		w, h = max(w*side/h, 1), side
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	return dst
+}
+
+func encodeImage(w io.Writer, img image.Image, format string) error {
+	if format == "png" {
+		return png.Encode(w, img)
+	}
+
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+}
+
+// UploadImage saves the JPEG or PNG image re-encoded without metadata with
+// variants of smaller sizes. Every variant also has the WebP copy, they are
+// skipped if ffmpeg fails to encode them. Size of all files is returned.
+func (s *Service) UploadImage(ctx context.Context, dir string, file io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(io.LimitReader(file, maxImageFileSize+1))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read image: %w", err)
+	}
+	if maxImageFileSize < len(data) {
+		return "", 0, ErrImageTooLarge
+	}
+
+	img, format, err := decodeImage(data)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ext := "jpg"
+	if format == "png" {
+		ext = "png"
+	}
+	filename := path.Join(dir, uuid.NewString()+"."+ext)
+
+	outDir, err := os.MkdirTemp(s.tempDir, "image-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("os.MkdirTemp: %w", err)
+	}
+	defer os.RemoveAll(outDir)
+
+	variants := []struct {
+		Size  string
+		Image image.Image
+	}{
+		{Size: ImageSizeFull, Image: img},
+	}
+	b := img.Bounds()
+	for _, size := range imageSizes {
+		if max(b.Dx(), b.Dy()) <= size.Side {
+			break
+		}
+		variants = append(variants, struct {
+			Size  string
+			Image image.Image
+		}{Size: size.Name, Image: scaleImage(img, size.Side)})
+	}
+
+	// Keys of files are mapped to their local files.
+	files := make([][2]string, 0, len(variants)*2)
+	withWebP := true
+	for i, v := range variants {
+		local := filepath.Join(outDir, fmt.Sprintf("%d.%s", i, ext))
+
+		f, err := os.Create(local)
+		if err != nil {
+			return "", 0, fmt.Errorf("os.Create: %w", err)
+		}
+		err = encodeImage(f, v.Image, format)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to encode image: %w", err)
+		}
+		files = append(files, [2]string{ImageVariant(filename, v.Size, ext), local})
+
+		if !withWebP {
+			continue
+		}
+
+		localWebP := filepath.Join(outDir, fmt.Sprintf("%d.%s", i, ImageFormatWebP))
+		err = runFFmpeg(ctx,
+			"-i", local,
+			"-c:v", "libwebp",
+			"-quality", fmt.Sprint(webpQuality),
+			localWebP,
+		)
+		if err != nil {
+			s.logger.Error("failed to encode webp image", zap.Error(err))
+			withWebP = false
+			continue
+		}
+		files = append(files, [2]string{ImageVariant(filename, v.Size, ImageFormatWebP), localWebP})
+	}
+
+	var size int64
+	for i, f := range files {
+		n, err := s.store(ctx, f[0], f[1])
+		if err != nil {
+			for _, stored := range files[:i] {
+				if err := s.storage.Delete(ctx, stored[0]); err != nil {
+					s.logger.Error("failed to delete file", zap.String("file", stored[0]), zap.Error(err))
+				}
+			}
+			return "", 0, fmt.Errorf("failed to save image: %w", err)
+		}
+		size += n
+	}
+
+	return filename, size, nil
+}
+
+// ResolveImageVariant returns the key of the existing variant of the image
+// that is the closest to the requested one. Images uploaded before variants
+// and small images do not have all of them.
+func (s *Service) ResolveImageVariant(ctx context.Context, filename, size, format string) (string, error) {
+	candidates := []string{
+		ImageVariant(filename, size, format),
+		ImageVariant(filename, size, ""),
+		ImageVariant(filename, ImageSizeFull, format),
+	}
+
+	for _, key := range candidates {
+		if key == filename {
+			break
+		}
+
+		_, err := s.storage.Stat(ctx, key)
+		if errors.Is(err, ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return key, nil
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,187 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestImageVariant(t *testing.T) {
+	tests := []struct {
+		filename, size, format string
+		want                   string
+	}{
+		{filename: "ma/1/logo.png", size: ImageSizeSmall, format: ImageFormatWebP, want: "ma/1/logo.png_small.webp"},
+		{filename: "ma/1/logo.png", size: ImageSizeMedium, want: "ma/1/logo.png_medium.png"},
+		{filename: "ma/1/logo.png", size: ImageSizeFull, format: ImageFormatWebP, want: "ma/1/logo.png_full.webp"},
+		{filename: "ma/1/logo.png", format: "png", want: "ma/1/logo.png"},
+		{filename: "ma/1/cover.jpg", size: ImageSizeFull, want: "ma/1/cover.jpg"},
+		{filename: "ma/1/cover.jpeg", size: ImageSizeLarge, want: "ma/1/cover.jpeg_large.jpg"},
+		{filename: "ma/1/cover.jpeg", format: "jpg", want: "ma/1/cover.jpeg"},
+	}
+	for _, tt := range tests {
+		got := ImageVariant(tt.filename, tt.size, tt.format)
+		if got != tt.want {
+			t.Errorf("ImageVariant(%q, %q, %q) = %q, want %q", tt.filename, tt.size, tt.format, got, tt.want)
+		}
+
+		original, ok := imageOriginal(got)
+		if got == tt.filename {
+			if ok {
+				t.Errorf("imageOriginal(%q) = %q, want not variant", got, original)
+			}
+			continue
+		}
+		if !ok || original != tt.filename {
+			t.Errorf("imageOriginal(%q) = %q, %v, want %q", got, original, ok, tt.filename)
+		}
+	}
+
+	for _, key := range []string{
+		"ma/1/notes_final.pdf",
+		"ma/1/logo.png_huge.webp",
+		"ma/1/logo.png_small.gif",
+		"ma/1/video.mp4_small.jpg",
+		"ma/1/logo.png_small.webp/part",
+	} {
+		if original, ok := imageOriginal(key); ok {
+			t.Errorf("imageOriginal(%q) = %q, want not variant", key, original)
+		}
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	tests := []struct {
+		w, h, side   int
+		wantW, wantH int
+	}{
+		{w: 4000, h: 3000, side: 320, wantW: 320, wantH: 240},
+		{w: 1000, h: 2000, side: 640, wantW: 320, wantH: 640},
+		{w: 200, h: 100, side: 320, wantW: 200, wantH: 100},
+		{w: 5000, h: 1, side: 320, wantW: 320, wantH: 1},
+	}
+	for _, tt := range tests {
+		img := scaleImage(image.NewNRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.side)
+		if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("scaleImage(%dx%d, %d) = %dx%d, want %dx%d",
+				tt.w, tt.h, tt.side, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestOrientImage(t *testing.T) {
+	// Red pixel is in the top left corner of the 3x2 image.
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	red := color.NRGBA{R: 255, A: 255}
+	src.Set(0, 0, red)
+
+	tests := []struct {
+		orientation  int
+		wantW, wantH int
+		wantX, wantY int
+	}{
+		{orientation: 1, wantW: 3, wantH: 2, wantX: 0, wantY: 0},
+		{orientation: 2, wantW: 3, wantH: 2, wantX: 2, wantY: 0},
+		{orientation: 3, wantW: 3, wantH: 2, wantX: 2, wantY: 1},
+		{orientation: 4, wantW: 3, wantH: 2, wantX: 0, wantY: 1},
+		{orientation: 5, wantW: 2, wantH: 3, wantX: 0, wantY: 0},
+		{orientation: 6, wantW: 2, wantH: 3, wantX: 1, wantY: 0},
+		{orientation: 7, wantW: 2, wantH: 3, wantX: 1, wantY: 2},
+		{orientation: 8, wantW: 2, wantH: 3, wantX: 0, wantY: 2},
+	}
+	for _, tt := range tests {
+		img := orientImage(src, tt.orientation)
+		if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+			t.Errorf("orientImage(%d) size = %dx%d, want %dx%d",
+				tt.orientation, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			continue
+		}
+		if got := color.NRGBAModel.Convert(img.At(tt.wantX, tt.wantY)); got != red {
+			t.Errorf("orientImage(%d) pixel at %d,%d = %v, want red",
+				tt.orientation, tt.wantX, tt.wantY, got)
+		}
+	}
+}
+
+// withOrientation inserts the EXIF segment with the orientation after the
+// start of the JPEG image.
+func withOrientation(data []byte, orientation uint16, order binary.ByteOrder) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, header...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestDecodeImage(t *testing.T) {
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, image.NewNRGBA(image.Rect(0, 0, 30, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := jpegOrientation(jpegData.Bytes()); got != 1 {
+		t.Errorf("jpegOrientation() without EXIF = %d, want 1", got)
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := withOrientation(jpegData.Bytes(), 6, order)
+		if got := jpegOrientation(data); got != 6 {
+			t.Errorf("jpegOrientation() %v = %d, want 6", order, got)
+		}
+
+		img, format, err := decodeImage(data)
+		if err != nil {
+			t.Fatalf("decodeImage() error = %v", err)
+		}
+		if b := img.Bounds(); format != "jpeg" || b.Dx() != 20 || b.Dy() != 30 {
+			t.Errorf("decodeImage() = %s %dx%d, want rotated jpeg 20x30", format, b.Dx(), b.Dy())
+		}
+	}
+
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewNRGBA(image.Rect(0, 0, 3000, 100))); err != nil {
+		t.Fatal(err)
+	}
+	img, format, err := decodeImage(pngData.Bytes())
+	if err != nil {
+		t.Fatalf("decodeImage() error = %v", err)
+	}
+	if b := img.Bounds(); format != "png" || b.Dx() != fullImageSide {
+		t.Errorf("decodeImage() = %s %dx%d, want png scaled to %d", format, b.Dx(), b.Dy(), fullImageSide)
+	}
+
+	if _, _, err := decodeImage([]byte("%PDF-1.4 not an image")); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("decodeImage() of pdf error = %v, want %v", err, ErrInvalidImage)
+	}
+
+	// Dimensions are rejected before the image is decoded.
+	var huge bytes.Buffer
+	if err := png.Encode(&huge, image.NewNRGBA(image.Rect(0, 0, maxImageSide+1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := decodeImage(huge.Bytes()); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("decodeImage() of huge image error = %v, want %v", err, ErrImageTooLarge)
+	}
+}
@@ -15,6 +15,10 @@ type MaterialFilePath struct {
 	UserID    uuid.UUID
 
 	ProductLevelID uuid.UUID
+
+	// Blob is the directory of files of the mini-app that are shared by
+	// materials.
+	Blob bool
 }
 
 const (
@@ -23,6 +27,7 @@ const (
 	lessonMaterialDir       = "l"
 	userMaterialDir         = "u"
 	productLevelMaterialDir = "pl"
+	blobMaterialDir         = "b"
 )
 
 func (p *MaterialFilePath) String() string {
@@ -33,6 +38,10 @@ func (p *MaterialFilePath) String() string {
 		panic("unexpected material file path parameters")
 	}
 
+	if p.Blob {
+		return filepath.Join(miniAppMaterialDir, p.MiniAppID.String(), blobMaterialDir)
+	}
+
 	if p.ProductID != uuid.Nil &&
 		p.LessonID != uuid.Nil &&
 		p.UserID != uuid.Nil {
@@ -106,6 +115,18 @@ func ParseMaterialFilePath(filePath string) (*MaterialFilePath, error) {
 			return nil, fmt.Errorf("miniAppID is not uuid: %w", err)
 		}
 		return &MaterialFilePath{MiniAppID: miniAppID}, nil
+	case 4:
+		if ids[0] != miniAppMaterialDir {
+			return nil, fmt.Errorf("unexpected directory: %v", ids[0])
+		}
+		if ids[2] != blobMaterialDir {
+			return nil, fmt.Errorf("unexpected directory: %v", ids[2])
+		}
+		miniAppID, err := uuid.Parse(ids[1])
+		if err != nil {
+			return nil, fmt.Errorf("miniAppID is not uuid: %w", err)
+		}
+		return &MaterialFilePath{MiniAppID: miniAppID, Blob: true}, nil
 	case 5:
 		if ids[0] != miniAppMaterialDir {
 			return nil, fmt.Errorf("unexpected directory: %v", ids[0])
@@ -56,6 +56,14 @@ func TestMaterialFilePath_String(t *testing.T) {
 				userMaterialDir, userID.String(),
 			),
 		},
+		{
+			name: "Blob",
+			path: MaterialFilePath{MiniAppID: miniAppID, Blob: true},
+			want: filepath.Join(
+				miniAppMaterialDir, miniAppID.String(),
+				blobMaterialDir,
+			),
+		},
 	}
 
 	for _, tt := range tests {
@@ -130,6 +138,14 @@ func TestParseMaterialFilePath(t *testing.T) {
 				UserID:    userID,
 			},
 		},
+		{
+			name: "Blob",
+			filePath: strings.Join([]string{
+				miniAppMaterialDir, miniAppID.String(),
+				blobMaterialDir, fileID.String(),
+			}, "/"),
+			want: &MaterialFilePath{MiniAppID: miniAppID, Blob: true},
+		},
 		{
 			name:     "Invalid MiniAppID",
 			filePath: "invalid-uuid",
@@ -27,6 +27,8 @@ const chunkSize = 40 * 1024 * 1024 // 40 MiB.
 
 var ErrNotFound = errors.New("asset not found")
 
+// MuxUpload uploads the file to Mux. File is kept, so it is deleted by the
+// caller once the asset is saved.
 func (s *Service) MuxUpload(ctx context.Context, filename string) (*model.MuxVideoMetadata, error) {
 	uploadResp, err := s.muxCreateUpload(ctx)
 	if err != nil {
@@ -80,12 +82,6 @@ func (s *Service) MuxUpload(ctx context.Context, filename string) (*model.MuxVid
 
 	// playbackURL := fmt.Sprintf("https://stream.mux.com/%s.m3u8?token=required", playback.Id)
 
-	if err := os.Remove(filepath.Join(s.uploadDir, filename)); err != nil {
-		s.logger.Error("failed to delete file", zap.String("file", filename), zap.Error(err))
-	} else {
-		s.logger.Info("deleted file", zap.String("file", filename))
-	}
-
 	return &model.MuxVideoMetadata{
 		AssetID:    assetID,
 		PlaybackID: playback.Id,
@@ -190,7 +186,13 @@ func (s *Service) muxChunkedUploadFile(
 	ctx context.Context, filename, uploadURL string, chunkSize int64,
 ) error {
 
-	file, err := os.Open(filepath.Join(s.uploadDir, filename))
+	filePath, release, err := s.fetch(ctx, filename)
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+	defer release()
+
+	file, err := os.Open(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %v", err)
 	}
@@ -343,6 +345,102 @@ func (s *Service) muxGetUpload(ctx context.Context, uploadID string) (*muxgo.Upl
 	return &uploadResp, nil
 }
 
+// MuxCreateTextTrack adds the subtitles to the asset and returns ID of the
+// track. Mux downloads the file by the presigned URL, ErrPresignNotSupported
+// is returned if the storage does not support them.
+func (s *Service) MuxCreateTextTrack(ctx context.Context, assetID string, subtitle *model.Subtitle) (string, error) {
+	if assetID == "" {
+		return "", fmt.Errorf("no asset id provided")
+	}
+
+	fileURL, err := s.PresignedURL(ctx, subtitle.Filename)
+	if err != nil {
+		return "", err
+	}
+
+	payload := muxgo.CreateTrackRequest{
+		Url:          fileURL,
+		Type:         "text",
+		TextType:     "subtitles",
+		LanguageCode: subtitle.Language,
+		Name:         subtitle.Label,
+		Passthrough:  subtitle.ID.String(),
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	u, err := url.JoinPath(muxBaseURL, "video/v1/assets", assetID, "tracks")
+	if err != nil {
+		return "", fmt.Errorf("failed to create url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.muxConfig.MuxTokenID, s.muxConfig.MuxTokenSecret)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("request failed with status: %v", resp.Status)
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var trackResp muxgo.CreateTrackResponse
+	err = json.Unmarshal(rawBody, &trackResp)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal track response: %w", err)
+	}
+
+	return trackResp.Data.Id, nil
+}
+
+func (s *Service) MuxDeleteTrack(ctx context.Context, assetID, trackID string) error {
+	if assetID == "" || trackID == "" {
+		return fmt.Errorf("no asset or track id provided")
+	}
+
+	u, err := url.JoinPath(muxBaseURL, "video/v1/assets", assetID, "tracks", trackID)
+	if err != nil {
+		return fmt.Errorf("failed to create url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.muxConfig.MuxTokenID, s.muxConfig.MuxTokenSecret)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get response: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return fmt.Errorf("request failed with status: %v", resp.Status)
+	}
+}
+
 func (s *Service) MuxSignPrivateVideo(playbackID string) (string, error) {
 	decodedKey, err := base64.StdEncoding.DecodeString(s.muxConfig.MuxSigningPrivateKey)
 	if err != nil {
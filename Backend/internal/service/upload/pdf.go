@@ -0,0 +1,760 @@
+package upload
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// pdfMaxDepth limits nesting of values and page trees of malformed files.
+const pdfMaxDepth = 64
+
+var (
+	ErrInvalidPDF   = errors.New("invalid pdf")
+	ErrEncryptedPDF = errors.New("encrypted pdf")
+	ErrPDFTooLarge  = errors.New("pdf is too large")
+)
+
+// Values of PDF objects. Numbers, strings, booleans and null are kept as they
+// are in the file since they are only written back.
+type (
+	pdfName  string
+	pdfRaw   string
+	pdfArray []any
+	pdfDict  map[pdfName]any
+	pdfRef   struct{ num, gen int }
+)
+
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+// pdfXref is a location of the object, compressed objects are kept in object
+// streams.
+type pdfXref struct {
+	offset int
+	gen    int
+
+	compressed bool
+	stream     int
+	index      int
+}
+
+// pdfDocument reads objects of the file by its cross-reference sections.
+type pdfDocument struct {
+	data []byte
+
+	xrefs      map[int]pdfXref
+	trailer    pdfDict
+	xrefOffset int
+	xrefStream bool
+
+	objStreams map[int]map[int]any
+}
+
+func readPDF(data []byte) (*pdfDocument, error) {
+	i := bytes.LastIndex(data, []byte("startxref"))
+	if i == -1 {
+		return nil, fmt.Errorf("%w: startxref not found", ErrInvalidPDF)
+	}
+
+	p := &pdfParser{data: data, pos: i + len("startxref")}
+	offset, err := p.readInt()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid startxref", ErrInvalidPDF)
+	}
+
+	doc := &pdfDocument{
+		data:       data,
+		xrefs:      make(map[int]pdfXref),
+		xrefOffset: offset,
+		objStreams: make(map[int]map[int]any),
+	}
+
+	// Sections are read from the latest one, entries of updates replace
+	// previous ones.
+	visited := make(map[int]bool)
+	for offset != 0 && !visited[offset] {
+		visited[offset] = true
+
+		trailer, isStream, err := doc.readXrefSection(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc.trailer == nil {
+			doc.trailer = trailer
+			doc.xrefStream = isStream
+		}
+
+		// Hybrid files keep compressed objects in the additional stream.
+		if xrefStm, ok := pdfInt(trailer["XRefStm"]); ok && !isStream && !visited[xrefStm] {
+			visited[xrefStm] = true
+			if _, _, err := doc.readXrefSection(xrefStm); err != nil {
+				return nil, err
+			}
+		}
+
+		offset, _ = pdfInt(trailer["Prev"])
+	}
+
+	if doc.trailer == nil {
+		return nil, fmt.Errorf("%w: trailer not found", ErrInvalidPDF)
+	}
+
+	return doc, nil
+}
+
+func (d *pdfDocument) readXrefSection(offset int) (pdfDict, bool, error) {
+	if offset < 0 || len(d.data) <= offset {
+		return nil, false, fmt.Errorf("%w: invalid xref offset", ErrInvalidPDF)
+	}
+
+	p := &pdfParser{data: d.data, pos: offset}
+	p.skipSpace()
+	if !bytes.HasPrefix(d.data[p.pos:], []byte("xref")) {
+		trailer, err := d.readXrefStream(offset)
+		return trailer, true, err
+	}
+	p.pos += len("xref")
+
+	for {
+		p.skipSpace()
+		if bytes.HasPrefix(d.data[p.pos:], []byte("trailer")) {
+			p.pos += len("trailer")
+			break
+		}
+
+		start, err := p.readInt()
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: invalid xref subsection", ErrInvalidPDF)
+		}
+		count, err := p.readInt()
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: invalid xref subsection", ErrInvalidPDF)
+		}
+
+		for num := start; num < start+count; num++ {
+			entryOffset, err := p.readInt()
+			if err != nil {
+				return nil, false, fmt.Errorf("%w: invalid xref entry", ErrInvalidPDF)
+			}
+			gen, err := p.readInt()
+			if err != nil {
+				return nil, false, fmt.Errorf("%w: invalid xref entry", ErrInvalidPDF)
+			}
+			p.skipSpace()
+			if len(d.data) <= p.pos {
+				return nil, false, fmt.Errorf("%w: invalid xref entry", ErrInvalidPDF)
+			}
+			kind := d.data[p.pos]
+			p.pos++
+
+			if _, ok := d.xrefs[num]; ok || kind != 'n' {
+				continue
+			}
+			d.xrefs[num] = pdfXref{offset: entryOffset, gen: gen}
+		}
+	}
+
+	value, err := p.readValue(0)
+	if err != nil {
+		return nil, false, err
+	}
+	trailer, ok := value.(pdfDict)
+	if !ok {
+		return nil, false, fmt.Errorf("%w: invalid trailer", ErrInvalidPDF)
+	}
+
+	return trailer, false, nil
+}
+
+func (d *pdfDocument) readXrefStream(offset int) (pdfDict, error) {
+	value, err := d.readObjectAt(offset, -1)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := value.(*pdfStream)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid xref stream", ErrInvalidPDF)
+	}
+
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	widths, _ := stream.dict["W"].(pdfArray)
+	if len(widths) != 3 {
+		return nil, fmt.Errorf("%w: invalid xref stream widths", ErrInvalidPDF)
+	}
+	var w [3]int
+	entrySize := 0
+	for i := range w {
+		w[i], _ = pdfInt(widths[i])
+		if w[i] < 0 || 8 < w[i] {
+			return nil, fmt.Errorf("%w: invalid xref stream widths", ErrInvalidPDF)
+		}
+		entrySize += w[i]
+	}
+	if entrySize == 0 {
+		return nil, fmt.Errorf("%w: invalid xref stream widths", ErrInvalidPDF)
+	}
+
+	size, _ := pdfInt(stream.dict["Size"])
+	index := pdfArray{pdfRaw("0"), pdfRaw(strconv.Itoa(size))}
+	if rawIndex, ok := stream.dict["Index"].(pdfArray); ok {
+		index = rawIndex
+	}
+
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, _ := pdfInt(index[i])
+		count, _ := pdfInt(index[i+1])
+
+		for num := start; num < start+count; num++ {
+			if len(data) < pos+entrySize {
+				return nil, fmt.Errorf("%w: xref stream is too short", ErrInvalidPDF)
+			}
+			field := func(i int) int {
+				v := 0
+				for _, b := range data[pos : pos+w[i]] {
+					v = v<<8 | int(b)
+				}
+				pos += w[i]
+				return v
+			}
+
+			kind := 1
+			if w[0] != 0 {
+				kind = field(0)
+			}
+			second, third := field(1), field(2)
+
+			if _, ok := d.xrefs[num]; ok {
+				continue
+			}
+			switch kind {
+			case 1:
+				d.xrefs[num] = pdfXref{offset: second, gen: third}
+			case 2:
+				d.xrefs[num] = pdfXref{compressed: true, stream: second, index: third}
+			}
+		}
+	}
+
+	return stream.dict, nil
+}
+
+// object returns the object by its number, missing objects are null.
+func (d *pdfDocument) object(num int) (any, error) {
+	xref, ok := d.xrefs[num]
+	if !ok {
+		return pdfRaw("null"), nil
+	}
+
+	if !xref.compressed {
+		return d.readObjectAt(xref.offset, num)
+	}
+
+	objects, ok := d.objStreams[xref.stream]
+	if !ok {
+		var err error
+		objects, err = d.readObjectStream(xref.stream)
+		if err != nil {
+			return nil, err
+		}
+		d.objStreams[xref.stream] = objects
+	}
+
+	value, ok := objects[num]
+	if !ok {
+		return pdfRaw("null"), nil
+	}
+
+	return value, nil
+}
+
+func (d *pdfDocument) readObjectStream(num int) (map[int]any, error) {
+	xref, ok := d.xrefs[num]
+	if !ok || xref.compressed {
+		return nil, fmt.Errorf("%w: object stream %d not found", ErrInvalidPDF, num)
+	}
+
+	value, err := d.readObjectAt(xref.offset, num)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := value.(*pdfStream)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid object stream %d", ErrInvalidPDF, num)
+	}
+
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := pdfInt(stream.dict["N"])
+	first, _ := pdfInt(stream.dict["First"])
+	if first < 0 || len(data) < first {
+		return nil, fmt.Errorf("%w: invalid object stream %d", ErrInvalidPDF, num)
+	}
+
+	header := &pdfParser{data: data[:first]}
+	objects := make(map[int]any, n)
+	for range n {
+		objNum, err := header.readInt()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid object stream %d", ErrInvalidPDF, num)
+		}
+		objOffset, err := header.readInt()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid object stream %d", ErrInvalidPDF, num)
+		}
+
+		p := &pdfParser{data: data, pos: first + objOffset}
+		value, err := p.readValue(0)
+		if err != nil {
+			return nil, err
+		}
+		objects[objNum] = value
+	}
+
+	return objects, nil
+}
+
+// readObjectAt reads the indirect object at the offset, num is checked if it
+// is not -1.
+func (d *pdfDocument) readObjectAt(offset, num int) (any, error) {
+	if offset < 0 || len(d.data) <= offset {
+		return nil, fmt.Errorf("%w: invalid object offset", ErrInvalidPDF)
+	}
+
+	p := &pdfParser{data: d.data, pos: offset}
+
+	objNum, err := p.readInt()
+	if err != nil || (num != -1 && objNum != num) {
+		return nil, fmt.Errorf("%w: object %d not found", ErrInvalidPDF, num)
+	}
+	if _, err := p.readInt(); err != nil {
+		return nil, fmt.Errorf("%w: object %d not found", ErrInvalidPDF, num)
+	}
+	if !p.readKeyword("obj") {
+		return nil, fmt.Errorf("%w: object %d not found", ErrInvalidPDF, num)
+	}
+
+	value, err := p.readValue(0)
+	if err != nil {
+		return nil, err
+	}
+
+	dict, ok := value.(pdfDict)
+	if !ok || !p.readKeyword("stream") {
+		return value, nil
+	}
+
+	// Stream data starts after the end of line.
+	if p.pos < len(d.data) && d.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(d.data) && d.data[p.pos] == '\n' {
+		p.pos++
+	}
+	start := p.pos
+
+	length := -1
+	if lengthValue, err := d.resolve(dict["Length"]); err == nil {
+		if n, ok := pdfInt(lengthValue); ok {
+			length = n
+		}
+	}
+	if length < 0 || len(d.data) < start+length ||
+		!bytes.Contains(d.data[start+length:min(len(d.data), start+length+32)], []byte("endstream")) {
+
+		// Length is wrong in some files, the stream ends with the keyword.
+		end := bytes.Index(d.data[start:], []byte("endstream"))
+		if end == -1 {
+			return nil, fmt.Errorf("%w: stream end not found", ErrInvalidPDF)
+		}
+		length = end
+	}
+
+	return &pdfStream{dict: dict, data: d.data[start : start+length]}, nil
+}
+
+func (d *pdfDocument) resolve(value any) (any, error) {
+	for range pdfMaxDepth {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value, nil
+		}
+
+		var err error
+		value, err = d.object(ref.num)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: too deep references", ErrInvalidPDF)
+}
+
+func (d *pdfDocument) resolveDict(value any) (pdfDict, error) {
+	value, err := d.resolve(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case pdfDict:
+		return v, nil
+	case *pdfStream:
+		return v.dict, nil
+	}
+
+	return nil, nil
+}
+
+// decodeStream returns data of the stream, only Flate encoded streams are
+// supported.
+func (d *pdfDocument) decodeStream(stream *pdfStream) ([]byte, error) {
+	filter, err := d.resolve(stream.dict["Filter"])
+	if err != nil {
+		return nil, err
+	}
+	params, err := d.resolve(stream.dict["DecodeParms"])
+	if err != nil {
+		return nil, err
+	}
+
+	if filters, ok := filter.(pdfArray); ok {
+		if 1 < len(filters) {
+			return nil, fmt.Errorf("%w: multiple stream filters are not supported", ErrInvalidPDF)
+		}
+		filter = pdfRaw("null")
+		if len(filters) == 1 {
+			filter = filters[0]
+		}
+		if paramsArray, ok := params.(pdfArray); ok && len(paramsArray) == 1 {
+			params = paramsArray[0]
+		}
+	}
+
+	switch filter {
+	case pdfRaw("null"), nil:
+		return stream.data, nil
+	case pdfName("FlateDecode"):
+	default:
+		return nil, fmt.Errorf("%w: stream filter %v is not supported", ErrInvalidPDF, filter)
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(stream.data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPDF, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPDF, err)
+	}
+
+	paramsDict, _ := params.(pdfDict)
+	predictor, _ := pdfInt(paramsDict["Predictor"])
+	if predictor < 10 {
+		if 1 < predictor {
+			return nil, fmt.Errorf("%w: predictor %d is not supported", ErrInvalidPDF, predictor)
+		}
+		return data, nil
+	}
+
+	columns, ok := pdfInt(paramsDict["Columns"])
+	if !ok {
+		columns = 1
+	}
+	colors, ok := pdfInt(paramsDict["Colors"])
+	if !ok {
+		colors = 1
+	}
+	bits, ok := pdfInt(paramsDict["BitsPerComponent"])
+	if !ok {
+		bits = 8
+	}
+
+	return pngUnpredict(data, (colors*bits+7)/8, (columns*colors*bits+7)/8)
+}
+
+// pngUnpredict reverses PNG filters of rows, every row starts with the type of
+// its filter.
+func pngUnpredict(data []byte, bpp, rowSize int) ([]byte, error) {
+	if bpp <= 0 || rowSize <= 0 {
+		return nil, fmt.Errorf("%w: invalid predictor parameters", ErrInvalidPDF)
+	}
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowSize)
+	for len(data) != 0 {
+		if len(data) < rowSize+1 {
+			return nil, fmt.Errorf("%w: predicted data is too short", ErrInvalidPDF)
+		}
+
+		filter, row := data[0], append([]byte(nil), data[1:rowSize+1]...)
+		data = data[rowSize+1:]
+
+		for i := range row {
+			var left, upLeft byte
+			if bpp <= i {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("%w: invalid png filter %d", ErrInvalidPDF, filter)
+			}
+		}
+
+		out = append(out, row...)
+		prev = row
+	}
+
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func pdfInt(value any) (int, bool) {
+	raw, ok := value.(pdfRaw)
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(string(raw))
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+func pdfFloat(value any) (float64, bool) {
+	raw, ok := value.(pdfRaw)
+	if !ok {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return f, true
+}
+
+type pdfParser struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		p.pos++
+	}
+}
+
+// readToken returns the regular token, e.g. the number or the keyword.
+func (p *pdfParser) readToken() string {
+	p.skipSpace()
+
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+
+	return string(p.data[start:p.pos])
+}
+
+func (p *pdfParser) readInt() (int, error) {
+	return strconv.Atoi(p.readToken())
+}
+
+// readKeyword reads the keyword if it is the next token.
+func (p *pdfParser) readKeyword(keyword string) bool {
+	pos := p.pos
+	if p.readToken() == keyword {
+		return true
+	}
+	p.pos = pos
+
+	return false
+}
+
+func (p *pdfParser) readValue(depth int) (any, error) {
+	if pdfMaxDepth < depth {
+		return nil, fmt.Errorf("%w: too deep value", ErrInvalidPDF)
+	}
+
+	p.skipSpace()
+	if len(p.data) <= p.pos {
+		return nil, fmt.Errorf("%w: unexpected end of file", ErrInvalidPDF)
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '/':
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+			p.pos++
+		}
+		return pdfName(p.data[start:p.pos]), nil
+
+	case bytes.HasPrefix(p.data[p.pos:], []byte("<<")):
+		p.pos += 2
+		dict := make(pdfDict)
+		for {
+			p.skipSpace()
+			if bytes.HasPrefix(p.data[p.pos:], []byte(">>")) {
+				p.pos += 2
+				return dict, nil
+			}
+
+			key, err := p.readValue(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(pdfName)
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid dictionary key", ErrInvalidPDF)
+			}
+
+			value, err := p.readValue(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			dict[name] = value
+		}
+
+	case c == '<':
+		end := bytes.IndexByte(p.data[p.pos:], '>')
+		if end == -1 {
+			return nil, fmt.Errorf("%w: unterminated hex string", ErrInvalidPDF)
+		}
+		raw := pdfRaw(p.data[p.pos : p.pos+end+1])
+		p.pos += end + 1
+		return raw, nil
+
+	case c == '(':
+		start := p.pos
+		nesting := 0
+		for p.pos < len(p.data) {
+			switch p.data[p.pos] {
+			case '\\':
+				p.pos++
+			case '(':
+				nesting++
+			case ')':
+				nesting--
+			}
+			p.pos++
+			if nesting == 0 {
+				return pdfRaw(p.data[start:p.pos]), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unterminated string", ErrInvalidPDF)
+
+	case c == '[':
+		p.pos++
+		array := make(pdfArray, 0)
+		for {
+			p.skipSpace()
+			if len(p.data) <= p.pos {
+				return nil, fmt.Errorf("%w: unterminated array", ErrInvalidPDF)
+			}
+			if p.data[p.pos] == ']' {
+				p.pos++
+				return array, nil
+			}
+
+			value, err := p.readValue(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+
+	case isPDFDelimiter(c):
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidPDF, c)
+	}
+
+	token := p.readToken()
+
+	// References are two integers followed by R.
+	if num, err := strconv.Atoi(token); err == nil {
+		pos := p.pos
+		if gen, err := strconv.Atoi(p.readToken()); err == nil && p.readKeyword("R") {
+			return pdfRef{num: num, gen: gen}, nil
+		}
+		p.pos = pos
+	}
+
+	return pdfRaw(token), nil
+}
@@ -0,0 +1,501 @@
+package upload
+
+import (
+	"academy/internal/model"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Names of watermark resources, they are prefixed to not clash with
+// resources of pages.
+const (
+	pdfWatermarkFont   = "AcademyWatermarkFont"
+	pdfWatermarkGState = "AcademyWatermarkGS"
+)
+
+const (
+	pdfWatermarkOpacity     = 0.2
+	pdfWatermarkMaxFontSize = 48
+	pdfWatermarkMinFontSize = 10
+	pdfWatermarkFooterSize  = 8
+)
+
+// pdfDefaultMediaBox is US Letter, it is used by pages without the box.
+var pdfDefaultMediaBox = [4]float64{0, 0, 612, 792}
+
+// WatermarkPDF returns the uploaded PDF with the text on every page. Files
+// larger than the limit are not read.
+func (s *Service) WatermarkPDF(ctx context.Context, filename, text string) ([]byte, error) {
+	file, info, err := s.storage.Get(ctx, filename, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	if model.MaxWatermarkedPDFSize < info.Size {
+		return nil, ErrPDFTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, model.MaxWatermarkedPDFSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	if model.MaxWatermarkedPDFSize < int64(len(data)) {
+		return nil, ErrPDFTooLarge
+	}
+
+	return watermarkPDF(data, text)
+}
+
+// watermarkPDF rewrites the file with the latest revision of its objects, so
+// the file without the watermark can't be restored from previous revisions.
+// Pages get the text across them and in the footer.
+func watermarkPDF(data []byte, text string) ([]byte, error) {
+	doc, err := readPDF(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, ErrEncryptedPDF
+	}
+
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: no pages", ErrInvalidPDF)
+	}
+
+	w := &pdfWriter{objects: make(map[int]any)}
+
+	w.next, _ = pdfInt(doc.trailer["Size"])
+	for num := range doc.xrefs {
+		w.next = max(w.next, num+1)
+	}
+
+	font := w.add(pdfDict{
+		"Type":     pdfName("Font"),
+		"Subtype":  pdfName("Type1"),
+		"BaseFont": pdfName("Helvetica"),
+		"Encoding": pdfName("WinAnsiEncoding"),
+	})
+	gstate := w.add(pdfDict{
+		"Type": pdfName("ExtGState"),
+		"ca":   pdfRaw(formatPDFNumber(pdfWatermarkOpacity)),
+		"CA":   pdfRaw(formatPDFNumber(pdfWatermarkOpacity)),
+	})
+	// Content of pages is wrapped into the saved graphics state, so the
+	// watermark is drawn with the default one.
+	save := w.addStream([]byte("q\n"))
+
+	for _, page := range pages {
+		contents := pdfArray{save}
+		switch v := page.dict["Contents"].(type) {
+		case pdfRef:
+			resolved, err := doc.resolve(v)
+			if err != nil {
+				return nil, err
+			}
+			if array, ok := resolved.(pdfArray); ok {
+				contents = append(contents, array...)
+			} else {
+				contents = append(contents, v)
+			}
+		case pdfArray:
+			contents = append(contents, v...)
+		}
+
+		watermark := w.addStream(pdfWatermarkContent(page.mediaBox, text))
+		contents = append(contents, watermark)
+
+		resources, err := doc.resourcesWith(page.resources, map[pdfName]pdfDict{
+			"Font":      {pdfWatermarkFont: font},
+			"ExtGState": {pdfWatermarkGState: gstate},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		dict := copyPDFDict(page.dict)
+		dict["Contents"] = contents
+		dict["Resources"] = resources
+		w.set(page.ref, dict)
+	}
+
+	return w.write(doc, len(data)+len(pages)*1024)
+}
+
+type pdfPage struct {
+	ref       pdfRef
+	dict      pdfDict
+	resources any
+	mediaBox  [4]float64
+}
+
+// pages returns leaves of the page tree with inherited attributes.
+func (d *pdfDocument) pages() ([]*pdfPage, error) {
+	catalog, err := d.resolveDict(d.trailer["Root"])
+	if err != nil {
+		return nil, err
+	}
+	if catalog == nil {
+		return nil, fmt.Errorf("%w: catalog not found", ErrInvalidPDF)
+	}
+
+	root, ok := catalog["Pages"].(pdfRef)
+	if !ok {
+		return nil, fmt.Errorf("%w: page tree not found", ErrInvalidPDF)
+	}
+
+	var pages []*pdfPage
+	visited := make(map[int]bool)
+
+	var walk func(ref pdfRef, resources any, mediaBox [4]float64, depth int) error
+	walk = func(ref pdfRef, resources any, mediaBox [4]float64, depth int) error {
+		if pdfMaxDepth < depth || visited[ref.num] {
+			return fmt.Errorf("%w: invalid page tree", ErrInvalidPDF)
+		}
+		visited[ref.num] = true
+
+		node, err := d.resolveDict(ref)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			return fmt.Errorf("%w: page %d not found", ErrInvalidPDF, ref.num)
+		}
+
+		if value, ok := node["Resources"]; ok {
+			resources = value
+		}
+		if box, ok := d.rectangle(node["MediaBox"]); ok {
+			mediaBox = box
+		}
+
+		kids, err := d.resolve(node["Kids"])
+		if err != nil {
+			return err
+		}
+		kidsArray, isNode := kids.(pdfArray)
+		if node["Type"] == pdfName("Page") || !isNode {
+			pages = append(pages, &pdfPage{
+				ref:       ref,
+				dict:      node,
+				resources: resources,
+				mediaBox:  mediaBox,
+			})
+			return nil
+		}
+
+		for _, kid := range kidsArray {
+			kidRef, ok := kid.(pdfRef)
+			if !ok {
+				return fmt.Errorf("%w: invalid page tree", ErrInvalidPDF)
+			}
+			if err := walk(kidRef, resources, mediaBox, depth+1); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(root, nil, pdfDefaultMediaBox, 0); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+func (d *pdfDocument) rectangle(value any) ([4]float64, bool) {
+	var rect [4]float64
+
+	value, err := d.resolve(value)
+	if err != nil {
+		return rect, false
+	}
+	array, ok := value.(pdfArray)
+	if !ok || len(array) != 4 {
+		return rect, false
+	}
+
+	for i := range rect {
+		item, err := d.resolve(array[i])
+		if err != nil {
+			return rect, false
+		}
+		if rect[i], ok = pdfFloat(item); !ok {
+			return rect, false
+		}
+	}
+
+	return rect, true
+}
+
+// resourcesWith returns the copy of resources with added ones, the copy is
+// written into the page, so other pages keep their resources.
+func (d *pdfDocument) resourcesWith(resources any, added map[pdfName]pdfDict) (pdfDict, error) {
+	dict, err := d.resolveDict(resources)
+	if err != nil {
+		return nil, err
+	}
+	dict = copyPDFDict(dict)
+
+	for category, values := range added {
+		categoryDict, err := d.resolveDict(dict[category])
+		if err != nil {
+			return nil, err
+		}
+		categoryDict = copyPDFDict(categoryDict)
+		for name, value := range values {
+			categoryDict[name] = value
+		}
+		dict[category] = categoryDict
+	}
+
+	return dict, nil
+}
+
+func copyPDFDict(dict pdfDict) pdfDict {
+	copied := make(pdfDict, len(dict)+2)
+	for k, v := range dict {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// pdfWatermarkContent draws the text across the page and in its footer.
+func pdfWatermarkContent(mediaBox [4]float64, text string) []byte {
+	length := utf8.RuneCountInString(text)
+	text = pdfLiteralString(text)
+
+	llx, lly := math.Min(mediaBox[0], mediaBox[2]), math.Min(mediaBox[1], mediaBox[3])
+	width, height := math.Abs(mediaBox[2]-mediaBox[0]), math.Abs(mediaBox[3]-mediaBox[1])
+
+	// Helvetica glyphs are about half of the font size wide.
+	textWidth := func(size float64) float64 {
+		return float64(length) * size * 0.5
+	}
+
+	diagonal := math.Hypot(width, height)
+	size := pdfWatermarkMaxFontSize * 1.0
+	if 0 < textWidth(1) {
+		size = math.Min(size, diagonal*0.8/textWidth(1))
+	}
+	size = math.Max(size, pdfWatermarkMinFontSize)
+
+	angle := math.Atan2(height, width)
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	f := formatPDFNumber
+
+	var b strings.Builder
+	b.WriteString("Q\nq\n")
+	fmt.Fprintf(&b, "/%s gs\n0.5 g\n", pdfWatermarkGState)
+	fmt.Fprintf(&b, "BT\n/%s %s Tf\n", pdfWatermarkFont, f(size))
+	fmt.Fprintf(&b, "%s %s %s %s %s %s Tm\n", f(cos), f(sin), f(-sin), f(cos), f(llx+width/2), f(lly+height/2))
+	fmt.Fprintf(&b, "%s %s Td\n%s Tj\nET\n", f(-textWidth(size)/2), f(-size/3), text)
+	fmt.Fprintf(&b, "BT\n/%s %d Tf\n", pdfWatermarkFont, pdfWatermarkFooterSize)
+	fmt.Fprintf(&b, "1 0 0 1 %s %s Tm\n%s Tj\nET\n", f(llx+pdfWatermarkFooterSize), f(lly+pdfWatermarkFooterSize), text)
+	b.WriteString("Q\n")
+
+	return []byte(b.String())
+}
+
+// pdfLiteralString escapes the text, symbols out of ASCII are replaced since
+// the standard font has no glyphs of most of them.
+func pdfLiteralString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || '~' < r:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(')')
+
+	return b.String()
+}
+
+func formatPDFNumber(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+
+	return s
+}
+
+// pdfWriter keeps added and replaced objects of the rewritten file.
+type pdfWriter struct {
+	next    int
+	objects map[int]any
+	gens    map[int]int
+}
+
+func (w *pdfWriter) add(value any) pdfRef {
+	ref := pdfRef{num: w.next}
+	w.next++
+	w.set(ref, value)
+
+	return ref
+}
+
+func (w *pdfWriter) addStream(data []byte) pdfRef {
+	return w.add(&pdfStream{dict: pdfDict{}, data: data})
+}
+
+func (w *pdfWriter) set(ref pdfRef, value any) {
+	if w.gens == nil {
+		w.gens = make(map[int]int)
+	}
+	w.objects[ref.num] = value
+	w.gens[ref.num] = ref.gen
+}
+
+// write writes objects of the document with the ones of the writer into the
+// file with the single cross-reference table. Object and cross-reference
+// streams are not written, objects compressed in them are written as is.
+func (w *pdfWriter) write(doc *pdfDocument, sizeHint int) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, sizeHint))
+
+	header, _, _ := bytes.Cut(doc.data, []byte("\n"))
+	version := "1.4"
+	if v, ok := bytes.CutPrefix(bytes.TrimSpace(header), []byte("%PDF-")); ok && 0 < len(v) && len(v) <= 3 {
+		version = string(v)
+	}
+	// Comment with binary bytes marks the file as binary for transfers.
+	fmt.Fprintf(buf, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+
+	offsets := make(map[int]pdfXref, len(doc.xrefs)+len(w.objects))
+	for num := range w.next {
+		value, ok := w.objects[num]
+		gen := w.gens[num]
+		if !ok {
+			xref, exists := doc.xrefs[num]
+			if !exists || num == 0 {
+				continue
+			}
+
+			var err error
+			value, err = doc.object(num)
+			if err != nil {
+				return nil, err
+			}
+			if !xref.compressed {
+				gen = xref.gen
+			}
+		}
+
+		if stream, ok := value.(*pdfStream); ok {
+			switch stream.dict["Type"] {
+			case pdfName("ObjStm"), pdfName("XRef"):
+				continue
+			}
+		}
+
+		offsets[num] = pdfXref{offset: buf.Len(), gen: gen}
+		fmt.Fprintf(buf, "%d %d obj\n", num, gen)
+		writePDFObject(buf, value)
+		buf.WriteString("\nendobj\n")
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n", w.next)
+	for num := range w.next {
+		xref, ok := offsets[num]
+		switch {
+		case ok:
+			fmt.Fprintf(buf, "%010d %05d n\r\n", xref.offset, xref.gen)
+		case num == 0:
+			buf.WriteString("0000000000 65535 f\r\n")
+		default:
+			buf.WriteString("0000000000 00000 f\r\n")
+		}
+	}
+
+	trailer := pdfDict{"Size": pdfRaw(strconv.Itoa(w.next))}
+	for _, key := range []pdfName{"Root", "Info", "ID"} {
+		if value, ok := doc.trailer[key]; ok {
+			trailer[key] = value
+		}
+	}
+
+	buf.WriteString("trailer\n")
+	writePDFValue(buf, trailer)
+	fmt.Fprintf(buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return buf.Bytes(), nil
+}
+
+// writePDFObject writes the value of the indirect object, length of streams
+// is written directly since objects of lengths could be dropped.
+func writePDFObject(buf *bytes.Buffer, value any) {
+	stream, ok := value.(*pdfStream)
+	if !ok {
+		writePDFValue(buf, value)
+		return
+	}
+
+	dict := copyPDFDict(stream.dict)
+	dict["Length"] = pdfRaw(strconv.Itoa(len(stream.data)))
+
+	writePDFValue(buf, dict)
+	buf.WriteString("\nstream\n")
+	buf.Write(stream.data)
+	buf.WriteString("\nendstream")
+}
+
+func writePDFValue(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case pdfName:
+		buf.WriteByte('/')
+		buf.WriteString(string(v))
+	case pdfRaw:
+		buf.WriteString(string(v))
+	case pdfRef:
+		fmt.Fprintf(buf, "%d %d R", v.num, v.gen)
+	case pdfArray:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i != 0 {
+				buf.WriteByte(' ')
+			}
+			writePDFValue(buf, item)
+		}
+		buf.WriteByte(']')
+	case pdfDict:
+		keys := make([]pdfName, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		buf.WriteString("<<")
+		for _, key := range keys {
+			buf.WriteString(" /")
+			buf.WriteString(string(key))
+			buf.WriteByte(' ')
+			writePDFValue(buf, v[key])
+		}
+		buf.WriteString(" >>")
+	default:
+		buf.WriteString("null")
+	}
+}
@@ -0,0 +1,252 @@
+package upload
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testPDF builds the file with the cross-reference table of objects that are
+// numbered from 1.
+func testPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n\r\n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+func flate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+
+	return buf.Bytes()
+}
+
+// testPDFWithStreams builds the file which pages are compressed in the object
+// stream and which cross-reference stream is encoded with the PNG predictor.
+func testPDFWithStreams() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+
+	content := "BT /F1 12 Tf 72 720 Td (Hello) Tj ET"
+
+	offsets := map[int]int{}
+	offsets[1] = buf.Len()
+	buf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	offsets[3] = buf.Len()
+	fmt.Fprintf(&buf, "3 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content)
+
+	// Pages 2 and 4 are in the object stream 5.
+	pages := "<< /Type /Pages /Kids [4 0 R] /Count 1 /MediaBox [0 0 595 842] /Resources << /Font << /F1 << /Type /Font /Subtype /Type1 /BaseFont /Times-Roman >> >> >> >>"
+	page := "<< /Type /Page /Parent 2 0 R /Contents 3 0 R >>"
+	header := fmt.Sprintf("2 0 4 %d ", len(pages)+1)
+	objStm := flate([]byte(header + pages + " " + page))
+
+	offsets[5] = buf.Len()
+	fmt.Fprintf(&buf, "5 0 obj\n<< /Type /ObjStm /N 2 /First %d /Filter /FlateDecode /Length %d >>\nstream\n", len(header), len(objStm))
+	buf.Write(objStm)
+	buf.WriteString("\nendstream\nendobj\n")
+
+	type entry struct{ kind, second, third int }
+	entries := []entry{
+		{0, 0, 255},
+		{1, offsets[1], 0},
+		{2, 5, 0},
+		{1, offsets[3], 0},
+		{2, 5, 1},
+		{1, offsets[5], 0},
+		{1, buf.Len(), 0},
+	}
+
+	// Rows are encoded with the Up filter.
+	var rows []byte
+	prev := make([]byte, 4)
+	for _, e := range entries {
+		row := []byte{byte(e.kind), byte(e.second >> 8), byte(e.second), byte(e.third)}
+		rows = append(rows, 2)
+		for i := range row {
+			rows = append(rows, row[i]-prev[i])
+		}
+		prev = row
+	}
+	xref := flate(rows)
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "6 0 obj\n<< /Type /XRef /Size 7 /W [1 2 1] /Root 1 0 R "+
+		"/Filter /FlateDecode /DecodeParms << /Columns 4 /Predictor 12 >> /Length %d >>\nstream\n", len(xref))
+	buf.Write(xref)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return buf.Bytes()
+}
+
+// checkWatermark checks that the file is rewritten with the single revision
+// and every page draws the text with the added font after its original
+// content.
+func checkWatermark(t *testing.T, watermarked []byte, text string, pageCount int) {
+	t.Helper()
+
+	// Previous revisions would be restored by truncating the file.
+	if n := bytes.Count(watermarked, []byte("%%EOF")); n != 1 {
+		t.Fatalf("file has %d revisions, want 1", n)
+	}
+	if bytes.Contains(watermarked, []byte("/Prev")) {
+		t.Fatalf("file refers to the previous revision")
+	}
+
+	doc, err := readPDF(watermarked)
+	if err != nil {
+		t.Fatalf("readPDF(watermarked): %v", err)
+	}
+	pages, err := doc.pages()
+	if err != nil {
+		t.Fatalf("pages(): %v", err)
+	}
+	if len(pages) != pageCount {
+		t.Fatalf("len(pages) = %d, want %d", len(pages), pageCount)
+	}
+
+	for i, page := range pages {
+		contents, ok := page.dict["Contents"].(pdfArray)
+		if !ok || len(contents) < 3 {
+			t.Fatalf("page %d contents = %v, want original wrapped with watermark", i, page.dict["Contents"])
+		}
+
+		var streams []string
+		for _, ref := range contents {
+			value, err := doc.resolve(ref)
+			if err != nil {
+				t.Fatalf("page %d: resolve(%v): %v", i, ref, err)
+			}
+			stream, ok := value.(*pdfStream)
+			if !ok {
+				t.Fatalf("page %d: content %v is not a stream", i, ref)
+			}
+			streams = append(streams, string(stream.data))
+		}
+
+		if streams[0] != "q\n" {
+			t.Errorf("page %d: first content = %q, want q", i, streams[0])
+		}
+		last := streams[len(streams)-1]
+		if !strings.HasPrefix(last, "Q\n") || !strings.Contains(last, "("+text+") Tj") {
+			t.Errorf("page %d: watermark = %q, want text %q", i, last, text)
+		}
+
+		resources, err := doc.resolveDict(page.dict["Resources"])
+		if err != nil {
+			t.Fatalf("page %d: resources: %v", i, err)
+		}
+		fonts, _ := resources["Font"].(pdfDict)
+		if _, ok := fonts[pdfWatermarkFont]; !ok {
+			t.Errorf("page %d: fonts = %v, want watermark font", i, fonts)
+		}
+		if _, ok := fonts["F1"]; !ok {
+			t.Errorf("page %d: fonts = %v, want original font", i, fonts)
+		}
+	}
+}
+
+func TestWatermarkPDF(t *testing.T) {
+	original := testPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Resources 4 0 R /Contents 6 0 R >>",
+		"<< /Font << /F1 7 0 R >> >>",
+		"<< /Type /Page /Parent 2 0 R /Resources 4 0 R /Contents [6 0 R 6 0 R] /MediaBox [0 0 842 595] >>",
+		"<< /Length 8 >>\nstream\n(a\\)) Tj\nendstream",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	)
+
+	text := "@student_1 (42) 2026-10-19 10:00 UTC"
+	escaped := `@student_1 \(42\) 2026-10-19 10:00 UTC`
+
+	watermarked, err := watermarkPDF(original, text)
+	if err != nil {
+		t.Fatalf("watermarkPDF(): %v", err)
+	}
+	checkWatermark(t, watermarked, escaped, 2)
+
+	// Shared resources of pages are not changed.
+	doc, _ := readPDF(watermarked)
+	resources, _ := doc.resolveDict(pdfRef{num: 4})
+	if fonts := resources["Font"].(pdfDict); len(fonts) != 1 {
+		t.Errorf("shared fonts = %v, want unchanged", fonts)
+	}
+
+	// Original content is kept.
+	pages, _ := doc.pages()
+	content, _ := doc.resolve(pages[0].dict["Contents"].(pdfArray)[1])
+	if stream := content.(*pdfStream); string(stream.data) != `(a\)) Tj` {
+		t.Errorf("original content = %q, want unchanged", stream.data)
+	}
+
+	// Watermarked files are rewritten again the same way.
+	again, err := watermarkPDF(watermarked, "second")
+	if err != nil {
+		t.Fatalf("watermarkPDF(watermarked): %v", err)
+	}
+	if n := bytes.Count(again, []byte("%%EOF")); n != 1 {
+		t.Errorf("file watermarked again has %d revisions, want 1", n)
+	}
+}
+
+func TestWatermarkPDFWithStreams(t *testing.T) {
+	original := testPDFWithStreams()
+
+	watermarked, err := watermarkPDF(original, "@student")
+	if err != nil {
+		t.Fatalf("watermarkPDF(): %v", err)
+	}
+	checkWatermark(t, watermarked, "@student", 1)
+
+	doc, _ := readPDF(watermarked)
+	if doc.xrefStream || bytes.Contains(watermarked, []byte("/ObjStm")) {
+		t.Errorf("compressed objects are not written as is")
+	}
+	pages, _ := doc.pages()
+	if pages[0].mediaBox != [4]float64{0, 0, 595, 842} {
+		t.Errorf("mediaBox = %v, want inherited [0 0 595 842]", pages[0].mediaBox)
+	}
+}
+
+func TestWatermarkPDFErrors(t *testing.T) {
+	encrypted := bytes.Replace(
+		testPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"),
+		[]byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 5 0 R"), 1)
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not a pdf", []byte("PK\x03\x04"), ErrInvalidPDF},
+		{"encrypted", encrypted, ErrEncryptedPDF},
+		{"no pages", testPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"), ErrInvalidPDF},
+		{"page loop", testPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [2 0 R] /Count 1 >>"), ErrInvalidPDF},
+	}
+
+	for _, tt := range tests {
+		if _, err := watermarkPDF(tt.data, "text"); !errors.Is(err, tt.want) {
+			t.Errorf("%s: watermarkPDF() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
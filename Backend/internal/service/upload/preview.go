@@ -0,0 +1,143 @@
+package upload
+
+import (
+	"academy/internal/model"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	posterHeight = 720
+
+	storyboardColumns        = 10
+	storyboardWidth          = 160
+	maxStoryboardThumbnails  = 100
+	minStoryboardIntervalSec = 2
+)
+
+// storyboardLayout returns the storyboard of the video without the file, nil
+// is returned if the duration of the video is unknown. Interval is increased
+// for long videos, so the number of thumbnails is limited.
+func storyboardLayout(probe *videoProbe) *model.VideoStoryboard {
+	if probe.Duration <= 0 {
+		return nil
+	}
+
+	interval := max(
+		int(math.Ceil(probe.Duration/maxStoryboardThumbnails)),
+		minStoryboardIntervalSec,
+	)
+
+	// Height keeps the aspect ratio and is even as required by encoders.
+	height := storyboardWidth * 9 / 16
+	if probe.Width != 0 {
+		height = int(math.Round(float64(storyboardWidth*probe.Height)/float64(probe.Width)/2)) * 2
+	}
+
+	count := int(math.Ceil(probe.Duration / float64(interval)))
+
+	return &model.VideoStoryboard{
+		Interval: interval,
+		Count:    count,
+		Columns:  min(count, storyboardColumns),
+		Width:    storyboardWidth,
+		Height:   max(height, 2),
+	}
+}
+
+// storyboardArgs returns ffmpeg arguments to tile thumbnails of the video
+// into the single image.
+func storyboardArgs(src, out string, storyboard *model.VideoStoryboard) []string {
+	rows := (storyboard.Count + storyboard.Columns - 1) / storyboard.Columns
+
+	return []string{
+		"-i", src,
+		"-vf", fmt.Sprintf("fps=1/%d,scale=%d:%d,tile=%dx%d",
+			storyboard.Interval,
+			storyboard.Width, storyboard.Height,
+			storyboard.Columns, rows,
+		),
+		"-frames:v", "1",
+		"-q:v", "5",
+		out,
+	}
+}
+
+// posterArgs returns ffmpeg arguments to take the most representative of the
+// first frames of the video, it is not upscaled.
+func posterArgs(src, out string, probe *videoProbe) []string {
+	return []string{
+		"-i", src,
+		"-vf", fmt.Sprintf("thumbnail,scale=-2:%d", min(probe.Height, posterHeight)),
+		"-frames:v", "1",
+		"-q:v", "3",
+		out,
+	}
+}
+
+// VideoPreview takes the poster frame and the storyboard of the video and
+// saves them next to it. Storyboard is skipped if the duration of the video
+// is unknown. Size of the saved files is returned.
+func (s *Service) VideoPreview(ctx context.Context, filename string) (*model.VideoPreview, int64, error) {
+	src, release, err := s.fetch(ctx, filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get file: %w", err)
+	}
+	defer release()
+
+	probe, err := probeVideo(ctx, src)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to probe video: %w", err)
+	}
+
+	outDir, err := os.MkdirTemp(s.tempDir, "preview-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("os.MkdirTemp: %w", err)
+	}
+	defer os.RemoveAll(outDir)
+
+	dir := path.Dir(filename)
+	preview := &model.VideoPreview{
+		Poster: path.Join(dir, uuid.NewString()+".jpg"),
+	}
+
+	posterFile := filepath.Join(outDir, "poster.jpg")
+	if err := runFFmpeg(ctx, posterArgs(src, posterFile, probe)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to take poster: %w", err)
+	}
+
+	storyboardFile := filepath.Join(outDir, "storyboard.jpg")
+	if storyboard := storyboardLayout(probe); storyboard != nil {
+		if err := runFFmpeg(ctx, storyboardArgs(src, storyboardFile, storyboard)...); err != nil {
+			return nil, 0, fmt.Errorf("failed to take storyboard: %w", err)
+		}
+
+		storyboard.Filename = path.Join(dir, uuid.NewString()+".jpg")
+		preview.Storyboard = storyboard
+	}
+
+	size, err := s.store(ctx, preview.Poster, posterFile)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to save poster: %w", err)
+	}
+
+	if preview.Storyboard != nil {
+		storyboardSize, err := s.store(ctx, preview.Storyboard.Filename, storyboardFile)
+		if err != nil {
+			if err := s.storage.Delete(ctx, preview.Poster); err != nil {
+				s.logger.Error("failed to delete poster", zap.String("file", preview.Poster), zap.Error(err))
+			}
+			return nil, 0, fmt.Errorf("failed to save storyboard: %w", err)
+		}
+		size += storyboardSize
+	}
+
+	return preview, size, nil
+}
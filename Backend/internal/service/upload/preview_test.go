@@ -0,0 +1,48 @@
+package upload
+
+import (
+	"academy/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStoryboardLayout(t *testing.T) {
+	tests := []struct {
+		probe *videoProbe
+		want  *model.VideoStoryboard
+	}{
+		{
+			probe: &videoProbe{Width: 1920, Height: 1080, Duration: 125.48},
+			want:  &model.VideoStoryboard{Interval: 2, Count: 63, Columns: 10, Width: 160, Height: 90},
+		},
+		{
+			probe: &videoProbe{Width: 1080, Height: 1920, Duration: 3600},
+			want:  &model.VideoStoryboard{Interval: 36, Count: 100, Columns: 10, Width: 160, Height: 284},
+		},
+		{
+			probe: &videoProbe{Width: 640, Height: 640, Duration: 5},
+			want:  &model.VideoStoryboard{Interval: 2, Count: 3, Columns: 3, Width: 160, Height: 160},
+		},
+		{
+			probe: &videoProbe{Width: 1280, Height: 720},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		if got := storyboardLayout(tt.probe); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("storyboardLayout(%+v) = %+v, want %+v", tt.probe, got, tt.want)
+		}
+	}
+
+	storyboard := storyboardLayout(&videoProbe{Width: 1920, Height: 1080, Duration: 125.48})
+	args := strings.Join(storyboardArgs("in.mp4", "out.jpg", storyboard), " ")
+	if !strings.Contains(args, "-vf fps=1/2,scale=160:90,tile=10x7") {
+		t.Errorf("storyboardArgs() = %s", args)
+	}
+
+	args = strings.Join(posterArgs("in.mp4", "out.jpg", &videoProbe{Height: 480}), " ")
+	if !strings.Contains(args, "-vf thumbnail,scale=-2:480") {
+		t.Errorf("posterArgs() = %s", args)
+	}
+}
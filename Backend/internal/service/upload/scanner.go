@@ -0,0 +1,67 @@
+package upload
+
+import (
+	"academy/internal/config"
+	"academy/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+)
+
+const ScanBackendClamd = "clamd"
+
+var (
+	ErrScanFailed         = errors.New("scan failed")
+	ErrUnknownScanBackend = errors.New("unknown scan backend")
+)
+
+// Scanner scans uploaded files for malware.
+type Scanner interface {
+	// Scan returns the result of the scan, error means the file is not
+	// scanned, e.g. the scanner is unavailable.
+	Scan(ctx context.Context, r io.Reader) (*model.FileScan, error)
+}
+
+// NewScanner creates the scanner of the config, it is nil if scanning is
+// disabled.
+func NewScanner(cfg *config.Config) (Scanner, error) {
+	switch cfg.Scan.Backend {
+	case "":
+		return nil, nil
+	case ScanBackendClamd:
+		return NewClamdScanner(&cfg.Scan)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScanBackend, cfg.Scan.Backend)
+	}
+}
+
+// ScanFile scans the uploaded file for malware, nil is returned if scanning is
+// disabled.
+func (s *Service) ScanFile(ctx context.Context, filename string) (*model.FileScan, error) {
+	if s.scanner == nil {
+		return nil, nil
+	}
+
+	file, _, err := s.storage.Get(ctx, filename, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	scan, err := s.scanner.Scan(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file: %w", err)
+	}
+
+	if scan.IsInfected() {
+		s.logger.Warn("infected file is uploaded",
+			zap.String("file", filename),
+			zap.String("signature", scan.Signature),
+		)
+	}
+
+	return scan, nil
+}
@@ -0,0 +1,145 @@
+package upload
+
+import (
+	"academy/internal/config"
+	"academy/internal/model"
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	clamdEngine    = "clamd"
+	clamdChunkSize = 64 * 1024
+
+	// DefaultClamdTimeout is a max duration of the scan if it is not
+	// configured.
+	DefaultClamdTimeout = time.Minute
+)
+
+// ClamdScanner streams files to clamd with the INSTREAM command.
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+func NewClamdScanner(cfg *config.ScanConfig) (*ClamdScanner, error) {
+	if cfg.ClamdAddress == "" {
+		return nil, fmt.Errorf("clamd address is required")
+	}
+
+	network, address := "tcp", cfg.ClamdAddress
+	if path, ok := strings.CutPrefix(cfg.ClamdAddress, "unix:"); ok {
+		network, address = "unix", path
+	}
+
+	timeout := cfg.ClamdTimeout
+	if timeout == 0 {
+		timeout = DefaultClamdTimeout
+	}
+
+	return &ClamdScanner{
+		network: network,
+		address: address,
+		timeout: timeout,
+	}, nil
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (*model.FileScan, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+
+	// clamd replies and closes the connection if the stream exceeds its
+	// limit, so the reply is read even if the file is not sent completely.
+	sendErr := clamdSend(conn, r)
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		if sendErr != nil {
+			return nil, sendErr
+		}
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return parseClamdReply(reply)
+}
+
+// clamdSend sends the file as chunks prefixed with their length, the stream
+// ends with the empty chunk.
+func clamdSend(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("failed to send clamd command: %w", err)
+	}
+
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := r.Read(buf[4:])
+		if 0 < n {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := w.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("failed to send file to clamd: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("failed to send file to clamd: %w", err)
+	}
+
+	return nil
+}
+
+// parseClamdReply parses replies like "stream: OK" and
+// "stream: Eicar-Signature FOUND".
+func parseClamdReply(reply string) (*model.FileScan, error) {
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	result, ok := strings.CutPrefix(reply, "stream: ")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrScanFailed, reply)
+	}
+
+	scan := &model.FileScan{
+		Engine:    clamdEngine,
+		ScannedAt: time.Now().UTC(),
+	}
+
+	if result == "OK" {
+		scan.Status = model.FileScanStatusClean
+		return scan, nil
+	}
+
+	if signature, ok := strings.CutSuffix(result, " FOUND"); ok {
+		scan.Status = model.FileScanStatusInfected
+		scan.Signature = signature
+		return scan, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrScanFailed, reply)
+}
@@ -0,0 +1,161 @@
+package upload
+
+import (
+	"academy/internal/config"
+	"academy/internal/model"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// eicar is a test string that is detected by antiviruses as malware.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd is a stand-in of clamd that supports the INSTREAM command, only
+// the EICAR test string is detected.
+type fakeClamd struct {
+	listener net.Listener
+	maxSize  int
+}
+
+func newFakeClamd(t *testing.T, maxSize int) *fakeClamd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeClamd{listener: listener, maxSize: maxSize}
+	go f.serve()
+
+	return f
+}
+
+func (f *fakeClamd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	if command != "zINSTREAM\x00" {
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var stream bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if f.maxSize < stream.Len()+int(size) {
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+		if _, err := io.CopyN(&stream, r, int64(size)); err != nil {
+			return
+		}
+	}
+
+	if strings.Contains(stream.String(), eicar) {
+		io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+		return
+	}
+	io.WriteString(conn, "stream: OK\x00")
+}
+
+func TestClamdScanner(t *testing.T) {
+	clamd := newFakeClamd(t, 1024*1024)
+
+	scanner, err := NewClamdScanner(&config.ScanConfig{ClamdAddress: clamd.listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("NewClamdScanner: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Files are larger than a chunk to be sent in parts.
+	clean := bytes.Repeat([]byte("lesson notes "), 10_000)
+
+	scan, err := scanner.Scan(ctx, bytes.NewReader(clean))
+	if err != nil {
+		t.Fatalf("Scan(clean): %v", err)
+	}
+	if scan.Status != model.FileScanStatusClean || scan.Engine != clamdEngine || scan.ScannedAt.IsZero() {
+		t.Errorf("Scan(clean) = %+v, want clean", scan)
+	}
+
+	infected := append(clean, eicar...)
+
+	scan, err = scanner.Scan(ctx, bytes.NewReader(infected))
+	if err != nil {
+		t.Fatalf("Scan(infected): %v", err)
+	}
+	if !scan.IsInfected() || scan.Signature != "Eicar-Test-Signature" {
+		t.Errorf("Scan(infected) = %+v, want Eicar-Test-Signature", scan)
+	}
+
+	_, err = scanner.Scan(ctx, bytes.NewReader(make([]byte, 2*1024*1024)))
+	if !errors.Is(err, ErrScanFailed) {
+		t.Errorf("Scan(too large) error = %v, want %v", err, ErrScanFailed)
+	}
+
+	clamd.listener.Close()
+
+	if _, err := scanner.Scan(ctx, bytes.NewReader(clean)); err == nil {
+		t.Errorf("Scan() with unavailable clamd error = nil, want error")
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		reply     string
+		status    model.FileScanStatus
+		signature string
+		wantErr   bool
+	}{
+		{reply: "stream: OK\x00", status: model.FileScanStatusClean},
+		{reply: "stream: Win.Test.EICAR_HDB-1 FOUND\x00", status: model.FileScanStatusInfected, signature: "Win.Test.EICAR_HDB-1"},
+		{reply: "INSTREAM size limit exceeded. ERROR\x00", wantErr: true},
+		{reply: "stream: lstat() failed. ERROR\x00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		scan, err := parseClamdReply(tt.reply)
+		if tt.wantErr {
+			if !errors.Is(err, ErrScanFailed) {
+				t.Errorf("parseClamdReply(%q) error = %v, want %v", tt.reply, err, ErrScanFailed)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseClamdReply(%q): %v", tt.reply, err)
+			continue
+		}
+		if scan.Status != tt.status || scan.Signature != tt.signature {
+			t.Errorf("parseClamdReply(%q) = %+v, want %s %q", tt.reply, scan, tt.status, tt.signature)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package upload
+
+import (
+	"academy/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	StorageBackendLocal = "local"
+	StorageBackendS3    = "s3"
+)
+
+var (
+	ErrObjectNotFound        = errors.New("object not found")
+	ErrPresignNotSupported   = errors.New("presigned urls are not supported by storage")
+	ErrInvalidRange          = errors.New("invalid range")
+	ErrUnknownStorageBackend = errors.New("unknown storage backend")
+)
+
+// Storage keeps uploaded files as objects. Keys are slash separated paths,
+// e.g. material file paths with the filename.
+type Storage interface {
+	// Put saves the object, size is -1 if it is unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get returns content of the object, the whole object if range is nil.
+	Get(ctx context.Context, key string, rng *ByteRange) (io.ReadCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes the object, missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every object which key starts with the prefix.
+	List(ctx context.Context, prefix string, fn func(*ObjectInfo) error) error
+	// PresignedURL returns URL the object can be downloaded by without
+	// authorization till the TTL expires.
+	PresignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ByteRange is a part of the object, length -1 means the rest of it.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+func (r *ByteRange) validate(size int64) error {
+	if r.Offset < 0 || size <= r.Offset || r.Length == 0 || r.Length < -1 {
+		return ErrInvalidRange
+	}
+
+	return nil
+}
+
+// header returns value of the HTTP Range header.
+func (r *ByteRange) header() string {
+	if r.Length == -1 {
+		return fmt.Sprintf("bytes=%d-", r.Offset)
+	}
+
+	return fmt.Sprintf("bytes=%d-%d", r.Offset, r.Offset+r.Length-1)
+}
+
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Backend {
+	case "", StorageBackendLocal:
+		return NewLocalStorage(cfg.App.UploadDirectory)
+	case StorageBackendS3:
+		return NewS3Storage(&cfg.Storage, nil)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStorageBackend, cfg.Storage.Backend)
+	}
+}
+
+// fetch returns path of the local file with content of the object. Objects
+// of remote storages are downloaded to the temp directory and removed by the
+// release func.
+func (s *Service) fetch(ctx context.Context, key string) (string, func(), error) {
+	if local, ok := s.storage.(*LocalStorage); ok {
+		return local.path(key), func() {}, nil
+	}
+
+	src, _, err := s.storage.Get(ctx, key, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(s.tempDir, "object-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	defer dst.Close()
+
+	release := func() {
+		_ = os.Remove(dst.Name())
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		release()
+		return "", nil, fmt.Errorf("failed to download object: %w", err)
+	}
+
+	return dst.Name(), release, nil
+}
+
+// store saves the local file as the object.
+func (s *Service) store(ctx context.Context, key, filePath string) (int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	if err := s.storage.Put(ctx, key, f, info.Size()); err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+// copyObject copies the object to the new key and returns its size. Local
+// files are hard linked instead.
+func (s *Service) copyObject(ctx context.Context, srcKey, dstKey string) (int64, error) {
+	if local, ok := s.storage.(*LocalStorage); ok {
+		return local.link(srcKey, dstKey)
+	}
+
+	src, info, err := s.storage.Get(ctx, srcKey, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	if err := s.storage.Put(ctx, dstKey, src, info.Size); err != nil {
+		return 0, err
+	}
+
+	return info.Size, nil
+}
+
+// countingReader counts bytes that are read.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
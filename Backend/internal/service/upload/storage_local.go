@@ -0,0 +1,243 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localTempPrefix is a prefix of files that are being written, they are not
+// listed as objects.
+const localTempPrefix = ".upload-"
+
+// LocalStorage keeps objects as files in the directory. Files are served by
+// the API, so presigned URLs are not supported.
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	dirInfo, err := os.Stat(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("directory not exist: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.Stat: %w", err)
+	}
+	if !dirInfo.IsDir() {
+		return nil, fmt.Errorf("provided path is file")
+	}
+
+	return &LocalStorage{root: filepath.Clean(root)}, nil
+}
+
+// path returns path of the object file, keys can not point outside the root.
+func (l *LocalStorage) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+func (l *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	filePath := l.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	// File is written aside and renamed, so readers never see a partial one.
+	dst, err := os.CreateTemp(filepath.Dir(filePath), localTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, r); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst.Name(), 0644); err != nil {
+		return fmt.Errorf("os.Chmod: %w", err)
+	}
+
+	if err := os.Rename(dst.Name(), filePath); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+
+	return nil
+}
+
+func (l *LocalStorage) Get(_ context.Context, key string, rng *ByteRange) (io.ReadCloser, *ObjectInfo, error) {
+	f, err := os.Open(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("os.Open: %w", err)
+	}
+
+	info, err := l.info(key, f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	if rng == nil {
+		return f, info, nil
+	}
+
+	if err := rng.validate(info.Size); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	length := rng.Length
+	if length == -1 || info.Size < rng.Offset+length {
+		length = info.Size - rng.Offset
+	}
+
+	return &sectionReadCloser{
+		Reader: io.NewSectionReader(f, rng.Offset, length),
+		Closer: f,
+	}, info, nil
+}
+
+func (l *LocalStorage) Stat(_ context.Context, key string) (*ObjectInfo, error) {
+	f, err := os.Open(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("os.Open: %w", err)
+	}
+	defer f.Close()
+
+	return l.info(key, f)
+}
+
+func (l *LocalStorage) info(key string, f *os.File) (*ObjectInfo, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, ErrObjectNotFound
+	}
+
+	return &ObjectInfo{
+		Key:     key,
+		Size:    stat.Size(),
+		ModTime: stat.ModTime(),
+	}, nil
+}
+
+// Delete also removes directories that become empty.
+func (l *LocalStorage) Delete(_ context.Context, key string) error {
+	filePath := l.path(key)
+
+	err := os.Remove(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("os.Remove: %w", err)
+	}
+
+	for dir := filepath.Dir(filePath); dir != l.root && strings.HasPrefix(dir, l.root); dir = filepath.Dir(dir) {
+		// Directory that is not empty is not removed.
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (l *LocalStorage) List(ctx context.Context, prefix string, fn func(*ObjectInfo) error) error {
+	// Only the directory of the prefix is walked.
+	dir := l.root
+	if i := strings.LastIndex(prefix, "/"); i != -1 {
+		dir = l.path(prefix[:i])
+	}
+
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), localTempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get file info: %w", err)
+		}
+
+		return fn(&ObjectInfo{
+			Key:     key,
+			Size:    stat.Size(),
+			ModTime: stat.ModTime(),
+		})
+	})
+
+	return err
+}
+
+// link creates the hard link of the object file and returns its size.
+func (l *LocalStorage) link(srcKey, dstKey string) (int64, error) {
+	dstPath := l.path(dstKey)
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	if err := os.Link(l.path(srcKey), dstPath); err != nil {
+		return 0, fmt.Errorf("failed to create link: %w", err)
+	}
+
+	info, err := os.Stat(dstPath)
+	if err != nil {
+		return 0, fmt.Errorf("os.Stat: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+func (l *LocalStorage) PresignedURL(context.Context, string, time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+type sectionReadCloser struct {
+	io.Reader
+	io.Closer
+}
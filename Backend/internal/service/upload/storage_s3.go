@@ -0,0 +1,494 @@
+package upload
+
+import (
+	"academy/internal/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3DefaultRegion  = "us-east-1"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3DateFormat     = "20060102T150405Z"
+	s3ErrorBodyLimit = 4 * 1024
+
+	// S3MaxPresignTTL is a max lifetime of presigned URLs in AWS.
+	S3MaxPresignTTL = 7 * 24 * time.Hour
+)
+
+// s3EmptyBodyHash is SHA-256 of the empty payload.
+var s3EmptyBodyHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+// S3Storage keeps objects in the bucket of S3-compatible storage. Requests
+// are signed with AWS Signature Version 4.
+type S3Storage struct {
+	client   *http.Client
+	endpoint *url.URL
+
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+
+	now func() time.Time
+}
+
+// NewS3Storage creates the storage, default HTTP client is used if client is
+// nil.
+func NewS3Storage(cfg *config.StorageConfig, client *http.Client) (*S3Storage, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+
+	endpoint, err := url.Parse(cfg.S3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", cfg.S3Endpoint)
+	}
+
+	region := cfg.S3Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3Storage{
+		client:   client,
+		endpoint: endpoint,
+
+		region:    region,
+		bucket:    cfg.S3Bucket,
+		accessKey: cfg.S3AccessKey,
+		secretKey: cfg.S3SecretKey,
+		pathStyle: cfg.S3PathStyle,
+
+		now: time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	// Content length is required, so the object of unknown size is buffered.
+	if size < 0 {
+		f, err := os.CreateTemp("", "s3-object-*")
+		if err != nil {
+			return fmt.Errorf("os.CreateTemp: %w", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		size, err = io.Copy(f, r)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("f.Seek: %w", err)
+		}
+		r = f
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	resp, err := s.do(req, s3UnsignedBody)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string, rng *ByteRange) (io.ReadCloser, *ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rng != nil {
+		if rng.Offset < 0 || rng.Length == 0 || rng.Length < -1 {
+			return nil, nil, ErrInvalidRange
+		}
+		req.Header.Set("Range", rng.header())
+	}
+
+	resp, err := s.do(req, s3EmptyBodyHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := s.objectInfo(key, resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+
+	return resp.Body, info, nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, s3EmptyBodyHash)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return s.objectInfo(key, resp)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, s3EmptyBodyHash)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string, fn func(*ObjectInfo) error) error {
+	var token string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := s.do(req, s3EmptyBodyHash)
+		if err != nil {
+			return err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode list response: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			// Folder placeholders created by some clients are not objects.
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+
+			err := fn(&ObjectInfo{
+				Key:     object.Key,
+				Size:    object.Size,
+				ModTime: object.LastModified,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3Storage) PresignedURL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || S3MaxPresignTTL < ttl {
+		return "", fmt.Errorf("invalid presigned url ttl: %v", ttl)
+	}
+
+	u := s.objectURL(key)
+	now := s.now().UTC()
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3DateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(ttl/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		s3EscapePath(u.Path),
+		s3CanonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	u.RawQuery = s3CanonicalQuery(query)
+
+	return u.String(), nil
+}
+
+// objectURL returns URL of the object, URL of the bucket if key is empty.
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.RawQuery = ""
+
+	base := strings.TrimSuffix(u.Path, "/")
+	if s.pathStyle {
+		base += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+
+	u.Path = base + "/" + key
+	u.RawPath = s3EscapePath(base) + "/" + s3EscapePath(key)
+
+	return &u
+}
+
+func (s *S3Storage) newRequest(
+	ctx context.Context,
+	method, key string,
+	query url.Values,
+	body io.ReadCloser,
+) (*http.Request, error) {
+
+	u := s.objectURL(key)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Body = body
+	}
+
+	return req, nil
+}
+
+// do signs and sends the request, response is returned only if it succeeds.
+func (s *S3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrObjectNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil, ErrInvalidRange
+	}
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	rawBody, _ := io.ReadAll(io.LimitReader(resp.Body, s3ErrorBodyLimit))
+	if err := xml.Unmarshal(rawBody, &s3Err); err != nil || s3Err.Code == "" {
+		return nil, fmt.Errorf("s3 request failed with status: %v", resp.Status)
+	}
+
+	return nil, fmt.Errorf("s3 request failed with status: %v: %s: %s",
+		resp.Status, s3Err.Code, s3Err.Message)
+}
+
+// sign adds the authorization header. Host, range and x-amz-* headers are
+// signed.
+func (s *S3Storage) sign(req *http.Request, payloadHash string, now time.Time) {
+	req.Header.Set("X-Amz-Date", now.Format(s3DateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s3Algorithm, s.accessKey, s.scope(now), signedHeaders, s.signature(now, canonicalRequest),
+	))
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3DateFormat),
+		s.scope(now),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, s3Service)
+	key = s3HMAC(key, "aws4_request")
+
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+// objectInfo reads size and modification time from the response headers.
+// Size of the whole object is taken from the content range if it is partial.
+func (s *S3Storage) objectInfo(key string, resp *http.Response) (*ObjectInfo, error) {
+	info := &ObjectInfo{
+		Key:  key,
+		Size: resp.ContentLength,
+	}
+
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		i := strings.LastIndex(contentRange, "/")
+		size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content range: %s", contentRange)
+		}
+		info.Size = size
+	}
+
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		modTime, err := http.ParseTime(lastModified)
+		if err == nil {
+			info.ModTime = modTime
+		}
+	}
+
+	return info, nil
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery returns the query sorted by keys with values escaped as
+// required by the signature.
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath escapes segments of the path, slashes are kept.
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes everything except unreserved characters.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package upload
+
+import (
+	"academy/internal/model"
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// hlsSubtitlesDir keeps copies of subtitles in the asset, so they are
+	// served by tokens of the asset.
+	hlsSubtitlesDir   = "subtitles"
+	hlsSubtitlesGroup = "subs"
+)
+
+// hlsSubtitleTrack is the subtitles rendition of the master playlist.
+type hlsSubtitleTrack struct {
+	Name     string
+	Language string
+	Playlist string
+}
+
+// hlsFirstVariant returns URI of the first variant of the master playlist.
+func hlsFirstVariant(master string) string {
+	scanner := bufio.NewScanner(strings.NewReader(master))
+
+	isVariant := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#EXT-X-STREAM-INF:") {
+			isVariant = true
+			continue
+		}
+		if isVariant && line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+
+	return ""
+}
+
+// hlsPlaylistDuration returns the sum of durations of segments of the media
+// playlist in seconds.
+func hlsPlaylistDuration(playlist string) float64 {
+	var duration float64
+
+	scanner := bufio.NewScanner(strings.NewReader(playlist))
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "#EXTINF:")
+		if !ok {
+			continue
+		}
+
+		value, _, _ = strings.Cut(value, ",")
+		if d, err := strconv.ParseFloat(value, 64); err == nil {
+			duration += d
+		}
+	}
+
+	return duration
+}
+
+// hlsSubtitlePlaylist returns the media playlist of the single WebVTT segment
+// that lasts the whole video.
+func hlsSubtitlePlaylist(vtt string, duration float64) string {
+	return fmt.Sprintf("#EXTM3U\n"+
+		"#EXT-X-VERSION:3\n"+
+		"#EXT-X-TARGETDURATION:%d\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-PLAYLIST-TYPE:VOD\n"+
+		"#EXTINF:%.3f,\n"+
+		"%s\n"+
+		"#EXT-X-ENDLIST\n",
+		int(math.Ceil(duration)), duration, vtt,
+	)
+}
+
+// hlsMasterWithSubtitles replaces subtitles renditions of the master playlist
+// with the tracks. Variants refer to the group only if there are tracks.
+func hlsMasterWithSubtitles(master string, tracks []hlsSubtitleTrack) string {
+	groupAttr := `,SUBTITLES="` + hlsSubtitlesGroup + `"`
+
+	var out strings.Builder
+	for i, line := range strings.Split(strings.TrimRight(master, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:") && strings.Contains(line, "TYPE=SUBTITLES"):
+			continue
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			line = strings.ReplaceAll(line, groupAttr, "")
+			if len(tracks) != 0 {
+				line += groupAttr
+			}
+		}
+
+		out.WriteString(line + "\n")
+
+		// Renditions are declared before variants, after the header.
+		if i == 0 {
+			for j, t := range tracks {
+				isDefault := "NO"
+				if j == 0 {
+					isDefault = "YES"
+				}
+				fmt.Fprintf(&out,
+					"#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=%q,NAME=%q,LANGUAGE=%q,DEFAULT=%s,AUTOSELECT=YES,URI=%q\n",
+					hlsSubtitlesGroup,
+					strings.ReplaceAll(t.Name, `"`, "'"),
+					t.Language,
+					isDefault,
+					t.Playlist,
+				)
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// SyncHLSSubtitles copies subtitles into the transcoded asset and lists them
+// in its master playlist, previous copies are deleted.
+func (s *Service) SyncHLSSubtitles(ctx context.Context, assetID string, subtitles []*model.Subtitle) error {
+	masterKey := path.Join(assetID, hlsPlaylist)
+
+	master, err := s.ReadFile(ctx, masterKey)
+	if err != nil {
+		return fmt.Errorf("failed to read master playlist: %w", err)
+	}
+
+	variant := hlsFirstVariant(string(master))
+	if variant == "" {
+		return fmt.Errorf("no variants in master playlist")
+	}
+
+	rendition, err := s.ReadFile(ctx, path.Join(assetID, variant))
+	if err != nil {
+		return fmt.Errorf("failed to read playlist %s: %w", variant, err)
+	}
+	duration := hlsPlaylistDuration(string(rendition))
+
+	dir := path.Join(assetID, hlsSubtitlesDir)
+	err = s.storage.List(ctx, dir+"/", func(object *ObjectInfo) error {
+		return s.storage.Delete(ctx, object.Key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete subtitles: %w", err)
+	}
+
+	tracks := make([]hlsSubtitleTrack, 0, len(subtitles))
+	for _, subtitle := range subtitles {
+		name := subtitle.ID.String()
+
+		if _, err := s.copyObject(ctx, subtitle.Filename, path.Join(dir, name+".vtt")); err != nil {
+			return fmt.Errorf("failed to copy subtitles %s: %w", subtitle.ID, err)
+		}
+
+		playlist := hlsSubtitlePlaylist(name+".vtt", duration)
+		err := s.storage.Put(ctx, path.Join(dir, name+".m3u8"), strings.NewReader(playlist), int64(len(playlist)))
+		if err != nil {
+			return fmt.Errorf("failed to save subtitles playlist: %w", err)
+		}
+
+		tracks = append(tracks, hlsSubtitleTrack{
+			Name:     subtitle.Label,
+			Language: subtitle.Language,
+			Playlist: path.Join(hlsSubtitlesDir, name+".m3u8"),
+		})
+	}
+
+	updated := hlsMasterWithSubtitles(string(master), tracks)
+	if err := s.storage.Put(ctx, masterKey, strings.NewReader(updated), int64(len(updated))); err != nil {
+		return fmt.Errorf("failed to save master playlist: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package upload
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHLSSubtitles(t *testing.T) {
+	master := "#EXTM3U\n" +
+		"#EXT-X-VERSION:3\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=2928000,RESOLUTION=1280x720\n" +
+		"720p/index.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1096000,RESOLUTION=854x480\n" +
+		"480p/index.m3u8\n"
+
+	if got := hlsFirstVariant(master); got != "720p/index.m3u8" {
+		t.Errorf("hlsFirstVariant() = %s, want 720p/index.m3u8", got)
+	}
+
+	playlist := "#EXTM3U\n#EXTINF:6.000000,\nsegment_00000.ts\n#EXTINF:4.5,\nsegment_00001.ts\n#EXT-X-ENDLIST\n"
+	if got := hlsPlaylistDuration(playlist); got != 10.5 {
+		t.Errorf("hlsPlaylistDuration() = %v, want 10.5", got)
+	}
+
+	sub := hlsSubtitlePlaylist("a.vtt", 10.5)
+	for _, want := range []string{"#EXT-X-TARGETDURATION:11\n", "#EXTINF:10.500,\na.vtt\n", "#EXT-X-ENDLIST\n"} {
+		if !strings.Contains(sub, want) {
+			t.Errorf("hlsSubtitlePlaylist() = %q, want %q", sub, want)
+		}
+	}
+
+	tracks := []hlsSubtitleTrack{
+		{Name: `English "CC"`, Language: "en", Playlist: "subtitles/a.m3u8"},
+		{Name: "Deutsch", Language: "de", Playlist: "subtitles/b.m3u8"},
+	}
+	withSubs := hlsMasterWithSubtitles(master, tracks)
+
+	want := "#EXTM3U\n" +
+		`#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English 'CC'",LANGUAGE="en",DEFAULT=YES,AUTOSELECT=YES,URI="subtitles/a.m3u8"` + "\n" +
+		`#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Deutsch",LANGUAGE="de",DEFAULT=NO,AUTOSELECT=YES,URI="subtitles/b.m3u8"` + "\n" +
+		"#EXT-X-VERSION:3\n" +
+		`#EXT-X-STREAM-INF:BANDWIDTH=2928000,RESOLUTION=1280x720,SUBTITLES="subs"` + "\n" +
+		"720p/index.m3u8\n" +
+		`#EXT-X-STREAM-INF:BANDWIDTH=1096000,RESOLUTION=854x480,SUBTITLES="subs"` + "\n" +
+		"480p/index.m3u8\n"
+	if withSubs != want {
+		t.Errorf("hlsMasterWithSubtitles() = %q, want %q", withSubs, want)
+	}
+
+	// Tracks are replaced, the master is restored without them.
+	if got := hlsMasterWithSubtitles(withSubs, tracks); got != want {
+		t.Errorf("hlsMasterWithSubtitles() again = %q, want %q", got, want)
+	}
+	if got := hlsMasterWithSubtitles(withSubs, nil); got != master {
+		t.Errorf("hlsMasterWithSubtitles() without tracks = %q, want %q", got, master)
+	}
+}
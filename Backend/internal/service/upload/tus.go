@@ -0,0 +1,236 @@
+package upload
+
+import (
+	repo "academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// tusDir keeps parts of uploads that are not complete yet.
+const tusDir = "tus"
+
+var (
+	ErrTusUploadNotFound   = errors.New("upload not found")
+	ErrTusOffsetConflict   = errors.New("upload offset does not match")
+	ErrTusChecksumMismatch = errors.New("checksum does not match")
+	ErrTusUploadIncomplete = errors.New("upload is not complete")
+	ErrStorageLimit        = errors.New("storage size exceeds the limit")
+)
+
+// tusPartKey returns a unique key of the part, so parts received at the same
+// offset by concurrent requests do not overwrite each other.
+func tusPartKey(uploadID uuid.UUID, offset int64) string {
+	return path.Join(tusDir, uploadID.String(), fmt.Sprintf("%020d-%s", offset, uuid.NewString()))
+}
+
+func (s *Service) CreateTusUpload(ctx context.Context, upload *model.TusUpload) error {
+	if err := s.tusUploadRepository.Create(ctx, upload); err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return nil
+}
+
+// TusUpload returns the upload of the mini-app, expired uploads are not
+// returned.
+func (s *Service) TusUpload(ctx context.Context, miniAppID, id uuid.UUID) (*model.TusUpload, error) {
+	upload, err := s.tusUploadRepository.GetByID(ctx, miniAppID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	if upload == nil || upload.ExpiresAt().Before(time.Now()) {
+		return nil, ErrTusUploadNotFound
+	}
+
+	return upload, nil
+}
+
+// WriteTusPart saves the part received at the offset. Checksum is verified
+// if the hash is not nil, the part is discarded if it does not match.
+func (s *Service) WriteTusPart(
+	ctx context.Context,
+	upload *model.TusUpload,
+	offset int64,
+	part io.Reader, size int64,
+	checksum hash.Hash, sum []byte,
+) error {
+
+	if offset != upload.Offset {
+		return ErrTusOffsetConflict
+	}
+	if size == 0 {
+		return nil
+	}
+
+	var r io.Reader = part
+	if checksum != nil {
+		r = io.TeeReader(part, checksum)
+	}
+
+	key := tusPartKey(upload.ID, offset)
+	if err := s.storage.Put(ctx, key, r, size); err != nil {
+		return fmt.Errorf("failed to save part: %w", err)
+	}
+
+	discard := func() {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			s.logger.Error("failed to delete upload part", zap.String("part", key), zap.Error(err))
+		}
+	}
+
+	if checksum != nil && subtle.ConstantTimeCompare(checksum.Sum(nil), sum) != 1 {
+		discard()
+		return ErrTusChecksumMismatch
+	}
+
+	// Storage size of the mini-app is checked when the offset is moved.
+	added, err := s.tusUploadRepository.AddPart(ctx, upload, key, size)
+	if repo.StorageLimitExceeded(err) {
+		discard()
+		return ErrStorageLimit
+	}
+	if err != nil {
+		discard()
+		return fmt.Errorf("failed to add part: %w", err)
+	}
+	if !added {
+		discard()
+		return ErrTusOffsetConflict
+	}
+
+	return nil
+}
+
+// CompleteTusUpload joins parts of the upload into the file in the directory
+// and returns its name. Parts are kept till the upload is deleted.
+func (s *Service) CompleteTusUpload(
+	ctx context.Context,
+	upload *model.TusUpload,
+	materialFilenameDir string, fileExtension string,
+) (filename string, err error) {
+
+	if !upload.IsComplete() {
+		return "", ErrTusUploadIncomplete
+	}
+
+	filename = filepath.Join(materialFilenameDir, uuid.New().String()+fileExtension)
+
+	// Parts are streamed one by one, so only one of them is open at a time.
+	pr, pw := io.Pipe()
+	go func() {
+		for _, key := range upload.Parts {
+			src, _, err := s.storage.Get(ctx, key, nil)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to get part %s: %w", key, err))
+				return
+			}
+
+			_, err = io.Copy(pw, src)
+
+			src.Close()
+
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to copy part %s: %w", key, err))
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	err = s.storage.Put(ctx, filename, pr, upload.Length)
+	pr.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+
+	return filename, nil
+}
+
+// DeleteTusUpload deletes the upload and its parts.
+func (s *Service) DeleteTusUpload(ctx context.Context, upload *model.TusUpload) error {
+	if err := s.tusUploadRepository.Delete(ctx, upload.ID); err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	if err := s.deleteTusParts(ctx, upload.ID); err != nil {
+		s.logger.Error("failed to delete upload parts",
+			zap.String("upload_id", upload.ID.String()), zap.Error(err))
+	}
+
+	return nil
+}
+
+func (s *Service) deleteTusParts(ctx context.Context, uploadID uuid.UUID) error {
+	prefix := path.Join(tusDir, uploadID.String()) + "/"
+
+	return s.storage.List(ctx, prefix, func(object *ObjectInfo) error {
+		return s.storage.Delete(ctx, object.Key)
+	})
+}
+
+// ClearExpiredTusUploads deletes uploads that received no parts during the
+// TTL and parts of uploads that no longer exist, e.g. of deleted materials.
+func (s *Service) ClearExpiredTusUploads(ctx context.Context) error {
+	ids, err := s.tusUploadRepository.DeleteOlderThan(ctx, time.Now().Add(-model.TusUploadTTL))
+	if err != nil {
+		return fmt.Errorf("failed to delete expired uploads: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := s.deleteTusParts(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete upload parts: %w", err)
+		}
+	}
+
+	partIDs := make(map[uuid.UUID][]string)
+	err = s.storage.List(ctx, tusDir+"/", func(object *ObjectInfo) error {
+		rawID, _, _ := strings.Cut(strings.TrimPrefix(object.Key, tusDir+"/"), "/")
+
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			return nil
+		}
+		partIDs[id] = append(partIDs[id], object.Key)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list upload parts: %w", err)
+	}
+
+	ids = make([]uuid.UUID, 0, len(partIDs))
+	for id := range partIDs {
+		ids = append(ids, id)
+	}
+
+	existing, err := s.tusUploadRepository.Exists(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to check uploads: %w", err)
+	}
+
+	for id, keys := range partIDs {
+		if existing[id] {
+			continue
+		}
+		for _, key := range keys {
+			if err := s.removeFile(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package upload
 
 import (
 	"academy/internal/config"
+	repo "academy/internal/database/repository"
 	"academy/internal/model"
 	"academy/internal/storage/repository"
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -25,6 +27,10 @@ import (
 const MaxChunkAge = 2 * time.Hour
 const MaxExcelAge = 10 * time.Minute
 
+// DefaultPresignTTL is a lifetime of presigned URLs of uploaded files if it is
+// not configured.
+const DefaultPresignTTL = 15 * time.Minute
+
 type Service struct {
 	logger *zap.Logger
 
@@ -32,11 +38,17 @@ type Service struct {
 	muxConfig    *config.MuxConfig
 	assetsToKeep map[string]struct{}
 
-	uploadDir string
-	tempDir   string
+	storage    Storage
+	scanner    Scanner
+	presignTTL time.Duration
+	tempDir    string
+
+	videoBackend string
+	secretKey    []byte
 
 	toFastStartMu sync.Mutex
 	compressMu    sync.Mutex
+	transcodeMu   sync.Mutex
 
 	userRepository         *repository.UserRepository
 	miniAppRepository      *repository.MiniAppRepository
@@ -45,6 +57,14 @@ type Service struct {
 	materialRepository     *repository.MaterialRepository
 	chunkRepository        *repository.ChunkRepository
 	productLevelRepository *repository.ProductLevelRepository
+	gamificationRepository *repository.GamificationRepository
+	botRepository          *repository.BotRepository
+	broadcastRepository    *repository.BroadcastRepository
+	tusUploadRepository    *repository.TusUploadRepository
+	subtitleRepository     *repository.SubtitleRepository
+	blobRepository         *repository.BlobRepository
+
+	transactionManager *repo.TransactionManager
 }
 
 func NewService(
@@ -57,20 +77,35 @@ func NewService(
 	materialRepository *repository.MaterialRepository,
 	chunkRepository *repository.ChunkRepository,
 	productLevelRepository *repository.ProductLevelRepository,
+	gamificationRepository *repository.GamificationRepository,
+	botRepository *repository.BotRepository,
+	broadcastRepository *repository.BroadcastRepository,
+	tusUploadRepository *repository.TusUploadRepository,
+	subtitleRepository *repository.SubtitleRepository,
+	blobRepository *repository.BlobRepository,
+	transactionManager *repo.TransactionManager,
 ) (*Service, error) {
 
-	dirInfo, err := os.Stat(cfg.App.UploadDirectory)
-	if errors.Is(err, fs.ErrNotExist) {
-		return nil, fmt.Errorf("directory not exist: %w", err)
+	storage, err := NewStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage: %w", err)
 	}
+
+	scanner, err := NewScanner(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("os.Stat: %w", err)
+		return nil, fmt.Errorf("failed to create scanner: %w", err)
 	}
-	if !dirInfo.IsDir() {
-		return nil, fmt.Errorf("provided path is file")
+
+	videoBackend := cfg.Video.Backend
+	switch videoBackend {
+	case "":
+		videoBackend = VideoBackendMux
+	case VideoBackendMux, VideoBackendHLS:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVideoBackend, videoBackend)
 	}
 
-	dirInfo, err = os.Stat(cfg.App.TempUploadDirectory)
+	dirInfo, err := os.Stat(cfg.App.TempUploadDirectory)
 	if errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("directory not exist: %w", err)
 	}
@@ -83,9 +118,7 @@ func NewService(
 
 	demoAssets := make(map[string]struct{}, 0)
 	if cfg.App.EnableDemoProduct {
-		demoProductFilename := filepath.Join(cfg.App.UploadDirectory, demoDir, productStructureFilename)
-
-		demoProductFile, err := os.Open(demoProductFilename)
+		demoProductFile, _, err := storage.Get(context.Background(), demoKey(productStructureFilename), nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open demo product: %w", err)
 		}
@@ -119,8 +152,13 @@ func NewService(
 		muxConfig:    &cfg.Mux,
 		assetsToKeep: demoAssets,
 
-		uploadDir: cfg.App.UploadDirectory,
-		tempDir:   cfg.App.TempUploadDirectory,
+		storage:    storage,
+		scanner:    scanner,
+		presignTTL: cfg.Storage.S3PresignTTL,
+		tempDir:    cfg.App.TempUploadDirectory,
+
+		videoBackend: videoBackend,
+		secretKey:    []byte(cfg.Auth.EncryptionKey),
 
 		userRepository:         userRepository,
 		miniAppRepository:      miniAppRepository,
@@ -129,6 +167,14 @@ func NewService(
 		materialRepository:     materialRepository,
 		chunkRepository:        chunkRepository,
 		productLevelRepository: productLevelRepository,
+		gamificationRepository: gamificationRepository,
+		botRepository:          botRepository,
+		broadcastRepository:    broadcastRepository,
+		tusUploadRepository:    tusUploadRepository,
+		subtitleRepository:     subtitleRepository,
+		blobRepository:         blobRepository,
+
+		transactionManager: transactionManager,
 	}, nil
 }
 
@@ -138,25 +184,14 @@ func (s *Service) Upload(
 	fileExtension string,
 ) (filename string, size int64, err error) {
 
-	if err := os.MkdirAll(filepath.Join(s.uploadDir, path), 0755); err != nil {
-		return filename, size, fmt.Errorf("failed to create directory: %v", err)
-	}
-
 	filename = filepath.Join(path, uuid.New().String()+fileExtension)
-	fullFilePath := filepath.Join(s.uploadDir, filename)
 
-	dst, err := os.Create(fullFilePath)
-	if err != nil {
-		return "", 0, fmt.Errorf("os.Create: %w", err)
-	}
-	defer dst.Close()
-
-	size, err = io.Copy(dst, file)
-	if err != nil {
-		return "", 0, err
+	counter := &countingReader{Reader: file}
+	if err := s.storage.Put(context.Background(), filename, counter, -1); err != nil {
+		return "", 0, fmt.Errorf("failed to save file: %w", err)
 	}
 
-	return filename, size, nil
+	return filename, counter.n, nil
 }
 
 func (s *Service) UploadExcel(
@@ -164,103 +199,143 @@ func (s *Service) UploadExcel(
 	rawBytes []byte, filenameBase string,
 ) (filename string, size int64, err error) {
 
-	if err := os.MkdirAll(filepath.Join(s.uploadDir, path), 0755); err != nil {
-		return filename, size, fmt.Errorf("failed to create directory: %v", err)
+	filename = filepath.Join(path, filenameBase)
+
+	err = s.storage.Put(context.Background(), filename, bytes.NewReader(rawBytes), int64(len(rawBytes)))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to save file: %w", err)
 	}
 
-	filename = filepath.Join(path, filenameBase)
+	return filename, int64(len(rawBytes)), nil
+}
 
-	dst, err := os.Create(filepath.Join(s.uploadDir, filename))
+// ReadFile returns content of the uploaded file.
+func (s *Service) ReadFile(ctx context.Context, filePath string) ([]byte, error) {
+	file, _, err := s.storage.Get(ctx, filePath, nil)
 	if err != nil {
-		return "", 0, fmt.Errorf("os.Create: %w", err)
+		return nil, fmt.Errorf("failed to get file %s: %w", filePath, err)
 	}
-	defer dst.Close()
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// Open returns the part of the uploaded file and info of the whole file.
+func (s *Service) Open(ctx context.Context, filePath string, rng *ByteRange) (io.ReadCloser, *ObjectInfo, error) {
+	return s.storage.Get(ctx, filePath, rng)
+}
 
-	n, err := dst.Write(rawBytes)
-	size = int64(n)
+// PresignedURL returns URL the uploaded file is downloaded from directly.
+// ErrPresignNotSupported is returned if files are served by the API.
+func (s *Service) PresignedURL(ctx context.Context, filePath string) (string, error) {
+	ttl := s.presignTTL
+	if ttl == 0 {
+		ttl = DefaultPresignTTL
+	}
+
+	return s.storage.PresignedURL(ctx, filePath, ttl)
+}
+
+// Copy copies the file and returns the size of the copy.
+func (s *Service) Copy(ctx context.Context, srcPath, dstPath string) (int64, error) {
+	size, err := s.copyObject(ctx, srcPath, dstPath)
 	if err != nil {
-		return "", size, err
+		return 0, fmt.Errorf("failed to copy %s: %w", srcPath, err)
 	}
 
-	return filename, size, nil
+	return size, nil
 }
 
+// Delete removes the file or all files of the material file path, variants
+// of images are removed with them. Blobs are kept while materials reference
+// them.
 func (s *Service) Delete(filePath string) error {
 	if filePath == "" {
 		return nil
 	}
 
-	fullFilePath := filepath.Join(s.uploadDir, filePath)
+	ctx := context.Background()
 
-	_, err := os.Stat(fullFilePath)
-	if err != nil {
-		return fmt.Errorf("os.Stat: %w", err)
+	if IsBlob(filePath) {
+		isDeleted, err := s.deleteBlob(ctx, filePath)
+		if err != nil {
+			return err
+		}
+		if !isDeleted {
+			return nil
+		}
 	}
 
-	err = os.RemoveAll(fullFilePath)
+	var deleted int
+	err := s.storage.List(ctx, filePath, func(object *ObjectInfo) error {
+		original, isVariant := imageOriginal(object.Key)
+		if object.Key != filePath && !strings.HasPrefix(object.Key, filePath+"/") &&
+			!(isVariant && original == filePath) {
+			return nil
+		}
+
+		if err := s.storage.Delete(ctx, object.Key); err != nil {
+			return fmt.Errorf("failed to delete file %s: %w", object.Key, err)
+		}
+		deleted++
+
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("os.RemoveAll: %w", err)
+		return err
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("failed to delete %s: %w", filePath, ErrObjectNotFound)
 	}
 
 	return nil
 }
 
+// ClearDanglingUploads removes files of the storage that are not referenced
+// by mini-apps, products, lessons and users.
 func (s *Service) ClearDanglingUploads(ctx context.Context) error {
-	err := filepath.WalkDir(s.uploadDir, func(
-		uploadFilePath string, info os.DirEntry, err error,
-	) error {
-
+	err := s.storage.List(ctx, "", func(object *ObjectInfo) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
 
-		if errors.Is(err, os.ErrNotExist) {
+		materialFilename := object.Key
+
+		if strings.HasPrefix(materialFilename, demoDir+"/") {
 			return nil
 		}
-		if err != nil {
-			return err
-		}
 
-		if info.IsDir() {
-			if uploadFilePath == s.uploadDir {
-				return nil
-			}
-			if uploadFilePath == filepath.Join(s.uploadDir, demoDir) {
-				return nil
-			}
-			isEmpty, err := isDirectoryEmpty(uploadFilePath)
-			if err != nil {
-				return fmt.Errorf("error checking directory %s: %w", uploadFilePath, err)
-			}
-			if !isEmpty {
-				return nil
-			}
-			err = os.Remove(uploadFilePath)
-			if err != nil {
-				s.logger.Error("error deleting empty directory",
-					zap.String("directory", uploadFilePath),
-					zap.Error(err),
-				)
-			} else {
-				s.logger.Info("deleted empty directory", zap.String("directory", uploadFilePath))
-			}
+		// Parts of resumable uploads are cleared with expired uploads.
+		if strings.HasPrefix(materialFilename, tusDir+"/") {
+			return nil
+		}
 
+		// Transcoded videos are deleted with replaced assets of materials.
+		if strings.HasPrefix(materialFilename, hlsDir+"/") {
 			return nil
 		}
 
-		materialFilename := strings.TrimPrefix(uploadFilePath, s.uploadDir)
-		materialFilename = strings.TrimPrefix(materialFilename, "/")
+		// Variants of images are kept while the image exists. Image is listed
+		// before its variants, so they are removed in the same run.
+		if original, ok := imageOriginal(materialFilename); ok {
+			_, err := s.storage.Stat(ctx, original)
+			if errors.Is(err, ErrObjectNotFound) {
+				return s.removeFile(ctx, materialFilename)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get image of variant: %w", err)
+			}
 
-		if strings.HasPrefix(materialFilename, demoDir) {
 			return nil
 		}
 
 		materialPath, err := ParseMaterialFilePath(materialFilename)
 		if err != nil {
 			s.logger.Error("error in ParseMaterialFilePath",
-				zap.String("directory", uploadFilePath),
+				zap.String("file", materialFilename),
 				zap.Error(err),
 			)
 			return nil
@@ -268,7 +343,28 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 
 		// Delete all top level files. Files should be groupped only by mini-apps.
 		if materialPath.MiniAppID == uuid.Nil {
-			return s.removeFile(uploadFilePath)
+			return s.removeFile(ctx, materialFilename)
+		}
+
+		// Blobs of deleted lessons and products are left without materials.
+		if materialPath.Blob {
+			isDeleted, err := s.deleteBlob(ctx, materialFilename)
+			if err != nil {
+				return err
+			}
+			if !isDeleted {
+				return nil
+			}
+
+			video, err := s.materialRepository.GetByVideoPreview(ctx, materialFilename)
+			if err != nil {
+				return fmt.Errorf("failed to get material by video preview: %w", err)
+			}
+			if video != nil {
+				return nil
+			}
+
+			return s.removeFile(ctx, materialFilename)
 		}
 
 		miniApp, err := s.miniAppRepository.GetByID(ctx, materialPath.MiniAppID)
@@ -276,17 +372,12 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 			return fmt.Errorf("failed to get mini-app: %w", err)
 		}
 		if errors.Is(err, sql.ErrNoRows) {
-			return s.removeFile(uploadFilePath)
+			return s.removeFile(ctx, materialFilename)
 		}
 
 		if filepath.Ext(materialFilename) == ".xlsx" {
-			fullInfo, err := info.Info()
-			if err != nil {
-				return fmt.Errorf("info.Info: %w", err)
-			}
-
-			if MaxExcelAge < time.Since(fullInfo.ModTime()) {
-				return s.removeFile(uploadFilePath)
+			if MaxExcelAge < time.Since(object.ModTime) {
+				return s.removeFile(ctx, materialFilename)
 			}
 
 			return nil
@@ -318,7 +409,31 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 				return nil
 			}
 
-			return s.removeFile(uploadFilePath)
+			badge, err := s.gamificationRepository.GetBadgeByImage(ctx, materialFilename)
+			if err != nil {
+				return fmt.Errorf("failed to get badge by image: %w", err)
+			}
+			if badge != nil {
+				return nil
+			}
+
+			botSettings, err := s.botRepository.GetByWelcomeImage(ctx, materialFilename)
+			if err != nil {
+				return fmt.Errorf("failed to get bot settings by welcome image: %w", err)
+			}
+			if botSettings != nil {
+				return nil
+			}
+
+			broadcast, err := s.broadcastRepository.GetByImage(ctx, materialFilename)
+			if err != nil {
+				return fmt.Errorf("failed to get broadcast by image: %w", err)
+			}
+			if broadcast != nil {
+				return nil
+			}
+
+			return s.removeFile(ctx, materialFilename)
 		}
 
 		product, err := s.productRepository.GetByID(ctx, materialPath.ProductID, false)
@@ -326,7 +441,7 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 			return fmt.Errorf("failed to get product: %w", err)
 		}
 		if errors.Is(err, sql.ErrNoRows) {
-			return s.removeFile(uploadFilePath)
+			return s.removeFile(ctx, materialFilename)
 		}
 
 		if materialPath.LessonID == uuid.Nil {
@@ -340,12 +455,12 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 					return fmt.Errorf("failed to get bonus material: %w", err)
 				}
 				if material == nil {
-					return s.removeFile(uploadFilePath)
+					return s.removeFile(ctx, materialFilename)
 				}
 				return nil
 			}
 
-			return s.removeFile(uploadFilePath)
+			return s.removeFile(ctx, materialFilename)
 		}
 
 		lesson, err := s.lessonRepository.GetByID(ctx, materialPath.LessonID, materialPath.UserID)
@@ -353,7 +468,7 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 			return fmt.Errorf("failed to get lesson: %w", err)
 		}
 		if errors.Is(err, sql.ErrNoRows) {
-			return s.removeFile(uploadFilePath)
+			return s.removeFile(ctx, materialFilename)
 		}
 
 		if materialPath.UserID == uuid.Nil {
@@ -361,8 +476,24 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 			if err != nil {
 				return fmt.Errorf("failed to get material: %w", err)
 			}
-			if material == nil {
-				return s.removeFile(uploadFilePath)
+			if material != nil {
+				return nil
+			}
+
+			video, err := s.materialRepository.GetByVideoPreview(ctx, materialFilename)
+			if err != nil {
+				return fmt.Errorf("failed to get material by video preview: %w", err)
+			}
+			if video != nil {
+				return nil
+			}
+
+			subtitle, err := s.subtitleRepository.GetByFilename(ctx, materialFilename)
+			if err != nil {
+				return fmt.Errorf("failed to get subtitles: %w", err)
+			}
+			if subtitle == nil {
+				return s.removeFile(ctx, materialFilename)
 			}
 
 			return nil
@@ -381,7 +512,7 @@ func (s *Service) ClearDanglingUploads(ctx context.Context) error {
 			}
 		}
 
-		return s.removeFile(uploadFilePath)
+		return s.removeFile(ctx, materialFilename)
 	})
 
 	if err != nil && !errors.Is(err, context.Canceled) {
@@ -406,8 +537,8 @@ func isDirectoryEmpty(path string) (bool, error) {
 	return false, nil
 }
 
-func (s *Service) removeFile(filename string) error {
-	err := os.Remove(filename)
+func (s *Service) removeFile(ctx context.Context, filename string) error {
+	err := s.storage.Delete(ctx, filename)
 	if err != nil {
 		return fmt.Errorf("error deleting file %s: %v", filename, err)
 	}
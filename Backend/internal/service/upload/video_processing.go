@@ -7,7 +7,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 
 	"github.com/google/uuid"
 )
@@ -16,18 +15,23 @@ func (s *Service) ConvertVideoForFastStart(ctx context.Context, filename string)
 	s.toFastStartMu.Lock()
 	defer s.toFastStartMu.Unlock()
 
-	materialFilenameDir := strings.TrimSuffix(filename, filepath.Base(filename))
+	src, release, err := s.fetch(ctx, filename)
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+	defer release()
 
-	tempFilename := filepath.Join(materialFilenameDir, uuid.New().String()+filepath.Ext(filename))
+	tempFilename := filepath.Join(s.tempDir, uuid.New().String()+filepath.Ext(filename))
+	defer os.Remove(tempFilename)
 
 	cmd := exec.CommandContext(ctx,
 		"ffmpeg",
 		"-f", "mp4",
-		"-i", filepath.Join(s.uploadDir, filename),
+		"-i", src,
 		"-c:v", "copy",
 		"-c:a", "copy",
 		"-movflags", "+faststart",
-		filepath.Join(s.uploadDir, tempFilename),
+		tempFilename,
 	)
 
 	var stderr bytes.Buffer
@@ -41,8 +45,7 @@ func (s *Service) ConvertVideoForFastStart(ctx context.Context, filename string)
 		return fmt.Errorf("ffmpeg error: %w: %v", err, stderr.String())
 	}
 
-	err := os.Rename(filepath.Join(s.uploadDir, tempFilename), filepath.Join(s.uploadDir, filename))
-	if err != nil {
+	if _, err := s.store(ctx, filename, tempFilename); err != nil {
 		return fmt.Errorf("failed to replace file: %w", err)
 	}
 
@@ -53,21 +56,26 @@ func (s *Service) CompressVideo(ctx context.Context, filename string) (int64, er
 	s.compressMu.Lock()
 	defer s.compressMu.Unlock()
 
-	materialFilenameDir := strings.TrimSuffix(filename, filepath.Base(filename))
+	src, release, err := s.fetch(ctx, filename)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get file: %w", err)
+	}
+	defer release()
 
-	tempFilename := filepath.Join(materialFilenameDir, uuid.New().String()+".mp4")
+	tempFilename := filepath.Join(s.tempDir, uuid.New().String()+".mp4")
+	defer os.Remove(tempFilename)
 
 	cmd := exec.CommandContext(ctx,
 		"ffmpeg",
 		"-f", "mp4",
-		"-i", filepath.Join(s.uploadDir, filename),
+		"-i", src,
 		"-c:v", "libx264",
 		"-crf", "21",
 		"-maxrate", "6M", "-bufsize", "12M",
 		"-preset", "faster",
 		"-c:a", "aac", "-b:a", "128k",
 		"-movflags", "+faststart",
-		filepath.Join(s.uploadDir, tempFilename),
+		tempFilename,
 	)
 
 	var stderr bytes.Buffer
@@ -83,15 +91,10 @@ func (s *Service) CompressVideo(ctx context.Context, filename string) (int64, er
 		// return fmt.Errorf("ffmpeg error: %w", err)
 	}
 
-	err := os.Rename(filepath.Join(s.uploadDir, tempFilename), filepath.Join(s.uploadDir, filename))
+	size, err := s.store(ctx, filename, tempFilename)
 	if err != nil {
 		return 0, fmt.Errorf("failed to replace file: %w", err)
 	}
 
-	info, err := os.Stat(filepath.Join(s.uploadDir, filename))
-	if err != nil {
-		return 0, fmt.Errorf("failed to get new file info: %w", err)
-	}
-
-	return info.Size(), nil
+	return size, nil
 }
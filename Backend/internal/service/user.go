@@ -70,6 +70,41 @@ func (s *UserService) GetByID(
 	return user, nil
 }
 
+// GetByTelegramID returns user of the mini-app or nil if there is no such one.
+func (s *UserService) GetByTelegramID(
+	ctx context.Context,
+	telegramID int64,
+	miniAppID uuid.UUID,
+) (*model.User, error) {
+
+	user, err := s.userRepository.GetByTelegramID(ctx, telegramID, miniAppID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by telegram id: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetByCalendarToken(ctx context.Context, token uuid.UUID) (*model.User, error) {
+	user, err := s.userRepository.GetByCalendarToken(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by calendar token: %w", err)
+	}
+
+	return user, nil
+}
+
+// CalendarToken returns token of the user's calendar feed, it is generated on
+// the first call.
+func (s *UserService) CalendarToken(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	token, err := s.userRepository.CalendarToken(ctx, userID, uuid.New())
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get calendar token: %w", err)
+	}
+
+	return token, nil
+}
+
 func (s *UserService) SignInWithTelegramMiniApp(
 	ctx context.Context,
 	miniAppID uuid.UUID,
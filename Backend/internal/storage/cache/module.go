@@ -7,7 +7,8 @@ import (
 func Module() fx.Option {
 	return fx.Module("cache",
 		fx.Provide(
-			NewJWTCacheStorage,
+			NewSessionStorage,
+			NewRequestLimiter,
 		),
 	)
 }
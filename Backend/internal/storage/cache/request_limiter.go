@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RequestLimiter counts requests in fixed windows, so the limit could be
+// shared by all instances of the API.
+type RequestLimiter struct {
+	client *redis.Client
+}
+
+func NewRequestLimiter(client *redis.Client) *RequestLimiter {
+	return &RequestLimiter{
+		client: client,
+	}
+}
+
+// Allow counts the request and reports whether number of requests in the
+// current window does not exceed the limit.
+func (l *RequestLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	now := time.Now()
+	windowKey := "rate:" + key + ":" + strconv.FormatInt(now.UnixNano()/int64(window), 10)
+
+	var count *redis.IntCmd
+	_, err := l.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		count = p.Incr(ctx, windowKey)
+		p.Expire(ctx, windowKey, window)
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return count.Val() <= int64(limit), nil
+}
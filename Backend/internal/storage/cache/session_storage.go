@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"academy/internal/config"
+	"academy/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrTokenReused is returned when the refresh token was already rotated,
+	// the session is revoked then since the token could be stolen.
+	ErrTokenReused = errors.New("refresh token reused")
+)
+
+// rotateScript replaces the token hash of the session if the stored one
+// matches. Mismatched session is deleted.
+var rotateScript = redis.NewScript(`
+local hash = redis.call('HGET', KEYS[1], 'token_hash')
+if not hash then
+	return -1
+end
+if hash ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[2])
+	return 0
+end
+redis.call('HSET', KEYS[1], 'token_hash', ARGV[3], 'user_agent', ARGV[4], 'device', ARGV[5], 'ip', ARGV[6], 'last_used_at', ARGV[7])
+redis.call('PEXPIRE', KEYS[1], ARGV[8])
+redis.call('PEXPIRE', KEYS[2], ARGV[8])
+return 1
+`)
+
+// SessionStorage keeps sessions as hashes by session ID, the set of session
+// IDs of the user is used to list and revoke them.
+type SessionStorage struct {
+	client          *redis.Client
+	conf            *config.Config
+	refreshTokenTTL time.Duration
+}
+
+func NewSessionStorage(
+	client *redis.Client,
+	cfg *config.Config,
+) *SessionStorage {
+
+	return &SessionStorage{
+		client:          client,
+		conf:            cfg,
+		refreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+	}
+}
+
+func sessionKey(sessionID uuid.UUID) string {
+	return "session:" + sessionID.String()
+}
+
+func userSessionsKey(userID uuid.UUID) string {
+	return "user_sessions:" + userID.String()
+}
+
+func (s *SessionStorage) Save(ctx context.Context, session *model.Session) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, sessionKey(session.ID), map[string]any{
+			"user_id":      session.UserID.String(),
+			"mini_app_id":  session.MiniAppID.String(),
+			"token_hash":   session.TokenHash,
+			"device":       session.Device,
+			"user_agent":   session.UserAgent,
+			"ip":           session.IP,
+			"created_at":   session.CreatedAt.Format(time.RFC3339),
+			"last_used_at": session.LastUsedAt.Format(time.RFC3339),
+		})
+		p.Expire(ctx, sessionKey(session.ID), s.refreshTokenTTL)
+		p.SAdd(ctx, userSessionsKey(session.UserID), session.ID.String())
+		p.Expire(ctx, userSessionsKey(session.UserID), s.refreshTokenTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+// Rotate replaces the refresh token of the session, prevHash is a hash of the
+// token being refreshed.
+func (s *SessionStorage) Rotate(ctx context.Context, session *model.Session, prevHash string) error {
+	result, err := rotateScript.Run(ctx, s.client,
+		[]string{sessionKey(session.ID), userSessionsKey(session.UserID)},
+		prevHash,
+		session.ID.String(),
+		session.TokenHash,
+		session.UserAgent,
+		session.Device,
+		session.IP,
+		session.LastUsedAt.Format(time.RFC3339),
+		s.refreshTokenTTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to rotate session token: %w", err)
+	}
+
+	switch result {
+	case -1:
+		return ErrSessionNotFound
+	case 0:
+		return ErrTokenReused
+	}
+
+	return nil
+}
+
+func (s *SessionStorage) Get(ctx context.Context, sessionID uuid.UUID) (*model.Session, error) {
+	values, err := s.client.HGetAll(ctx, sessionKey(sessionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if len(values) == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	return parseSession(sessionID, values)
+}
+
+// ListByUser returns sessions of the user, expired ones are removed from the
+// set of the user.
+func (s *SessionStorage) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	ids, err := s.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]*model.Session, 0, len(ids))
+	expired := make([]any, 0)
+
+	for _, id := range ids {
+		sessionID, err := uuid.Parse(id)
+		if err != nil {
+			expired = append(expired, id)
+			continue
+		}
+
+		session, err := s.Get(ctx, sessionID)
+		if errors.Is(err, ErrSessionNotFound) {
+			expired = append(expired, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if len(expired) != 0 {
+		if err := s.client.SRem(ctx, userSessionsKey(userID), expired...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to remove expired sessions: %w", err)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionStorage) Delete(ctx context.Context, userID uuid.UUID, sessionIDs ...uuid.UUID) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, id := range sessionIDs {
+			p.Del(ctx, sessionKey(id))
+			p.SRem(ctx, userSessionsKey(userID), id.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return nil
+}
+
+func parseSession(sessionID uuid.UUID, values map[string]string) (*model.Session, error) {
+	userID, err := uuid.Parse(values["user_id"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid session user_id: %w", err)
+	}
+
+	miniAppID, err := uuid.Parse(values["mini_app_id"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid session mini_app_id: %w", err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, values["created_at"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid session created_at: %w", err)
+	}
+
+	lastUsedAt, err := time.Parse(time.RFC3339, values["last_used_at"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid session last_used_at: %w", err)
+	}
+
+	return &model.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		MiniAppID:  miniAppID,
+		TokenHash:  values["token_hash"],
+		Device:     values["device"],
+		UserAgent:  values["user_agent"],
+		IP:         values["ip"],
+		CreatedAt:  createdAt,
+		LastUsedAt: lastUsedAt,
+	}, nil
+}
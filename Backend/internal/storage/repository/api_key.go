@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository struct {
+	repository.Generic[model.APIKey, uuid.UUID]
+}
+
+func NewAPIKeyRepository(
+	genericRepository repository.Generic[model.APIKey, uuid.UUID],
+) *APIKeyRepository {
+	return &APIKeyRepository{
+		Generic: genericRepository,
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	_, err := r.DB.NewInsert().Model(key).Exec(ctx)
+
+	return err
+}
+
+// GetByHash returns the key or nil if there is no such key.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	key := new(model.APIKey)
+
+	err := r.DB.NewSelect().
+		Model(key).
+		Where(`api_key.key_hash = ?`, hash).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) MiniAppKeys(ctx context.Context, miniAppID uuid.UUID) ([]*model.APIKey, error) {
+	keys := make([]*model.APIKey, 0)
+
+	err := r.DB.NewSelect().
+		Model(&keys).
+		Where(`api_key.mini_app_id = ?`, miniAppID).
+		Order(`api_key.created_at DESC`).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Revoke revokes the key of the mini-app, false is returned if there is no
+// such active key.
+func (r *APIKeyRepository) Revoke(ctx context.Context, miniAppID, id uuid.UUID) (bool, error) {
+	now := time.Now().UTC()
+
+	res, err := r.DB.NewUpdate().
+		Model((*model.APIKey)(nil)).
+		Set(`revoked_at = ?`, now).
+		Set(`updated_at = ?`, now).
+		Where(`mini_app_id = ?`, miniAppID).
+		Where(`id = ?`, id).
+		Where(`revoked_at IS NULL`).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
+// TouchLastUsed sets last used time of the key. It is updated not more often
+// than the interval, so frequent requests do not write on each call.
+func (r *APIKeyRepository) TouchLastUsed(
+	ctx context.Context,
+	id uuid.UUID,
+	now time.Time,
+	interval time.Duration,
+) error {
+
+	_, err := r.DB.NewUpdate().
+		Model((*model.APIKey)(nil)).
+		Set(`last_used_at = ?`, now).
+		Where(`id = ?`, id).
+		Where(`last_used_at IS NULL OR last_used_at < ?`, now.Add(-interval)).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// auditEntityTables are tables of entities that snapshots are taken from.
+var auditEntityTables = map[string]string{
+	model.AuditEntityMiniApp:      "mini_apps",
+	model.AuditEntityUser:         "users",
+	model.AuditEntityModInvite:    "mod_invites",
+	model.AuditEntityModRole:      "mod_roles",
+	model.AuditEntityProduct:      "products",
+	model.AuditEntityProductLevel: "product_levels",
+	model.AuditEntityLesson:       "lessons",
+	model.AuditEntityMaterial:     "materials",
+	model.AuditEntityCohort:       "cohorts",
+	model.AuditEntityBadge:        "badges",
+	model.AuditEntityBroadcast:    "broadcasts",
+	model.AuditEntitySupport:      "support_tickets",
+	model.AuditEntityAPIKey:       "api_keys",
+}
+
+type AuditRepository struct {
+	repository.Generic[model.AuditLog, uuid.UUID]
+}
+
+func NewAuditRepository(
+	genericRepository repository.Generic[model.AuditLog, uuid.UUID],
+) *AuditRepository {
+	return &AuditRepository{
+		Generic: genericRepository,
+	}
+}
+
+// Snapshot returns columns of the entity row or nil if there is no such row
+// or the entity has no table.
+func (r *AuditRepository) Snapshot(ctx context.Context, entityType string, id uuid.UUID) (map[string]any, error) {
+	table, ok := auditEntityTables[entityType]
+	if !ok || id == uuid.Nil {
+		return nil, nil
+	}
+
+	var raw json.RawMessage
+	err := r.DB.NewRaw(`SELECT to_jsonb(t) FROM ? AS t WHERE t.id = ?`, bun.Ident(table), id).
+		Scan(ctx, &raw)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]any)
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func (r *AuditRepository) Find(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterAuditLogRequest,
+) ([]*model.AuditLog, int, error) {
+
+	logs := make([]*model.AuditLog, 0)
+
+	applyFilter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where(`audit_log.mini_app_id = ?`, miniAppID)
+
+		if filter.ActorID != uuid.Nil {
+			q = q.Where(`audit_log.actor_id = ?`, filter.ActorID)
+		}
+		if filter.Action != "" {
+			q = q.Where(`audit_log.action = ?`, filter.Action)
+		}
+		if filter.EntityType != "" {
+			q = q.Where(`audit_log.entity_type = ?`, filter.EntityType)
+		}
+		if filter.EntityID != uuid.Nil {
+			q = q.Where(`audit_log.entity_id = ?`, filter.EntityID)
+		}
+		if !filter.From.IsZero() {
+			q = q.Where(`audit_log.created_at >= ?`, filter.From)
+		}
+		if !filter.To.IsZero() {
+			q = q.Where(`audit_log.created_at < ?`, filter.To)
+		}
+
+		return q
+	}
+
+	total, err := applyFilter(r.DB.NewSelect().Model(&logs)).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.AuditLog{}, total, nil
+	}
+
+	query := applyFilter(r.DB.NewSelect().Model(&logs)).
+		Relation("Actor").
+		Order(`audit_log.created_at DESC`).
+		Limit(int(filter.Limit))
+
+	if filter.Offset != 0 {
+		query = query.Offset(int(filter.Offset))
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, total, err
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BlobRepository struct {
+	repository.Generic[model.Blob, uuid.UUID]
+}
+
+func (r *BlobRepository) WithTx(tx bun.Tx) *BlobRepository {
+	return &BlobRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewBlobRepository(
+	genericRepository repository.Generic[model.Blob, uuid.UUID],
+) *BlobRepository {
+	return &BlobRepository{
+		Generic: genericRepository,
+	}
+}
+
+// Create saves the blob, the blob saved first is kept if the file is
+// uploaded concurrently.
+func (r *BlobRepository) Create(ctx context.Context, blob *model.Blob) error {
+	_, err := r.DB.NewInsert().
+		Model(blob).
+		On(`CONFLICT (filename) DO NOTHING`).
+		Exec(ctx)
+
+	return err
+}
+
+// GetByFilename returns the blob of the file or nil if there is no such blob.
+func (r *BlobRepository) GetByFilename(ctx context.Context, filename string) (*model.Blob, error) {
+	blob := new(model.Blob)
+
+	err := r.DB.NewSelect().
+		Model(blob).
+		Where(`blob.filename = ?`, filename).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return blob, nil
+}
+
+// Lock locks the blob until the end of the transaction. False is returned if
+// there is no such blob.
+func (r *BlobRepository) Lock(ctx context.Context, filename string) (bool, error) {
+	var id uuid.UUID
+
+	err := r.DB.NewSelect().
+		Model((*model.Blob)(nil)).
+		Column("id").
+		Where(`blob.filename = ?`, filename).
+		For("UPDATE").
+		Scan(ctx, &id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteUnreferenced deletes the blob if no material references it. False is
+// returned if the blob is still referenced. The blob should be locked by the
+// transaction, otherwise the material that references it could be saved
+// concurrently.
+func (r *BlobRepository) DeleteUnreferenced(ctx context.Context, filename string) (bool, error) {
+	res, err := r.DB.NewDelete().
+		Model((*model.Blob)(nil)).
+		Where(`filename = ?`, filename).
+		Where(`NOT EXISTS (SELECT 1 FROM materials AS m WHERE m.filename = ?)`, filename).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
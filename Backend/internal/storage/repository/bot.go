@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BotRepository struct {
+	repository.Generic[model.BotSettings, uuid.UUID]
+}
+
+func (r *BotRepository) WithTx(tx bun.Tx) *BotRepository {
+	return &BotRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewBotRepository(
+	genericRepository repository.Generic[model.BotSettings, uuid.UUID],
+) *BotRepository {
+	return &BotRepository{
+		Generic: genericRepository,
+	}
+}
+
+// Settings returns bot settings of the mini-app or nil if they are not saved
+// yet.
+func (r *BotRepository) Settings(ctx context.Context, miniAppID uuid.UUID) (*model.BotSettings, error) {
+	settings := new(model.BotSettings)
+
+	err := r.DB.NewSelect().
+		Model(settings).
+		Where(`mini_app_id = ?`, miniAppID).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *BotRepository) SaveSettings(ctx context.Context, settings *model.BotSettings) error {
+	_, err := r.DB.NewInsert().
+		Model(settings).
+		On(`CONFLICT (mini_app_id) DO UPDATE`).
+		Set(`welcome_message = EXCLUDED.welcome_message`).
+		Set(`welcome_image = EXCLUDED.welcome_image`).
+		Set(`welcome_image_size = EXCLUDED.welcome_image_size`).
+		Set(`buttons = EXCLUDED.buttons`).
+		Set(`updated_at = EXCLUDED.updated_at`).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *BotRepository) GetByWelcomeImage(ctx context.Context, image string) (*model.BotSettings, error) {
+	settings := new(model.BotSettings)
+
+	err := r.DB.NewSelect().
+		Model(settings).
+		Where(`welcome_image = ?`, image).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// ActiveBots returns mini-apps which bots should be running.
+func (r *BotRepository) ActiveBots(ctx context.Context) ([]*model.ActiveBot, error) {
+	bots := make([]*model.ActiveBot, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`id, name, url, bot_token`).
+		TableExpr(`mini_apps`).
+		Where(`is_active`).
+		Where(`deleted_at IS NULL`).
+		Where(`bot_token <> ''`).
+		Scan(ctx, &bots)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bots, nil
+}
@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BroadcastRepository struct {
+	repository.Generic[model.Broadcast, uuid.UUID]
+}
+
+func (r *BroadcastRepository) WithTx(tx bun.Tx) *BroadcastRepository {
+	return &BroadcastRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewBroadcastRepository(
+	genericRepository repository.Generic[model.Broadcast, uuid.UUID],
+) *BroadcastRepository {
+	return &BroadcastRepository{
+		Generic: genericRepository,
+	}
+}
+
+func (r *BroadcastRepository) Find(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterBroadcastsRequest,
+) ([]*model.Broadcast, int, error) {
+
+	broadcasts := make([]*model.Broadcast, 0)
+
+	applyFilter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where(`mini_app_id = ?`, miniAppID)
+
+		if len(filter.Status) != 0 {
+			q = q.Where(`status IN (?)`, bun.In(filter.Status))
+		}
+
+		return q
+	}
+
+	total, err := applyFilter(r.DB.NewSelect().Model(&broadcasts)).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.Broadcast{}, total, nil
+	}
+
+	query := applyFilter(r.DB.NewSelect().Model(&broadcasts)).
+		Order(`created_at DESC`).
+		Limit(int(filter.Limit))
+
+	if filter.Offset != 0 {
+		query = query.Offset(int(filter.Offset))
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, total, err
+	}
+
+	return broadcasts, total, nil
+}
+
+// Stats returns number of recipients by delivery status for each broadcast.
+func (r *BroadcastRepository) Stats(
+	ctx context.Context,
+	broadcastIDs []uuid.UUID,
+) (map[uuid.UUID]*model.BroadcastStats, error) {
+
+	stats := make([]struct {
+		BroadcastID uuid.UUID `bun:"broadcast_id"`
+		model.BroadcastStats
+	}, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`broadcast_id`).
+		ColumnExpr(`COUNT(*) AS total`).
+		ColumnExpr(`COUNT(*) FILTER (WHERE status = ?) AS pending`, model.BroadcastRecipientStatusPending).
+		ColumnExpr(`COUNT(*) FILTER (WHERE status = ?) AS sent`, model.BroadcastRecipientStatusSent).
+		ColumnExpr(`COUNT(*) FILTER (WHERE status = ?) AS failed`, model.BroadcastRecipientStatusFailed).
+		ColumnExpr(`COUNT(*) FILTER (WHERE status = ?) AS blocked`, model.BroadcastRecipientStatusBlocked).
+		TableExpr(`broadcast_recipients`).
+		Where(`broadcast_id IN (?)`, bun.In(broadcastIDs)).
+		GroupExpr(`broadcast_id`).
+		Scan(ctx, &stats)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID]*model.BroadcastStats, len(stats))
+	for _, s := range stats {
+		result[s.BroadcastID] = &s.BroadcastStats
+	}
+
+	return result, nil
+}
+
+// segmentQuery selects students of the mini-app that belong to the segment.
+func (r *BroadcastRepository) segmentQuery(
+	miniAppID uuid.UUID,
+	segment *model.BroadcastSegment,
+) *bun.SelectQuery {
+
+	query := r.DB.NewSelect().
+		TableExpr(`users AS u`).
+		Where(`u.mini_app_id = ?`, miniAppID).
+		Where(`u.role = ?`, model.UserRoleStudent).
+		Where(`u.is_active = TRUE`)
+
+	if segment.ProductID == uuid.Nil {
+		return query
+	}
+
+	query = query.Where(`EXISTS (
+		SELECT 1 FROM product_access AS pa
+		WHERE pa.user_id = u.id AND pa.product_id = ? AND pa.deleted_at IS NULL
+	)`, segment.ProductID)
+
+	if len(segment.ProductLevelIDs) != 0 {
+		query = query.Where(`EXISTS (
+			SELECT 1 FROM payments
+			WHERE payments.user_id = u.id
+				AND payments.product_level_id IN (?)
+				AND payments.status = ?
+				AND (
+					payments.access_duration IS NULL
+					OR CURRENT_TIMESTAMP < payments.access_start + payments.access_duration
+				)
+		)`, bun.In(segment.ProductLevelIDs), model.PaymentStatusCompleted)
+	}
+
+	if len(segment.CohortIDs) != 0 {
+		query = query.Where(`EXISTS (
+			SELECT 1 FROM cohort_students AS cs
+			WHERE cs.user_id = u.id AND cs.cohort_id IN (?)
+		)`, bun.In(segment.CohortIDs))
+	}
+
+	// Progress is compared multiplied by the number of lessons, so products
+	// without lessons do not need special handling.
+	const progressExpr = `(
+		SELECT COALESCE(SUM(
+			CASE
+				WHEN lp.status = 'accepted' THEN 100
+				WHEN lp.status IN ('pending', 'quarantined') THEN 50
+				ELSE 0
+			END
+		), 0)
+		FROM lesson_progress AS lp
+		JOIN lessons AS l ON l.id = lp.lesson_id
+		WHERE lp.user_id = u.id AND l.product_id = ?
+	)`
+	const lessonsExpr = `(SELECT COUNT(*) FROM lessons WHERE product_id = ?)`
+
+	if segment.MinProgress != nil {
+		query = query.Where(progressExpr+` >= ? * `+lessonsExpr,
+			segment.ProductID, *segment.MinProgress, segment.ProductID)
+	}
+	if segment.MaxProgress != nil {
+		query = query.Where(progressExpr+` < ? * `+lessonsExpr,
+			segment.ProductID, *segment.MaxProgress, segment.ProductID)
+	}
+
+	return query
+}
+
+// CountSegment returns number of students in the segment.
+func (r *BroadcastRepository) CountSegment(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	segment *model.BroadcastSegment,
+) (int, error) {
+
+	var count int
+
+	err := r.segmentQuery(miniAppID, segment).
+		ColumnExpr(`COUNT(*)`).
+		Scan(ctx, &count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CreateRecipients adds students of the broadcast segment to recipients.
+func (r *BroadcastRepository) CreateRecipients(ctx context.Context, broadcast *model.Broadcast) error {
+	query := r.segmentQuery(broadcast.MiniAppID, broadcast.Segment).
+		ColumnExpr(`?, u.id`, broadcast.ID)
+
+	_, err := r.DB.NewRaw(`
+	INSERT INTO broadcast_recipients ("broadcast_id", "user_id")
+	?
+	ON CONFLICT DO NOTHING
+	`, query).Exec(ctx)
+
+	return err
+}
+
+// UpdateStatus updates status of the broadcast if its current status is one
+// of given. It reports whether the broadcast is updated.
+func (r *BroadcastRepository) UpdateStatus(
+	ctx context.Context,
+	broadcast *model.Broadcast,
+	from ...model.BroadcastStatus,
+) (bool, error) {
+
+	res, err := r.DB.NewUpdate().
+		Model(broadcast).
+		Column("status", "started_at", "completed_at", "updated_at").
+		WherePK().
+		Where(`status IN (?)`, bun.In(from)).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
+// Due returns scheduled broadcasts which sending time has come.
+func (r *BroadcastRepository) Due(ctx context.Context, now time.Time, limit int) ([]*model.Broadcast, error) {
+	broadcasts := make([]*model.Broadcast, 0)
+
+	err := r.DB.NewSelect().
+		Model(&broadcasts).
+		Where(`status = ?`, model.BroadcastStatusScheduled).
+		Where(`scheduled_at <= ?`, now).
+		Order(`scheduled_at`).
+		Limit(limit).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return broadcasts, nil
+}
+
+func (r *BroadcastRepository) Sending(ctx context.Context) ([]*model.Broadcast, error) {
+	broadcasts := make([]*model.Broadcast, 0)
+
+	err := r.DB.NewSelect().
+		Model(&broadcasts).
+		Where(`status = ?`, model.BroadcastStatusSending).
+		Order(`started_at`).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return broadcasts, nil
+}
+
+func (r *BroadcastRepository) PendingRecipients(
+	ctx context.Context,
+	broadcastID uuid.UUID,
+	limit int,
+) ([]*model.PendingBroadcastRecipient, error) {
+
+	recipients := make([]*model.PendingBroadcastRecipient, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`br.user_id, u.telegram_id`).
+		TableExpr(`broadcast_recipients AS br`).
+		Join(`JOIN users AS u ON u.id = br.user_id`).
+		Where(`br.broadcast_id = ?`, broadcastID).
+		Where(`br.status = ?`, model.BroadcastRecipientStatusPending).
+		OrderExpr(`br.user_id`).
+		Limit(limit).
+		Scan(ctx, &recipients)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+func (r *BroadcastRepository) UpdateRecipient(ctx context.Context, recipient *model.BroadcastRecipient) error {
+	_, err := r.DB.NewUpdate().
+		Model(recipient).
+		Column("status", "error", "sent_at").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+// FailPendingRecipients marks all recipients that the broadcast is not sent
+// to yet as failed.
+func (r *BroadcastRepository) FailPendingRecipients(ctx context.Context, broadcastID uuid.UUID, reason string) error {
+	_, err := r.DB.NewUpdate().
+		Model((*model.BroadcastRecipient)(nil)).
+		Set(`status = ?`, model.BroadcastRecipientStatusFailed).
+		Set(`error = ?`, reason).
+		Where(`broadcast_id = ?`, broadcastID).
+		Where(`status = ?`, model.BroadcastRecipientStatusPending).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *BroadcastRepository) Recipients(
+	ctx context.Context,
+	broadcastID uuid.UUID,
+	filter *model.FilterBroadcastRecipientsRequest,
+) ([]*model.BroadcastRecipient, int, error) {
+
+	recipients := make([]*model.BroadcastRecipient, 0)
+
+	applyFilter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where(`broadcast_recipient.broadcast_id = ?`, broadcastID)
+
+		if len(filter.Status) != 0 {
+			q = q.Where(`broadcast_recipient.status IN (?)`, bun.In(filter.Status))
+		}
+
+		return q
+	}
+
+	total, err := applyFilter(r.DB.NewSelect().Model(&recipients)).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.BroadcastRecipient{}, total, nil
+	}
+
+	query := applyFilter(r.DB.NewSelect().Model(&recipients)).
+		Relation("User").
+		Order(`broadcast_recipient.user_id`).
+		Limit(int(filter.Limit))
+
+	if filter.Offset != 0 {
+		query = query.Offset(int(filter.Offset))
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, total, err
+	}
+
+	return recipients, total, nil
+}
+
+func (r *BroadcastRepository) GetByImage(ctx context.Context, image string) (*model.Broadcast, error) {
+	broadcast := new(model.Broadcast)
+
+	err := r.DB.NewSelect().
+		Model(broadcast).
+		Where(`image = ?`, image).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return broadcast, nil
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type CohortRepository struct {
+	repository.Generic[model.Cohort, uuid.UUID]
+}
+
+func (r *CohortRepository) WithTx(tx bun.Tx) *CohortRepository {
+	return &CohortRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewCohortRepository(
+	genericRepository repository.Generic[model.Cohort, uuid.UUID],
+) *CohortRepository {
+	return &CohortRepository{
+		Generic: genericRepository,
+	}
+}
+
+// cohortsQuery selects cohorts with number of assigned students.
+func (r *CohortRepository) cohortsQuery(cohorts any) *bun.SelectQuery {
+	return r.DB.NewSelect().
+		Model(cohorts).
+		ColumnExpr(`cohort.*`).
+		ColumnExpr(`(
+			SELECT COUNT(*) FROM cohort_students AS cs WHERE cs.cohort_id = cohort.id
+		) AS total_students`)
+}
+
+func (r *CohortRepository) Create(ctx context.Context, cohort *model.Cohort) error {
+	_, err := r.DB.NewInsert().Model(cohort).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.setModerators(ctx, cohort)
+}
+
+func (r *CohortRepository) Update(ctx context.Context, cohort *model.Cohort) error {
+	_, err := r.DB.NewUpdate().
+		Model(cohort).
+		ExcludeColumn("invite_id", "created_at").
+		WherePK().
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return r.setModerators(ctx, cohort)
+}
+
+func (r *CohortRepository) setModerators(ctx context.Context, cohort *model.Cohort) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.CohortModerator)(nil)).
+		Where(`cohort_id = ?`, cohort.ID).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(cohort.Moderators) == 0 {
+		return nil
+	}
+
+	_, err = r.DB.NewInsert().
+		Model(&cohort.Moderators).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *CohortRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Cohort, error) {
+	cohort := new(model.Cohort)
+
+	err := r.cohortsQuery(cohort).
+		Relation("Moderators").
+		Relation("Moderators.User").
+		Where(`cohort.id = ?`, id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cohort, nil
+}
+
+func (r *CohortRepository) GetByInviteID(
+	ctx context.Context,
+	miniAppID, inviteID uuid.UUID,
+) (*model.Cohort, error) {
+
+	cohort := new(model.Cohort)
+
+	err := r.cohortsQuery(cohort).
+		Where(`cohort.invite_id = ?`, inviteID).
+		Where(`cohort.product_id IN (SELECT id FROM products WHERE mini_app_id = ?)`, miniAppID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cohort, nil
+}
+
+func (r *CohortRepository) ProductCohorts(ctx context.Context, productID uuid.UUID) ([]*model.Cohort, error) {
+	cohorts := make([]*model.Cohort, 0)
+
+	err := r.cohortsQuery(&cohorts).
+		Relation("Moderators").
+		Relation("Moderators.User").
+		Where(`cohort.product_id = ?`, productID).
+		Order("cohort.start_date", "cohort.created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cohorts, nil
+}
+
+func (r *CohortRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.Cohort)(nil)).
+		Where(`id = ?`, id).
+		Exec(ctx)
+
+	return err
+}
+
+// StudentCohort returns cohort of the product that student is assigned to or
+// nil if there is no such one.
+func (r *CohortRepository) StudentCohort(
+	ctx context.Context,
+	productID, userID uuid.UUID,
+) (*model.Cohort, error) {
+
+	cohort := new(model.Cohort)
+
+	err := r.cohortsQuery(cohort).
+		Join(`JOIN cohort_students AS cs ON cs.cohort_id = cohort.id`).
+		Where(`cs.product_id = ?`, productID).
+		Where(`cs.user_id = ?`, userID).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cohort, nil
+}
+
+// UpcomingCohort returns the nearest not started cohort of the product that
+// has free seats or nil if there is no such one.
+func (r *CohortRepository) UpcomingCohort(
+	ctx context.Context,
+	productID uuid.UUID,
+	now time.Time,
+) (*model.Cohort, error) {
+
+	cohort := new(model.Cohort)
+
+	err := r.cohortsQuery(cohort).
+		Where(`cohort.product_id = ?`, productID).
+		Where(`cohort.start_date > ?`, now).
+		Where(`cohort.seat_limit = 0 OR cohort.seat_limit > (
+			SELECT COUNT(*) FROM cohort_students AS cs WHERE cs.cohort_id = cohort.id
+		)`).
+		Order("cohort.start_date").
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cohort, nil
+}
+
+// LockSeats locks the cohort until the end of the transaction and returns
+// number of its students. Students are counted after the lock is taken, so
+// concurrent assignments see each other.
+func (r *CohortRepository) LockSeats(ctx context.Context, cohortID uuid.UUID) (int64, error) {
+	_, err := r.DB.NewSelect().
+		Model((*model.Cohort)(nil)).
+		Column("id").
+		Where(`cohort.id = ?`, cohortID).
+		For("UPDATE").
+		Exec(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.DB.NewSelect().
+		TableExpr(`cohort_students`).
+		Where(`cohort_id = ?`, cohortID).
+		Count(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(count), nil
+}
+
+// AssignStudents adds students to the cohort. Students that are already
+// assigned to another cohort of the product are moved.
+func (r *CohortRepository) AssignStudents(ctx context.Context, students []*model.CohortStudent) error {
+	if len(students) == 0 {
+		return nil
+	}
+
+	_, err := r.DB.NewInsert().
+		Model(&students).
+		On(`CONFLICT (product_id, user_id) DO UPDATE`).
+		Set(`cohort_id = EXCLUDED.cohort_id`).
+		Exec(ctx)
+
+	return err
+}
+
+// CohortStudentIDs returns ids of the given users that are already assigned to
+// the cohort.
+func (r *CohortRepository) CohortStudentIDs(
+	ctx context.Context,
+	cohortID uuid.UUID,
+	ids []uuid.UUID,
+) ([]uuid.UUID, error) {
+
+	studentIDs := make([]uuid.UUID, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`user_id`).
+		TableExpr(`cohort_students`).
+		Where(`cohort_id = ?`, cohortID).
+		Where(`user_id IN (?)`, bun.In(ids)).
+		Scan(ctx, &studentIDs)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return studentIDs, nil
+}
+
+// ModeratorCohortIDs returns product cohorts assigned to the moderator.
+func (r *CohortRepository) ModeratorCohortIDs(
+	ctx context.Context,
+	productID, userID uuid.UUID,
+) ([]uuid.UUID, error) {
+
+	ids := make([]uuid.UUID, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`cm.cohort_id`).
+		TableExpr(`cohort_moderators AS cm`).
+		Join(`JOIN cohorts AS c ON c.id = cm.cohort_id`).
+		Where(`c.product_id = ?`, productID).
+		Where(`cm.user_id = ?`, userID).
+		Scan(ctx, &ids)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// CountModerators returns number of mini-app moderators with given ids.
+func (r *CohortRepository) CountModerators(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	ids []uuid.UUID,
+) (int, error) {
+
+	var count int
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`COUNT(DISTINCT user_id)`).
+		TableExpr(`mod_invites`).
+		Where(`mini_app_id = ?`, miniAppID).
+		Where(`user_id IN (?)`, bun.In(ids)).
+		Scan(ctx, &count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountStudents returns number of mini-app students with given ids.
+func (r *CohortRepository) CountStudents(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	ids []uuid.UUID,
+) (int, error) {
+
+	return r.DB.NewSelect().
+		Model((*model.User)(nil)).
+		Where(`mini_app_id = ?`, miniAppID).
+		Where(`role = ?`, model.UserRoleStudent).
+		Where(`id IN (?)`, bun.In(ids)).
+		Count(ctx)
+}
@@ -0,0 +1,369 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type GamificationRepository struct {
+	repository.Generic[model.PointTransaction, uuid.UUID]
+}
+
+func (r *GamificationRepository) WithTx(tx bun.Tx) *GamificationRepository {
+	return &GamificationRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewGamificationRepository(
+	genericRepository repository.Generic[model.PointTransaction, uuid.UUID],
+) *GamificationRepository {
+	return &GamificationRepository{
+		Generic: genericRepository,
+	}
+}
+
+func (r *GamificationRepository) GetPointRules(
+	ctx context.Context, miniAppID uuid.UUID,
+) ([]*model.PointRule, error) {
+
+	rules := make([]*model.PointRule, 0)
+
+	err := r.DB.NewSelect().
+		Model(&rules).
+		Where(`mini_app_id = ?`, miniAppID).
+		Order("event", "threshold").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *GamificationRepository) DeletePointRules(ctx context.Context, miniAppID uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.PointRule)(nil)).
+		Where(`mini_app_id = ?`, miniAppID).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *GamificationRepository) CreatePointRules(ctx context.Context, rules []*model.PointRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	_, err := r.DB.NewInsert().
+		Model(&rules).
+		Exec(ctx)
+
+	return err
+}
+
+// CreatePointTransactions skips transactions that was already applied and
+// returns number of newly created ones.
+func (r *GamificationRepository) CreatePointTransactions(
+	ctx context.Context, transactions []*model.PointTransaction,
+) (int64, error) {
+
+	if len(transactions) == 0 {
+		return 0, nil
+	}
+
+	res, err := r.DB.NewInsert().
+		Model(&transactions).
+		On(`CONFLICT ("user_id", "idempotency_key") DO NOTHING`).
+		Exec(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (r *GamificationRepository) ActivityDays(
+	ctx context.Context, userID uuid.UUID, limit int,
+) ([]time.Time, error) {
+
+	days := make([]time.Time, 0)
+
+	err := r.DB.NewRaw(`
+	SELECT DISTINCT (created_at AT TIME ZONE 'UTC')::DATE AS day
+	FROM point_transactions
+	WHERE user_id = ? AND event = ?
+	ORDER BY day DESC
+	LIMIT ?
+	`, userID, model.PointEventLessonAccepted, limit).
+		Scan(ctx, &days)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// AwardBadges gives the user all badges of the mini-app whose required points
+// are reached. Product badges take into account only product points.
+func (r *GamificationRepository) AwardBadges(ctx context.Context, miniAppID, userID uuid.UUID) error {
+	_, err := r.DB.NewRaw(`
+	INSERT INTO user_badges ("user_id", "badge_id")
+	SELECT ?, b.id FROM badges AS b
+	WHERE b.mini_app_id = ? AND b.required_points <= (
+		SELECT COALESCE(SUM(pt.points), 0) FROM point_transactions AS pt
+		WHERE pt.user_id = ? AND pt.mini_app_id = b.mini_app_id
+		AND (b.product_id IS NULL OR pt.product_id = b.product_id)
+	)
+	ON CONFLICT DO NOTHING
+	`, userID, miniAppID, userID).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *GamificationRepository) TotalPoints(
+	ctx context.Context, userID, productID uuid.UUID,
+) (int64, error) {
+
+	var total int64
+
+	query := r.DB.NewSelect().
+		ColumnExpr(`COALESCE(SUM(points), 0)`).
+		TableExpr(`point_transactions`).
+		Where(`user_id = ?`, userID)
+
+	if productID != uuid.Nil {
+		query = query.Where(`product_id = ?`, productID)
+	}
+
+	if err := query.Scan(ctx, &total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *GamificationRepository) FindPointTransactions(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterPointTransactionsRequest,
+) ([]*model.PointTransaction, int, error) {
+
+	transactions := make([]*model.PointTransaction, 0)
+
+	applyFilter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where(`point_transaction.mini_app_id = ?`, miniAppID)
+
+		if len(filter.UserID) != 0 {
+			q = q.Where(`point_transaction.user_id IN (?)`, bun.In(filter.UserID))
+		}
+		if len(filter.ProductID) != 0 {
+			q = q.Where(`point_transaction.product_id IN (?)`, bun.In(filter.ProductID))
+		}
+		if len(filter.Event) != 0 {
+			q = q.Where(`point_transaction.event IN (?)`, bun.In(filter.Event))
+		}
+
+		return q
+	}
+
+	total, err := applyFilter(r.DB.NewSelect().Model(&transactions)).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.PointTransaction{}, total, nil
+	}
+
+	query := applyFilter(r.DB.NewSelect().Model(&transactions)).
+		Relation("User").
+		Order(`point_transaction.created_at DESC`).
+		Limit(int(filter.Limit))
+
+	if filter.Offset != 0 {
+		query = query.Offset(int(filter.Offset))
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, total, err
+	}
+
+	return transactions, total, nil
+}
+
+// leaderboardQuery ranks active product students by product points. Students
+// that opted out are excluded.
+const leaderboardQuery = `
+	WITH scores AS (
+		SELECT
+			u.id AS user_id,
+			u.telegram_username,
+			u.first_name,
+			u.avatar,
+			COALESCE(SUM(pt.points), 0) AS points
+		FROM product_access AS pa
+		JOIN users AS u ON u.id = pa.user_id AND u.role = 'student' AND NOT u.hide_from_leaderboard
+		LEFT JOIN point_transactions AS pt ON pt.user_id = u.id AND pt.product_id = pa.product_id
+		WHERE pa.product_id = ? AND pa.deleted_at IS NULL
+		GROUP BY u.id, u.telegram_username, u.first_name, u.avatar
+	), ranks AS (
+		SELECT RANK() OVER (ORDER BY points DESC) AS rank, * FROM scores
+	)
+`
+
+func (r *GamificationRepository) Leaderboard(
+	ctx context.Context,
+	productID uuid.UUID,
+	limit, offset uint,
+) ([]*model.LeaderboardEntry, int, error) {
+
+	var total int
+	err := r.DB.NewRaw(leaderboardQuery+`SELECT COUNT(*) FROM ranks`, productID).
+		Scan(ctx, &total)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]*model.LeaderboardEntry, 0)
+	if total == 0 {
+		return entries, 0, nil
+	}
+
+	err = r.DB.NewRaw(leaderboardQuery+`
+	SELECT * FROM ranks
+	ORDER BY rank, user_id
+	LIMIT ? OFFSET ?
+	`, productID, limit, offset).
+		Scan(ctx, &entries)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	return entries, total, nil
+}
+
+func (r *GamificationRepository) LeaderboardPosition(
+	ctx context.Context,
+	productID, userID uuid.UUID,
+) (*model.LeaderboardEntry, error) {
+
+	entry := new(model.LeaderboardEntry)
+
+	err := r.DB.NewRaw(leaderboardQuery+`
+	SELECT * FROM ranks WHERE user_id = ?
+	`, productID, userID).
+		Scan(ctx, entry)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (r *GamificationRepository) CreateBadge(ctx context.Context, badge *model.Badge) error {
+	_, err := r.DB.NewInsert().Model(badge).Exec(ctx)
+
+	return err
+}
+
+func (r *GamificationRepository) GetBadge(ctx context.Context, id uuid.UUID) (*model.Badge, error) {
+	badge := new(model.Badge)
+
+	err := r.DB.NewSelect().
+		Model(badge).
+		Where(`id = ?`, id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return badge, nil
+}
+
+func (r *GamificationRepository) GetBadgeByImage(ctx context.Context, image string) (*model.Badge, error) {
+	badge := new(model.Badge)
+
+	err := r.DB.NewSelect().
+		Model(badge).
+		Where(`image = ?`, image).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return badge, nil
+}
+
+func (r *GamificationRepository) Badges(ctx context.Context, miniAppID uuid.UUID) ([]*model.Badge, error) {
+	badges := make([]*model.Badge, 0)
+
+	err := r.DB.NewSelect().
+		Model(&badges).
+		Where(`mini_app_id = ?`, miniAppID).
+		Order("required_points", "created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return badges, nil
+}
+
+func (r *GamificationRepository) UserBadges(ctx context.Context, userID uuid.UUID) ([]*model.UserBadge, error) {
+	badges := make([]*model.UserBadge, 0)
+
+	err := r.DB.NewSelect().
+		Model(&badges).
+		Relation("Badge").
+		Where(`user_badge.user_id = ?`, userID).
+		Order("user_badge.created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return badges, nil
+}
+
+func (r *GamificationRepository) UpdateBadge(ctx context.Context, badge *model.Badge) error {
+	_, err := r.DB.NewUpdate().
+		Model(badge).
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+func (r *GamificationRepository) DeleteBadge(ctx context.Context, miniAppID, id uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.Badge)(nil)).
+		Where(`mini_app_id = ?`, miniAppID).
+		Where(`id = ?`, id).
+		Exec(ctx)
+
+	return err
+}
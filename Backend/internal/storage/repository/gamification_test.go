@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// testTx opens a transaction in the migrated database of TEST_POSTGRES_DSN,
+// it is rolled back at the end of the test.
+func testTx(t *testing.T) bun.Tx {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	db := bun.NewDB(sqlDB, pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	return tx
+}
+
+func createTestUser(t *testing.T, ctx context.Context, tx bun.Tx) (miniAppID, userID uuid.UUID) {
+	t.Helper()
+
+	miniAppID, userID = uuid.New(), uuid.New()
+
+	_, err := tx.NewRaw(`
+		INSERT INTO mini_apps (
+			"id", "plan_id", "bot_token", "owner_telegram_id", "name", "logo", "logo_size",
+			"teacher_avatar", "language", "url", "support", "is_active"
+		) VALUES (?, 'free_forever', '', ?, ?, '', 0, '', 'en', '', '', TRUE)`,
+		miniAppID, int64(miniAppID.ID()), miniAppID.String(),
+	).Exec(ctx)
+	if err != nil {
+		t.Fatalf("failed to create mini-app: %v", err)
+	}
+
+	_, err = tx.NewRaw(`
+		INSERT INTO users (
+			"id", "mini_app_id", "role", "telegram_id", "telegram_username",
+			"first_name", "last_name", "avatar", "language", "is_active"
+		) VALUES (?, ?, 'owner', 1, '', '', '', '', 'en', TRUE)`,
+		userID, miniAppID,
+	).Exec(ctx)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	return miniAppID, userID
+}
+
+func TestGamificationRepository_DefaultRules(t *testing.T) {
+	ctx := context.Background()
+	tx := testTx(t)
+	miniAppID, userID := createTestUser(t, ctx, tx)
+
+	r := NewGamificationRepository(
+		repository.NewGenericRepository[model.PointTransaction, uuid.UUID](nil),
+	).WithTx(tx)
+
+	rules, err := r.GetPointRules(ctx, miniAppID)
+	if err != nil {
+		t.Fatalf("GetPointRules() error = %v", err)
+	}
+	if len(rules) != 0 {
+		t.Fatalf("GetPointRules() = %d rules, want none", len(rules))
+	}
+
+	transactions := make([]*model.PointTransaction, 0)
+	for _, rule := range model.DefaultPointRules(miniAppID) {
+		transactions = append(transactions, model.NewPointTransaction(
+			miniAppID, userID, uuid.Nil, uuid.Nil, rule, "subject",
+		))
+	}
+
+	created, err := r.CreatePointTransactions(ctx, transactions)
+	if err != nil {
+		t.Fatalf("CreatePointTransactions() error = %v", err)
+	}
+	if created != int64(len(transactions)) {
+		t.Errorf("CreatePointTransactions() = %d, want %d", created, len(transactions))
+	}
+
+	created, err = r.CreatePointTransactions(ctx, transactions)
+	if err != nil {
+		t.Fatalf("CreatePointTransactions() repeated error = %v", err)
+	}
+	if created != 0 {
+		t.Errorf("CreatePointTransactions() repeated = %d, want 0", created)
+	}
+
+	total, err := r.TotalPoints(ctx, userID, uuid.Nil)
+	if err != nil {
+		t.Fatalf("TotalPoints() error = %v", err)
+	}
+
+	var want int64
+	for _, transaction := range transactions {
+		want += transaction.Points
+	}
+	if total != want {
+		t.Errorf("TotalPoints() = %d, want %d", total, want)
+	}
+}
+
+func TestGamificationRepository_EditRules(t *testing.T) {
+	ctx := context.Background()
+	tx := testTx(t)
+	miniAppID, userID := createTestUser(t, ctx, tx)
+
+	r := NewGamificationRepository(
+		repository.NewGenericRepository[model.PointTransaction, uuid.UUID](nil),
+	).WithTx(tx)
+
+	rule := model.NewPointRule(miniAppID, model.PointEventLessonAccepted, 0, 10)
+	if err := r.CreatePointRules(ctx, []*model.PointRule{rule}); err != nil {
+		t.Fatalf("CreatePointRules() error = %v", err)
+	}
+
+	transaction := model.NewPointTransaction(miniAppID, userID, uuid.Nil, uuid.Nil, rule, "subject")
+	if _, err := r.CreatePointTransactions(ctx, []*model.PointTransaction{transaction}); err != nil {
+		t.Fatalf("CreatePointTransactions() error = %v", err)
+	}
+
+	// Rule references are cleared by ON DELETE SET NULL.
+	if err := r.DeletePointRules(ctx, miniAppID); err != nil {
+		t.Fatalf("DeletePointRules() error = %v", err)
+	}
+
+	var ruleID uuid.NullUUID
+	err := tx.NewSelect().
+		Model((*model.PointTransaction)(nil)).
+		Column("rule_id").
+		Where("id = ?", transaction.ID).
+		Scan(ctx, &ruleID)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if ruleID.Valid {
+		t.Errorf("rule_id = %s, want NULL", ruleID.UUID)
+	}
+
+	_, err = tx.NewUpdate().
+		Model((*model.PointTransaction)(nil)).
+		Set("points = points + 1").
+		Where("id = ?", transaction.ID).
+		Exec(ctx)
+	if err == nil {
+		t.Error("transaction update is not rejected")
+	}
+}
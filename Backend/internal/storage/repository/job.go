@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type JobRepository struct {
+	repository.Generic[model.Job, uuid.UUID]
+}
+
+func NewJobRepository(
+	genericRepository repository.Generic[model.Job, uuid.UUID],
+) *JobRepository {
+	return &JobRepository{
+		Generic: genericRepository,
+	}
+}
+
+// Enqueue creates the job, false is returned if the active job with the same
+// key already exists.
+func (r *JobRepository) Enqueue(ctx context.Context, job *model.Job) (bool, error) {
+	res, err := r.DB.NewInsert().
+		Model(job).
+		On(`CONFLICT ("key") WHERE "status" IN ('pending', 'running') DO NOTHING`).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
+// Lease locks the job that is due or whose lease expired for the worker and
+// counts the attempt. Nil is returned if there are no such jobs. Jobs locked
+// by other transactions are skipped, so workers do not wait for each other.
+func (r *JobRepository) Lease(
+	ctx context.Context,
+	workerID string,
+	now time.Time,
+	lease time.Duration,
+) (*model.Job, error) {
+
+	job := new(model.Job)
+
+	due := r.DB.NewSelect().
+		Model((*model.Job)(nil)).
+		Column("id").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where(`status = ? AND run_at <= ?`, model.JobStatusPending, now).
+				WhereOr(`status = ? AND locked_until < ?`, model.JobStatusRunning, now)
+		}).
+		Order("run_at").
+		Limit(1).
+		For("UPDATE SKIP LOCKED")
+
+	err := r.DB.NewUpdate().
+		Model(job).
+		Set(`status = ?`, model.JobStatusRunning).
+		Set(`attempts = attempts + 1`).
+		Set(`locked_by = ?`, workerID).
+		Set(`locked_until = ?`, now.Add(lease)).
+		Set(`updated_at = ?`, now).
+		Where(`id = (?)`, due).
+		Returning("*").
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ExtendLease extends the lease of the running job, false is returned if the
+// job is no longer leased by the worker.
+func (r *JobRepository) ExtendLease(
+	ctx context.Context,
+	id uuid.UUID,
+	workerID string,
+	until time.Time,
+) (bool, error) {
+
+	res, err := r.DB.NewUpdate().
+		Model((*model.Job)(nil)).
+		Set(`locked_until = ?`, until).
+		Where(`id = ?`, id).
+		Where(`status = ?`, model.JobStatusRunning).
+		Where(`locked_by = ?`, workerID).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
+// Release saves the result of the attempt of the job leased by the worker
+// and unlocks it.
+func (r *JobRepository) Release(ctx context.Context, job *model.Job, workerID string) error {
+	_, err := r.DB.NewUpdate().
+		Model(job).
+		Column("status", "run_at", "last_error", "completed_at", "updated_at").
+		Set(`locked_by = NULL`).
+		Set(`locked_until = NULL`).
+		WherePK().
+		Where(`status = ?`, model.JobStatusRunning).
+		Where(`locked_by = ?`, workerID).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *JobRepository) Find(ctx context.Context, filter *model.FilterJobsRequest) ([]*model.Job, int, error) {
+	jobs := make([]*model.Job, 0)
+
+	applyFilter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		if filter.Status != "" {
+			q = q.Where(`job.status = ?`, filter.Status)
+		}
+		if filter.Kind != "" {
+			q = q.Where(`job.kind = ?`, filter.Kind)
+		}
+
+		return q
+	}
+
+	total, err := applyFilter(r.DB.NewSelect().Model(&jobs)).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.Job{}, total, nil
+	}
+
+	query := applyFilter(r.DB.NewSelect().Model(&jobs)).
+		Order(`job.created_at DESC`).
+		Limit(int(filter.Limit))
+
+	if filter.Offset != 0 {
+		query = query.Offset(int(filter.Offset))
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+// Retry enqueues the dead job again with all its attempts, false is returned
+// if there is no such dead job.
+func (r *JobRepository) Retry(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	res, err := r.DB.NewUpdate().
+		Model((*model.Job)(nil)).
+		Set(`status = ?`, model.JobStatusPending).
+		Set(`attempts = 0`).
+		Set(`run_at = ?`, now).
+		Set(`updated_at = ?`, now).
+		Where(`id = ?`, id).
+		Where(`status = ?`, model.JobStatusDead).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
+// DeadKeys returns the keys that belong to dead jobs.
+func (r *JobRepository) DeadKeys(ctx context.Context, keys []string) ([]string, error) {
+	dead := make([]string, 0)
+
+	err := r.DB.NewSelect().
+		Model((*model.Job)(nil)).
+		Column("key").
+		Where(`key IN (?)`, bun.In(keys)).
+		Where(`status = ?`, model.JobStatusDead).
+		Scan(ctx, &dead)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dead, nil
+}
+
+// DeleteCompleted deletes jobs completed before the time, dead jobs are kept.
+func (r *JobRepository) DeleteCompleted(ctx context.Context, before time.Time) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.Job)(nil)).
+		Where(`status = ?`, model.JobStatusCompleted).
+		Where(`completed_at < ?`, before).
+		Exec(ctx)
+
+	return err
+}
@@ -43,6 +43,9 @@ func (r *LessonRepository) GetByID(ctx context.Context, id, userID uuid.UUID) (*
 		Where(`id = ?`, id).
 		Relation("Materials", func(q *bun.SelectQuery) *bun.SelectQuery {
 			return q.Order("category", "index")
+		}).
+		Relation("Materials.Subtitles", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("subtitle.created_at")
 		})
 
 	if userID != uuid.Nil {
@@ -143,3 +146,189 @@ func (r *LessonRepository) IsLessonUnlocked(
 
 	return len(lessons) != 0, nil
 }
+
+// calendarEventColumns selects model.CalendarEvent of lesson l of product p
+// shifted by the schedule of cohort c.
+const calendarEventColumns = `
+	l.id AS lesson_id,
+	l.product_id,
+	p.title AS product_title,
+	l.title,
+	l.description,
+	l.event_start + COALESCE(c.schedule_offset, INTERVAL '0') AS event_start,
+	l.event_end + COALESCE(c.schedule_offset, INTERVAL '0') AS event_end,
+	l.time_zone,
+	l.updated_at
+`
+
+// lessonAccessCondition checks that lesson l is free or paid by the student
+// of product access pa. Payment status is expected as an argument.
+const lessonAccessCondition = `(
+	NOT EXISTS (SELECT 1 FROM product_level_lessons AS pll WHERE pll.lesson_id = l.id)
+	OR EXISTS (
+		SELECT 1 FROM paid_lessons
+		JOIN payments ON payments.id = paid_lessons.payment_id
+		WHERE paid_lessons.lesson_id = l.id
+			AND payments.user_id = pa.user_id
+			AND payments.status = ?
+			AND (
+				payments.access_duration IS NULL
+				OR CURRENT_TIMESTAMP < payments.access_start + payments.access_duration
+			)
+	)
+)`
+
+// studentEventsQuery selects events of the products that students have access
+// to. Events of product levels are included only if they are paid.
+func (r *LessonRepository) studentEventsQuery() *bun.SelectQuery {
+	return r.DB.NewSelect().
+		ColumnExpr(calendarEventColumns).
+		TableExpr(`product_access AS pa`).
+		Join(`JOIN users AS u ON u.id = pa.user_id`).
+		Join(`JOIN products AS p ON p.id = pa.product_id`).
+		Join(`JOIN lessons AS l ON l.product_id = p.id`).
+		Join(`LEFT JOIN cohort_students AS cs ON cs.product_id = p.id AND cs.user_id = pa.user_id`).
+		Join(`LEFT JOIN cohorts AS c ON c.id = cs.cohort_id`).
+		Where(`pa.deleted_at IS NULL`).
+		Where(`u.role = ?`, model.UserRoleStudent).
+		Where(`p.is_active = TRUE`).
+		Where(`l.is_active = TRUE`).
+		Where(`l.content_type = ?`, model.LessonTypeEvent).
+		Where(`l.event_start IS NOT NULL`).
+		Where(lessonAccessCondition, model.PaymentStatusCompleted)
+}
+
+// UserEvents returns events available to the student that end after since.
+func (r *LessonRepository) UserEvents(
+	ctx context.Context,
+	userID uuid.UUID,
+	since time.Time,
+) ([]*model.CalendarEvent, error) {
+
+	events := make([]*model.CalendarEvent, 0)
+
+	err := r.studentEventsQuery().
+		Where(`pa.user_id = ?`, userID).
+		Where(`l.event_end + COALESCE(c.schedule_offset, INTERVAL '0') > ?`, since).
+		OrderExpr(`event_start`).
+		Scan(ctx, &events)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ProductEvents returns active events of the product. If the user is set then
+// only events available to the student are returned, shifted by the schedule
+// of the student's cohort.
+func (r *LessonRepository) ProductEvents(
+	ctx context.Context,
+	productID, userID uuid.UUID,
+) ([]*model.CalendarEvent, error) {
+
+	events := make([]*model.CalendarEvent, 0)
+
+	var query *bun.SelectQuery
+	if userID != uuid.Nil {
+		query = r.studentEventsQuery().
+			Where(`pa.user_id = ?`, userID).
+			Where(`pa.product_id = ?`, productID)
+	} else {
+		query = r.DB.NewSelect().
+			ColumnExpr(calendarEventColumns).
+			TableExpr(`lessons AS l`).
+			Join(`JOIN products AS p ON p.id = l.product_id`).
+			Join(`LEFT JOIN cohort_students AS cs ON cs.product_id = p.id AND cs.user_id = ?`, userID).
+			Join(`LEFT JOIN cohorts AS c ON c.id = cs.cohort_id`).
+			Where(`l.product_id = ?`, productID).
+			Where(`l.is_active = TRUE`).
+			Where(`l.content_type = ?`, model.LessonTypeEvent).
+			Where(`l.event_start IS NOT NULL`)
+	}
+
+	err := query.
+		OrderExpr(`event_start`).
+		Scan(ctx, &events)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// PendingEventReminders returns events starting before now plus
+// model.EventReminderTime that students of mini-apps with bots have not been
+// reminded about.
+func (r *LessonRepository) PendingEventReminders(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*model.PendingEventReminder, error) {
+
+	reminders := make([]*model.PendingEventReminder, 0)
+
+	err := r.studentEventsQuery().
+		ColumnExpr(`u.id AS user_id, u.telegram_id, m.id AS mini_app_id, m.bot_token`).
+		Join(`JOIN mini_apps AS m ON m.id = p.mini_app_id`).
+		Where(`m.bot_token <> ''`).
+		Where(`m.deleted_at IS NULL`).
+		Where(`NOT ? = ANY(u.muted_notifications)`, model.NotificationCategoryEvents).
+		Where(`l.event_start + COALESCE(c.schedule_offset, INTERVAL '0') BETWEEN ? AND ?`,
+			now, now.Add(model.EventReminderTime)).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM event_reminders AS er WHERE er.lesson_id = l.id AND er.user_id = u.id
+		)`).
+		OrderExpr(`event_start`).
+		Limit(limit).
+		Scan(ctx, &reminders)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (r *LessonRepository) CreateEventReminders(ctx context.Context, reminders []*model.EventReminder) error {
+	if len(reminders) == 0 {
+		return nil
+	}
+
+	_, err := r.DB.NewInsert().
+		Model(&reminders).
+		On(`CONFLICT DO NOTHING`).
+		Exec(ctx)
+
+	return err
+}
+
+// CreateAttendance saves check-in of the student, repeated check-ins are
+// ignored.
+func (r *LessonRepository) CreateAttendance(ctx context.Context, attendance *model.EventAttendance) error {
+	_, err := r.DB.NewInsert().
+		Model(attendance).
+		On(`CONFLICT DO NOTHING`).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *LessonRepository) Attendance(ctx context.Context, lessonID uuid.UUID) ([]*model.EventAttendance, error) {
+	attendance := make([]*model.EventAttendance, 0)
+
+	err := r.DB.NewSelect().
+		Model(&attendance).
+		Relation("User").
+		Where(`event_attendance.lesson_id = ?`, lessonID).
+		Order("event_attendance.created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attendance, nil
+}
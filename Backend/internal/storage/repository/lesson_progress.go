@@ -4,6 +4,7 @@ import (
 	"academy/internal/database/repository"
 	"academy/internal/model"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -108,6 +109,10 @@ func (r *LessonProgressRepository) Find(
 		if len(filter.Status) != 0 {
 			q = q.Where(`status IN (?)`, bun.In(filter.Status))
 		}
+		if len(filter.CohortID) != 0 {
+			q = q.Where(`user_id IN (SELECT user_id FROM cohort_students WHERE cohort_id IN (?))`,
+				bun.In(filter.CohortID))
+		}
 	}
 
 	progress := make([]*model.LessonProgress, 0)
@@ -154,6 +159,33 @@ func (r *LessonProgressRepository) Update(ctx context.Context, progress *model.L
 	return err
 }
 
+// UpdateQuarantined updates the quarantined progress if it was not submitted
+// again since it was updated at the time.
+func (r *LessonProgressRepository) UpdateQuarantined(
+	ctx context.Context,
+	progress *model.LessonProgress,
+	updatedAt time.Time,
+) (bool, error) {
+
+	res, err := r.DB.NewUpdate().
+		Model(progress).
+		WherePK().
+		Where(`status = ?`, model.LessonProgressStatusQuarantined).
+		Where(`updated_at = ?`, updatedAt).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
 func (r *LessonProgressRepository) Delete(
 	ctx context.Context, userID uuid.UUID, productIDs []uuid.UUID,
 ) ([]*model.LessonProgress, error) {
@@ -56,7 +56,8 @@ func (r *MaterialRepository) GetByFilename(ctx context.Context, filename string)
 
 	query := r.DB.NewSelect().
 		Model(material).
-		Where(`filename = ?`, filename)
+		Where(`filename = ?`, filename).
+		Limit(1)
 
 	err := query.Scan(ctx)
 
@@ -71,6 +72,100 @@ func (r *MaterialRepository) GetByFilename(ctx context.Context, filename string)
 	return material, nil
 }
 
+// FindByFilename returns materials which share the file.
+func (r *MaterialRepository) FindByFilename(ctx context.Context, filename string) ([]*model.Material, error) {
+	materials := make([]*model.Material, 0)
+
+	err := r.DB.NewSelect().
+		Model(&materials).
+		Where(`filename = ?`, filename).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
+
+// GetByVideoPreview returns the video which poster or storyboard is the file.
+func (r *MaterialRepository) GetByVideoPreview(ctx context.Context, filename string) (*model.Material, error) {
+	material := new(model.Material)
+
+	query := r.DB.NewSelect().
+		Model(material).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where(`metadata->>'poster' = ?`, filename).
+				WhereOr(`metadata->'storyboard'->>'filename' = ?`, filename)
+		}).
+		Limit(1)
+
+	err := query.Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+// GetLessonCover returns the cover of the lesson, nil is returned if it is
+// not uploaded.
+func (r *MaterialRepository) GetLessonCover(ctx context.Context, lessonID uuid.UUID) (*model.Material, error) {
+	material := new(model.Material)
+
+	query := r.DB.NewSelect().
+		Model(material).
+		Where(`lesson_id = ?`, lessonID).
+		Where(`category = ?`, model.MaterialCategoryLessonCover).
+		Limit(1)
+
+	err := query.Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+// FindVideosWithoutPreview returns lesson videos that are played from their
+// files and have no preview yet.
+func (r *MaterialRepository) FindVideosWithoutPreview(ctx context.Context, limit int) ([]*model.Material, error) {
+	materials := make([]*model.Material, 0)
+
+	query := r.DB.NewSelect().
+		Model(&materials).
+		Where(`category = ?`, model.MaterialCategoryLessonContent).
+		Where(`content_type IN (?)`, bun.In([]model.MaterialType{
+			model.MaterialTypeVideo,
+			model.MaterialTypeCircleVideo,
+		})).
+		Where(`status = ?`, model.MaterialStatusReady).
+		Where(`filename != ''`).
+		Where(`metadata IS NULL`).
+		Order("updated_at")
+
+	if limit != 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
+
 func (r *MaterialRepository) FindByStatus(
 	ctx context.Context, status model.MaterialStatus, withMetadata bool,
 	limit, offset int,
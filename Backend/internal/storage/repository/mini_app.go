@@ -377,12 +377,16 @@ func (r *MiniAppRepository) CreateModInvite(
 	return nil
 }
 
-func (r *MiniAppRepository) CreateModInvitePermission(
-	ctx context.Context, invitePermission *model.ModInvitePermission,
+// SetModInviteRole changes the role of the invite, claimed invites included.
+func (r *MiniAppRepository) SetModInviteRole(
+	ctx context.Context, inviteID, roleID uuid.UUID,
 ) error {
 
-	_, err := r.DB.NewInsert().
-		Model(invitePermission).
+	_, err := r.DB.NewUpdate().
+		Model((*model.ModInvite)(nil)).
+		Set(`role_id = ?`, roleID).
+		Set(`updated_at = ?`, time.Now()).
+		Where(`id = ?`, inviteID).
 		Exec(ctx)
 
 	if err != nil {
@@ -392,22 +396,6 @@ func (r *MiniAppRepository) CreateModInvitePermission(
 	return nil
 }
 
-func (r *MiniAppRepository) DeleteModInvitePermissions(
-	ctx context.Context, inviteID uuid.UUID,
-) error {
-
-	_, err := r.DB.NewDelete().
-		Model((*model.ModInvitePermission)(nil)).
-		Where(`invite_id = ?`, inviteID).
-		Exec(ctx)
-
-	if err != nil {
-		return err
-	}
-
-	return err
-}
-
 func (r *MiniAppRepository) GetModInvite(
 	ctx context.Context,
 	id uuid.UUID,
@@ -432,27 +420,35 @@ func (r *MiniAppRepository) GetModInvite(
 	return invite, nil
 }
 
+// CheckPermission checks that the role of the moderator has any of the
+// permissions or their parents. Permission that is limited to products is
+// accepted only for one of them, nil product requires the permission in all
+// products.
 func (r *MiniAppRepository) CheckPermission(
 	ctx context.Context,
 	userID uuid.UUID,
+	productID uuid.UUID,
 	permissionName ...model.PermissionName,
 ) (bool, error) {
 
-	permissions := make([]*model.ModInvitePermission, 0)
+	var ok bool
 
 	query := r.DB.NewRaw(`
-	SELECT mip.* FROM mod_invites AS mi
-	JOIN mod_invite_permissions AS mip ON mip.invite_id = mi.id
-		AND mip.permission_name IN (?)
-	WHERE "user_id" = ?
-	`, bun.In(permissionName), userID)
+	SELECT EXISTS (
+		SELECT 1 FROM mod_invites AS mi
+		JOIN mod_role_permissions AS rp ON rp.role_id = mi.role_id
+			AND rp.permission_name IN (?)
+		WHERE mi.user_id = ?
+			AND (cardinality(rp.product_ids) = 0 OR ?::UUID = ANY(rp.product_ids))
+	)
+	`, bun.In(model.WithParentPermissions(permissionName...)), userID, productID)
 
-	err := query.Scan(ctx, &permissions)
+	err := query.Scan(ctx, &ok)
 	if err != nil {
 		return false, err
 	}
 
-	return 0 < len(permissions), nil
+	return ok, nil
 }
 
 func (r *MiniAppRepository) GetPermissions(
@@ -465,12 +461,14 @@ func (r *MiniAppRepository) GetPermissions(
 	query := r.DB.NewRaw(`
 	SELECT
 		p.name,
-		p.description
+		p.description,
+		p.parent_name,
+		rp.product_ids
 	FROM mod_invites AS mi
-	JOIN mod_invite_permissions AS mip ON mip.invite_id = mi.id
-	JOIN permissions AS p ON p.name = mip.permission_name
-	WHERE "user_id" = ?
-	GROUP BY p.name, p.description
+	JOIN mod_role_permissions AS rp ON rp.role_id = mi.role_id
+	JOIN permissions AS p ON p.name = rp.permission_name
+	WHERE mi.user_id = ?
+	ORDER BY p.name
 	`, userID)
 
 	err := query.Scan(ctx, &permissions)
@@ -519,7 +517,8 @@ func (r *MiniAppRepository) ModInvites(
 	invites := make([]*model.ModInvite, 0)
 
 	countQuery := r.DB.NewSelect().
-		Model(&invites)
+		Model(&invites).
+		Where(`mod_invite.mini_app_id = ?`, miniAppID)
 
 	total, err := countQuery.Count(ctx)
 	if err != nil {
@@ -532,9 +531,10 @@ func (r *MiniAppRepository) ModInvites(
 
 	query := r.DB.NewSelect().
 		Model(&invites).
+		Where(`mod_invite.mini_app_id = ?`, miniAppID).
 		Relation("User").
-		Relation("Permissions").
-		Order(`created_at DESC`).
+		Relation("Role").
+		Order(`mod_invite.created_at DESC`).
 		Limit(int(filter.Limit))
 
 	if filter.Offset != 0 {
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type ModRoleRepository struct {
+	repository.Generic[model.ModRole, uuid.UUID]
+}
+
+func (r *ModRoleRepository) WithTx(tx bun.Tx) *ModRoleRepository {
+	return &ModRoleRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewModRoleRepository(
+	genericRepository repository.Generic[model.ModRole, uuid.UUID],
+) *ModRoleRepository {
+	return &ModRoleRepository{
+		Generic: genericRepository,
+	}
+}
+
+// rolesQuery selects roles with number of invites that have them.
+func (r *ModRoleRepository) rolesQuery(roles any) *bun.SelectQuery {
+	return r.DB.NewSelect().
+		Model(roles).
+		ColumnExpr(`mod_role.*`).
+		ColumnExpr(`(
+			SELECT COUNT(*) FROM mod_invites AS mi WHERE mi.role_id = mod_role.id
+		) AS total_invites`).
+		Relation("Permissions").
+		Relation("Permissions.Permission")
+}
+
+func (r *ModRoleRepository) Create(ctx context.Context, role *model.ModRole) error {
+	_, err := r.DB.NewInsert().Model(role).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.setPermissions(ctx, role)
+}
+
+func (r *ModRoleRepository) Update(ctx context.Context, role *model.ModRole) error {
+	_, err := r.DB.NewUpdate().
+		Model(role).
+		Column("name", "updated_at").
+		WherePK().
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return r.setPermissions(ctx, role)
+}
+
+func (r *ModRoleRepository) setPermissions(ctx context.Context, role *model.ModRole) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.ModRolePermission)(nil)).
+		Where(`role_id = ?`, role.ID).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+
+	_, err = r.DB.NewInsert().
+		Model(&role.Permissions).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *ModRoleRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.ModRole, error) {
+	role := new(model.ModRole)
+
+	err := r.rolesQuery(role).
+		Where(`mod_role.id = ?`, id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (r *ModRoleRepository) MiniAppRoles(ctx context.Context, miniAppID uuid.UUID) ([]*model.ModRole, error) {
+	roles := make([]*model.ModRole, 0)
+
+	err := r.rolesQuery(&roles).
+		Where(`mod_role.mini_app_id = ?`, miniAppID).
+		Order("mod_role.name").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// ExistsByName checks whether the mini-app has other role with the name.
+func (r *ModRoleRepository) ExistsByName(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	name string,
+	exceptID uuid.UUID,
+) (bool, error) {
+
+	return r.DB.NewSelect().
+		Model((*model.ModRole)(nil)).
+		Where(`mini_app_id = ?`, miniAppID).
+		Where(`name = ?`, name).
+		Where(`id <> ?`, exceptID).
+		Exists(ctx)
+}
+
+func (r *ModRoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.ModRole)(nil)).
+		Where(`id = ?`, id).
+		Exec(ctx)
+
+	return err
+}
@@ -54,5 +54,61 @@ func Module() fx.Option {
 			repository.NewGenericRepository[model.JettonTransfer, uuid.UUID],
 			NewJettonTransferRepository,
 		),
+		fx.Provide(
+			repository.NewGenericRepository[model.PointTransaction, uuid.UUID],
+			NewGamificationRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.Cohort, uuid.UUID],
+			NewCohortRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.BotSettings, uuid.UUID],
+			NewBotRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.Notification, uuid.UUID],
+			NewNotificationRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.Broadcast, uuid.UUID],
+			NewBroadcastRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.StaffNotification, uuid.UUID],
+			NewStaffNotificationRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.SupportTicket, uuid.UUID],
+			NewSupportRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.AuditLog, uuid.UUID],
+			NewAuditRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.ModRole, uuid.UUID],
+			NewModRoleRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.APIKey, uuid.UUID],
+			NewAPIKeyRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.TusUpload, uuid.UUID],
+			NewTusUploadRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.Job, uuid.UUID],
+			NewJobRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.Subtitle, uuid.UUID],
+			NewSubtitleRepository,
+		),
+		fx.Provide(
+			repository.NewGenericRepository[model.Blob, uuid.UUID],
+			NewBlobRepository,
+		),
 	)
 }
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type NotificationRepository struct {
+	repository.Generic[model.Notification, uuid.UUID]
+}
+
+func (r *NotificationRepository) WithTx(tx bun.Tx) *NotificationRepository {
+	return &NotificationRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewNotificationRepository(
+	genericRepository repository.Generic[model.Notification, uuid.UUID],
+) *NotificationRepository {
+	return &NotificationRepository{
+		Generic: genericRepository,
+	}
+}
+
+// Create saves the notification, the one with already used dedup key is
+// ignored.
+func (r *NotificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	_, err := r.DB.NewInsert().
+		Model(notification).
+		On(`CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING`).
+		Exec(ctx)
+
+	return err
+}
+
+// EnqueueLessonReleases creates notifications about lessons released between
+// since and now for students that have access to them. Release dates are
+// shifted by the schedule of the student's cohort.
+func (r *NotificationRepository) EnqueueLessonReleases(ctx context.Context, since, now time.Time) error {
+	_, err := r.DB.NewRaw(`
+	INSERT INTO notifications ("mini_app_id", "user_id", "category", "kind", "params", "dedup_key")
+	SELECT
+		p.mini_app_id,
+		pa.user_id,
+		?,
+		?,
+		jsonb_build_object('lesson', l.title, 'product', p.title),
+		'lesson_released:' || l.id
+	FROM product_access AS pa
+	JOIN users AS u ON u.id = pa.user_id
+	JOIN products AS p ON p.id = pa.product_id
+	JOIN lessons AS l ON l.product_id = p.id
+	LEFT JOIN cohort_students AS cs ON cs.product_id = p.id AND cs.user_id = pa.user_id
+	LEFT JOIN cohorts AS c ON c.id = cs.cohort_id
+	WHERE pa.deleted_at IS NULL
+		AND u.role = ?
+		AND p.is_active = TRUE
+		AND l.is_active = TRUE
+		AND l.content_type <> ?
+		AND l.release_date + COALESCE(c.schedule_offset, INTERVAL '0') > ?
+		AND l.release_date + COALESCE(c.schedule_offset, INTERVAL '0') <= ?
+		AND `+lessonAccessCondition+`
+	ON CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING
+	`,
+		model.NotificationCategoryLessons,
+		model.NotificationKindLessonReleased,
+		model.UserRoleStudent,
+		model.LessonTypeEvent,
+		since, now,
+		model.PaymentStatusCompleted,
+	).Exec(ctx)
+
+	return err
+}
+
+// EnqueueAccessExpiring creates notifications about paid access that ends
+// between now and until. Payments that are followed by another payment of the
+// same product level are skipped since access is extended.
+func (r *NotificationRepository) EnqueueAccessExpiring(ctx context.Context, now, until time.Time) error {
+	_, err := r.DB.NewRaw(`
+	INSERT INTO notifications ("mini_app_id", "user_id", "category", "kind", "params", "dedup_key")
+	SELECT
+		pm.mini_app_id,
+		pm.user_id,
+		?,
+		?,
+		jsonb_build_object(
+			'title', p.title,
+			'date', to_char((pm.access_start + pm.access_duration) AT TIME ZONE 'UTC', 'YYYY-MM-DD')
+		),
+		'access_expiring:' || pm.id
+	FROM payments AS pm
+	JOIN products AS p ON p.id = pm.product_id
+	WHERE pm.status = ?
+		AND pm.user_id IS NOT NULL
+		AND pm.product_level_id IS NOT NULL
+		AND pm.access_duration IS NOT NULL
+		AND pm.access_start + pm.access_duration > ?
+		AND pm.access_start + pm.access_duration <= ?
+		AND NOT EXISTS (
+			SELECT 1 FROM payments AS next
+			WHERE next.user_id = pm.user_id
+				AND next.product_level_id = pm.product_level_id
+				AND next.status = pm.status
+				AND next.created_at > pm.created_at
+		)
+	ON CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING
+	`,
+		model.NotificationCategoryAccess,
+		model.NotificationKindAccessExpiring,
+		model.PaymentStatusCompleted,
+		now, until,
+	).Exec(ctx)
+
+	return err
+}
+
+// Pending returns the oldest notifications that are not sent yet.
+func (r *NotificationRepository) Pending(ctx context.Context, limit int) ([]*model.PendingNotification, error) {
+	notifications := make([]*model.PendingNotification, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`n.id, n.mini_app_id, n.user_id, n.category, n.kind, n.params`).
+		ColumnExpr(`u.telegram_id, u.language AS user_language, u.muted_notifications`).
+		ColumnExpr(`m.bot_token, m.language AS mini_app_language`).
+		TableExpr(`notifications AS n`).
+		Join(`JOIN users AS u ON u.id = n.user_id`).
+		Join(`JOIN mini_apps AS m ON m.id = n.mini_app_id`).
+		Where(`n.status = ?`, model.NotificationStatusPending).
+		OrderExpr(`n.created_at`).
+		Limit(limit).
+		Scan(ctx, &notifications)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepository) UpdateStatus(ctx context.Context, notification *model.Notification) error {
+	_, err := r.DB.NewUpdate().
+		Model(notification).
+		Column("status", "error", "sent_at").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+// DeleteOld deletes processed notifications created before olderThan. Dedup
+// keys of deleted notifications could be used again, so they should be older
+// than scheduled notifications scan.
+func (r *NotificationRepository) DeleteOld(ctx context.Context, olderThan time.Time) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.Notification)(nil)).
+		Where(`status <> ?`, model.NotificationStatusPending).
+		Where(`created_at < ?`, olderThan).
+		Exec(ctx)
+
+	return err
+}
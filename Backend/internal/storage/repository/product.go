@@ -62,6 +62,9 @@ func (r *ProductRepository) GetByID(
 			Relation("Levels.ProductLevelLessons").
 			Relation("Levels.Bonus", func(q *bun.SelectQuery) *bun.SelectQuery {
 				return q.Order("index")
+			}).
+			Relation("Prerequisites", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Order("created_at")
 			})
 	}
 
@@ -97,6 +100,131 @@ func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+// SetPrerequisites replaces all product prerequisites with new ones.
+func (r *ProductRepository) SetPrerequisites(
+	ctx context.Context,
+	productID uuid.UUID,
+	prerequisites []*model.ProductPrerequisite,
+) error {
+
+	_, err := r.DB.NewDelete().
+		Model((*model.ProductPrerequisite)(nil)).
+		Where(`product_id = ?`, productID).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(prerequisites) == 0 {
+		return nil
+	}
+
+	_, err = r.DB.NewInsert().
+		Model(&prerequisites).
+		Exec(ctx)
+
+	return err
+}
+
+// CountProducts returns number of mini-app products with given ids.
+func (r *ProductRepository) CountProducts(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	ids []uuid.UUID,
+) (int, error) {
+
+	return r.DB.NewSelect().
+		Model((*model.Product)(nil)).
+		Where(`mini_app_id = ?`, miniAppID).
+		Where(`id IN (?)`, bun.In(ids)).
+		Count(ctx)
+}
+
+// RequiresProduct checks whether any of the required products depends on the
+// product through their own prerequisites. It is used to prevent cycles that
+// would make products unreachable.
+func (r *ProductRepository) RequiresProduct(
+	ctx context.Context,
+	productID uuid.UUID,
+	requiredProductIDs []uuid.UUID,
+) (bool, error) {
+
+	var exists bool
+
+	err := r.DB.NewRaw(`
+	WITH RECURSIVE required AS (
+		SELECT id AS product_id FROM products WHERE id IN (?)
+		UNION
+		SELECT pp.required_product_id
+		FROM product_prerequisites AS pp
+		JOIN required AS r ON r.product_id = pp.product_id
+	)
+	SELECT EXISTS (SELECT 1 FROM required WHERE product_id = ?)
+	`, bun.In(requiredProductIDs), productID).
+		Scan(ctx, &exists)
+
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// UnmetPrerequisites returns product prerequisites that the user has not met.
+// Students that have already purchased the product are not affected by
+// prerequisites added later.
+func (r *ProductRepository) UnmetPrerequisites(
+	ctx context.Context,
+	userID, productID uuid.UUID,
+) ([]*model.UnmetPrerequisite, error) {
+
+	unmet := make([]*model.UnmetPrerequisite, 0)
+
+	err := r.DB.NewRaw(`
+	SELECT
+		pp.*,
+		rp.title AS required_product_title,
+		COALESCE(pl.name, '') AS required_product_level_name
+	FROM product_prerequisites AS pp
+	JOIN products AS rp ON rp.id = pp.required_product_id
+	LEFT JOIN product_levels AS pl ON pl.id = pp.required_product_level_id
+	WHERE pp.product_id = ?
+	AND NOT EXISTS (
+		SELECT 1 FROM payments
+		WHERE user_id = ? AND product_id = pp.product_id AND status = 'completed'
+	)
+	AND NOT CASE pp.condition
+		WHEN 'purchased' THEN EXISTS (
+			SELECT 1 FROM payments AS p
+			WHERE p.user_id = ?
+			AND p.product_id = pp.required_product_id
+			AND p.status = 'completed'
+			AND (pp.required_product_level_id IS NULL OR p.product_level_id = pp.required_product_level_id)
+		)
+		ELSE NOT EXISTS (
+			SELECT 1 FROM lessons AS l
+			LEFT JOIN lesson_progress AS lp
+				ON lp.lesson_id = l.id AND lp.user_id = ? AND lp.status = 'accepted'
+			WHERE l.product_id = pp.required_product_id
+			AND (pp.required_product_level_id IS NULL OR l.id IN (
+				SELECT lesson_id FROM product_level_lessons
+				WHERE product_level_id = pp.required_product_level_id
+			))
+			AND lp.lesson_id IS NULL
+		)
+	END
+	ORDER BY pp.created_at
+	`, productID, userID, userID, userID).
+		Scan(ctx, &unmet)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return unmet, nil
+}
+
 func (r *ProductRepository) GetProductAccess(
 	ctx context.Context,
 	userID, productID uuid.UUID,
@@ -218,7 +346,7 @@ func (r *ProductRepository) Feedback(ctx context.Context, productID uuid.UUID) (
 func (r *ProductRepository) Students(
 	ctx context.Context,
 	productID uuid.UUID, usernameSearch string,
-	limit, offset uint,
+	cohortIDs []uuid.UUID, limit, offset uint,
 ) (*model.ProductStudents, error) {
 
 	var productStudents model.ProductStudents
@@ -238,7 +366,7 @@ func (r *ProductRepository) Students(
 				SUM(
 					CASE
 						WHEN lp.status = 'accepted' THEN 10000
-						WHEN lp.status = 'pending' THEN 5000
+						WHEN lp.status IN ('pending', 'quarantined') THEN 5000
 						ELSE 0
 					END
 				)::NUMERIC / (COUNT(DISTINCT user_id) * tl.total_lessons)
@@ -249,6 +377,10 @@ func (r *ProductRepository) Students(
 		Join(`CROSS JOIN total_lessons AS tl`).
 		GroupExpr(`tl.total_lessons`)
 
+	if len(cohortIDs) != 0 {
+		query1 = query1.Where(`lp.user_id IN (SELECT user_id FROM cohort_students WHERE cohort_id IN (?))`, bun.In(cohortIDs))
+	}
+
 	err := query1.Scan(ctx, &productStudents)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -278,7 +410,7 @@ func (r *ProductRepository) Students(
 				SUM(
 					CASE
 						WHEN status = 'accepted' THEN 10000
-						WHEN status = 'pending' THEN 5000
+						WHEN status IN ('pending', 'quarantined') THEN 5000
 						ELSE 0
 					END
 				)::NUMERIC / tl.total_lessons
@@ -299,6 +431,10 @@ func (r *ProductRepository) Students(
 		query2 = query2.Where(`u.telegram_username ILIKE ('%' || ? || '%')`, usernameSearch)
 	}
 
+	if len(cohortIDs) != 0 {
+		query2 = query2.Where(`lp.user_id IN (SELECT user_id FROM cohort_students WHERE cohort_id IN (?))`, bun.In(cohortIDs))
+	}
+
 	err = query2.Scan(ctx, &productStudents.Students)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -331,7 +467,11 @@ func (r *ProductRepository) StudentsDetails(
 		u.telegram_username,
 		u.created_at AS joined_at,
 		COUNT( CASE WHEN lp.status = 'accepted' THEN 1 END ) AS completed_lessons,
-		tl.total_lessons
+		tl.total_lessons,
+		(
+			SELECT COALESCE(SUM(pt.points), 0) FROM point_transactions AS pt
+			WHERE pt.user_id = u.id AND pt.product_id = ?
+		) AS points
 	FROM lesson_progress AS lp
 	JOIN product_lessons AS l ON l.id = lp.lesson_id
 	JOIN users AS u ON u.id = lp.user_id AND u.role = 'student'
@@ -340,7 +480,7 @@ func (r *ProductRepository) StudentsDetails(
 	GROUP BY tl.total_lessons, u.id, u.first_name, u.last_name,
 		u.telegram_id, u.telegram_username, u.created_at
 	ORDER BY u.id
-	`, product.ID, dateFrom, dateTo.AddDate(0, 0, 1)).
+	`, product.ID, product.ID, dateFrom, dateTo.AddDate(0, 0, 1)).
 		Scan(ctx, &result)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type StaffNotificationRepository struct {
+	repository.Generic[model.StaffNotification, uuid.UUID]
+}
+
+func (r *StaffNotificationRepository) WithTx(tx bun.Tx) *StaffNotificationRepository {
+	return &StaffNotificationRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewStaffNotificationRepository(
+	genericRepository repository.Generic[model.StaffNotification, uuid.UUID],
+) *StaffNotificationRepository {
+	return &StaffNotificationRepository{
+		Generic: genericRepository,
+	}
+}
+
+// Moderators assigned to cohorts of the product are notified only about their
+// students. Condition expects recipient as r and student as u.
+const staffCohortCondition = `(
+	NOT EXISTS (
+		SELECT 1 FROM cohort_moderators AS cm
+		JOIN cohorts AS c ON c.id = cm.cohort_id
+		WHERE cm.user_id = r.user_id AND c.product_id = p.id
+	)
+	OR EXISTS (
+		SELECT 1 FROM cohort_moderators AS cm
+		JOIN cohort_students AS cs ON cs.cohort_id = cm.cohort_id
+		WHERE cm.user_id = r.user_id AND cs.product_id = p.id AND cs.user_id = u.id
+	)
+)`
+
+// EnqueueSubmission creates notifications about homework of the student that
+// waits for the review.
+func (r *StaffNotificationRepository) EnqueueSubmission(ctx context.Context, userID, lessonID uuid.UUID) error {
+	category := model.StaffNotificationCategorySubmissions
+
+	_, err := r.DB.NewRaw(`
+	INSERT INTO staff_notifications ("mini_app_id", "user_id", "category", "kind", "params")
+	SELECT
+		p.mini_app_id,
+		r.user_id,
+		?,
+		?,
+		jsonb_build_object(
+			'student', TRIM(u.first_name || ' ' || u.last_name),
+			'lesson', l.title,
+			'product', p.title
+		)
+	FROM lessons AS l
+	JOIN products AS p ON p.id = l.product_id
+	JOIN users AS u ON u.id = ?
+	CROSS JOIN LATERAL func_staff_notification_recipients(p.mini_app_id, ?::VARCHAR[], p.id) AS r
+	WHERE l.id = ?
+		AND `+staffCohortCondition+`
+	`,
+		category,
+		model.StaffNotificationKindHomeworkSubmitted,
+		userID,
+		pgdialect.Array(category.Permissions()),
+		lessonID,
+	).Exec(ctx)
+
+	return err
+}
+
+// EnqueueReview creates notifications about the review for recipients whose
+// score threshold is above the review score.
+func (r *StaffNotificationRepository) EnqueueReview(ctx context.Context, reviewID uuid.UUID) error {
+	category := model.StaffNotificationCategoryReviews
+
+	_, err := r.DB.NewRaw(`
+	INSERT INTO staff_notifications ("mini_app_id", "user_id", "category", "kind", "params", "dedup_key")
+	SELECT
+		p.mini_app_id,
+		r.user_id,
+		?,
+		?,
+		jsonb_build_object(
+			'student', TRIM(u.first_name || ' ' || u.last_name),
+			'lesson', l.title,
+			'score', (rv.score * 100 / ?)::TEXT,
+			'text', CASE WHEN rv.text = '' THEN '' ELSE E'\n\n' || LEFT(rv.text, 500) END
+		),
+		'review_received:' || rv.id
+	FROM reviews AS rv
+	JOIN lessons AS l ON l.id = rv.lesson_id
+	JOIN products AS p ON p.id = l.product_id
+	JOIN users AS u ON u.id = rv.user_id
+	CROSS JOIN LATERAL func_staff_notification_recipients(p.mini_app_id, ?::VARCHAR[], p.id) AS r
+	LEFT JOIN staff_notification_settings AS s ON s.user_id = r.user_id
+	WHERE rv.id = ?
+		AND rv.score < COALESCE(s.review_score_threshold, ?)
+	ON CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING
+	`,
+		category,
+		model.StaffNotificationKindReviewReceived,
+		model.MaxScore,
+		pgdialect.Array(category.Permissions()),
+		reviewID,
+		model.DefaultReviewScoreThreshold,
+	).Exec(ctx)
+
+	return err
+}
+
+// EnqueuePlanLimits creates warnings about plan limits that are close to be
+// reached. Warnings are created once per period.
+func (r *StaffNotificationRepository) EnqueuePlanLimits(ctx context.Context, period string) error {
+	category := model.StaffNotificationCategoryLimits
+
+	_, err := r.DB.NewRaw(`
+	INSERT INTO staff_notifications ("mini_app_id", "user_id", "category", "kind", "params", "dedup_key")
+	SELECT
+		m.id,
+		r.user_id,
+		?,
+		lim.kind,
+		jsonb_build_object('percent', (lim.total * 100 / lim.max)::TEXT),
+		lim.kind || ':' || ?
+	FROM mini_apps AS m
+	CROSS JOIN LATERAL (VALUES
+		(?, m.storage_size, m.max_storage_size),
+		(?, m.total_students, m.max_total_students),
+		(?, m.total_products, m.max_total_products),
+		(?, m.total_events, m.max_total_events)
+	) AS lim(kind, total, max)
+	CROSS JOIN LATERAL func_staff_notification_recipients(m.id, ?::VARCHAR[]) AS r
+	WHERE m.deleted_at IS NULL
+		AND 0 < lim.max
+		AND lim.max * ? <= lim.total * 100
+	ON CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING
+	`,
+		category,
+		period,
+		model.StaffNotificationKindStorageLimit,
+		model.StaffNotificationKindStudentsLimit,
+		model.StaffNotificationKindProductsLimit,
+		model.StaffNotificationKindEventsLimit,
+		pgdialect.Array(category.Permissions()),
+		model.PlanLimitWarningPercent,
+	).Exec(ctx)
+
+	return err
+}
+
+// Pending returns the oldest notifications that are not sent yet.
+func (r *StaffNotificationRepository) Pending(ctx context.Context, limit int) ([]*model.PendingStaffNotification, error) {
+	notifications := make([]*model.PendingStaffNotification, 0)
+
+	err := r.DB.NewSelect().
+		ColumnExpr(`n.id, n.mini_app_id, n.user_id, n.category, n.kind, n.params`).
+		ColumnExpr(`u.telegram_id, u.language AS user_language`).
+		ColumnExpr(`m.name AS mini_app_name, m.language AS mini_app_language`).
+		ColumnExpr(`COALESCE(s.muted_categories, '{}') AS muted_categories`).
+		TableExpr(`staff_notifications AS n`).
+		Join(`JOIN users AS u ON u.id = n.user_id`).
+		Join(`JOIN mini_apps AS m ON m.id = n.mini_app_id`).
+		Join(`LEFT JOIN staff_notification_settings AS s ON s.user_id = n.user_id`).
+		Where(`n.status = ?`, model.NotificationStatusPending).
+		OrderExpr(`n.created_at`).
+		Limit(limit).
+		Scan(ctx, &notifications)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *StaffNotificationRepository) UpdateStatus(ctx context.Context, notification *model.StaffNotification) error {
+	_, err := r.DB.NewUpdate().
+		Model(notification).
+		Column("status", "error", "sent_at").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+// DeleteOld deletes processed notifications created before olderThan.
+func (r *StaffNotificationRepository) DeleteOld(ctx context.Context, olderThan time.Time) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.StaffNotification)(nil)).
+		Where(`status <> ?`, model.NotificationStatusPending).
+		Where(`created_at < ?`, olderThan).
+		Exec(ctx)
+
+	return err
+}
+
+// GetSettings returns settings of the user, defaults are returned if the user
+// has not changed them.
+func (r *StaffNotificationRepository) GetSettings(
+	ctx context.Context,
+	userID uuid.UUID,
+) (*model.StaffNotificationSettings, error) {
+
+	settings := new(model.StaffNotificationSettings)
+
+	err := r.DB.NewSelect().
+		Model(settings).
+		Where(`user_id = ?`, userID).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.NewStaffNotificationSettings(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *StaffNotificationRepository) SaveSettings(ctx context.Context, settings *model.StaffNotificationSettings) error {
+	_, err := r.DB.NewInsert().
+		Model(settings).
+		On(`CONFLICT ("user_id") DO UPDATE`).
+		Set(`muted_categories = EXCLUDED.muted_categories`).
+		Set(`review_score_threshold = EXCLUDED.review_score_threshold`).
+		Set(`updated_at = EXCLUDED.updated_at`).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type SubtitleRepository struct {
+	repository.Generic[model.Subtitle, uuid.UUID]
+}
+
+func NewSubtitleRepository(
+	genericRepository repository.Generic[model.Subtitle, uuid.UUID],
+) *SubtitleRepository {
+	return &SubtitleRepository{
+		Generic: genericRepository,
+	}
+}
+
+func (r *SubtitleRepository) Create(ctx context.Context, subtitle *model.Subtitle) error {
+	_, err := r.DB.NewInsert().Model(subtitle).Exec(ctx)
+
+	return err
+}
+
+func (r *SubtitleRepository) Update(ctx context.Context, subtitle *model.Subtitle) error {
+	_, err := r.DB.NewUpdate().
+		Model(subtitle).
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+// UpdateMuxTrack saves the track the subtitles were added to the asset as.
+func (r *SubtitleRepository) UpdateMuxTrack(ctx context.Context, subtitle *model.Subtitle) error {
+	_, err := r.DB.NewUpdate().
+		Model(subtitle).
+		Column("mux_asset_id", "mux_track_id").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+func (r *SubtitleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.Subtitle)(nil)).
+		Where(`id = ?`, id).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *SubtitleRepository) FindByMaterial(ctx context.Context, materialID uuid.UUID) ([]*model.Subtitle, error) {
+	subtitles := make([]*model.Subtitle, 0)
+
+	err := r.DB.NewSelect().
+		Model(&subtitles).
+		Where(`subtitle.material_id = ?`, materialID).
+		Order("subtitle.created_at").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return subtitles, nil
+}
+
+// GetByLanguage returns the track of the material or nil if there is no such
+// track.
+func (r *SubtitleRepository) GetByLanguage(ctx context.Context, materialID uuid.UUID, language string) (*model.Subtitle, error) {
+	subtitle := new(model.Subtitle)
+
+	err := r.DB.NewSelect().
+		Model(subtitle).
+		Where(`subtitle.material_id = ?`, materialID).
+		Where(`subtitle.language = ?`, language).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return subtitle, nil
+}
+
+// GetByFilename returns the track of the file or nil if there is no such
+// track.
+func (r *SubtitleRepository) GetByFilename(ctx context.Context, filename string) (*model.Subtitle, error) {
+	subtitle := new(model.Subtitle)
+
+	err := r.DB.NewSelect().
+		Model(subtitle).
+		Where(`subtitle.filename = ?`, filename).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return subtitle, nil
+}
+
+// Search returns tracks of lessons of the product which transcripts match the
+// query, the best matches go first. Inactive lessons are skipped if only
+// active is set.
+func (r *SubtitleRepository) Search(
+	ctx context.Context,
+	productID uuid.UUID,
+	query string,
+	onlyActive bool,
+	limit, offset int,
+) ([]*model.TranscriptMatch, error) {
+
+	matches := make([]*model.TranscriptMatch, 0)
+
+	q := r.DB.NewSelect().
+		TableExpr(`material_subtitles AS s`).
+		Join(`JOIN materials AS m ON m.id = s.material_id`).
+		Join(`JOIN lessons AS l ON l.id = m.lesson_id`).
+		Join(`CROSS JOIN websearch_to_tsquery('simple', ?) AS q`, query).
+		ColumnExpr(`l.id AS lesson_id, l.title AS lesson_title`).
+		ColumnExpr(`m.id AS material_id, m.title AS material_title`).
+		ColumnExpr(`s.language`).
+		ColumnExpr(`ts_headline('simple', s.transcript, q, 'MaxFragments=2, MaxWords=20, MinWords=5') AS headline`).
+		Where(`l.product_id = ?`, productID).
+		Where(`to_tsvector('simple', s.transcript) @@ q`)
+
+	if onlyActive {
+		q = q.Where(`l.is_active`)
+	}
+
+	err := q.
+		OrderExpr(`ts_rank(to_tsvector('simple', s.transcript), q) DESC, l.index, s.created_at`).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx, &matches)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type SupportRepository struct {
+	repository.Generic[model.SupportTicket, uuid.UUID]
+}
+
+func (r *SupportRepository) WithTx(tx bun.Tx) *SupportRepository {
+	return &SupportRepository{Generic: r.Generic.WithTx(tx)}
+}
+
+func NewSupportRepository(
+	genericRepository repository.Generic[model.SupportTicket, uuid.UUID],
+) *SupportRepository {
+	return &SupportRepository{
+		Generic: genericRepository,
+	}
+}
+
+func (r *SupportRepository) Find(
+	ctx context.Context,
+	miniAppID uuid.UUID,
+	filter *model.FilterSupportTicketsRequest,
+) ([]*model.SupportTicket, int, error) {
+
+	tickets := make([]*model.SupportTicket, 0)
+
+	applyFilter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where(`support_ticket.mini_app_id = ?`, miniAppID)
+
+		if len(filter.Status) != 0 {
+			q = q.Where(`support_ticket.status IN (?)`, bun.In(filter.Status))
+		}
+		if filter.AssigneeID != uuid.Nil {
+			q = q.Where(`support_ticket.assignee_id = ?`, filter.AssigneeID)
+		}
+		if filter.Search != "" {
+			q = q.Where(`(
+				support_ticket.subject ILIKE ('%' || ? || '%')
+				OR EXISTS (
+					SELECT 1 FROM support_messages AS sm
+					WHERE sm.ticket_id = support_ticket.id AND sm.text ILIKE ('%' || ? || '%')
+				)
+				OR EXISTS (
+					SELECT 1 FROM users AS su
+					WHERE su.id = support_ticket.user_id AND (
+						su.first_name || ' ' || su.last_name ILIKE ('%' || ? || '%')
+						OR su.telegram_username ILIKE ('%' || ? || '%')
+					)
+				)
+			)`, filter.Search, filter.Search, filter.Search, filter.Search)
+		}
+
+		return q
+	}
+
+	total, err := applyFilter(r.DB.NewSelect().Model(&tickets)).Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return []*model.SupportTicket{}, total, nil
+	}
+
+	query := applyFilter(r.DB.NewSelect().Model(&tickets)).
+		Relation("User").
+		Relation("Assignee").
+		Order(`support_ticket.last_message_at DESC`).
+		Limit(int(filter.Limit))
+
+	if filter.Offset != 0 {
+		query = query.Offset(int(filter.Offset))
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, total, err
+	}
+
+	return tickets, total, nil
+}
+
+// GetWithMessages returns ticket with the student, the assignee and messages
+// in chronological order.
+func (r *SupportRepository) GetWithMessages(ctx context.Context, id uuid.UUID) (*model.SupportTicket, error) {
+	ticket := new(model.SupportTicket)
+
+	err := r.DB.NewSelect().
+		Model(ticket).
+		Relation("User").
+		Relation("Assignee").
+		Relation("Messages", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order(`support_message.created_at`)
+		}).
+		Relation("Messages.Author").
+		Where(`support_ticket.id = ?`, id).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+// LastActive returns the last ticket of the student that is not closed or nil
+// if there is none.
+func (r *SupportRepository) LastActive(ctx context.Context, userID uuid.UUID) (*model.SupportTicket, error) {
+	ticket := new(model.SupportTicket)
+
+	err := r.DB.NewSelect().
+		Model(ticket).
+		Where(`user_id = ?`, userID).
+		Where(`status <> ?`, model.SupportTicketStatusClosed).
+		Order(`created_at DESC`).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+func (r *SupportRepository) CreateMessage(ctx context.Context, message *model.SupportMessage) error {
+	_, err := r.DB.NewInsert().
+		Model(message).
+		Exec(ctx)
+
+	return err
+}
+
+// UpdateTicket updates state of the ticket. Assignee and closing time could be
+// cleared, so zero values are written too.
+func (r *SupportRepository) UpdateTicket(ctx context.Context, ticket *model.SupportTicket) error {
+	_, err := r.DB.NewUpdate().
+		Model(ticket).
+		Column("assignee_id", "status", "last_message_at", "closed_at", "updated_at").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
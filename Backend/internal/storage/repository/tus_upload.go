@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"academy/internal/database/repository"
+	"academy/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type TusUploadRepository struct {
+	repository.Generic[model.TusUpload, uuid.UUID]
+}
+
+func NewTusUploadRepository(
+	genericRepository repository.Generic[model.TusUpload, uuid.UUID],
+) *TusUploadRepository {
+	return &TusUploadRepository{
+		Generic: genericRepository,
+	}
+}
+
+func (r *TusUploadRepository) Create(ctx context.Context, upload *model.TusUpload) error {
+	_, err := r.DB.NewInsert().Model(upload).Exec(ctx)
+
+	return err
+}
+
+// GetByID returns the upload of the mini-app or nil if there is no such
+// upload.
+func (r *TusUploadRepository) GetByID(ctx context.Context, miniAppID, id uuid.UUID) (*model.TusUpload, error) {
+	upload := new(model.TusUpload)
+
+	err := r.DB.NewSelect().
+		Model(upload).
+		Where(`tus_upload.mini_app_id = ?`, miniAppID).
+		Where(`tus_upload.id = ?`, id).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// AddPart moves the offset of the upload if it is still at the offset the
+// part was received at. False is returned if another part was added first.
+func (r *TusUploadRepository) AddPart(ctx context.Context, upload *model.TusUpload, part string, size int64) (bool, error) {
+	now := time.Now().UTC()
+
+	res, err := r.DB.NewUpdate().
+		Model((*model.TusUpload)(nil)).
+		Set(`"offset" = "offset" + ?`, size).
+		Set(`parts = array_append(parts, ?)`, part).
+		Set(`updated_at = ?`, now).
+		Where(`id = ?`, upload.ID).
+		Where(`"offset" = ?`, upload.Offset).
+		Exec(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	upload.Offset += size
+	upload.Parts = append(upload.Parts, part)
+	upload.UpdatedAt = now
+
+	return true, nil
+}
+
+func (r *TusUploadRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.DB.NewDelete().
+		Model((*model.TusUpload)(nil)).
+		Where(`id = ?`, id).
+		Exec(ctx)
+
+	return err
+}
+
+// DeleteOlderThan deletes uploads without parts received after the time and
+// returns their IDs.
+func (r *TusUploadRepository) DeleteOlderThan(ctx context.Context, t time.Time) ([]uuid.UUID, error) {
+	uploads := make([]*model.TusUpload, 0)
+
+	err := r.DB.NewDelete().
+		Model(&uploads).
+		Where(`updated_at < ?`, t).
+		Returning(`id`).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return []uuid.UUID{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(uploads))
+	for i, upload := range uploads {
+		ids[i] = upload.ID
+	}
+
+	return ids, nil
+}
+
+// Exists returns IDs of the uploads that exist.
+func (r *TusUploadRepository) Exists(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	existing := make(map[uuid.UUID]bool, len(ids))
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	found := make([]uuid.UUID, 0)
+	err := r.DB.NewSelect().
+		Model((*model.TusUpload)(nil)).
+		Column(`id`).
+		Where(`id IN (?)`, bun.In(ids)).
+		Scan(ctx, &found)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range found {
+		existing[id] = true
+	}
+
+	return existing, nil
+}
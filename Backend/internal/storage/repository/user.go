@@ -72,6 +72,35 @@ func (r *UserRepository) GetByTelegramID(
 	return user, nil
 }
 
+func (r *UserRepository) GetByCalendarToken(ctx context.Context, token uuid.UUID) (*model.User, error) {
+	user := new(model.User)
+	err := r.DB.NewSelect().Model(user).Where("calendar_token = ?", token).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CalendarToken returns calendar token of the user. Given token is set if the
+// user has no one yet.
+func (r *UserRepository) CalendarToken(ctx context.Context, userID, token uuid.UUID) (uuid.UUID, error) {
+	var calendarToken uuid.UUID
+
+	err := r.DB.NewUpdate().
+		Model((*model.User)(nil)).
+		Set("calendar_token = COALESCE(calendar_token, ?)", token).
+		Where("id = ?", userID).
+		Returning("calendar_token").
+		Scan(ctx, &calendarToken)
+
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return calendarToken, nil
+}
+
 func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
 	_, err := r.DB.NewUpdate().
 		Model(user).
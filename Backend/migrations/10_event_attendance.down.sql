@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS event_attendance;
+
+DROP TYPE IF EXISTS attendance_source;
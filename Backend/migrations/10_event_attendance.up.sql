@@ -0,0 +1,13 @@
+CREATE TYPE attendance_source AS ENUM (
+    'join_link', 'bot'
+);
+
+-- Only the first check-in of the student is kept.
+CREATE TABLE IF NOT EXISTS event_attendance (
+    "lesson_id" UUID REFERENCES lessons("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "source" attendance_source NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY("lesson_id", "user_id")
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_bot_settings_changes ON bot_settings;
+DROP FUNCTION IF EXISTS func_account_bot_settings_changes();
+
+DROP TABLE IF EXISTS bot_settings;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS bot_settings (
+    "mini_app_id" UUID PRIMARY KEY REFERENCES mini_apps("id") ON DELETE CASCADE,
+    "welcome_message" TEXT NOT NULL,
+    "welcome_image" VARCHAR(255) NOT NULL,
+    "welcome_image_size" INT DEFAULT 0 NOT NULL,
+    "buttons" JSONB DEFAULT '[]' NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION func_account_bot_settings_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.welcome_image_size, 0) - COALESCE(OLD.welcome_image_size, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_bot_settings_changes
+AFTER INSERT OR UPDATE OR DELETE ON bot_settings
+FOR EACH ROW
+EXECUTE FUNCTION func_account_bot_settings_changes();
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS trg_notify_payment_completed ON payments;
+DROP FUNCTION IF EXISTS func_notify_payment_completed();
+
+DROP TABLE IF EXISTS notifications;
+
+ALTER TABLE users DROP COLUMN IF EXISTS "muted_notifications";
+
+DROP TYPE IF EXISTS notification_status;
+DROP TYPE IF EXISTS notification_category;
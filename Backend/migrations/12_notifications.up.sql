@@ -0,0 +1,54 @@
+CREATE TYPE notification_category AS ENUM (
+    'homework', 'lessons', 'payments', 'access', 'events'
+);
+
+CREATE TYPE notification_status AS ENUM (
+    'pending', 'sent', 'failed', 'skipped'
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "muted_notifications" notification_category[] DEFAULT '{}' NOT NULL;
+
+CREATE TABLE IF NOT EXISTS notifications (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "category" notification_category NOT NULL,
+    "kind" VARCHAR(50) NOT NULL,
+    "params" JSONB DEFAULT '{}' NOT NULL,
+    "dedup_key" VARCHAR(255) DEFAULT '' NOT NULL,
+    "status" notification_status DEFAULT 'pending' NOT NULL,
+    "error" TEXT DEFAULT '' NOT NULL,
+    "sent_at" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedup_key ON notifications ("user_id", "dedup_key") WHERE "dedup_key" <> '';
+CREATE INDEX IF NOT EXISTS idx_notifications_pending ON notifications ("created_at") WHERE "status" = 'pending';
+
+-- Students are notified about payments that get completed asynchronously by
+-- payment providers. Free product levels are completed on creation.
+CREATE OR REPLACE FUNCTION func_notify_payment_completed()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status = 'completed' AND OLD.status <> 'completed'
+        AND NEW.user_id IS NOT NULL AND NEW.product_level_id IS NOT NULL THEN
+        INSERT INTO notifications ("mini_app_id", "user_id", "category", "kind", "params", "dedup_key")
+        VALUES (
+            NEW.mini_app_id,
+            NEW.user_id,
+            'payments',
+            'payment_completed',
+            jsonb_build_object('title', NEW.comment),
+            'payment_completed:' || NEW.id
+        )
+        ON CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notify_payment_completed
+AFTER UPDATE OF "status" ON payments
+FOR EACH ROW
+EXECUTE FUNCTION func_notify_payment_completed();
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trg_broadcast_changes ON broadcasts;
+DROP FUNCTION IF EXISTS func_account_broadcast_changes();
+
+DROP TABLE IF EXISTS broadcast_recipients;
+DROP TABLE IF EXISTS broadcasts;
+
+DROP TYPE IF EXISTS broadcast_recipient_status;
+DROP TYPE IF EXISTS broadcast_status;
@@ -0,0 +1,58 @@
+CREATE TYPE broadcast_status AS ENUM (
+    'scheduled', 'sending', 'completed', 'cancelled'
+);
+
+CREATE TYPE broadcast_recipient_status AS ENUM (
+    'pending', 'sent', 'failed', 'blocked'
+);
+
+CREATE TABLE IF NOT EXISTS broadcasts (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "author_id" UUID REFERENCES users("id") ON DELETE SET NULL,
+    "segment" JSONB DEFAULT '{}' NOT NULL,
+    "text" TEXT NOT NULL,
+    "image" VARCHAR(255) NOT NULL,
+    "image_size" INT DEFAULT 0 NOT NULL,
+    "buttons" JSONB DEFAULT '[]' NOT NULL,
+    "status" broadcast_status NOT NULL,
+    "scheduled_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "started_at" TIMESTAMP WITH TIME ZONE,
+    "completed_at" TIMESTAMP WITH TIME ZONE,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcasts_mini_app_id ON broadcasts ("mini_app_id", "created_at");
+CREATE INDEX IF NOT EXISTS idx_broadcasts_scheduled ON broadcasts ("scheduled_at") WHERE "status" IN ('scheduled', 'sending');
+
+CREATE TABLE IF NOT EXISTS broadcast_recipients (
+    "broadcast_id" UUID REFERENCES broadcasts("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "status" broadcast_recipient_status DEFAULT 'pending' NOT NULL,
+    "error" TEXT DEFAULT '' NOT NULL,
+    "sent_at" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY("broadcast_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcast_recipients_pending ON broadcast_recipients ("broadcast_id") WHERE "status" = 'pending';
+
+CREATE OR REPLACE FUNCTION func_account_broadcast_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.image_size, 0) - COALESCE(OLD.image_size, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_broadcast_changes
+AFTER INSERT OR UPDATE OF "image_size" OR DELETE ON broadcasts
+FOR EACH ROW
+EXECUTE FUNCTION func_account_broadcast_changes();
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trg_notify_staff_payment ON payments;
+DROP FUNCTION IF EXISTS func_notify_staff_payment();
+DROP FUNCTION IF EXISTS func_staff_notification_recipients(UUID, VARCHAR[]);
+
+DROP TABLE IF EXISTS staff_notifications;
+DROP TABLE IF EXISTS staff_notification_settings;
+
+DROP TYPE IF EXISTS staff_notification_category;
@@ -0,0 +1,92 @@
+CREATE TYPE staff_notification_category AS ENUM (
+    'submissions', 'reviews', 'payments', 'limits'
+);
+
+CREATE TABLE IF NOT EXISTS staff_notification_settings (
+    "user_id" UUID PRIMARY KEY REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "muted_categories" staff_notification_category[] DEFAULT '{}' NOT NULL,
+    "review_score_threshold" INT NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS staff_notifications (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "category" staff_notification_category NOT NULL,
+    "kind" VARCHAR(50) NOT NULL,
+    "params" JSONB DEFAULT '{}' NOT NULL,
+    "dedup_key" VARCHAR(255) DEFAULT '' NOT NULL,
+    "status" notification_status DEFAULT 'pending' NOT NULL,
+    "error" TEXT DEFAULT '' NOT NULL,
+    "sent_at" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_staff_notifications_dedup_key ON staff_notifications ("user_id", "dedup_key") WHERE "dedup_key" <> '';
+CREATE INDEX IF NOT EXISTS idx_staff_notifications_pending ON staff_notifications ("created_at") WHERE "status" = 'pending';
+
+-- Owner and moderators of the mini-app that have any of the permissions.
+CREATE OR REPLACE FUNCTION func_staff_notification_recipients(app_id UUID, permission_names VARCHAR[])
+RETURNS TABLE ("user_id" UUID) AS $$
+    SELECT u.id
+    FROM users AS u
+    WHERE u.mini_app_id = app_id
+        AND u.is_active = TRUE
+        AND (
+            u.role = 'owner'
+            OR u.role = 'moderator' AND EXISTS (
+                SELECT 1
+                FROM mod_invites AS mi
+                JOIN mod_invite_permissions AS mip ON mip.invite_id = mi.id
+                WHERE mi.mini_app_id = app_id
+                    AND mi.user_id = u.id
+                    AND mip.permission_name = ANY(permission_names)
+            )
+        );
+$$ LANGUAGE sql STABLE;
+
+-- Staff is notified about completed and refunded payments of students.
+CREATE OR REPLACE FUNCTION func_notify_staff_payment()
+RETURNS TRIGGER AS $$
+DECLARE
+    payment_kind VARCHAR(50);
+BEGIN
+    IF NEW.status = OLD.status OR NEW.user_id IS NULL OR NEW.product_level_id IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    IF NEW.status = 'completed' THEN
+        payment_kind := 'payment_completed';
+    ELSIF NEW.status = 'refunded' THEN
+        payment_kind := 'payment_refunded';
+    ELSE
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO staff_notifications ("mini_app_id", "user_id", "category", "kind", "params", "dedup_key")
+    SELECT
+        NEW.mini_app_id,
+        r.user_id,
+        'payments',
+        payment_kind,
+        jsonb_build_object(
+            'student', TRIM(u.first_name || ' ' || u.last_name),
+            'title', NEW.comment,
+            'amount', NEW.amount::TEXT,
+            'currency', NEW.currency
+        ),
+        payment_kind || ':' || NEW.id
+    FROM func_staff_notification_recipients(NEW.mini_app_id, ARRAY['Account Settings', 'Products Control']) AS r
+    JOIN users AS u ON u.id = NEW.user_id
+    ON CONFLICT ("user_id", "dedup_key") WHERE "dedup_key" <> '' DO NOTHING;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notify_staff_payment
+AFTER UPDATE OF "status" ON payments
+FOR EACH ROW
+EXECUTE FUNCTION func_notify_staff_payment();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS support_messages;
+DROP TABLE IF EXISTS support_tickets;
+
+DROP TYPE IF EXISTS support_ticket_status;
@@ -0,0 +1,31 @@
+CREATE TYPE support_ticket_status AS ENUM (
+    'open', 'answered', 'closed'
+);
+
+CREATE TABLE IF NOT EXISTS support_tickets (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "assignee_id" UUID REFERENCES users("id") ON DELETE SET NULL,
+    "subject" VARCHAR(100) NOT NULL,
+    "status" support_ticket_status NOT NULL,
+    "last_message_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "closed_at" TIMESTAMP WITH TIME ZONE,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_support_tickets_mini_app_id ON support_tickets ("mini_app_id", "last_message_at");
+CREATE INDEX IF NOT EXISTS idx_support_tickets_user_id ON support_tickets ("user_id") WHERE "status" <> 'closed';
+
+CREATE TABLE IF NOT EXISTS support_messages (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    "ticket_id" UUID REFERENCES support_tickets("id") ON DELETE CASCADE NOT NULL,
+    "author_id" UUID REFERENCES users("id") ON DELETE SET NULL,
+    "is_reply" BOOLEAN NOT NULL,
+    "text" TEXT NOT NULL,
+    "telegram_message_id" INT DEFAULT 0 NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_support_messages_ticket_id ON support_messages ("ticket_id", "created_at");
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS func_audit_log_append_only();
+
+DROP TABLE IF EXISTS audit_log;
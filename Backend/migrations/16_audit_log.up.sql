@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "actor_id" UUID NOT NULL,
+    "actor_role" user_role NOT NULL,
+    "action" VARCHAR(50) NOT NULL,
+    "entity_type" VARCHAR(50) NOT NULL,
+    "entity_id" UUID,
+    "changes" JSONB DEFAULT '{}' NOT NULL,
+    "params" JSONB DEFAULT '{}' NOT NULL,
+    "ip" VARCHAR(45) DEFAULT '' NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_mini_app_id ON audit_log ("mini_app_id", "created_at");
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log ("actor_id", "created_at");
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity_id ON audit_log ("entity_id") WHERE "entity_id" IS NOT NULL;
+
+-- Actor is not referenced, so the record stays when the moderator is deleted.
+-- Records are deleted only with the mini-app.
+CREATE OR REPLACE FUNCTION func_audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'Audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_log_append_only
+BEFORE UPDATE ON audit_log
+FOR EACH ROW
+EXECUTE FUNCTION func_audit_log_append_only();
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS mod_invite_permissions (
+    "invite_id" UUID REFERENCES mod_invites("id") ON DELETE CASCADE NOT NULL,
+    "permission_name" VARCHAR(60) REFERENCES permissions("name") ON DELETE CASCADE NOT NULL,
+
+    PRIMARY KEY("invite_id", "permission_name")
+);
+
+-- Fine-grained permissions are granted back as their parent permissions.
+INSERT INTO mod_invite_permissions ("invite_id", "permission_name")
+SELECT DISTINCT mi.id, COALESCE(p.parent_name, p.name)
+FROM mod_invites AS mi
+JOIN mod_role_permissions AS rp ON rp.role_id = mi.role_id
+JOIN permissions AS p ON p.name = rp.permission_name;
+
+DROP FUNCTION IF EXISTS func_staff_notification_recipients(UUID, VARCHAR[], UUID);
+
+CREATE OR REPLACE FUNCTION func_staff_notification_recipients(app_id UUID, permission_names VARCHAR[])
+RETURNS TABLE ("user_id" UUID) AS $$
+    SELECT u.id
+    FROM users AS u
+    WHERE u.mini_app_id = app_id
+        AND u.is_active = TRUE
+        AND (
+            u.role = 'owner'
+            OR u.role = 'moderator' AND EXISTS (
+                SELECT 1
+                FROM mod_invites AS mi
+                JOIN mod_invite_permissions AS mip ON mip.invite_id = mi.id
+                WHERE mi.mini_app_id = app_id
+                    AND mi.user_id = u.id
+                    AND mip.permission_name = ANY(permission_names)
+            )
+        );
+$$ LANGUAGE sql STABLE;
+
+DROP INDEX IF EXISTS idx_mod_invites_role_id;
+ALTER TABLE mod_invites DROP COLUMN IF EXISTS "role_id";
+
+DROP TABLE IF EXISTS mod_role_permissions;
+DROP TABLE IF EXISTS mod_roles;
+
+DELETE FROM permissions WHERE "parent_name" IS NOT NULL;
+ALTER TABLE permissions DROP COLUMN IF EXISTS "parent_name";
@@ -0,0 +1,86 @@
+-- Fine-grained permissions are parts of the coarse parent permission.
+ALTER TABLE permissions ADD COLUMN IF NOT EXISTS "parent_name" VARCHAR(60) REFERENCES permissions("name") ON DELETE CASCADE;
+
+INSERT INTO permissions ("name", "description", "parent_name") VALUES
+('Homework Review', 'Grade, and leave feedback for homework of students.', 'Student Interaction'),
+('Support Tickets', 'Reply to support tickets of students.', 'Student Interaction'),
+('Student Bans', 'Ban and unban students in products.', 'Student Management'),
+('Student Levels', 'Promote students to levels of products.', 'Student Management'),
+('Content Editing', 'Create/edit/delete lessons of products.', 'Products Control'),
+('Product Reports', 'Reports of products (student''s feedback, students and leaderboard).', 'Analytics');
+
+CREATE TABLE IF NOT EXISTS mod_roles (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    UNIQUE("mini_app_id", "name")
+);
+
+-- Empty products mean that the permission is granted in all products.
+CREATE TABLE IF NOT EXISTS mod_role_permissions (
+    "role_id" UUID REFERENCES mod_roles("id") ON DELETE CASCADE NOT NULL,
+    "permission_name" VARCHAR(60) REFERENCES permissions("name") ON DELETE CASCADE NOT NULL,
+    "product_ids" UUID[] DEFAULT '{}' NOT NULL,
+
+    PRIMARY KEY("role_id", "permission_name")
+);
+
+ALTER TABLE mod_invites ADD COLUMN IF NOT EXISTS "role_id" UUID REFERENCES mod_roles("id") ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_mod_invites_role_id ON mod_invites ("role_id");
+
+-- Invites with the same permissions in the mini-app get the same role.
+CREATE TEMPORARY TABLE tmp_invite_roles AS
+SELECT
+    mi.id AS invite_id,
+    mi.mini_app_id,
+    string_agg(mip.permission_name, ', ' ORDER BY mip.permission_name) AS role_name
+FROM mod_invites AS mi
+JOIN mod_invite_permissions AS mip ON mip.invite_id = mi.id
+GROUP BY mi.id, mi.mini_app_id;
+
+INSERT INTO mod_roles ("mini_app_id", "name")
+SELECT DISTINCT mini_app_id, role_name FROM tmp_invite_roles;
+
+INSERT INTO mod_role_permissions ("role_id", "permission_name")
+SELECT DISTINCT r.id, mip.permission_name
+FROM tmp_invite_roles AS t
+JOIN mod_roles AS r ON r.mini_app_id = t.mini_app_id AND r.name = t.role_name
+JOIN mod_invite_permissions AS mip ON mip.invite_id = t.invite_id;
+
+UPDATE mod_invites AS mi SET "role_id" = r.id
+FROM tmp_invite_roles AS t
+JOIN mod_roles AS r ON r.mini_app_id = t.mini_app_id AND r.name = t.role_name
+WHERE mi.id = t.invite_id;
+
+DROP TABLE IF EXISTS tmp_invite_roles;
+DROP TABLE IF EXISTS mod_invite_permissions;
+
+-- Owner and moderators of the mini-app that have any of the permissions. The
+-- permission that is scoped to products is taken into account only for them.
+DROP FUNCTION IF EXISTS func_staff_notification_recipients(UUID, VARCHAR[]);
+
+CREATE OR REPLACE FUNCTION func_staff_notification_recipients(
+    app_id UUID, permission_names VARCHAR[], scope_product_id UUID DEFAULT NULL
+)
+RETURNS TABLE ("user_id" UUID) AS $$
+    SELECT u.id
+    FROM users AS u
+    WHERE u.mini_app_id = app_id
+        AND u.is_active = TRUE
+        AND (
+            u.role = 'owner'
+            OR u.role = 'moderator' AND EXISTS (
+                SELECT 1
+                FROM mod_invites AS mi
+                JOIN mod_role_permissions AS rp ON rp.role_id = mi.role_id
+                WHERE mi.mini_app_id = app_id
+                    AND mi.user_id = u.id
+                    AND rp.permission_name = ANY(permission_names)
+                    AND (cardinality(rp.product_ids) = 0 OR scope_product_id = ANY(rp.product_ids))
+            )
+        );
+$$ LANGUAGE sql STABLE;
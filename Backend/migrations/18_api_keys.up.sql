@@ -0,0 +1,20 @@
+-- Only the hash of the key is stored, the key is shown once on creation.
+CREATE TABLE IF NOT EXISTS api_keys (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "prefix" VARCHAR(16) NOT NULL,
+    "key_hash" VARCHAR(64) UNIQUE NOT NULL,
+    "scopes" VARCHAR(60)[] DEFAULT '{}' NOT NULL,
+    "rate_limit" INT NOT NULL,
+    "expires_at" TIMESTAMP WITH TIME ZONE,
+    "last_used_at" TIMESTAMP WITH TIME ZONE,
+    "revoked_at" TIMESTAMP WITH TIME ZONE,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_mini_app_id ON api_keys ("mini_app_id", "created_at");
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS "api_key_id" UUID;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_tus_upload_changes ON tus_uploads;
+DROP FUNCTION IF EXISTS func_account_tus_upload_changes();
+
+DROP TABLE IF EXISTS tus_uploads;
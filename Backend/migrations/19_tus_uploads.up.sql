@@ -0,0 +1,36 @@
+-- Resumable uploads of material files by the tus protocol. Received parts are
+-- kept in the storage till the upload is complete, their size is accounted
+-- in the storage size of the mini-app.
+CREATE TABLE IF NOT EXISTS tus_uploads (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "material_id" UUID REFERENCES materials("id") ON DELETE CASCADE NOT NULL,
+    "length" BIGINT NOT NULL,
+    "offset" BIGINT DEFAULT 0 NOT NULL,
+    "parts" TEXT[] DEFAULT '{}' NOT NULL,
+    "metadata" JSONB DEFAULT '{}' NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CHECK ("offset" <= "length")
+);
+
+CREATE INDEX IF NOT EXISTS idx_tus_uploads_material_id ON tus_uploads ("material_id");
+CREATE INDEX IF NOT EXISTS idx_tus_uploads_updated_at ON tus_uploads ("updated_at");
+
+CREATE OR REPLACE FUNCTION func_account_tus_upload_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.offset, 0) - COALESCE(OLD.offset, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_tus_upload_changes
+AFTER INSERT OR UPDATE OR DELETE ON tus_uploads
+FOR EACH ROW
+EXECUTE FUNCTION func_account_tus_upload_changes();
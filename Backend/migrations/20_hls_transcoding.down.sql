@@ -0,0 +1,13 @@
+UPDATE materials SET "status" = 'pending_move_to_mux' WHERE "status" = 'pending_transcoding';
+
+ALTER TYPE material_status RENAME TO material_status_old;
+
+CREATE TYPE material_status AS ENUM (
+    'ready', 'pending_compressing', 'pending_move_to_mux'
+);
+
+ALTER TABLE materials ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE materials ALTER COLUMN "status" TYPE material_status USING "status"::TEXT::material_status;
+ALTER TABLE materials ALTER COLUMN "status" SET DEFAULT 'ready';
+
+DROP TYPE IF EXISTS material_status_old;
@@ -0,0 +1 @@
+ALTER TYPE material_status ADD VALUE IF NOT EXISTS 'pending_transcoding';
@@ -0,0 +1,27 @@
+CREATE TYPE job_status AS ENUM (
+    'pending', 'running', 'completed', 'dead'
+);
+
+-- Jobs are leased by workers of all replicas, expired leases of crashed
+-- workers are leased again. Only one active job with the same key exists.
+CREATE TABLE IF NOT EXISTS jobs (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "kind" VARCHAR(100) NOT NULL,
+    "key" VARCHAR(255),
+    "payload" JSONB DEFAULT '{}' NOT NULL,
+    "status" job_status DEFAULT 'pending' NOT NULL,
+    "attempts" INT DEFAULT 0 NOT NULL,
+    "max_attempts" INT NOT NULL,
+    "run_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "locked_by" VARCHAR(100),
+    "locked_until" TIMESTAMP WITH TIME ZONE,
+    "last_error" TEXT DEFAULT '' NOT NULL,
+    "completed_at" TIMESTAMP WITH TIME ZONE,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_key ON jobs ("key") WHERE "status" IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs ("run_at") WHERE "status" = 'pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs ("locked_until") WHERE "status" = 'running';
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs ("status", "kind", "created_at");
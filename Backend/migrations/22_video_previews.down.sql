@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_materials_storyboard;
+DROP INDEX IF EXISTS idx_materials_poster;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_materials_poster ON materials USING HASH (("metadata"->>'poster'));
+CREATE INDEX IF NOT EXISTS idx_materials_storyboard ON materials USING HASH (("metadata"->'storyboard'->>'filename'));
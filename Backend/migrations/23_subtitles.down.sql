@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_material_subtitle_changes ON material_subtitles;
+DROP FUNCTION IF EXISTS func_account_material_subtitle_changes();
+
+DROP TABLE IF EXISTS material_subtitles;
@@ -0,0 +1,39 @@
+-- Subtitle tracks of video and audio materials, one track per language. Files
+-- are converted to WebVTT, their text is kept as the transcript for search.
+CREATE TABLE IF NOT EXISTS material_subtitles (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "material_id" UUID REFERENCES materials("id") ON DELETE CASCADE NOT NULL,
+    "language" VARCHAR(35) NOT NULL,
+    "label" VARCHAR(100) NOT NULL,
+    "filename" VARCHAR(255) NOT NULL,
+    "size" INT DEFAULT 0 NOT NULL,
+    "transcript" TEXT NOT NULL,
+    "mux_asset_id" VARCHAR(255) DEFAULT '' NOT NULL,
+    "mux_track_id" VARCHAR(255) DEFAULT '' NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    UNIQUE ("material_id", "language")
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_subtitles_filename ON material_subtitles USING HASH ("filename");
+CREATE INDEX IF NOT EXISTS idx_material_subtitles_transcript ON material_subtitles USING GIN (to_tsvector('simple', "transcript"));
+
+CREATE OR REPLACE FUNCTION func_account_material_subtitle_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.size, 0) - COALESCE(OLD.size, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_material_subtitle_changes
+AFTER INSERT OR UPDATE OF "size" OR DELETE ON material_subtitles
+FOR EACH ROW
+EXECUTE FUNCTION func_account_material_subtitle_changes();
@@ -0,0 +1,41 @@
+CREATE OR REPLACE FUNCTION func_account_material_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    affected_mini_app_id UUID;
+BEGIN
+    CASE
+        WHEN COALESCE(NEW.mini_app_id, OLD.mini_app_id) IS NOT NULL THEN 
+            affected_mini_app_id := COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+        WHEN COALESCE(NEW.product_level_id, OLD.product_level_id) IS NOT NULL THEN
+            affected_mini_app_id := (
+                SELECT DISTINCT mini_app_id FROM products WHERE id = (
+                    SELECT DISTINCT product_id FROM product_levels
+                    WHERE id = COALESCE(NEW.product_level_id, OLD.product_level_id)
+                )
+            );
+        WHEN COALESCE(NEW.lesson_id, OLD.lesson_id) IS NOT NULL THEN
+            affected_mini_app_id := (
+                SELECT DISTINCT mini_app_id FROM products WHERE id = (
+                    SELECT DISTINCT product_id FROM lessons
+                    WHERE id = COALESCE(NEW.lesson_id, OLD.lesson_id)
+                )
+            );
+    END CASE;
+
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.size, 0) - COALESCE(OLD.size, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = affected_mini_app_id;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_lock_material_blob ON materials;
+DROP FUNCTION IF EXISTS func_lock_material_blob();
+
+DROP TRIGGER IF EXISTS trg_blob_changes ON blobs;
+DROP FUNCTION IF EXISTS func_account_blob_changes();
+
+DROP TABLE IF EXISTS blobs;
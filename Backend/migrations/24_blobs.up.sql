@@ -0,0 +1,98 @@
+-- Files of materials stored once per mini-app by SHA-256 of their content.
+-- Materials reference blobs by filename, a blob is deleted with the last of
+-- them.
+CREATE TABLE IF NOT EXISTS blobs (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "hash" CHAR(64) NOT NULL,
+    "filename" VARCHAR(255) UNIQUE NOT NULL,
+    "size" BIGINT DEFAULT 0 NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_blobs_mini_app_id_hash ON blobs ("mini_app_id", "hash");
+
+CREATE OR REPLACE FUNCTION func_account_blob_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.size, 0) - COALESCE(OLD.size, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_blob_changes
+AFTER INSERT OR UPDATE OF "size" OR DELETE ON blobs
+FOR EACH ROW
+EXECUTE FUNCTION func_account_blob_changes();
+
+-- Files of blobs are counted by blobs, so materials that reference them are
+-- not counted.
+CREATE OR REPLACE FUNCTION func_account_material_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    affected_mini_app_id UUID;
+    new_size BIGINT := COALESCE(NEW.size, 0);
+    old_size BIGINT := COALESCE(OLD.size, 0);
+BEGIN
+    CASE
+        WHEN COALESCE(NEW.mini_app_id, OLD.mini_app_id) IS NOT NULL THEN 
+            affected_mini_app_id := COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+        WHEN COALESCE(NEW.product_level_id, OLD.product_level_id) IS NOT NULL THEN
+            affected_mini_app_id := (
+                SELECT DISTINCT mini_app_id FROM products WHERE id = (
+                    SELECT DISTINCT product_id FROM product_levels
+                    WHERE id = COALESCE(NEW.product_level_id, OLD.product_level_id)
+                )
+            );
+        WHEN COALESCE(NEW.lesson_id, OLD.lesson_id) IS NOT NULL THEN
+            affected_mini_app_id := (
+                SELECT DISTINCT mini_app_id FROM products WHERE id = (
+                    SELECT DISTINCT product_id FROM lessons
+                    WHERE id = COALESCE(NEW.lesson_id, OLD.lesson_id)
+                )
+            );
+    END CASE;
+
+    IF NEW.filename ~ '^ma/[^/]+/b/' THEN
+        new_size := 0;
+    END IF;
+    IF OLD.filename ~ '^ma/[^/]+/b/' THEN
+        old_size := 0;
+    END IF;
+
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + new_size - old_size,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = affected_mini_app_id;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Materials lock the blob they reference until the end of the transaction,
+-- so the blob isn't deleted as unreferenced before the material is saved.
+-- Material is rejected if the blob is already deleted.
+CREATE OR REPLACE FUNCTION func_lock_material_blob()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM 1 FROM blobs WHERE "filename" = NEW.filename FOR SHARE;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Blob of the file is deleted';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_lock_material_blob
+BEFORE INSERT OR UPDATE OF "filename" ON materials
+FOR EACH ROW
+WHEN (NEW.filename ~ '^ma/[^/]+/b/')
+EXECUTE FUNCTION func_lock_material_blob();
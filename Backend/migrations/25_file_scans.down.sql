@@ -0,0 +1,27 @@
+ALTER TABLE materials DROP COLUMN IF EXISTS "scan";
+
+UPDATE materials SET "status" = 'ready' WHERE "status" = 'quarantined';
+
+ALTER TYPE material_status RENAME TO material_status_old;
+
+CREATE TYPE material_status AS ENUM (
+    'ready', 'pending_compressing', 'pending_move_to_mux', 'pending_transcoding'
+);
+
+ALTER TABLE materials ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE materials ALTER COLUMN "status" TYPE material_status USING "status"::TEXT::material_status;
+ALTER TABLE materials ALTER COLUMN "status" SET DEFAULT 'ready';
+
+DROP TYPE IF EXISTS material_status_old;
+
+UPDATE lesson_progress SET "status" = 'pending' WHERE "status" = 'quarantined';
+
+ALTER TYPE lesson_progress_status RENAME TO lesson_progress_status_old;
+
+CREATE TYPE lesson_progress_status AS ENUM (
+    'pending', 'failed', 'accepted'
+);
+
+ALTER TABLE lesson_progress ALTER COLUMN "status" TYPE lesson_progress_status USING "status"::TEXT::lesson_progress_status;
+
+DROP TYPE IF EXISTS lesson_progress_status_old;
@@ -0,0 +1,4 @@
+ALTER TYPE material_status ADD VALUE IF NOT EXISTS 'quarantined';
+ALTER TYPE lesson_progress_status ADD VALUE IF NOT EXISTS 'quarantined';
+
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS "scan" JSONB;
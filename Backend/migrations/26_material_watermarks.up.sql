@@ -0,0 +1 @@
+ALTER TABLE materials ADD COLUMN IF NOT EXISTS "is_watermarked" BOOLEAN DEFAULT FALSE NOT NULL;
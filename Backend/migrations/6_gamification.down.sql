@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS trg_badge_changes ON badges;
+DROP FUNCTION IF EXISTS func_account_badge_changes();
+
+DROP TABLE IF EXISTS user_badges;
+DROP TABLE IF EXISTS badges;
+
+DROP TRIGGER IF EXISTS trg_point_transactions_append_only ON point_transactions;
+DROP FUNCTION IF EXISTS func_point_transactions_append_only();
+
+DROP TABLE IF EXISTS point_transactions;
+DROP TABLE IF EXISTS point_rules;
+
+ALTER TABLE users DROP COLUMN IF EXISTS "hide_from_leaderboard";
+
+DROP TYPE IF EXISTS point_event;
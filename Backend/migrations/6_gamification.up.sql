@@ -0,0 +1,105 @@
+CREATE TYPE point_event AS ENUM ('lesson_accepted', 'quiz_score', 'streak', 'review', 'manual');
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "hide_from_leaderboard" BOOLEAN DEFAULT FALSE NOT NULL;
+
+CREATE TABLE IF NOT EXISTS point_rules (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "event" point_event NOT NULL,
+    "threshold" INT DEFAULT 0 NOT NULL,
+    "points" INT NOT NULL,
+    "is_active" BOOLEAN DEFAULT TRUE NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    UNIQUE("mini_app_id", "event", "threshold")
+);
+
+CREATE TABLE IF NOT EXISTS point_transactions (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "product_id" UUID REFERENCES products("id") ON DELETE SET NULL,
+    "lesson_id" UUID REFERENCES lessons("id") ON DELETE SET NULL,
+    "rule_id" UUID REFERENCES point_rules("id") ON DELETE SET NULL,
+    "actor_id" UUID REFERENCES users("id") ON DELETE SET NULL,
+    "event" point_event NOT NULL,
+    "points" INT NOT NULL,
+    "reason" VARCHAR(255) NOT NULL,
+    "idempotency_key" VARCHAR(255) NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    UNIQUE("user_id", "idempotency_key")
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_transactions_mini_app_id ON point_transactions USING HASH ("mini_app_id");
+CREATE INDEX IF NOT EXISTS idx_point_transactions_product_id ON point_transactions USING HASH ("product_id");
+CREATE INDEX IF NOT EXISTS idx_point_transactions_user_id ON point_transactions USING HASH ("user_id");
+
+-- Point history is append-only so it can be audited. Corrections are made
+-- with new 'manual' transactions. The only allowed update is clearing
+-- references by ON DELETE SET NULL of the deleted product, lesson, rule or
+-- actor.
+CREATE OR REPLACE FUNCTION func_point_transactions_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.id, NEW.mini_app_id, NEW.user_id, NEW.event, NEW.points, NEW.reason, NEW.idempotency_key, NEW.created_at)
+        IS NOT DISTINCT FROM
+        (OLD.id, OLD.mini_app_id, OLD.user_id, OLD.event, OLD.points, OLD.reason, OLD.idempotency_key, OLD.created_at)
+        AND (NEW.product_id IS NULL OR NEW.product_id = OLD.product_id)
+        AND (NEW.lesson_id IS NULL OR NEW.lesson_id = OLD.lesson_id)
+        AND (NEW.rule_id IS NULL OR NEW.rule_id = OLD.rule_id)
+        AND (NEW.actor_id IS NULL OR NEW.actor_id = OLD.actor_id)
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'point transactions are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_point_transactions_append_only
+BEFORE UPDATE ON point_transactions
+FOR EACH ROW
+EXECUTE FUNCTION func_point_transactions_append_only();
+
+CREATE TABLE IF NOT EXISTS badges (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "mini_app_id" UUID REFERENCES mini_apps("id") ON DELETE CASCADE NOT NULL,
+    "product_id" UUID REFERENCES products("id") ON DELETE CASCADE,
+    "title" VARCHAR(55) NOT NULL,
+    "description" VARCHAR(255) NOT NULL,
+    "image" VARCHAR(255) NOT NULL,
+    "image_size" INT DEFAULT 0 NOT NULL,
+    "required_points" INT NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_badges_mini_app_id ON badges USING HASH ("mini_app_id");
+
+CREATE TABLE IF NOT EXISTS user_badges (
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "badge_id" UUID REFERENCES badges("id") ON DELETE CASCADE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY ("user_id", "badge_id")
+);
+
+CREATE OR REPLACE FUNCTION func_account_badge_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        storage_size = storage_size + COALESCE(NEW.image_size, 0) - COALESCE(OLD.image_size, 0),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = COALESCE(NEW.mini_app_id, OLD.mini_app_id);
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_badge_changes
+AFTER INSERT OR UPDATE OR DELETE ON badges
+FOR EACH ROW
+EXECUTE FUNCTION func_account_badge_changes();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS product_prerequisites;
+
+DROP TYPE IF EXISTS prerequisite_condition;
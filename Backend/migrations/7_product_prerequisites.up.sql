@@ -0,0 +1,17 @@
+CREATE TYPE prerequisite_condition AS ENUM ('completed', 'purchased');
+
+-- Requirements a student must meet before getting access to the product. If
+-- "required_product_level_id" is set then the condition applies to the level
+-- only, otherwise to the whole required product.
+CREATE TABLE IF NOT EXISTS product_prerequisites (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "product_id" UUID REFERENCES products("id") ON DELETE CASCADE NOT NULL,
+    "required_product_id" UUID REFERENCES products("id") ON DELETE CASCADE NOT NULL,
+    "required_product_level_id" UUID REFERENCES product_levels("id") ON DELETE CASCADE,
+    "condition" prerequisite_condition NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    CHECK("product_id" <> "required_product_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_prerequisites_product_id ON product_prerequisites USING HASH ("product_id");
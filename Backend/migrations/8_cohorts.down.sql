@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trg_assign_payment_cohort ON payments;
+DROP FUNCTION IF EXISTS func_assign_payment_cohort();
+
+ALTER TABLE payments DROP COLUMN IF EXISTS "cohort_id";
+
+DROP TABLE IF EXISTS cohort_moderators;
+DROP TABLE IF EXISTS cohort_students;
+DROP TABLE IF EXISTS cohorts;
@@ -0,0 +1,77 @@
+CREATE TABLE IF NOT EXISTS cohorts (
+    "id" UUID PRIMARY KEY DEFAULT uuid_generate_v4() NOT NULL,
+    "product_id" UUID REFERENCES products("id") ON DELETE CASCADE NOT NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "start_date" TIMESTAMP WITH TIME ZONE,
+    "schedule_offset" INTERVAL,
+    "seat_limit" INT DEFAULT 0 NOT NULL,
+    "invite_id" UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cohorts_product_id ON cohorts USING HASH ("product_id");
+
+-- Student could be a member of only one cohort of the product.
+CREATE TABLE IF NOT EXISTS cohort_students (
+    "product_id" UUID REFERENCES products("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "cohort_id" UUID REFERENCES cohorts("id") ON DELETE CASCADE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY("product_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_cohort_students_cohort_id ON cohort_students USING HASH ("cohort_id");
+
+CREATE TABLE IF NOT EXISTS cohort_moderators (
+    "cohort_id" UUID REFERENCES cohorts("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY("cohort_id", "user_id")
+);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS "cohort_id" UUID REFERENCES cohorts("id") ON DELETE SET NULL;
+
+-- Students are assigned to the cohort chosen on purchase once the payment is
+-- completed. Existing membership is kept. Cohort row is locked before seats
+-- are counted, so concurrent purchases can't overfill it. Completed payment
+-- isn't rejected when the cohort is full, student is left without a cohort
+-- and could be assigned by staff.
+CREATE OR REPLACE FUNCTION func_assign_payment_cohort()
+RETURNS TRIGGER AS $$
+DECLARE
+    cohort_seat_limit INT;
+    cohort_students_count INT;
+BEGIN
+    SELECT "seat_limit" INTO cohort_seat_limit
+    FROM cohorts
+    WHERE "id" = NEW.cohort_id
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT COUNT(*) INTO cohort_students_count
+    FROM cohort_students
+    WHERE "cohort_id" = NEW.cohort_id;
+
+    IF cohort_seat_limit > 0 AND cohort_students_count >= cohort_seat_limit THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO cohort_students ("product_id", "user_id", "cohort_id")
+    VALUES (NEW.product_id, NEW.user_id, NEW.cohort_id)
+    ON CONFLICT DO NOTHING;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_assign_payment_cohort
+AFTER INSERT OR UPDATE OF "status" ON payments
+FOR EACH ROW
+WHEN (NEW.status = 'completed' AND NEW.cohort_id IS NOT NULL AND NEW.user_id IS NOT NULL AND NEW.product_id IS NOT NULL)
+EXECUTE FUNCTION func_assign_payment_cohort();
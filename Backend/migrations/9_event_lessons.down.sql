@@ -0,0 +1,39 @@
+CREATE OR REPLACE FUNCTION func_account_lesson_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        total_events = total_events
+            + CASE WHEN (NEW.release_date IS NOT NULL) THEN 1 ELSE 0 END
+            - CASE WHEN (OLD.release_date IS NOT NULL) THEN 1 ELSE 0 END,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = (
+        SELECT mini_app_id FROM products
+        WHERE id = COALESCE(NEW.product_id, OLD.product_id)
+    );
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE mini_apps DISABLE TRIGGER trg_mini_app_changes;
+
+UPDATE mini_apps SET total_events = (
+    SELECT COUNT(*) FROM lessons
+    JOIN products ON products.id = lessons.product_id
+    WHERE products.mini_app_id = mini_apps.id AND lessons.release_date IS NOT NULL
+);
+
+ALTER TABLE mini_apps ENABLE TRIGGER trg_mini_app_changes;
+
+DROP TABLE IF EXISTS event_reminders;
+
+ALTER TABLE users DROP COLUMN IF EXISTS "calendar_token";
+
+DROP INDEX IF EXISTS idx_lessons_event_start;
+
+ALTER TABLE lessons
+    DROP COLUMN IF EXISTS "meeting_url",
+    DROP COLUMN IF EXISTS "time_zone",
+    DROP COLUMN IF EXISTS "event_end",
+    DROP COLUMN IF EXISTS "event_start";
@@ -0,0 +1,49 @@
+ALTER TABLE lessons
+    ADD COLUMN IF NOT EXISTS "event_start" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "event_end" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "time_zone" VARCHAR(64) DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS "meeting_url" VARCHAR(512) DEFAULT '' NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_lessons_event_start ON lessons ("event_start") WHERE "content_type" = 'event';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "calendar_token" UUID UNIQUE;
+
+-- Reminders already sent to students, so every student gets only one reminder
+-- per event.
+CREATE TABLE IF NOT EXISTS event_reminders (
+    "lesson_id" UUID REFERENCES lessons("id") ON DELETE CASCADE NOT NULL,
+    "user_id" UUID REFERENCES users("id") ON DELETE CASCADE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY("lesson_id", "user_id")
+);
+
+-- Only event lessons are counted as events instead of all scheduled lessons.
+CREATE OR REPLACE FUNCTION func_account_lesson_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE mini_apps
+    SET
+        total_events = total_events
+            + CASE WHEN (NEW.content_type = 'event') THEN 1 ELSE 0 END
+            - CASE WHEN (OLD.content_type = 'event') THEN 1 ELSE 0 END,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE id = (
+        SELECT mini_app_id FROM products
+        WHERE id = COALESCE(NEW.product_id, OLD.product_id)
+    );
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recalculation must not fail for mini-apps that exceed the limit.
+ALTER TABLE mini_apps DISABLE TRIGGER trg_mini_app_changes;
+
+UPDATE mini_apps SET total_events = (
+    SELECT COUNT(*) FROM lessons
+    JOIN products ON products.id = lessons.product_id
+    WHERE products.mini_app_id = mini_apps.id AND lessons.content_type = 'event'
+);
+
+ALTER TABLE mini_apps ENABLE TRIGGER trg_mini_app_changes;